@@ -0,0 +1,104 @@
+import { describe, expect, it, vi } from "vitest";
+
+vi.mock("@offworld/sdk/internal", () => ({
+	CONFIG_KEYS: ["repoRoot", "defaultModel", "agents"],
+	LOG_LEVELS: ["debug", "info", "warn", "error", "silent"],
+	listMapNames: vi.fn(() => ({ repos: [], references: [] })),
+}));
+
+import {
+	getCompletions,
+	renderCompletionScript,
+	type CompletionCommand,
+	type CompletionOption,
+	type CompletionSources,
+} from "../utils/completion";
+
+function option(long: string, extra: Partial<CompletionOption> = {}): CompletionOption {
+	return { long, required: false, optional: false, ...extra };
+}
+
+function command(
+	name: string,
+	spec: {
+		commands?: CompletionCommand[];
+		options?: CompletionOption[];
+		args?: { name: string; choices?: string[] }[];
+		aliases?: string[];
+	} = {},
+): CompletionCommand {
+	return {
+		name: () => name,
+		aliases: () => spec.aliases ?? [],
+		commands: spec.commands ?? [],
+		options: spec.options ?? [],
+		registeredArguments: (spec.args ?? []).map((arg) => ({
+			name: () => arg.name,
+			argChoices: arg.choices,
+		})),
+	};
+}
+
+const program = command("ow", {
+	commands: [
+		command("pull", {
+			args: [{ name: "repo" }],
+			options: [
+				option("--reference", { short: "-r", required: true }),
+				option("--branch", { required: true }),
+				option("--force", { short: "-f" }),
+			],
+		}),
+		command("generate", { aliases: ["gen"], args: [{ name: "repo" }] }),
+		command("config", {
+			commands: [command("set", { args: [{ name: "key" }, { name: "value" }] })],
+		}),
+		command("completion", { args: [{ name: "shell", choices: ["bash", "zsh", "fish"] }] }),
+	],
+});
+
+const sources: CompletionSources = {
+	repos: () => ["colinhacks/zod", "tanstack/router"],
+	references: () => ["colinhacks-zod", "tanstack-router"],
+	configKeys: () => ["repoRoot", "defaultModel", "agents"],
+};
+
+describe("getCompletions", () => {
+	it("completes subcommands by prefix", () => {
+		expect(getCompletions(program, ["co"], sources)).toEqual(["config", "completion"]);
+		expect(getCompletions(program, ["config", ""], sources)).toEqual(["set"]);
+	});
+
+	it("completes repo names for repo arguments, including through aliases", () => {
+		expect(getCompletions(program, ["pull", "t"], sources)).toEqual(["tanstack/router"]);
+		expect(getCompletions(program, ["gen", ""], sources)).toEqual([
+			"colinhacks/zod",
+			"tanstack/router",
+		]);
+	});
+
+	it("completes option values and skips them when locating arguments", () => {
+		expect(getCompletions(program, ["pull", "-r", ""], sources)).toEqual([
+			"colinhacks-zod",
+			"tanstack-router",
+		]);
+		expect(getCompletions(program, ["pull", "--branch", "main", "z"], sources)).toEqual([]);
+		expect(getCompletions(program, ["--log-level", "w"], sources)).toEqual(["warn"]);
+	});
+
+	it("completes flags, config keys and enum choices", () => {
+		expect(getCompletions(program, ["pull", "--f"], sources)).toEqual(["--force"]);
+		expect(getCompletions(program, ["config", "set", "re"], sources)).toEqual(["repoRoot"]);
+		expect(getCompletions(program, ["config", "set", "agents", ""], sources)).toEqual([]);
+		expect(getCompletions(program, ["-c", "a"], sources)).toEqual(["agents="]);
+		expect(getCompletions(program, ["completion", ""], sources)).toEqual(["bash", "zsh", "fish"]);
+	});
+});
+
+describe("renderCompletionScript", () => {
+	it("calls the hidden completion entry point", () => {
+		for (const shell of ["bash", "zsh", "fish"] as const) {
+			expect(renderCompletionScript(shell)).toContain("ow __complete --");
+		}
+	});
+});
@@ -0,0 +1,37 @@
+import { describe, expect, it, vi } from "vitest";
+
+vi.mock("@offworld/sdk/internal", () => ({
+	CONFIG_KEYS: ["maxCommitDistance", "agents"],
+	parseConfigValue: (key: string, value: string) =>
+		key === "agents" ? value.split(",") : Number(value),
+}));
+
+import { extractConfigFlags } from "../utils/config-flags";
+
+describe("extractConfigFlags", () => {
+	it("takes -c before the command and --config anywhere", () => {
+		const result = extractConfigFlags([
+			"-c",
+			"maxCommitDistance=0",
+			"pull",
+			"tanstack/query",
+			"--config=agents=claude-code",
+		]);
+
+		expect(result.argv).toEqual(["pull", "tanstack/query"]);
+		expect(result.overrides).toEqual({ maxCommitDistance: 0, agents: ["claude-code"] });
+	});
+
+	it("leaves a subcommand's own -c alone", () => {
+		for (const command of ["init", "sync"]) {
+			const result = extractConfigFlags(["project", command, "-c", "8"]);
+
+			expect(result.argv).toEqual(["project", command, "-c", "8"]);
+			expect(result.overrides).toEqual({});
+		}
+	});
+
+	it("rejects -c without an assignment before the command", () => {
+		expect(() => extractConfigFlags(["-c", "8", "pull"])).toThrow("Expected key=value");
+	});
+});
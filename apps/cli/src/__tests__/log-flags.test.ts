@@ -0,0 +1,22 @@
+import { describe, expect, it, vi } from "vitest";
+
+vi.mock("@offworld/sdk/internal", () => ({
+	parseLogLevel: (value: string) => value,
+}));
+
+import { extractLogFlags } from "../utils/log-flags";
+
+describe("extractLogFlags", () => {
+	it("keeps everything after the first = in inline values", () => {
+		const result = extractLogFlags(["--log-file=/tmp/a=b.log", "pull", "--log-level", "debug"]);
+
+		expect(result).toEqual({ argv: ["pull"], level: "debug", file: "/tmp/a=b.log" });
+	});
+
+	it("leaves flags after -- alone", () => {
+		const result = extractLogFlags(["pull", "--", "--log-level=debug"]);
+
+		expect(result.argv).toEqual(["pull", "--", "--log-level=debug"]);
+		expect(result.level).toBeUndefined();
+	});
+});
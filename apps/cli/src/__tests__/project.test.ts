@@ -1,5 +1,24 @@
-import { describe, expect, it } from "vitest";
-import { isInternalDependencyVersion } from "../handlers/project";
+import { existsSync, mkdtempSync, rmSync, utimesSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { afterEach, beforeEach, describe, expect, it, vi } from "vitest";
+
+const state = vi.hoisted(() => ({ dir: "" }));
+
+vi.mock("@offworld/sdk/internal", async (importOriginal) => ({
+	...(await importOriginal<typeof import("@offworld/sdk/internal")>()),
+	Paths: {
+		get state() {
+			return state.dir;
+		},
+	},
+}));
+
+import {
+	acquireSyncLock,
+	getSyncLockPath,
+	isInternalDependencyVersion,
+} from "../handlers/project";
 
 describe("isInternalDependencyVersion", () => {
 	it("treats workspace and local path protocols as internal", () => {
@@ -27,3 +46,46 @@ describe("isInternalDependencyVersion", () => {
 		expect(isInternalDependencyVersion("   ")).toBe(false);
 	});
 });
+
+describe("acquireSyncLock", () => {
+	let dir: string;
+	let projectRoot: string;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-sync-lock-"));
+		state.dir = join(dir, "state");
+		projectRoot = join(dir, "project");
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("lets only one sync hold the lock until it is released", () => {
+		const release = acquireSyncLock(projectRoot);
+		expect(release).not.toBeNull();
+		expect(acquireSyncLock(projectRoot)).toBeNull();
+		expect(existsSync(join(projectRoot, ".offworld"))).toBe(false);
+
+		release!();
+		expect(existsSync(getSyncLockPath(projectRoot))).toBe(false);
+		expect(acquireSyncLock(projectRoot)).not.toBeNull();
+	});
+
+	it("replaces a stale lock", () => {
+		acquireSyncLock(projectRoot);
+		const old = new Date(Date.now() - 60 * 60 * 1000);
+		utimesSync(getSyncLockPath(projectRoot), old, old);
+
+		expect(acquireSyncLock(projectRoot)).not.toBeNull();
+	});
+
+	it("leaves a stale lock to the sync already taking it over", () => {
+		acquireSyncLock(projectRoot);
+		const old = new Date(Date.now() - 60 * 60 * 1000);
+		utimesSync(getSyncLockPath(projectRoot), old, old);
+		writeFileSync(`${getSyncLockPath(projectRoot)}.takeover`, "12345");
+
+		expect(acquireSyncLock(projectRoot)).toBeNull();
+	});
+});
@@ -9,9 +9,12 @@ const mocks = vi.hoisted(() => {
 		isRepoCloned: vi.fn(),
 		getClonedRepoPath: vi.fn(),
 		getCommitSha: vi.fn(),
-		getCommitDistance: vi.fn(),
+		resolveCommitDistance: vi.fn(),
+		findForkUpstream: vi.fn(),
+		getForkDivergence: vi.fn(),
 		parseRepoInput: vi.fn(),
 		loadConfig: vi.fn(),
+		getRepoSettings: vi.fn(),
 		loadAuthData: vi.fn(),
 		getMetaPath: vi.fn(),
 		installReference: vi.fn(),
@@ -39,14 +42,22 @@ vi.mock("@offworld/sdk/internal", () => ({
 	isRepoCloned: mocks.isRepoCloned,
 	getClonedRepoPath: mocks.getClonedRepoPath,
 	getCommitSha: mocks.getCommitSha,
-	getCommitDistance: mocks.getCommitDistance,
+	resolveCommitDistance: mocks.resolveCommitDistance,
+	formatCommitDistance: ({ behind, ahead }: { behind: number; ahead: number }) =>
+		`${behind} behind, ${ahead} ahead`,
+	findForkUpstream: mocks.findForkUpstream,
+	getForkDivergence: mocks.getForkDivergence,
+	formatForkDivergence: ({ ahead }: { ahead: number }) => `${ahead} commits ahead`,
+	withForkSupplement: (content: string) => `${content}\n\n## Fork Supplement`,
 	parseRepoInput: mocks.parseRepoInput,
 	loadConfig: mocks.loadConfig,
+	getRepoSettings: mocks.getRepoSettings,
 	loadAuthData: mocks.loadAuthData,
 	getMetaPath: mocks.getMetaPath,
 	RepoExistsError: mocks.RepoExistsError,
 	installReference: mocks.installReference,
 	toReferenceFileName: mocks.toReferenceFileName,
+	getLogger: () => ({ debug: vi.fn(), info: vi.fn(), warn: vi.fn(), error: vi.fn() }),
 	Paths: { offworldReferencesDir: "/tmp/offworld-refs" },
 }));
 
@@ -110,11 +121,26 @@ describe("pullHandler --clone-only", () => {
 			maxCommitDistance: 20,
 			acceptUnknownDistance: false,
 		});
+		mocks.getRepoSettings.mockReturnValue({
+			model: "anthropic/claude-sonnet-4-20250514",
+			sparse: false,
+			maxCommitDistance: 20,
+			acceptUnknownDistance: false,
+			trustRemote: true,
+			matched: [],
+		});
 		mocks.loadAuthData.mockReturnValue(null);
 		mocks.getMetaPath.mockReturnValue("/tmp/does-not-exist");
 		mocks.getCommitSha.mockReturnValue("abcdef0123456789");
 		mocks.toReferenceFileName.mockImplementation((name: string) => `${name.replace("/", "-")}.md`);
-		mocks.getCommitDistance.mockReturnValue(0);
+		mocks.resolveCommitDistance.mockResolvedValue({
+			behind: 0,
+			ahead: 0,
+			method: "local",
+			resolvedAt: "2026-01-01T00:00:00.000Z",
+		});
+		mocks.findForkUpstream.mockResolvedValue(null);
+		mocks.getForkDivergence.mockResolvedValue(null);
 		mocks.resolveReferenceKeywordsForRepo.mockResolvedValue([]);
 		mocks.checkRemote.mockResolvedValue({ exists: false, commitSha: undefined });
 		mocks.checkRemoteByName.mockResolvedValue({ exists: false, commitSha: undefined });
@@ -168,6 +194,100 @@ describe("pullHandler --clone-only", () => {
 		expect(mocks.generateReferenceWithAI).not.toHaveBeenCalled();
 	});
 
+	it("skips remote references when the repo override disables them", async () => {
+		mocks.isRepoCloned.mockReturnValue(true);
+		mocks.getClonedRepoPath.mockReturnValue("/tmp/repos/owner-repo");
+		mocks.getRepoSettings.mockReturnValue({
+			model: "anthropic/claude-sonnet-4-20250514",
+			sparse: false,
+			maxCommitDistance: 20,
+			acceptUnknownDistance: false,
+			trustRemote: false,
+			matched: ["owner/*"],
+		});
+
+		const result = await pullHandler({
+			repo: "owner/repo",
+			skipUpdate: true,
+			quiet: true,
+		});
+
+		expect(result.referenceSource).toBe("local");
+		expect(mocks.checkRemote).not.toHaveBeenCalled();
+		expect(mocks.generateReferenceWithAI).toHaveBeenCalledTimes(1);
+	});
+
+	it("counts commits on both sides when judging a remote reference on another branch", async () => {
+		mocks.isRepoCloned.mockReturnValue(true);
+		mocks.getClonedRepoPath.mockReturnValue("/tmp/repos/owner-repo");
+		mocks.checkRemote.mockResolvedValue({ exists: true, commitSha: "1234567fedcba" });
+		mocks.resolveCommitDistance.mockResolvedValue({
+			behind: 15,
+			ahead: 10,
+			method: "fetch",
+			resolvedAt: "2026-01-01T00:00:00.000Z",
+		});
+
+		const result = await pullHandler({
+			repo: "owner/repo",
+			skipUpdate: true,
+			quiet: true,
+		});
+
+		expect(mocks.resolveCommitDistance).toHaveBeenCalledWith(
+			expect.objectContaining({ qualifiedName: "github.com:owner/repo" }),
+			"/tmp/repos/owner-repo",
+			"1234567fedcba",
+			"abcdef0123456789",
+		);
+		expect(mocks.pullReference).not.toHaveBeenCalled();
+		expect(result.referenceSource).toBe("local");
+		expect(mocks.generateReferenceWithAI).toHaveBeenCalledTimes(1);
+	});
+
+	it("installs the upstream reference with a supplement for a fork without its own", async () => {
+		mocks.isRepoCloned.mockReturnValue(true);
+		mocks.getClonedRepoPath.mockReturnValue("/tmp/repos/owner-repo");
+		mocks.findForkUpstream.mockResolvedValue({
+			upstream: {
+				type: "remote",
+				provider: "github",
+				owner: "upstream",
+				repo: "repo",
+				fullName: "upstream/repo",
+				qualifiedName: "github.com:upstream/repo",
+				cloneUrl: "https://github.com/upstream/repo.git",
+			},
+			method: "github",
+			referenceSha: "1234567fedcba",
+		});
+		mocks.getForkDivergence.mockResolvedValue({ ahead: 4, behind: 0, commits: [], files: [] });
+		mocks.pullReference.mockResolvedValue({
+			referenceContent: "# upstream ref",
+			commitSha: "1234567fedcba",
+			generatedAt: "2026-01-01T00:00:00.000Z",
+		});
+
+		const result = await pullHandler({
+			repo: "owner/repo",
+			skipUpdate: true,
+			quiet: true,
+		});
+
+		expect(result).toMatchObject({ success: true, referenceSource: "remote" });
+		expect(mocks.pullReference).toHaveBeenCalledWith("upstream/repo");
+		expect(mocks.installReference).toHaveBeenCalledWith(
+			"github.com:owner/repo",
+			"owner/repo",
+			"/tmp/repos/owner-repo",
+			"# upstream ref\n\n## Fork Supplement",
+			expect.objectContaining({ commitSha: "1234567fedcba" }),
+			[],
+			expect.objectContaining({ referenceSource: "remote", forkOf: "github.com:upstream/repo" }),
+		);
+		expect(mocks.generateReferenceWithAI).not.toHaveBeenCalled();
+	});
+
 	it("rejects --clone-only combined with --reference", async () => {
 		mocks.isRepoCloned.mockReturnValue(true);
 		mocks.getClonedRepoPath.mockReturnValue("/tmp/repos/owner-repo");
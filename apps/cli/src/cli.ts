@@ -1,17 +1,74 @@
 #!/usr/bin/env node
 
+import { resolve } from "node:path";
+import {
+	createLogger,
+	expandTilde,
+	getPluginCommands,
+	loadPlugins,
+	setConfigOverrides,
+	setDefaultLogger,
+	setJournalCommand,
+} from "@offworld/sdk/internal";
 import { loadDevEnv } from "./env-loader.js";
-import { createOwCli, version } from "./index.js";
+import { createOwCli, router, version } from "./index.js";
+import { getCompletions } from "./utils/completion.js";
+import { extractConfigFlags } from "./utils/config-flags.js";
+import { extractLogFlags } from "./utils/log-flags.js";
 
 loadDevEnv();
 
 const cli = createOwCli();
 
-const args = process.argv.slice(2);
+// Hidden entry point for completion scripts. Runs before plugins and logging to stay fast.
+if (process.argv[2] === "__complete") {
+	const words = process.argv.slice(process.argv[3] === "--" ? 4 : 3);
+	for (const candidate of getCompletions(cli.buildProgram(), words)) {
+		console.log(candidate);
+	}
+	process.exit(0);
+}
+
+let args: string[];
+try {
+	const logFlags = extractLogFlags(process.argv.slice(2));
+	setDefaultLogger(
+		createLogger({
+			level: logFlags.level,
+			file: logFlags.file ? resolve(expandTilde(logFlags.file)) : undefined,
+		}),
+	);
+
+	const { argv, overrides } = extractConfigFlags(logFlags.argv);
+	setConfigOverrides(overrides);
+	args = argv;
+} catch (error) {
+	console.error(error instanceof Error ? error.message : String(error));
+	process.exit(1);
+}
+
+setJournalCommand(["ow", ...args].join(" "));
+
+// Load failures are reported through the logger (warn level)
+await loadPlugins();
+
+// Built-in commands always win over plugin commands with the same name
+const pluginCommand =
+	args[0] && !(args[0] in router)
+		? getPluginCommands().find((command) => command.name === args[0])
+		: undefined;
+
 if (args.length === 0) {
 	cli.run({ argv: ["--help"] });
 } else if (args[0] === "-v" || args[0] === "--version") {
 	console.log(`offworld v${version}`);
+} else if (pluginCommand) {
+	try {
+		await pluginCommand.run(args.slice(1));
+	} catch (error) {
+		console.error(error instanceof Error ? error.message : String(error));
+		process.exit(1);
+	}
 } else {
-	cli.run();
+	cli.run({ argv: args });
 }
@@ -0,0 +1,100 @@
+/**
+ * API command handler
+ */
+
+import * as p from "@clack/prompts";
+import {
+	ApiExtractionError,
+	getMapEntry,
+	openApiLookup,
+	recordUsage,
+	type ApiLookup,
+	type ApiSignature,
+} from "@offworld/sdk/internal";
+
+export interface ApiOptions {
+	repo: string;
+	/** Exported name, or Name.member for class members and namespace exports */
+	symbol: string;
+	json?: boolean;
+}
+
+export interface ApiResult {
+	found: boolean;
+	signatures: ApiSignature[];
+	/** Exported names close to the requested symbol when nothing matched */
+	suggestions?: string[];
+}
+
+const MAX_SUGGESTIONS = 8;
+
+function suggestNames(lookup: ApiLookup, symbol: string): string[] {
+	const needle = symbol.split(".")[0]!.toLowerCase();
+	const names = new Set(lookup.list().map((entry) => entry.name));
+	return [...names]
+		.filter((name) => {
+			const lower = name.toLowerCase();
+			return lower.includes(needle) || needle.includes(lower);
+		})
+		.slice(0, MAX_SUGGESTIONS);
+}
+
+export async function apiHandler(options: ApiOptions): Promise<ApiResult> {
+	const { repo, symbol, json } = options;
+
+	const entry = getMapEntry(repo);
+	if (!entry) {
+		if (json) {
+			console.log(JSON.stringify({ found: false, signatures: [] }));
+		} else {
+			p.log.error(`Repo not found: ${repo}`);
+		}
+		return { found: false, signatures: [] };
+	}
+
+	const { localPath } = entry.entry;
+	let lookup: ApiLookup;
+	try {
+		lookup = await openApiLookup(localPath);
+	} catch (error) {
+		if (!(error instanceof ApiExtractionError)) throw error;
+		if (json) {
+			console.log(JSON.stringify({ found: false, signatures: [], error: error.message }));
+		} else {
+			p.log.error(error.message);
+		}
+		return { found: false, signatures: [] };
+	}
+	recordUsage(entry.qualifiedName, "api");
+
+	const signatures = lookup.find(symbol);
+	if (signatures.length === 0) {
+		const suggestions = suggestNames(lookup, symbol);
+		if (json) {
+			console.log(JSON.stringify({ found: false, signatures: [], suggestions }, null, 2));
+		} else {
+			p.log.warn(`No exported symbol named ${symbol}`);
+			if (suggestions.length > 0) {
+				p.log.info(`Did you mean: ${suggestions.join(", ")}`);
+			}
+		}
+		return { found: false, signatures: [], suggestions };
+	}
+
+	if (json) {
+		console.log(JSON.stringify({ found: true, signatures }, null, 2));
+	} else {
+		for (const signature of signatures) {
+			const from = signature.packageName ? ` (${signature.packageName})` : "";
+			console.log(`${signature.name}: ${signature.kind}${from}`);
+			console.log(`  ${signature.file}:${signature.line}`);
+			console.log(`\`\`\`ts\n${signature.signature}\n\`\`\``);
+			if (signature.documentation) {
+				console.log(signature.documentation);
+			}
+			console.log("");
+		}
+	}
+
+	return { found: true, signatures };
+}
@@ -0,0 +1,19 @@
+/**
+ * Completion command handler
+ */
+
+import { renderCompletionScript, type CompletionShell } from "../utils/completion";
+
+export interface CompletionOptions {
+	shell: CompletionShell;
+}
+
+export interface CompletionResult {
+	script: string;
+}
+
+export async function completionHandler(options: CompletionOptions): Promise<CompletionResult> {
+	const script = renderCompletionScript(options.shell);
+	process.stdout.write(script);
+	return { script };
+}
@@ -6,14 +6,19 @@ import * as p from "@clack/prompts";
 import {
 	loadConfig,
 	saveConfig,
+	resetConfig,
+	resolveConfig,
 	getConfigPath,
+	getSystemConfigPath,
+	getProjectConfigPath,
 	detectInstalledAgents,
 	getAllAgentConfigs,
+	recordConfigChange,
+	resolveDaemonSchedule,
 	Paths,
+	type ConfigOrigin,
 } from "@offworld/sdk/internal";
-import { ConfigSchema, AgentSchema } from "@offworld/types/schemas";
-import type { Agent } from "@offworld/types";
-import { z } from "zod";
+import { DaemonConfigSchema, SkillModeSchema } from "@offworld/types/schemas";
 import { existsSync } from "node:fs";
 import { join, resolve } from "node:path";
 
@@ -23,6 +28,10 @@ const VALID_KEYS = [
 	"maxCommitDistance",
 	"acceptUnknownDistance",
 	"agents",
+	"plugins",
+	"skillMode",
+	"apiAppendix",
+	"daemon",
 ] as const;
 type ConfigKey = (typeof VALID_KEYS)[number];
 
@@ -32,10 +41,13 @@ function isValidKey(key: string): key is ConfigKey {
 
 export interface ConfigShowOptions {
 	json?: boolean;
+	/** Show where each value came from */
+	origin?: boolean;
 }
 
 export interface ConfigShowResult {
 	config: Record<string, unknown>;
+	origins: Record<string, ConfigOrigin>;
 	paths: {
 		skillDir: string;
 		referencesDir: string;
@@ -45,7 +57,7 @@ export interface ConfigShowResult {
 }
 
 export async function configShowHandler(options: ConfigShowOptions): Promise<ConfigShowResult> {
-	const config = loadConfig();
+	const { config, origins } = resolveConfig();
 
 	const projectMapPath = resolve(process.cwd(), ".offworld/map.json");
 	const hasProjectMap = existsSync(projectMapPath);
@@ -62,22 +74,33 @@ export async function configShowHandler(options: ConfigShowOptions): Promise<Con
 	if (options.json) {
 		const output = {
 			...config,
+			...(options.origin ? { origins } : {}),
 			paths,
 		};
 		console.log(JSON.stringify(output, null, 2));
 	} else {
 		p.log.info("Current configuration:\n");
 		for (const [key, value] of Object.entries(config)) {
-			console.log(`  ${key}: ${JSON.stringify(value)}`);
+			const origin = options.origin ? `  (${formatOrigin(origins[key as ConfigKey])})` : "";
+			console.log(`  ${key}: ${JSON.stringify(value)}${origin}`);
 		}
 		console.log("");
 		p.log.info(`Config file: ${getConfigPath()}`);
+		if (options.origin) {
+			p.log.info(`System config: ${getSystemConfigPath()}`);
+			p.log.info(`Project config: ${getProjectConfigPath()}`);
+		}
 		if (hasProjectMap) {
 			p.log.info(`Project map: ${projectMapPath}`);
 		}
 	}
 
-	return { config, paths };
+	return { config, origins, paths };
+}
+
+function formatOrigin(origin: ConfigOrigin | undefined): string {
+	if (!origin) return "default";
+	return origin.location ? `${origin.source}: ${origin.location}` : origin.source;
 }
 
 export interface ConfigSetOptions {
@@ -104,26 +127,61 @@ export async function configSetHandler(options: ConfigSetOptions): Promise<Confi
 		};
 	}
 
-	let parsedValue: string | number | boolean | Agent[];
+	let parsedValue: string | number | boolean | string[] | Record<string, unknown>;
 
 	if (key === "agents") {
 		const agentValues = value
 			.split(",")
 			.map((a) => a.trim())
 			.filter(Boolean);
-		const agentsResult = z.array(AgentSchema).safeParse(agentValues);
+		const validAgents = getAllAgentConfigs().map((cfg) => cfg.name);
+		const invalidAgents = agentValues.filter((a) => !validAgents.includes(a));
 
-		if (!agentsResult.success) {
-			const invalidAgents = agentValues.filter((a) => !AgentSchema.options.includes(a as Agent));
+		if (invalidAgents.length > 0) {
 			p.log.error(`Invalid agent(s): ${invalidAgents.join(", ")}`);
-			p.log.info(`Valid agents: ${AgentSchema.options.join(", ")}`);
+			p.log.info(`Valid agents: ${validAgents.join(", ")}`);
 			return {
 				success: false,
 				message: `Invalid agents: ${invalidAgents.join(", ")}`,
 			};
 		}
 
-		parsedValue = agentsResult.data;
+		parsedValue = agentValues;
+	} else if (key === "skillMode") {
+		const parsed = SkillModeSchema.safeParse(value.trim());
+		if (!parsed.success) {
+			p.log.error(`skillMode must be one of: ${SkillModeSchema.options.join(", ")}.`);
+			return {
+				success: false,
+				message: "Invalid skillMode value",
+			};
+		}
+		parsedValue = parsed.data;
+	} else if (key === "daemon") {
+		let parsed: ReturnType<typeof DaemonConfigSchema.safeParse>;
+		try {
+			parsed = DaemonConfigSchema.safeParse(JSON.parse(value));
+			if (parsed.success) resolveDaemonSchedule(parsed.data);
+		} catch (error) {
+			p.log.error(error instanceof Error ? error.message : String(error));
+			return {
+				success: false,
+				message: "Invalid daemon value",
+			};
+		}
+		if (!parsed.success) {
+			p.log.error(`daemon must be a JSON object of daemon settings: ${parsed.error.message}`);
+			return {
+				success: false,
+				message: "Invalid daemon value",
+			};
+		}
+		parsedValue = parsed.data;
+	} else if (key === "plugins") {
+		parsedValue = value
+			.split(",")
+			.map((plugin) => plugin.trim())
+			.filter(Boolean);
 	} else if (key === "maxCommitDistance") {
 		const parsed = Number.parseInt(value, 10);
 		if (Number.isNaN(parsed) || parsed < 0) {
@@ -134,13 +192,13 @@ export async function configSetHandler(options: ConfigSetOptions): Promise<Confi
 			};
 		}
 		parsedValue = parsed;
-	} else if (key === "acceptUnknownDistance") {
+	} else if (key === "acceptUnknownDistance" || key === "apiAppendix") {
 		const normalized = value.trim().toLowerCase();
 		if (normalized !== "true" && normalized !== "false") {
-			p.log.error("acceptUnknownDistance must be 'true' or 'false'.");
+			p.log.error(`${key} must be 'true' or 'false'.`);
 			return {
 				success: false,
-				message: "Invalid acceptUnknownDistance value",
+				message: `Invalid ${key} value`,
 			};
 		}
 		parsedValue = normalized === "true";
@@ -150,7 +208,8 @@ export async function configSetHandler(options: ConfigSetOptions): Promise<Confi
 
 	try {
 		const updates = { [key]: parsedValue };
-		saveConfig(updates);
+		const before = loadConfig();
+		recordConfigChange(before, saveConfig(updates));
 		p.log.success(`Set ${key} = ${JSON.stringify(parsedValue)}`);
 
 		return {
@@ -199,11 +258,12 @@ export interface ConfigResetResult {
 
 export async function configResetHandler(): Promise<ConfigResetResult> {
 	try {
-		const defaults = ConfigSchema.parse({});
-		saveConfig(defaults);
+		const before = loadConfig();
+		const config = resetConfig();
+		recordConfigChange(before, config);
 
-		p.log.success("Configuration reset to defaults:");
-		for (const [key, value] of Object.entries(defaults)) {
+		p.log.success("User configuration cleared. Effective configuration:");
+		for (const [key, value] of Object.entries(config)) {
 			console.log(`  ${key}: ${JSON.stringify(value)}`);
 		}
 
@@ -227,7 +287,7 @@ export async function configPathHandler(): Promise<ConfigPathResult> {
 
 export interface ConfigAgentsResult {
 	success: boolean;
-	agents?: Agent[];
+	agents?: string[];
 }
 
 export async function configAgentsHandler(): Promise<ConfigAgentsResult> {
@@ -261,11 +321,11 @@ export async function configAgentsHandler(): Promise<ConfigAgentsResult> {
 		return { success: false };
 	}
 
-	const parsedAgents = z.array(AgentSchema).safeParse(agentsResult);
-	const agents = parsedAgents.success ? parsedAgents.data : [];
+	const validAgents = new Set(allAgentConfigs.map((cfg) => cfg.name));
+	const agents = agentsResult.filter((a) => validAgents.has(a));
 
 	try {
-		saveConfig({ agents });
+		recordConfigChange(config, saveConfig({ agents }));
 		p.log.success(`Agents set to: ${agents.join(", ") || "(none)"}`);
 		return { success: true, agents };
 	} catch (error) {
@@ -0,0 +1,276 @@
+/**
+ * Daemon command handlers
+ */
+
+import { spawn } from "node:child_process";
+import {
+	existsSync,
+	mkdirSync,
+	readFileSync,
+	unwatchFile,
+	watchFile,
+	writeFileSync,
+} from "node:fs";
+import { dirname } from "node:path";
+import * as p from "@clack/prompts";
+import {
+	createLogger,
+	DAEMON_TASKS,
+	DaemonNotRunningError,
+	getDaemonPaths,
+	getSystemdUnitPath,
+	isDaemonRunning,
+	prepareDaemonLog,
+	readDaemonPid,
+	renderSystemdUnit,
+	requestDaemon,
+	setDefaultLogger,
+	startDaemon,
+	type DaemonStatus,
+	type DaemonTask,
+} from "@offworld/sdk/internal";
+
+export interface DaemonStartOptions {
+	/** Run in this process instead of detaching */
+	foreground?: boolean;
+}
+
+export interface DaemonStartResult {
+	started: boolean;
+	pid?: number;
+}
+
+export interface DaemonStatusOptions {
+	json?: boolean;
+}
+
+export interface DaemonStatusResult {
+	running: boolean;
+	status?: DaemonStatus;
+}
+
+export interface DaemonLogsOptions {
+	lines?: number;
+	follow?: boolean;
+}
+
+export interface DaemonRunOptions {
+	task: DaemonTask;
+}
+
+export interface DaemonUnitOptions {
+	/** Write the unit to the systemd user directory instead of printing it */
+	install?: boolean;
+}
+
+export interface DaemonUnitResult {
+	unit: string;
+	path?: string;
+}
+
+/** How long `ow daemon start` waits for the detached daemon to answer */
+const START_TIMEOUT_MS = 5000;
+
+/**
+ * Command that runs this CLI again: the script when running under node/bun, otherwise the
+ * compiled binary itself.
+ */
+function getSelfCommand(): string[] {
+	const script = process.argv[1];
+	return script && existsSync(script) ? [process.execPath, script] : [process.execPath];
+}
+
+function formatTime(iso: string | undefined): string {
+	return iso ? iso.slice(0, 16).replace("T", " ") : "never";
+}
+
+function formatInterval(ms: number | null): string {
+	if (ms === null) return "off";
+	if (ms % 86_400_000 === 0) return `${ms / 86_400_000}d`;
+	if (ms % 3_600_000 === 0) return `${ms / 3_600_000}h`;
+	return `${Math.round(ms / 60_000)}m`;
+}
+
+function formatLogLine(line: string): string {
+	try {
+		const { time, level, message, ...fields } = JSON.parse(line) as Record<string, unknown>;
+		const extra = Object.entries(fields)
+			.map(([key, value]) => `${key}=${typeof value === "string" ? value : JSON.stringify(value)}`)
+			.join(" ");
+		const stamp = String(time).slice(0, 19).replace("T", " ");
+		return `${stamp}  ${String(level).padEnd(5)}  ${String(message)}${extra ? `  ${extra}` : ""}`;
+	} catch {
+		return line;
+	}
+}
+
+export async function daemonStartHandler(
+	options: DaemonStartOptions = {},
+): Promise<DaemonStartResult> {
+	if (await isDaemonRunning()) {
+		p.log.info(`Daemon already running (pid ${readDaemonPid() ?? "unknown"}).`);
+		return { started: false, pid: readDaemonPid() ?? undefined };
+	}
+
+	if (options.foreground) {
+		setDefaultLogger(createLogger({ level: "info", file: prepareDaemonLog(), fileLevel: "info" }));
+		const daemon = await startDaemon();
+		const stop = () => void daemon.stop();
+		process.once("SIGINT", stop);
+		process.once("SIGTERM", stop);
+		await daemon.closed;
+		return { started: true, pid: process.pid };
+	}
+
+	const [command, ...args] = getSelfCommand();
+	const child = spawn(command!, [...args, "daemon", "start", "--foreground"], {
+		detached: true,
+		stdio: "ignore",
+	});
+	child.unref();
+
+	const deadline = Date.now() + START_TIMEOUT_MS;
+	while (Date.now() < deadline) {
+		await new Promise((resolve) => setTimeout(resolve, 200));
+		if (await isDaemonRunning()) {
+			p.log.success(`Daemon started (pid ${child.pid}).`);
+			return { started: true, pid: child.pid };
+		}
+	}
+
+	p.log.error(`Daemon did not start. Check the log: ${getDaemonPaths().log}`);
+	return { started: false };
+}
+
+export async function daemonStatusHandler(
+	options: DaemonStatusOptions = {},
+): Promise<DaemonStatusResult> {
+	let status: DaemonStatus;
+	try {
+		status = await requestDaemon<DaemonStatus>("status");
+	} catch (error) {
+		if (options.json) {
+			console.log(JSON.stringify({ running: false }, null, 2));
+		} else if (error instanceof DaemonNotRunningError) {
+			p.log.info("Daemon is not running.");
+		} else {
+			p.log.error(error instanceof Error ? error.message : String(error));
+		}
+		return { running: false };
+	}
+
+	if (options.json) {
+		console.log(JSON.stringify({ running: true, status }, null, 2));
+		return { running: true, status };
+	}
+
+	p.log.success(`Daemon running (pid ${status.pid}, v${status.version})`);
+	const queued = status.queue.length > 0 ? ` (queued: ${status.queue.join(", ")})` : "";
+	const lines = [
+		`Started:  ${formatTime(status.startedAt)}`,
+		`Socket:   ${status.socketPath}`,
+		`OpenCode: ${status.openCode ? "warm" : "off"}`,
+		`Running:  ${status.running ?? "idle"}${queued}`,
+		"",
+	];
+	for (const task of DAEMON_TASKS) {
+		const state = status.tasks[task];
+		const interval = formatInterval(status.schedule.intervals[task]);
+		const result = state.lastError ? `failed: ${state.lastError}` : (state.lastSummary ?? "");
+		const times = `last ${formatTime(state.lastRunAt)}  next ${formatTime(state.nextRunAt)}`;
+		const suffix = result ? `  ${result}` : "";
+		lines.push(`${task.padEnd(12)}  every ${interval.padEnd(4)}  ${times}${suffix}`);
+	}
+	console.log(lines.join("\n"));
+	return { running: true, status };
+}
+
+export async function daemonLogsHandler(options: DaemonLogsOptions = {}): Promise<void> {
+	const { lines = 50, follow = false } = options;
+	const { log } = getDaemonPaths();
+
+	if (!existsSync(log)) {
+		p.log.info("No daemon log yet.");
+		return;
+	}
+
+	const content = readFileSync(log, "utf-8");
+	const tail = content.split("\n").filter(Boolean).slice(-lines);
+	for (const line of tail) console.log(formatLogLine(line));
+	if (!follow) return;
+
+	let offset = Buffer.byteLength(content);
+	watchFile(log, { interval: 500 }, (current) => {
+		// Rotated or truncated: start over from the beginning
+		if (current.size < offset) offset = 0;
+		const added = readFileSync(log).subarray(offset);
+		offset += added.length;
+		for (const line of added.toString("utf-8").split("\n").filter(Boolean)) {
+			console.log(formatLogLine(line));
+		}
+	});
+	await new Promise<void>((resolve) => {
+		process.once("SIGINT", () => {
+			unwatchFile(log);
+			resolve();
+		});
+	});
+}
+
+export async function daemonStopHandler(): Promise<void> {
+	try {
+		await requestDaemon("stop");
+		p.log.success("Daemon stopped.");
+		return;
+	} catch (error) {
+		if (!(error instanceof DaemonNotRunningError)) {
+			p.log.warn(error instanceof Error ? error.message : String(error));
+		}
+	}
+
+	// Socket gone or unresponsive: fall back to the recorded pid
+	const pid = readDaemonPid();
+	if (pid === null) {
+		p.log.info("Daemon is not running.");
+		return;
+	}
+	try {
+		process.kill(pid, "SIGTERM");
+		p.log.success(`Sent SIGTERM to daemon (pid ${pid}).`);
+	} catch {
+		p.log.info("Daemon is not running.");
+	}
+}
+
+export async function daemonRunHandler(options: DaemonRunOptions): Promise<void> {
+	try {
+		const { queued } = await requestDaemon<{ queued: boolean }>("run", { task: options.task });
+		if (queued) {
+			p.log.success(`Queued ${options.task}. Follow progress with 'ow daemon logs -f'.`);
+		} else {
+			p.log.info(`${options.task} is already queued or running.`);
+		}
+	} catch (error) {
+		p.log.error(error instanceof Error ? error.message : String(error));
+	}
+}
+
+export async function daemonUnitHandler(
+	options: DaemonUnitOptions = {},
+): Promise<DaemonUnitResult> {
+	const unit = renderSystemdUnit(getSelfCommand());
+
+	if (!options.install) {
+		process.stdout.write(unit);
+		return { unit };
+	}
+
+	const path = getSystemdUnitPath();
+	mkdirSync(dirname(path), { recursive: true });
+	writeFileSync(path, unit, "utf-8");
+	p.log.success(`Wrote ${path}`);
+	p.log.info(
+		"Enable it with: systemctl --user daemon-reload && systemctl --user enable --now offworld",
+	);
+	return { unit, path };
+}
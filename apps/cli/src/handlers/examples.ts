@@ -0,0 +1,154 @@
+/**
+ * Examples command handlers
+ */
+
+import { join } from "node:path";
+import * as p from "@clack/prompts";
+import {
+	buildExamplesIndex,
+	getCommitSha,
+	getMapEntry,
+	readExamplesIndex,
+	readGlobalMap,
+	recordUsage,
+	searchExamples,
+	writeExamplesIndex,
+	type ExampleMatch,
+	type ExamplesIndex,
+} from "@offworld/sdk/internal";
+import { createSpinner } from "../utils/spinner";
+
+export interface ExamplesBuildOptions {
+	repo?: string;
+	/** Build for every repo in the global map */
+	all?: boolean;
+}
+
+export interface ExamplesBuildResult {
+	built: Array<{ repo: string; examples: number }>;
+	failed: Array<{ repo: string; error: string }>;
+}
+
+export interface ExamplesSearchOptions {
+	repo: string;
+	query: string;
+	limit?: number;
+	json?: boolean;
+}
+
+export interface ExamplesSearchResult {
+	found: boolean;
+	matches: ExampleMatch[];
+}
+
+function toFullName(qualifiedName: string): string {
+	return qualifiedName.slice(qualifiedName.indexOf(":") + 1);
+}
+
+function buildAndSave(qualifiedName: string, localPath: string): ExamplesIndex {
+	const index = buildExamplesIndex(localPath, qualifiedName);
+	writeExamplesIndex(toFullName(qualifiedName), index);
+	return index;
+}
+
+function isStale(index: ExamplesIndex, localPath: string): boolean {
+	try {
+		return index.commitSha !== getCommitSha(localPath);
+	} catch {
+		return false;
+	}
+}
+
+export async function examplesBuildHandler(
+	options: ExamplesBuildOptions,
+): Promise<ExamplesBuildResult> {
+	const result: ExamplesBuildResult = { built: [], failed: [] };
+
+	let targets: Array<[string, string]>;
+	if (options.all) {
+		targets = Object.entries(readGlobalMap().repos).map(([name, entry]) => [name, entry.localPath]);
+	} else if (options.repo) {
+		const entry = getMapEntry(options.repo);
+		if (!entry) {
+			p.log.error(`Repo not found: ${options.repo}`);
+			return result;
+		}
+		targets = [[entry.qualifiedName, entry.entry.localPath]];
+	} else {
+		p.log.error("Specify a repo or --all.");
+		return result;
+	}
+
+	const s = createSpinner();
+	for (const [qualifiedName, localPath] of targets) {
+		s.start(`Indexing examples in ${toFullName(qualifiedName)}`);
+		try {
+			const index = buildAndSave(qualifiedName, localPath);
+			result.built.push({ repo: qualifiedName, examples: index.examples.length });
+			s.stop(`${toFullName(qualifiedName)}: ${index.examples.length} snippets`);
+		} catch (error) {
+			const message = error instanceof Error ? error.message : String(error);
+			result.failed.push({ repo: qualifiedName, error: message });
+			s.stop(`${toFullName(qualifiedName)}: failed`);
+			p.log.warn(message);
+		}
+	}
+
+	if (targets.length > 1) {
+		p.log.success(`Indexed ${result.built.length} repos (${result.failed.length} failed).`);
+	}
+	return result;
+}
+
+export async function examplesSearchHandler(
+	options: ExamplesSearchOptions,
+): Promise<ExamplesSearchResult> {
+	const { repo, query, limit = 5, json } = options;
+
+	const entry = getMapEntry(repo);
+	if (!entry) {
+		if (json) {
+			console.log(JSON.stringify({ found: false, matches: [] }));
+		} else {
+			p.log.error(`Repo not found: ${repo}`);
+		}
+		return { found: false, matches: [] };
+	}
+
+	const { qualifiedName } = entry;
+	const { localPath } = entry.entry;
+	let index = readExamplesIndex(toFullName(qualifiedName));
+	// Build on first use and after the clone moves, so results always point at current lines
+	if (!index || isStale(index, localPath)) {
+		if (!json) p.log.info(`Indexing examples in ${toFullName(qualifiedName)}...`);
+		index = buildAndSave(qualifiedName, localPath);
+	}
+	recordUsage(qualifiedName, "examples");
+
+	const matches = searchExamples(index, query, { limit });
+
+	if (json) {
+		console.log(
+			JSON.stringify(
+				{
+					found: true,
+					matches: matches.map((match) => ({ ...match, file: join(localPath, match.path) })),
+				},
+				null,
+				2,
+			),
+		);
+	} else if (matches.length === 0) {
+		p.log.warn(`No examples found for: ${query}`);
+	} else {
+		for (const match of matches) {
+			const symbols = match.symbols.length > 0 ? `  [${match.symbols.join(", ")}]` : "";
+			console.log(`${join(localPath, match.path)}:${match.startLine}`);
+			console.log(`  ${match.kind}: ${match.title}${symbols}`);
+			console.log(`\`\`\`${match.language}\n${match.code}\n\`\`\``);
+			console.log("");
+		}
+	}
+
+	return { found: true, matches };
+}
@@ -88,18 +88,27 @@ export async function generateHandler(options: GenerateOptions): Promise<Generat
 		const result = await generateReferenceWithAI(repoPath, referenceRepoName, {
 			provider,
 			model,
+			source,
 			onDebug: (msg: string) => s.message(msg),
 		});
 		s.stop("Reference generated");
 
-		const { referenceContent, commitSha } = result;
+		const { referenceContent, shortContent, commitSha } = result;
 		const referenceUpdatedAt = new Date().toISOString();
 		const meta = { referenceUpdatedAt, commitSha, version: "0.1.0" };
 		const keywords = await resolveReferenceKeywordsForRepo(repoPath, referenceRepoName);
 
 		const referencePath = getReferencePath(referenceRepoName);
 
-		installReference(qualifiedName, referenceRepoName, repoPath, referenceContent, meta, keywords);
+		await installReference(
+			qualifiedName,
+			referenceRepoName,
+			repoPath,
+			referenceContent,
+			meta,
+			keywords,
+			{ referenceSource: "local", shortContent },
+		);
 
 		p.log.success(`Reference saved to: ${referencePath}`);
 		p.log.info(`Reference installed for: ${qualifiedName}`);
@@ -48,14 +48,66 @@ export {
 	type AuthStatusResult,
 } from "./auth.js";
 export { initHandler, type InitOptions, type InitResult } from "./init.js";
-export { projectInitHandler, type ProjectInitOptions, type ProjectInitResult } from "./project.js";
+export {
+	projectInitHandler,
+	projectSyncHandler,
+	projectHooksInstallHandler,
+	projectHooksUninstallHandler,
+	projectHooksStatusHandler,
+	type ProjectInitOptions,
+	type ProjectInitResult,
+	type ProjectSyncOptions,
+	type ProjectSyncResult,
+	type ProjectHooksOptions,
+	type ProjectHooksHandlerResult,
+} from "./project.js";
 export { upgradeHandler, type UpgradeOptions, type UpgradeResult } from "./upgrade.js";
 export { uninstallHandler, type UninstallOptions, type UninstallResult } from "./uninstall.js";
 export {
 	mapShowHandler,
 	mapSearchHandler,
+	mapRelatedHandler,
+	mapGraphHandler,
 	type MapShowOptions,
 	type MapShowResult,
 	type MapSearchOptions,
 	type MapSearchResult,
+	type MapRelatedOptions,
+	type MapRelatedResult,
+	type MapGraphOptions,
+	type MapGraphResult,
 } from "./map.js";
+export {
+	examplesBuildHandler,
+	examplesSearchHandler,
+	type ExamplesBuildOptions,
+	type ExamplesBuildResult,
+	type ExamplesSearchOptions,
+	type ExamplesSearchResult,
+} from "./examples.js";
+export { apiHandler, type ApiOptions, type ApiResult } from "./api.js";
+export { statsHandler, type StatsOptions, type StatsResult } from "./stats.js";
+export { logHandler, type LogOptions, type LogResult } from "./log.js";
+export {
+	pluginsListHandler,
+	type PluginsListOptions,
+	type PluginsListResult,
+} from "./plugins.js";
+export { completionHandler, type CompletionOptions, type CompletionResult } from "./completion.js";
+export {
+	daemonStartHandler,
+	daemonStatusHandler,
+	daemonLogsHandler,
+	daemonStopHandler,
+	daemonRunHandler,
+	daemonUnitHandler,
+	type DaemonStartOptions,
+	type DaemonStartResult,
+	type DaemonStatusOptions,
+	type DaemonStatusResult,
+	type DaemonLogsOptions,
+	type DaemonRunOptions,
+	type DaemonUnitOptions,
+	type DaemonUnitResult,
+} from "./daemon.js";
+export { serveHandler, type ServeOptions, type ServeResult } from "./serve.js";
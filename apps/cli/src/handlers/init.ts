@@ -20,12 +20,11 @@ import {
 	readGlobalMap,
 	writeGlobalMap,
 	resolveReferenceKeywords,
+	recordConfigChange,
 	type ProviderInfo,
 	type ModelInfo,
 } from "@offworld/sdk/internal";
-import type { Config, Agent } from "@offworld/types";
-import { AgentSchema } from "@offworld/types/schemas";
-import { z } from "zod";
+import type { Config } from "@offworld/types";
 import { authLoginHandler } from "./auth.js";
 
 export interface InitOptions {
@@ -272,19 +271,18 @@ export async function initHandler(options: InitOptions = {}): Promise<InitResult
 		hint: config.globalSkillsDir,
 	}));
 
-	let agents: Agent[];
+	let agents: string[];
 	if (options.agents) {
 		const agentNames = options.agents.split(",").map((a) => a.trim());
 		const validAgents = allAgentConfigs
 			.filter((c) => agentNames.includes(c.name))
 			.map((c) => c.name);
-		const agentsResult = z.array(AgentSchema).safeParse(validAgents);
-		if (!agentsResult.success || agentsResult.data.length === 0) {
+		if (validAgents.length === 0) {
 			p.log.error("No valid agent names provided");
 			p.outro("Setup failed");
 			return { success: false, configPath };
 		}
-		agents = agentsResult.data;
+		agents = validAgents;
 	} else {
 		const initialAgents =
 			existingConfig.agents && existingConfig.agents.length > 0
@@ -303,8 +301,8 @@ export async function initHandler(options: InitOptions = {}): Promise<InitResult
 			return { success: false, configPath };
 		}
 
-		const agentsResult = z.array(AgentSchema).safeParse(selectResult);
-		agents = agentsResult.success ? agentsResult.data : [];
+		const knownAgents = new Set(allAgentConfigs.map((c) => c.name));
+		agents = selectResult.filter((a) => knownAgents.has(a));
 	}
 
 	const defaultModel = `${provider}/${model}`;
@@ -315,7 +313,7 @@ export async function initHandler(options: InitOptions = {}): Promise<InitResult
 	};
 
 	try {
-		saveConfig(newConfig);
+		recordConfigChange(existingConfig, saveConfig(newConfig));
 		installGlobalSkill();
 
 		p.log.success("Configuration saved!");
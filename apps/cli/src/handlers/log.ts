@@ -0,0 +1,72 @@
+/**
+ * Log command handler (operation journal)
+ */
+
+import * as p from "@clack/prompts";
+import {
+	JOURNAL_OPERATIONS,
+	parseSince,
+	readJournal,
+	type JournalEntry,
+	type JournalOperation,
+} from "@offworld/sdk/internal";
+
+export interface LogOptions {
+	repo?: string;
+	/** Duration (7d, 12h) or date */
+	since?: string;
+	op?: string;
+	limit?: number;
+	json?: boolean;
+}
+
+export interface LogResult {
+	entries: JournalEntry[];
+}
+
+function isJournalOperation(op: string): op is JournalOperation {
+	return JOURNAL_OPERATIONS.includes(op as JournalOperation);
+}
+
+function formatEntry(entry: JournalEntry): string {
+	const time = entry.time.slice(0, 16).replace("T", " ");
+	const target = entry.repo ?? "config";
+	const summary = entry.summary ? `  ${entry.summary}` : "";
+	const command = entry.command ? `  (${entry.command})` : "";
+	return `${time}  ${entry.op.padEnd(7)}  ${target}${summary}${command}`;
+}
+
+export async function logHandler(options: LogOptions = {}): Promise<LogResult> {
+	const { repo, op, limit = 50, json = false } = options;
+
+	let since: Date | undefined;
+	try {
+		since = options.since ? parseSince(options.since) : undefined;
+	} catch (error) {
+		p.log.error(error instanceof Error ? error.message : String(error));
+		return { entries: [] };
+	}
+
+	if (op && !isJournalOperation(op)) {
+		p.log.error(`Invalid operation: ${op}. Valid operations: ${JOURNAL_OPERATIONS.join(", ")}`);
+		return { entries: [] };
+	}
+
+	const entries = readJournal({ repo, since, op, limit });
+
+	if (json) {
+		console.log(JSON.stringify(entries, null, 2));
+		return { entries };
+	}
+
+	if (entries.length === 0) {
+		p.log.info(repo ? `No journal entries for ${repo}.` : "No journal entries.");
+		return { entries };
+	}
+
+	for (const entry of entries) {
+		console.log(formatEntry(entry));
+	}
+
+	return { entries };
+}
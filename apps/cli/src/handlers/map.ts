@@ -2,14 +2,32 @@
  * Map command handlers for fast repo routing
  */
 
+import { existsSync } from "node:fs";
 import * as p from "@clack/prompts";
-import { getMapEntry, searchMap, Paths, type SearchResult } from "@offworld/sdk/internal";
+import {
+	getMapEntry,
+	getReferenceFreshness,
+	getRelatedReferences,
+	getRepoDependencies,
+	readGlobalMap,
+	recordUsage,
+	renderDependencyGraph,
+	searchMap,
+	toShortReferenceFileName,
+	Paths,
+	type GraphFormat,
+	type ReferenceFreshness,
+	type RelatedReference,
+	type SearchResult,
+} from "@offworld/sdk/internal";
 
 export interface MapShowOptions {
 	repo: string;
 	json?: boolean;
 	path?: boolean;
 	ref?: boolean;
+	/** With ref, print the cheat sheet path when one exists */
+	short?: boolean;
 }
 
 export interface MapShowResult {
@@ -19,10 +37,40 @@ export interface MapShowResult {
 	localPath?: string;
 	primary?: string;
 	keywords?: string[];
+	/** Installed repos this repo depends on */
+	upstream?: string[];
+	/** Installed repos that depend on this repo */
+	downstream?: string[];
+	/** Reference commit, clone HEAD and the distance between them */
+	freshness?: ReferenceFreshness;
+	/** Archived on GitHub */
+	archived?: boolean;
+	/** npm deprecation message */
+	deprecated?: string;
+	/** Qualified name of the repo this one is a fork of */
+	forkOf?: string;
+}
+
+function toFullName(qualifiedName: string): string {
+	return qualifiedName.slice(qualifiedName.indexOf(":") + 1);
+}
+
+function describeDistance(distance: number | null): string {
+	if (distance === null) return "distance unknown";
+	if (distance === 0) return "up to date";
+	return `${distance} commit${distance === 1 ? "" : "s"} behind`;
+}
+
+function staleWarning(freshness: ReferenceFreshness, localPath: string): string | null {
+	if (!freshness.stale) return null;
+	return (
+		`Reference is ${freshness.commitDistance} commits behind the clone ` +
+		`(maxCommitDistance ${freshness.maxCommitDistance}). Prefer the source in ${localPath}.`
+	);
 }
 
 export async function mapShowHandler(options: MapShowOptions): Promise<MapShowResult> {
-	const { repo, json, path, ref } = options;
+	const { repo, json, path, ref, short } = options;
 
 	const result = getMapEntry(repo);
 
@@ -36,10 +84,13 @@ export async function mapShowHandler(options: MapShowOptions): Promise<MapShowRe
 	}
 
 	const { scope, qualifiedName, entry } = result;
+	recordUsage(qualifiedName, "map show");
 
 	const primary = "primary" in entry ? entry.primary : entry.reference;
 	const keywords = entry.keywords ?? [];
 	const refPath = `${Paths.offworldReferencesDir}/${primary}`;
+	const shortPath = `${Paths.offworldReferencesDir}/${toShortReferenceFileName(primary)}`;
+	const hasShort = existsSync(shortPath);
 	if (path) {
 		console.log(entry.localPath);
 		return {
@@ -52,8 +103,13 @@ export async function mapShowHandler(options: MapShowOptions): Promise<MapShowRe
 		};
 	}
 
+	const freshness = getReferenceFreshness(qualifiedName, entry.localPath);
+	const warning = staleWarning(freshness, entry.localPath);
+
 	if (ref) {
-		console.log(refPath);
+		console.log(short && hasShort ? shortPath : refPath);
+		// stderr keeps stdout a bare path for scripts
+		if (warning) console.error(warning);
 		return {
 			found: true,
 			scope,
@@ -61,9 +117,14 @@ export async function mapShowHandler(options: MapShowOptions): Promise<MapShowRe
 			localPath: entry.localPath,
 			primary,
 			keywords,
+			freshness,
 		};
 	}
 
+	const globalMap = readGlobalMap();
+	const { upstream, downstream } = getRepoDependencies(qualifiedName, globalMap);
+	const { archived, deprecated, forkOf } = globalMap.repos[qualifiedName] ?? {};
+
 	if (json) {
 		console.log(
 			JSON.stringify(
@@ -74,7 +135,14 @@ export async function mapShowHandler(options: MapShowOptions): Promise<MapShowRe
 					localPath: entry.localPath,
 					primary,
 					referencePath: refPath,
+					shortReferencePath: hasShort ? shortPath : null,
 					keywords,
+					upstream,
+					downstream,
+					freshness,
+					archived,
+					deprecated,
+					forkOf,
 				},
 				null,
 				2,
@@ -85,9 +153,39 @@ export async function mapShowHandler(options: MapShowOptions): Promise<MapShowRe
 		console.log(`Scope:     ${scope}`);
 		console.log(`Path:      ${entry.localPath}`);
 		console.log(`Reference: ${refPath}`);
+		if (hasShort) {
+			console.log(`Short ref: ${shortPath}`);
+		}
+		if (freshness.referenceCommit) {
+			const head = freshness.cloneCommit ? `clone ${freshness.cloneCommit.slice(0, 7)}, ` : "";
+			const distance = describeDistance(freshness.commitDistance);
+			console.log(`Commit:    ${freshness.referenceCommit.slice(0, 7)} (${head}${distance})`);
+		}
+		if (freshness.generatedAt) {
+			const source = freshness.source ? ` (${freshness.source})` : "";
+			console.log(`Generated: ${freshness.generatedAt.slice(0, 10)}${source}`);
+		}
 		if (keywords.length > 0) {
 			console.log(`Keywords:  ${keywords.join(", ")}`);
 		}
+		if (upstream.length > 0) {
+			console.log(`Upstream:  ${upstream.map(toFullName).join(", ")}`);
+		}
+		if (downstream.length > 0) {
+			console.log(`Used by:   ${downstream.map(toFullName).join(", ")}`);
+		}
+		if (forkOf) {
+			console.log(`Fork of:   ${toFullName(forkOf)}`);
+		}
+		if (archived) {
+			p.log.warn("Archived on GitHub: no further releases are expected.");
+		}
+		if (deprecated) {
+			p.log.warn(`Deprecated on npm: ${deprecated}`);
+		}
+		if (warning) {
+			p.log.warn(warning);
+		}
 	}
 
 	return {
@@ -97,6 +195,12 @@ export async function mapShowHandler(options: MapShowOptions): Promise<MapShowRe
 		localPath: entry.localPath,
 		primary,
 		keywords,
+		upstream,
+		downstream,
+		freshness,
+		archived,
+		deprecated,
+		forkOf,
 	};
 }
 
@@ -114,6 +218,10 @@ export async function mapSearchHandler(options: MapSearchOptions): Promise<MapSe
 	const { term, limit = 10, json } = options;
 
 	const results = searchMap(term, { limit });
+	// Only the top hit counts as a read; the rest are candidates the agent may ignore
+	if (results[0]) {
+		recordUsage(results[0].qualifiedName, "map search");
+	}
 
 	if (json) {
 		console.log(JSON.stringify(results, null, 2));
@@ -134,3 +242,77 @@ export async function mapSearchHandler(options: MapSearchOptions): Promise<MapSe
 
 	return { results };
 }
+
+export interface MapRelatedOptions {
+	repo: string;
+	json?: boolean;
+}
+
+export interface MapRelatedResult {
+	found: boolean;
+	related: RelatedReference[];
+}
+
+function describeLink(related: RelatedReference): string {
+	if (related.mentions && related.mentionedBy) return "mentioned both ways";
+	return related.mentions ? "mentioned here" : "mentions this repo";
+}
+
+export async function mapRelatedHandler(options: MapRelatedOptions): Promise<MapRelatedResult> {
+	const { repo, json } = options;
+
+	const result = getMapEntry(repo);
+	if (!result) {
+		if (json) {
+			console.log(JSON.stringify({ found: false, related: [] }));
+		} else {
+			p.log.error(`Repo not found: ${repo}`);
+		}
+		return { found: false, related: [] };
+	}
+
+	const related = getRelatedReferences(result.qualifiedName, readGlobalMap());
+
+	if (json) {
+		console.log(JSON.stringify({ found: true, related }, null, 2));
+	} else if (related.length === 0) {
+		p.log.info(`No installed references are related to ${repo}.`);
+	} else {
+		for (const r of related) {
+			console.log(`${r.fullName}  (${describeLink(r)})`);
+			console.log(`  ref:  ${r.referencePath}`);
+			console.log("");
+		}
+	}
+
+	return { found: true, related };
+}
+
+export interface MapGraphOptions {
+	/** Limit the graph to this repo's dependencies and dependents */
+	repo?: string;
+	format?: GraphFormat;
+}
+
+export interface MapGraphResult {
+	found: boolean;
+	output?: string;
+}
+
+export async function mapGraphHandler(options: MapGraphOptions): Promise<MapGraphResult> {
+	const { repo, format = "dot" } = options;
+
+	let qualifiedName: string | undefined;
+	if (repo) {
+		const result = getMapEntry(repo);
+		if (!result) {
+			p.log.error(`Repo not found: ${repo}`);
+			return { found: false };
+		}
+		qualifiedName = result.qualifiedName;
+	}
+
+	const output = renderDependencyGraph(readGlobalMap(), { repo: qualifiedName, format });
+	console.log(output);
+	return { found: true, output };
+}
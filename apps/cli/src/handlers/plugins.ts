@@ -0,0 +1,58 @@
+/**
+ * Plugin command handlers
+ */
+
+import * as p from "@clack/prompts";
+import { getLoadedPlugins, loadPlugins, PLUGIN_API_VERSION } from "@offworld/sdk/internal";
+import type { LoadedPlugin } from "@offworld/sdk/internal";
+
+export interface PluginsListOptions {
+	json?: boolean;
+}
+
+export interface PluginsListResult {
+	apiVersion: number;
+	plugins: LoadedPlugin[];
+}
+
+function describeContributions(plugin: LoadedPlugin): string {
+	const { manifestParsers, resolvers, agents, postProcessors, commands } = plugin.contributions;
+	const parts = [
+		manifestParsers.length > 0 ? `manifests: ${manifestParsers.join(", ")}` : null,
+		resolvers.length > 0 ? `resolvers: ${resolvers.join(", ")}` : null,
+		agents.length > 0 ? `agents: ${agents.join(", ")}` : null,
+		postProcessors.length > 0 ? `post-processors: ${postProcessors.join(", ")}` : null,
+		commands.length > 0 ? `commands: ${commands.join(", ")}` : null,
+	].filter((part): part is string => part !== null);
+	return parts.length > 0 ? parts.join("; ") : "no contributions";
+}
+
+export async function pluginsListHandler(
+	options: PluginsListOptions = {},
+): Promise<PluginsListResult> {
+	await loadPlugins();
+	const plugins = getLoadedPlugins();
+
+	if (options.json) {
+		console.log(JSON.stringify({ apiVersion: PLUGIN_API_VERSION, plugins }, null, 2));
+		return { apiVersion: PLUGIN_API_VERSION, plugins };
+	}
+
+	if (plugins.length === 0) {
+		p.log.info("No plugins configured. Add them with: ow config set plugins <name,...>");
+		return { apiVersion: PLUGIN_API_VERSION, plugins };
+	}
+
+	p.log.info(`Plugin API v${PLUGIN_API_VERSION}`);
+	for (const plugin of plugins) {
+		const version = plugin.version ? ` v${plugin.version}` : "";
+		if (plugin.error) {
+			p.log.error(`${plugin.name}${version} (${plugin.source}): ${plugin.error}`);
+		} else {
+			p.log.success(`${plugin.name}${version} (${plugin.source})`);
+			p.log.message(`  ${describeContributions(plugin)}`);
+		}
+	}
+
+	return { apiVersion: PLUGIN_API_VERSION, plugins };
+}
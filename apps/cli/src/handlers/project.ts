@@ -11,12 +11,30 @@ import {
 	toReferenceFileName,
 	readGlobalMap,
 	writeProjectMap,
+	getChangedFiles,
+	hasManifestChanges,
+	installProjectHooks,
+	uninstallProjectHooks,
+	getProjectHooksStatus,
+	PROJECT_GIT_HOOKS,
+	Paths,
+	type GitHookManager,
 	type InstalledReference,
+	type ProjectMapRepoEntry,
 	type ReferenceMatch,
 } from "@offworld/sdk/internal";
 import { createOpenCodeContext, type OpenCodeContext } from "@offworld/sdk/ai";
-import { existsSync } from "node:fs";
-import { dirname, join } from "node:path";
+import { createHash } from "node:crypto";
+import {
+	closeSync,
+	existsSync,
+	mkdirSync,
+	openSync,
+	rmSync,
+	statSync,
+	writeFileSync,
+} from "node:fs";
+import { dirname, join, resolve } from "node:path";
 import { homedir } from "node:os";
 import { pullHandler } from "./pull";
 
@@ -141,6 +159,30 @@ async function runWithConcurrency<T>(
 	return results;
 }
 
+function toProjectMapEntries(matches: ReferenceMatch[]): Record<string, ProjectMapRepoEntry> {
+	const map = readGlobalMap();
+	const repos = new Map<string, ReferenceMatch>();
+	for (const match of matches) {
+		if (!match.repo || repos.has(match.repo)) continue;
+		repos.set(match.repo, match);
+	}
+
+	return Object.fromEntries(
+		Array.from(repos.values()).map((match) => {
+			const qualifiedName = `github.com:${match.repo}`;
+			const entry = map.repos[qualifiedName];
+			return [
+				qualifiedName,
+				{
+					localPath: entry?.localPath ?? "",
+					reference: toReferenceFileName(match.repo!),
+					keywords: entry?.keywords ?? [],
+				},
+			];
+		}),
+	);
+}
+
 export async function projectInitHandler(
 	options: ProjectInitOptions = {},
 ): Promise<ProjectInitResult> {
@@ -475,29 +517,7 @@ export async function projectInitHandler(
 	}
 
 	if (successfulMatches.length > 0) {
-		const map = readGlobalMap();
-		const successfulRepos = new Map<string, ReferenceMatch>();
-		for (const match of successfulMatches) {
-			if (!match.repo || successfulRepos.has(match.repo)) continue;
-			successfulRepos.set(match.repo, match);
-		}
-
-		const projectEntries = Object.fromEntries(
-			Array.from(successfulRepos.values()).map((match) => {
-				const qualifiedName = `github.com:${match.repo}`;
-				const entry = map.repos[qualifiedName];
-				return [
-					qualifiedName,
-					{
-						localPath: entry?.localPath ?? "",
-						reference: toReferenceFileName(match.repo!),
-						keywords: entry?.keywords ?? [],
-					},
-				];
-			}),
-		);
-
-		writeProjectMap(projectRoot, projectEntries);
+		writeProjectMap(projectRoot, toProjectMapEntries(successfulMatches));
 	} else {
 		p.log.warn("No references were installed. Project map was not updated.");
 	}
@@ -517,3 +537,248 @@ export async function projectInitHandler(
 
 	return { success: true, referencesInstalled: installed.length };
 }
+
+export interface ProjectSyncOptions {
+	/** Only sync if a manifest or lockfile changed since this revision */
+	from?: string;
+	/** End of the revision range (default: HEAD) */
+	to?: string;
+	/** Print nothing except errors */
+	quiet?: boolean;
+	/** Max parallel installs for remote refs */
+	concurrency?: number;
+}
+
+export interface ProjectSyncResult {
+	success: boolean;
+	/** No manifest changed in the revision range, or another sync is running */
+	skipped?: boolean;
+	message?: string;
+	referencesInstalled?: number;
+}
+
+const SYNC_LOCK_STALE_MS = 10 * 60 * 1000;
+
+/**
+ * Lock file for a project's syncs. It lives in the state directory, keyed by project root,
+ * so nothing is written next to the committed project map.
+ */
+export function getSyncLockPath(projectRoot: string): string {
+	const key = createHash("sha256").update(resolve(projectRoot)).digest("hex").slice(0, 16);
+	return join(Paths.state, "locks", `project-sync-${key}.lock`);
+}
+
+function createLockFile(lockPath: string): boolean {
+	let fd: number;
+	try {
+		fd = openSync(lockPath, "wx");
+	} catch (error) {
+		if ((error as NodeJS.ErrnoException).code === "EEXIST") return false;
+		throw error;
+	}
+	try {
+		writeFileSync(fd, String(process.pid), "utf-8");
+	} finally {
+		closeSync(fd);
+	}
+	return true;
+}
+
+function isStale(lockPath: string): boolean {
+	try {
+		return Date.now() - statSync(lockPath).mtimeMs >= SYNC_LOCK_STALE_MS;
+	} catch {
+		return false;
+	}
+}
+
+/**
+ * Take the project's sync lock so hooks fired in quick succession don't sync concurrently.
+ * The lock file is created exclusively. One older than SYNC_LOCK_STALE_MS is left over from a
+ * crashed sync; replacing it happens under a second exclusive `.takeover` file, so two syncs
+ * that both find it stale can't both take it. Returns a release function, or null if the
+ * lock is held by another sync.
+ */
+export function acquireSyncLock(projectRoot: string): (() => void) | null {
+	const lockPath = getSyncLockPath(projectRoot);
+	const release = () => rmSync(lockPath, { force: true });
+	mkdirSync(dirname(lockPath), { recursive: true });
+	if (createLockFile(lockPath)) return release;
+
+	const takeoverPath = `${lockPath}.takeover`;
+	if (isStale(takeoverPath)) rmSync(takeoverPath, { force: true });
+	if (!createLockFile(takeoverPath)) return null;
+	try {
+		if (existsSync(lockPath) && !isStale(lockPath)) return null;
+		rmSync(lockPath, { force: true });
+		return createLockFile(lockPath) ? release : null;
+	} finally {
+		rmSync(takeoverPath, { force: true });
+	}
+}
+
+/**
+ * Non-interactive project reconcile: rebuild the project map and AGENTS.md from the current
+ * manifests, installing references that are already installed or available remotely.
+ * Never generates references.
+ */
+export async function projectSyncHandler(
+	options: ProjectSyncOptions = {},
+): Promise<ProjectSyncResult> {
+	const { quiet = false } = options;
+	const log = quiet ? () => {} : (message: string) => p.log.info(message);
+
+	if (!existsSync(getConfigPath())) {
+		p.log.error("No global config found. Run 'ow init' first to set up global configuration.");
+		return { success: false, message: "No global config found" };
+	}
+
+	const projectRoot = detectProjectRoot() || process.cwd();
+
+	if (options.from !== undefined) {
+		const changed = getChangedFiles(projectRoot, options.from, options.to);
+		if (changed && !hasManifestChanges(changed)) {
+			log("No manifest changes; nothing to sync.");
+			return { success: true, skipped: true, message: "No manifest changes" };
+		}
+	}
+
+	const release = acquireSyncLock(projectRoot);
+	if (!release) {
+		log("Another project sync is running.");
+		return { success: true, skipped: true, message: "Sync already running" };
+	}
+
+	try {
+		const dependencies = parseDependencies(projectRoot).filter(
+			(dep) => !isInternalDependencyVersion(dep.version),
+		);
+		const resolved = await Promise.all(
+			dependencies.map((dep) => resolveDependencyRepo(dep.name, dep.version)),
+		);
+		const matches = dedupeMatchesByRepo(
+			await matchDependenciesToReferencesWithRemoteCheck(resolved),
+		).filter((match) => match.repo);
+
+		const synced = matches.filter((match) => match.status === "installed");
+		const remote = matches.filter((match) => match.status === "remote");
+		const skipped = matches.filter((match) => match.status === "generate");
+
+		const requested = options.concurrency ?? 4;
+		const concurrency = Number.isFinite(requested) && requested > 0 ? Math.trunc(requested) : 4;
+		const pulled = await runWithConcurrency(
+			remote.map((match) => async () => {
+				try {
+					const result = await pullHandler({
+						repo: match.repo!,
+						force: false,
+						verbose: false,
+						allowGenerate: false,
+						quiet: true,
+						skipConfirm: true,
+						skipUpdate: true,
+					});
+					return result.success && result.referenceInstalled ? match : null;
+				} catch (error) {
+					const errMsg = error instanceof Error ? error.message : "Unknown error";
+					p.log.error(`${match.dep}: ${errMsg}`);
+					return null;
+				}
+			}),
+			concurrency,
+		);
+		synced.push(...pulled.filter((match): match is ReferenceMatch => match !== null));
+
+		if (synced.length === 0) {
+			log("No references available. Project map was not updated.");
+			return { success: true, referencesInstalled: 0 };
+		}
+
+		writeProjectMap(projectRoot, toProjectMapEntries(synced));
+		updateAgentFiles(
+			projectRoot,
+			synced.map((match) => ({
+				dependency: match.dep,
+				reference: toReferenceFileName(match.repo!),
+				path: getReferencePath(match.repo!),
+			})),
+		);
+
+		log(`Synced ${synced.length} references`);
+		if (skipped.length > 0) {
+			log(
+				`Skipped ${skipped.length} without a reference (run 'ow project init' to generate): ` +
+					skipped.map((match) => match.dep).join(", "),
+			);
+		}
+
+		return { success: true, referencesInstalled: synced.length };
+	} finally {
+		release();
+	}
+}
+
+export interface ProjectHooksOptions {
+	/** Hook manager to target (default: detected) */
+	manager?: GitHookManager;
+}
+
+export interface ProjectHooksHandlerResult {
+	success: boolean;
+	message?: string;
+	files?: string[];
+}
+
+export async function projectHooksInstallHandler(
+	options: ProjectHooksOptions = {},
+): Promise<ProjectHooksHandlerResult> {
+	const projectRoot = detectProjectRoot() || process.cwd();
+
+	try {
+		const result = installProjectHooks(projectRoot, options.manager);
+		p.log.success(`Installed ${PROJECT_GIT_HOOKS.join(" and ")} hooks (${result.manager})`);
+		for (const file of result.files) {
+			p.log.info(pc.dim(`  ${file}`));
+		}
+		if (result.manager === "lefthook") {
+			p.log.info("Run 'lefthook install' if your hooks are not synced automatically.");
+		}
+		return { success: true, files: result.files };
+	} catch (error) {
+		const message = error instanceof Error ? error.message : String(error);
+		p.log.error(message);
+		return { success: false, message };
+	}
+}
+
+export async function projectHooksUninstallHandler(
+	options: ProjectHooksOptions = {},
+): Promise<ProjectHooksHandlerResult> {
+	const projectRoot = detectProjectRoot() || process.cwd();
+
+	try {
+		const result = uninstallProjectHooks(projectRoot, options.manager);
+		if (result.files.length === 0) {
+			p.log.info("No offworld hooks installed.");
+		} else {
+			p.log.success(`Removed offworld hooks from ${result.files.length} file(s)`);
+		}
+		return { success: true, files: result.files };
+	} catch (error) {
+		const message = error instanceof Error ? error.message : String(error);
+		p.log.error(message);
+		return { success: false, message };
+	}
+}
+
+export async function projectHooksStatusHandler(): Promise<ProjectHooksHandlerResult> {
+	const projectRoot = detectProjectRoot() || process.cwd();
+	const status = getProjectHooksStatus(projectRoot);
+
+	p.log.info(`Hook manager: ${status.manager}`);
+	for (const hook of PROJECT_GIT_HOOKS) {
+		const installed = status.installed.includes(hook);
+		p.log.info(`  ${hook}: ${installed ? pc.green("installed") : pc.dim("not installed")}`);
+	}
+	return { success: true };
+}
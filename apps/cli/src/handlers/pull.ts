@@ -5,14 +5,21 @@ import {
 	isRepoCloned,
 	getClonedRepoPath,
 	getCommitSha,
-	getCommitDistance,
+	resolveCommitDistance,
+	formatCommitDistance,
+	findForkUpstream,
+	getForkDivergence,
+	formatForkDivergence,
+	withForkSupplement,
 	parseRepoInput,
 	loadConfig,
+	getRepoSettings,
 	loadAuthData,
 	getMetaPath,
 	RepoExistsError,
 	installReference,
 	toReferenceFileName,
+	getLogger,
 	Paths,
 } from "@offworld/sdk/internal";
 import {
@@ -22,11 +29,11 @@ import {
 	checkRemoteByName,
 } from "@offworld/sdk/sync";
 import { generateReferenceWithAI, type OpenCodeContext } from "@offworld/sdk/ai";
-import { ReferenceMetaSchema, type RepoSource } from "@offworld/types";
+import { ReferenceMetaSchema, type RemoteRepoSource, type RepoSource } from "@offworld/types";
 import { existsSync, readFileSync } from "node:fs";
 import { homedir } from "node:os";
 import { join } from "node:path";
-import { createSpinner } from "../utils/spinner";
+import { createSpinner, type SpinnerLike } from "../utils/spinner";
 import { resolveReferenceKeywordsForRepo } from "./shared";
 
 export interface PullOptions {
@@ -75,6 +82,7 @@ function toTildePath(absolutePath: string): string {
 }
 
 function verboseLog(message: string, verbose: boolean): void {
+	getLogger().debug(message, { command: "pull" });
 	if (verbose) {
 		p.log.info(`[${timestamp()}] ${message}`);
 	}
@@ -122,10 +130,117 @@ async function saveRemoteReference(
 	referenceContent: string,
 	commitSha: string,
 	referenceUpdatedAt: string,
+	shortContent?: string,
+	verified?: boolean,
 ): Promise<void> {
 	const meta = { referenceUpdatedAt, commitSha, version: "0.1.0" };
 	const keywords = await resolveReferenceKeywordsForRepo(localPath, referenceRepoName);
-	installReference(qualifiedName, referenceRepoName, localPath, referenceContent, meta, keywords);
+	await installReference(
+		qualifiedName,
+		referenceRepoName,
+		localPath,
+		referenceContent,
+		meta,
+		keywords,
+		{ referenceSource: "remote", shortContent, verified },
+	);
+}
+
+interface UpstreamPullContext {
+	spinner: SpinnerLike;
+	log: (message: string) => void;
+	logSuccess: (message: string) => void;
+	verbose: boolean;
+	/** Use the upstream reference without asking */
+	autoAccept: boolean;
+}
+
+interface UpstreamPullResult {
+	/** Qualified name of the fork's upstream, if one with a reference was found */
+	upstream: string | null;
+	referenceInstalled: boolean;
+}
+
+/**
+ * Offer a fork's upstream reference as its base, with a supplement summarizing how far the
+ * fork has diverged from the commit the reference was generated at.
+ */
+async function pullUpstreamReference(
+	source: RemoteRepoSource,
+	repoPath: string,
+	currentSha: string,
+	ctx: UpstreamPullContext,
+): Promise<UpstreamPullResult> {
+	const { spinner: s, log, logSuccess, verbose, autoAccept } = ctx;
+
+	s.start("Checking for a fork upstream with a reference...");
+	const fork = await findForkUpstream(source, repoPath);
+	if (!fork) {
+		s.stop("No upstream reference found");
+		return { upstream: null, referenceInstalled: false };
+	}
+
+	const upstreamName = fork.upstream.fullName;
+	const detectedBy = fork.method === "github" ? "GitHub fork metadata" : "shared history";
+	verboseLog(`Fork of ${upstreamName} (detected by ${detectedBy})`, verbose);
+	let divergence = await getForkDivergence(source, repoPath, fork.referenceSha, currentSha);
+	const divergenceInfo = divergence ? ` (fork is ${formatForkDivergence(divergence)})` : "";
+	s.stop(`Fork of ${upstreamName}${divergenceInfo}`);
+	log(`Preview: https://offworld.sh/${upstreamName}`);
+
+	const result: UpstreamPullResult = {
+		upstream: fork.upstream.qualifiedName,
+		referenceInstalled: false,
+	};
+	if (!autoAccept) {
+		const confirmResult = await p.confirm({
+			message: `Use the ${upstreamName} reference as the base for ${source.fullName}?`,
+			initialValue: true,
+		});
+		if (p.isCancel(confirmResult)) {
+			throw new Error("Operation cancelled");
+		}
+		if (!confirmResult) {
+			log("Skipping upstream reference, generating locally...");
+			return result;
+		}
+	}
+
+	s.start("Downloading upstream reference...");
+	const remoteReference = await pullReference(upstreamName);
+	if (!remoteReference) {
+		s.stop("Upstream download failed, generating locally...");
+		return result;
+	}
+	s.stop("Downloaded upstream reference");
+
+	const upstream = { ...fork, referenceSha: remoteReference.commitSha };
+	if (upstream.referenceSha !== fork.referenceSha) {
+		divergence = await getForkDivergence(source, repoPath, upstream.referenceSha, currentSha);
+	}
+	const meta = {
+		referenceUpdatedAt: remoteReference.generatedAt ?? new Date().toISOString(),
+		commitSha: remoteReference.commitSha,
+		version: "0.1.0",
+	};
+	const keywords = await resolveReferenceKeywordsForRepo(repoPath, source.fullName);
+	await installReference(
+		source.qualifiedName,
+		source.fullName,
+		repoPath,
+		withForkSupplement(remoteReference.referenceContent, source, upstream, divergence),
+		meta,
+		keywords,
+		{
+			referenceSource: "remote",
+			shortContent: remoteReference.shortContent,
+			forkOf: result.upstream ?? undefined,
+		},
+	);
+
+	const referencePath = join(Paths.offworldReferencesDir, toReferenceFileName(source.fullName));
+	logSuccess(`Reference file (from ${upstreamName}) at: ${toTildePath(referencePath)}`);
+	return { ...result, referenceInstalled: true };
 }
 
 function parseModelFlag(model?: string): { provider?: string; model?: string } {
@@ -140,7 +255,7 @@ function parseModelFlag(model?: string): { provider?: string; model?: string } {
 export async function pullHandler(options: PullOptions): Promise<PullResult> {
 	const {
 		repo,
-		sparse = false,
+		sparse,
 		branch,
 		force = false,
 		cloneOnly = false,
@@ -184,6 +299,11 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 			verbose,
 		);
 
+		const settings = getRepoSettings(source, config);
+		if (settings.matched.length > 0) {
+			verboseLog(`Repo overrides: ${settings.matched.join(", ")}`, verbose);
+		}
+
 		let repoPath: string;
 
 		if (source.type === "remote") {
@@ -209,8 +329,9 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 			} else {
 				s.start(`Cloning ${source.fullName}...`);
 				try {
+					// Unset flags fall back to the repo's config overrides inside cloneRepo
 					repoPath = await cloneRepo(source, {
-						sparse,
+						sparse: sparse || undefined,
 						branch,
 						config,
 						force,
@@ -267,7 +388,10 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 			};
 		}
 
-		if (source.type === "remote" && (!force || isReferenceOverride)) {
+		let remoteExists = false;
+		if (source.type === "remote" && !settings.trustRemote && !isReferenceOverride) {
+			verboseLog("Remote references disabled for this repo (trustRemote: false)", verbose);
+		} else if (source.type === "remote" && (!force || isReferenceOverride)) {
 			verboseLog(`Checking offworld.sh for reference: ${source.fullName}`, verbose);
 			s.start("Checking offworld.sh for reference...");
 
@@ -277,13 +401,15 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 					: await checkRemote(source.fullName);
 
 				if (remoteCheck.exists && remoteCheck.commitSha) {
+					remoteExists = true;
 					const remoteSha = remoteCheck.commitSha;
 					const remoteShaNorm = remoteSha.slice(0, 7);
 					const currentShaNorm = currentSha.slice(0, 7);
 
-					const maxCommitDistance = config.maxCommitDistance ?? 20;
-					const acceptUnknownDistance = config.acceptUnknownDistance ?? false;
-					const commitDistance = getCommitDistance(repoPath, remoteSha, currentSha);
+					const { maxCommitDistance, acceptUnknownDistance } = settings;
+					const distance = await resolveCommitDistance(source, repoPath, remoteSha, currentSha);
+					const commitDistance = distance ? distance.behind + distance.ahead : null;
+					const distanceLabel = distance ? formatCommitDistance(distance) : "";
 					const isExactMatch = remoteShaNorm === currentShaNorm || commitDistance === 0;
 					const isWithinDistance = commitDistance !== null && commitDistance <= maxCommitDistance;
 					const hasUnknownDistance = commitDistance === null;
@@ -299,10 +425,10 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 								s.stop("Remote reference found (exact match)");
 							} else if (isWithinDistance) {
 								verboseLog(
-									`Remote reference is ${commitDistance} commits behind (within ${maxCommitDistance} threshold)`,
+									`Remote reference is ${distanceLabel} (within ${maxCommitDistance} threshold, via ${distance?.method})`,
 									verbose,
 								);
-								s.stop(`Remote reference found (${commitDistance} commits behind)`);
+								s.stop(`Remote reference found (${distanceLabel})`);
 							} else if (hasUnknownDistance) {
 								const fallbackStatus = acceptUnknownDistance
 									? "Remote reference found (distance unknown, accepted)"
@@ -356,6 +482,8 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 									remoteReference.referenceContent,
 									remoteReference.commitSha,
 									remoteReference.generatedAt ?? new Date().toISOString(),
+									remoteReference.shortContent,
+									remoteReference.isVerified,
 								);
 
 								const remoteReferenceFileName = toReferenceFileName(qualifiedName);
@@ -383,8 +511,7 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 							s.stop("Remote download failed, generating locally...");
 						}
 					} else {
-						const distanceInfo =
-							commitDistance !== null ? ` (${commitDistance} commits behind)` : "";
+						const distanceInfo = distance ? ` (${distanceLabel})` : "";
 						verboseLog(
 							`Remote reference too outdated${distanceInfo}, threshold is ${maxCommitDistance}`,
 							verbose,
@@ -410,6 +537,35 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 			throw new Error(`Reference not found on offworld.sh: ${referenceName}`);
 		}
 
+		// Forks without a reference of their own can start from their upstream's
+		let forkOf: string | undefined;
+		if (source.type === "remote" && settings.trustRemote && !force && !remoteExists) {
+			try {
+				const fork = await pullUpstreamReference(source, repoPath, currentSha, {
+					spinner: s,
+					log,
+					logSuccess,
+					verbose,
+					autoAccept: quiet || skipConfirm,
+				});
+				if (fork.referenceInstalled) {
+					return {
+						success: true,
+						repoPath,
+						referenceSource: "remote",
+						referenceInstalled: true,
+					};
+				}
+				forkOf = fork.upstream ?? undefined;
+			} catch (err) {
+				verboseLog(
+					`Upstream check failed: ${err instanceof Error ? err.message : "Unknown"}`,
+					verbose,
+				);
+				s.stop("Upstream check failed, continuing locally");
+			}
+		}
+
 		if (!allowGenerate && source.type === "remote") {
 			const message =
 				"Remote reference unavailable, outdated, or declined; local generation is disabled.";
@@ -444,23 +600,25 @@ export async function pullHandler(options: PullOptions): Promise<PullResult> {
 			const result = await generateReferenceWithAI(repoPath, qualifiedName, {
 				provider,
 				model,
+				source,
 				openCodeContext: options.openCodeContext,
 				onDebug,
 			});
 
-			const { referenceContent, commitSha: referenceCommitSha } = result;
+			const { referenceContent, shortContent, commitSha: referenceCommitSha } = result;
 			const referenceUpdatedAt = new Date().toISOString();
 			const meta = { referenceUpdatedAt, commitSha: referenceCommitSha, version: "0.1.0" };
 			const referenceRepoName = source.type === "remote" ? source.fullName : source.name;
 			const keywords = await resolveReferenceKeywordsForRepo(repoPath, referenceRepoName);
 
-			installReference(
+			await installReference(
 				source.qualifiedName,
 				referenceRepoName,
 				repoPath,
 				referenceContent,
 				meta,
 				keywords,
+				{ referenceSource: "local", shortContent, forkOf },
 			);
 
 			const referenceFileName = toReferenceFileName(qualifiedName);
@@ -8,10 +8,16 @@ import {
 	getToken,
 	getMetaPath,
 	getReferencePath,
+	getShortReferencePath,
+	stripApiAppendix,
+	stripRelatedBlock,
 	toReferenceName,
 	getCommitSha,
 	getClonedRepoPath,
 	isRepoCloned,
+	findRepoRedirect,
+	readGlobalMap,
+	stripForkSupplement,
 	NotLoggedInError,
 	TokenExpiredError,
 } from "@offworld/sdk/internal";
@@ -92,7 +98,15 @@ function loadLocalReference(
 	}
 
 	try {
-		const referenceContent = readFileSync(referencePath, "utf-8");
+		// Cross-links hold local paths, the signature appendix is rebuilt on install and a fork
+		// supplement describes one clone, so none of them leaves the machine
+		const referenceContent = stripForkSupplement(
+			stripApiAppendix(stripRelatedBlock(readFileSync(referencePath, "utf-8"))),
+		);
+		const shortReferencePath = getShortReferencePath(fullName);
+		const shortContent = existsSync(shortReferencePath)
+			? readFileSync(shortReferencePath, "utf-8")
+			: undefined;
 		const json = JSON.parse(readFileSync(metaPath, "utf-8"));
 		const parsed = ReferenceMetaSchema.safeParse(json);
 		if (!parsed.success) {
@@ -107,6 +121,7 @@ function loadLocalReference(
 			referenceName,
 			referenceDescription,
 			referenceContent,
+			shortContent,
 			commitSha: meta.commitSha,
 			generatedAt: meta.referenceUpdatedAt,
 		};
@@ -153,6 +168,20 @@ export async function pushHandler(options: PushOptions): Promise<PushResult> {
 			return { success: false, message: "Repository not cloned locally" };
 		}
 
+		const movedTo = await findRepoRedirect(source);
+		if (movedTo) {
+			p.log.error(`${source.fullName} has moved to ${movedTo.fullName}.`);
+			p.log.info(`Run 'ow repo update --pattern ${source.qualifiedName}' to migrate it first.`);
+			return { success: false, message: `Repository moved to ${movedTo.fullName}` };
+		}
+
+		const forkOf = readGlobalMap().repos[source.qualifiedName]?.forkOf;
+		if (forkOf) {
+			p.log.error(`The reference for ${source.fullName} was generated for its upstream.`);
+			p.log.info(`Run 'ow generate ${source.fullName} --force' to generate one for the fork.`);
+			return { success: false, message: "Reference belongs to the upstream repo" };
+		}
+
 		s.start("Loading local reference...");
 		const metaDir = getMetaPath(source.fullName);
 		const referencePath = getReferencePath(source.fullName);
@@ -7,8 +7,12 @@ import {
 	parseRepoInput,
 	removeRepo,
 	toReferenceFileName,
+	toShortReferenceFileName,
 	readGlobalMap,
 	getMetaPath,
+	getReferenceSkillPaths,
+	removeReferenceSkill,
+	toReferenceName,
 	Paths,
 } from "@offworld/sdk/internal";
 import { existsSync, rmSync } from "node:fs";
@@ -53,7 +57,9 @@ function getAffectedPathsFromMap(qualifiedName: string): {
 	return {
 		repoPath: existsSync(repoPath) ? repoPath : undefined,
 		referencePath: referencePath && existsSync(referencePath) ? referencePath : undefined,
-		symlinkPaths: [],
+		symlinkPaths: referenceFileName
+			? getReferenceSkillPaths(referenceFileName.replace(/\.md$/, ""))
+			: [],
 	};
 }
 
@@ -99,6 +105,11 @@ export async function rmHandler(options: RmOptions): Promise<RmResult> {
 			if (!repoOnly && affected.referencePath) {
 				console.log(`  Reference: ${affected.referencePath}`);
 			}
+			if (!repoOnly) {
+				for (const skillPath of affected.symlinkPaths) {
+					console.log(`  Skill: ${skillPath}`);
+				}
+			}
 			console.log("");
 		}
 
@@ -173,8 +184,9 @@ async function handleReferenceOnlyRemoval(
 	const referenceFileName = toReferenceFileName(repoName);
 	const referencePath = join(Paths.offworldReferencesDir, referenceFileName);
 	const metaPath = getMetaPath(repoName);
+	const skillPaths = getReferenceSkillPaths(toReferenceName(repoName));
 
-	if (!existsSync(referencePath) && !existsSync(metaPath)) {
+	if (!existsSync(referencePath) && !existsSync(metaPath) && skillPaths.length === 0) {
 		p.log.warn(`No reference files found for: ${repoName}`);
 		return {
 			success: false,
@@ -190,6 +202,9 @@ async function handleReferenceOnlyRemoval(
 		if (existsSync(metaPath)) {
 			console.log(`  Meta: ${metaPath}`);
 		}
+		for (const skillPath of skillPaths) {
+			console.log(`  Skill: ${skillPath}`);
+		}
 		console.log("");
 	}
 
@@ -219,12 +234,16 @@ async function handleReferenceOnlyRemoval(
 	s.start("Removing reference files...");
 
 	if (existsSync(referencePath)) rmSync(referencePath, { force: true });
+	rmSync(join(Paths.offworldReferencesDir, toShortReferenceFileName(referenceFileName)), {
+		force: true,
+	});
 	if (existsSync(metaPath)) rmSync(metaPath, { recursive: true, force: true });
+	removeReferenceSkill(toReferenceName(repoName));
 
 	s.stop("Reference files removed");
 	p.log.success(`Removed reference files for: ${repoName}`);
 	return {
 		success: true,
-		removed: { referencePath },
+		removed: { referencePath, symlinkPaths: skillPaths },
 	};
 }
@@ -9,6 +9,10 @@ import {
 	getRepoRoot,
 	loadConfig,
 	readGlobalMap,
+	checkRepoIdentities,
+	toRemoteSource,
+	moveRepo,
+	type RepoIdentity,
 } from "@offworld/sdk/internal";
 import { existsSync, rmSync } from "node:fs";
 import { formatRepoForDisplay, type RepoListItem } from "./shared.js";
@@ -29,12 +33,18 @@ export interface RepoUpdateOptions {
 	all?: boolean;
 	pattern?: string;
 	dryRun?: boolean;
+	/** Migrate renamed or transferred repos without asking */
+	yes?: boolean;
+	/** Skip the renamed/archived/deprecated check */
+	skipChecks?: boolean;
 }
 
 export interface RepoUpdateResult {
 	updated: string[];
 	skipped: string[];
 	errors: Array<{ repo: string; error: string }>;
+	/** Renamed or transferred repos moved to their new name */
+	moved: Array<{ from: string; to: string }>;
 }
 
 export interface RepoPruneOptions {
@@ -51,6 +61,7 @@ export interface RepoPruneResult {
 
 export interface RepoStatusOptions {
 	json?: boolean;
+	refresh?: boolean;
 }
 
 export interface RepoStatusResult {
@@ -129,6 +140,8 @@ export async function repoListHandler(options: RepoListOptions): Promise<RepoLis
 			hasReference,
 			referenceUpdatedAt: entry.updatedAt,
 			exists,
+			archived: entry.archived,
+			deprecated: entry.deprecated,
 		});
 	}
 
@@ -151,11 +164,11 @@ export async function repoListHandler(options: RepoListOptions): Promise<RepoLis
 }
 
 export async function repoUpdateHandler(options: RepoUpdateOptions): Promise<RepoUpdateResult> {
-	const { all = false, pattern, dryRun = false } = options;
+	const { all = false, pattern, dryRun = false, yes = false, skipChecks = false } = options;
 
 	if (!all && !pattern) {
 		p.log.error("Specify --all or a pattern to update.");
-		return { updated: [], skipped: [], errors: [] };
+		return { updated: [], skipped: [], errors: [], moved: [] };
 	}
 
 	const qualifiedNames = listRepos();
@@ -166,7 +179,7 @@ export async function repoUpdateHandler(options: RepoUpdateOptions): Promise<Rep
 
 	if (total === 0) {
 		p.log.info(pattern ? `No repos matching "${pattern}"` : "No repos to update");
-		return { updated: [], skipped: [], errors: [] };
+		return { updated: [], skipped: [], errors: [], moved: [] };
 	}
 
 	let processed = 0;
@@ -215,7 +228,57 @@ export async function repoUpdateHandler(options: RepoUpdateOptions): Promise<Rep
 		p.log.info(`Summary: ${parts.join(", ")}`);
 	}
 
-	return result;
+	if (skipChecks) return { ...result, moved: [] };
+
+	spinner.start("Checking for renamed, archived and deprecated repos...");
+	const identities = await checkRepoIdentities({
+		filter: (q) => !pattern || matchesPattern(q, pattern),
+		dryRun,
+		onProgress: (repo) => spinner.message(`Checking ${repo}`),
+	});
+	spinner.stop("Repo check complete");
+
+	for (const { qualifiedName, archived, deprecated } of identities) {
+		if (archived) p.log.warn(`${qualifiedName} is archived on GitHub`);
+		if (deprecated) p.log.warn(`${qualifiedName} is deprecated on npm: ${deprecated}`);
+	}
+
+	const moved = await migrateMovedRepos(identities, { dryRun, yes });
+	return { ...result, moved };
+}
+
+async function migrateMovedRepos(
+	identities: RepoIdentity[],
+	options: { dryRun: boolean; yes: boolean },
+): Promise<RepoUpdateResult["moved"]> {
+	const moved: RepoUpdateResult["moved"] = [];
+
+	for (const { qualifiedName, movedTo } of identities) {
+		const source = toRemoteSource(qualifiedName);
+		if (!movedTo || !source) continue;
+
+		p.log.warn(`${source.fullName} has moved to ${movedTo.fullName}`);
+		if (options.dryRun) continue;
+
+		if (!options.yes) {
+			const confirm = await p.confirm({
+				message: `Migrate the clone, map entry and reference to ${movedTo.fullName}?`,
+			});
+			if (p.isCancel(confirm) || !confirm) continue;
+		}
+
+		try {
+			const result = await moveRepo(source, movedTo);
+			moved.push({ from: result.from, to: result.to });
+			p.log.success(`Moved ${source.fullName} → ${movedTo.fullName} (${result.localPath})`);
+		} catch (error) {
+			p.log.error(
+				`Could not move ${source.fullName}: ${error instanceof Error ? error.message : error}`,
+			);
+		}
+	}
+
+	return moved;
 }
 
 export async function repoPruneHandler(options: RepoPruneOptions): Promise<RepoPruneResult> {
@@ -285,18 +348,24 @@ export async function repoPruneHandler(options: RepoPruneOptions): Promise<RepoP
 }
 
 export async function repoStatusHandler(options: RepoStatusOptions): Promise<RepoStatusResult> {
-	const { json = false } = options;
+	const { json = false, refresh = false } = options;
 
 	const spinner = p.spinner();
 	spinner.start("Calculating repo status...");
 
+	let measured = 0;
 	const status = await getRepoStatus({
+		refresh,
 		onProgress: (current, total, repo) => {
 			spinner.message(`[${current}/${total}] ${repo}`);
 		},
+		onDiskUsage: (repo, _bytes, totalBytes, cached) => {
+			if (!cached) measured++;
+			spinner.message(`${formatBytes(totalBytes)} so far - ${repo}`);
+		},
 	});
 
-	spinner.stop("Status complete");
+	spinner.stop(measured > 0 ? `Status complete (measured ${measured} repos)` : "Status complete");
 
 	const output: RepoStatusResult = {
 		total: status.total,
@@ -0,0 +1,52 @@
+/**
+ * Serve command handler (local HTTP API)
+ */
+
+import * as p from "@clack/prompts";
+import {
+	DEFAULT_HTTP_PORT,
+	getHttpToken,
+	rotateHttpToken,
+	startHttpServer,
+} from "@offworld/sdk/internal";
+
+export interface ServeOptions {
+	port?: number;
+	/** Issue a new token before starting, invalidating the old one */
+	rotateToken?: boolean;
+	/** Browser origins allowed to call the API */
+	corsOrigins?: string[];
+}
+
+export interface ServeResult {
+	url?: string;
+}
+
+export async function serveHandler(options: ServeOptions = {}): Promise<ServeResult> {
+	const { port = DEFAULT_HTTP_PORT, rotateToken = false, corsOrigins } = options;
+	const token = rotateToken ? rotateHttpToken() : getHttpToken();
+
+	let server: Awaited<ReturnType<typeof startHttpServer>>;
+	try {
+		server = await startHttpServer({ port, token, corsOrigins });
+	} catch (error) {
+		if ((error as NodeJS.ErrnoException).code === "EADDRINUSE") {
+			p.log.error(`Port ${port} is in use. Pick another with --port.`);
+		} else {
+			p.log.error(error instanceof Error ? error.message : String(error));
+		}
+		return {};
+	}
+
+	p.log.success(`Offworld API listening on ${server.url}`);
+	p.log.info(`OpenAPI document: ${server.url}/openapi.json`);
+	p.log.info(`curl -H "Authorization: Bearer ${token}" ${server.url}/v1/search?q=zod`);
+	p.log.info("Press Ctrl+C to stop.");
+
+	await new Promise<void>((resolve) => {
+		const stop = () => void server.close().then(resolve);
+		process.once("SIGINT", stop);
+		process.once("SIGTERM", stop);
+	});
+	return { url: server.url };
+}
@@ -14,6 +14,10 @@ export interface RepoListItem {
 	referenceUpdatedAt?: string;
 	commitSha?: string;
 	exists: boolean;
+	/** Archived on GitHub */
+	archived?: boolean;
+	/** npm deprecation message */
+	deprecated?: string;
 }
 
 /**
@@ -30,6 +34,8 @@ export function formatRepoForDisplay(item: RepoListItem, showPaths: boolean): st
 
 	if (showPaths) parts.push(`(${item.localPath})`);
 	if (!item.exists) parts.push("[missing]");
+	if (item.archived) parts.push("[archived]");
+	if (item.deprecated) parts.push("[deprecated]");
 
 	return parts.join(" ");
 }
@@ -62,6 +68,8 @@ export async function entryToListItem(qualifiedName: string): Promise<RepoListIt
 		referenceUpdatedAt: entry.updatedAt,
 		commitSha: undefined, // Map doesn't store commitSha yet
 		exists,
+		archived: entry.archived,
+		deprecated: entry.deprecated,
 	};
 }
 
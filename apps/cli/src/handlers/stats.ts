@@ -0,0 +1,66 @@
+/**
+ * Stats command handler
+ */
+
+import * as p from "@clack/prompts";
+import { getUsageStats, type RepoUsage, type UsageStats } from "@offworld/sdk/internal";
+
+export interface StatsOptions {
+	limit?: number;
+	json?: boolean;
+}
+
+export type StatsResult = UsageStats;
+
+function formatUsage(usage: RepoUsage): string {
+	const commands = Object.entries(usage.commands)
+		.sort(([, a], [, b]) => b - a)
+		.map(([command, count]) => `${command} ×${count}`)
+		.join(", ");
+	const lastUsed = usage.lastUsedAt.slice(0, 10);
+	return `  ${String(usage.count).padStart(5)}  ${usage.repo}  (${commands}; last ${lastUsed})`;
+}
+
+export async function statsHandler(options: StatsOptions = {}): Promise<StatsResult> {
+	const { limit = 10, json = false } = options;
+
+	const stats = getUsageStats({ limit });
+
+	if (json) {
+		console.log(JSON.stringify(stats, null, 2));
+		return stats;
+	}
+
+	if (stats.totalEvents === 0) {
+		p.log.info("No usage recorded yet. Reads via 'ow map show' and 'ow map search' are counted.");
+		if (stats.unused.length > 0) {
+			p.log.info(`${stats.unused.length} references installed.`);
+		}
+		return stats;
+	}
+
+	p.log.info(`${stats.totalEvents} reads since ${stats.since?.slice(0, 10)} (stored locally only)`);
+
+	console.log("\nMost used:");
+	for (const usage of stats.mostUsed) {
+		console.log(formatUsage(usage));
+	}
+
+	console.log("\nLeast used:");
+	for (const usage of stats.leastUsed) {
+		console.log(formatUsage(usage));
+	}
+
+	if (stats.unused.length > 0) {
+		console.log(`\nNever used (${stats.unused.length}):`);
+		for (const repo of stats.unused.slice(0, limit)) {
+			console.log(`         ${repo}`);
+		}
+		if (stats.unused.length > limit) {
+			console.log(`         ... and ${stats.unused.length - limit} more`);
+		}
+	}
+	console.log("");
+
+	return stats;
+}
@@ -8,13 +8,19 @@ import {
 	getCurrentVersion,
 	fetchLatestVersion,
 	executeUpgrade,
+	rollbackUpgrade,
+	getLastUpgrade,
 	type InstallMethod,
+	type ReleaseChannel,
 } from "@offworld/sdk/internal";
 import { createSpinner } from "../utils/spinner.js";
 
 export interface UpgradeOptions {
 	target?: string;
 	method?: InstallMethod;
+	channel?: ReleaseChannel;
+	/** Restore the version installed before the last upgrade */
+	rollback?: boolean;
 }
 
 export interface UpgradeResult {
@@ -24,8 +30,31 @@ export interface UpgradeResult {
 	message?: string;
 }
 
+async function rollbackHandler(): Promise<UpgradeResult> {
+	const last = getLastUpgrade();
+	if (!last) {
+		p.log.error("No previous upgrade to roll back");
+		return { success: false, message: "No previous upgrade to roll back" };
+	}
+
+	p.log.info(`Rolling back from ${last.toVersion} to ${last.fromVersion}...`);
+	try {
+		await rollbackUpgrade({ onProgress: (message) => p.log.step(message) });
+		p.log.success(`Restored ${last.fromVersion}`);
+		return { success: true, from: last.toVersion, to: last.fromVersion };
+	} catch (error) {
+		const message = error instanceof Error ? error.message : "Unknown error";
+		p.log.error(message);
+		return { success: false, message };
+	}
+}
+
 export async function upgradeHandler(options: UpgradeOptions): Promise<UpgradeResult> {
-	const { target, method: methodOverride } = options;
+	const { target, method: methodOverride, channel = "stable" } = options;
+
+	if (options.rollback) {
+		return rollbackHandler();
+	}
 
 	try {
 		const s = createSpinner();
@@ -54,7 +83,7 @@ export async function upgradeHandler(options: UpgradeOptions): Promise<UpgradeRe
 		let targetVersion = target;
 		if (!targetVersion) {
 			s.start("Fetching latest version...");
-			const latest = await fetchLatestVersion(effectiveMethod);
+			const latest = await fetchLatestVersion(effectiveMethod, channel);
 			s.stop(latest ? `Latest version: ${latest}` : "Could not fetch latest version");
 
 			if (!latest) {
@@ -73,10 +102,14 @@ export async function upgradeHandler(options: UpgradeOptions): Promise<UpgradeRe
 
 		p.log.info(`Upgrading from ${currentVersion} to ${targetVersion}...`);
 
-		await executeUpgrade(effectiveMethod, targetVersion);
+		// Package manager output is streamed to the terminal, so progress is logged, not spun
+		await executeUpgrade(effectiveMethod, targetVersion, {
+			channel,
+			onProgress: (message) => p.log.step(message),
+		});
 
 		p.log.success(`Successfully upgraded to ${targetVersion}`);
-		p.log.info("Restart your terminal or run 'ow --version' to verify.");
+		p.log.info("Run 'ow upgrade --rollback' to return to the previous version.");
 
 		return {
 			success: true,
@@ -1,6 +1,7 @@
 import { os } from "@orpc/server";
 import { createCli } from "trpc-cli";
 import { z } from "zod";
+import { GRAPH_FORMATS, JOURNAL_OPERATIONS } from "@offworld/sdk/internal";
 import {
 	pullHandler,
 	generateHandler,
@@ -17,6 +18,10 @@ import {
 	authStatusHandler,
 	initHandler,
 	projectInitHandler,
+	projectSyncHandler,
+	projectHooksInstallHandler,
+	projectHooksUninstallHandler,
+	projectHooksStatusHandler,
 	repoListHandler,
 	repoUpdateHandler,
 	repoPruneHandler,
@@ -27,7 +32,24 @@ import {
 	uninstallHandler,
 	mapShowHandler,
 	mapSearchHandler,
+	mapRelatedHandler,
+	mapGraphHandler,
+	examplesBuildHandler,
+	examplesSearchHandler,
+	apiHandler,
+	statsHandler,
+	logHandler,
+	completionHandler,
+	daemonStartHandler,
+	daemonStatusHandler,
+	daemonLogsHandler,
+	daemonStopHandler,
+	daemonRunHandler,
+	daemonUnitHandler,
+	serveHandler,
+	pluginsListHandler,
 } from "./handlers/index.js";
+import { COMPLETION_SHELLS } from "./utils/completion.js";
 
 export const version = "0.3.8";
 
@@ -194,11 +216,15 @@ export const router = os.router({
 			.input(
 				z.object({
 					json: z.boolean().default(false).describe("Output as JSON"),
+					origin: z
+						.boolean()
+						.default(false)
+						.describe("Show which file, env var or flag set each value"),
 				}),
 			)
 			.meta({ description: "Show all config settings", default: true })
 			.handler(async ({ input }) => {
-				await configShowHandler({ json: input.json });
+				await configShowHandler({ json: input.json, origin: input.origin });
 			}),
 
 		set: os
@@ -216,6 +242,7 @@ Valid keys:
   defaultModel         (string)  AI provider/model (e.g., anthropic/claude-sonnet-4-20250514)
   maxCommitDistance     (number)  Max commit distance to accept remote references (default: 20)
   acceptUnknownDistance (boolean) Accept remote refs when distance is unknown (default: false)
+  apiAppendix          (boolean) Append verified API signatures to references (default: false)
   agents               (list)    Comma-separated agents (e.g., claude-code,opencode)`,
 			})
 			.handler(async ({ input }) => {
@@ -231,7 +258,7 @@ Valid keys:
 			.meta({
 				description: `Get a config value
 
-Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, agents`,
+Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, apiAppendix, agents`,
 			})
 			.handler(async ({ input }) => {
 				await configGetHandler({ key: input.key });
@@ -239,7 +266,7 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 
 		reset: os
 			.input(z.object({}))
-			.meta({ description: "Reset config to defaults" })
+			.meta({ description: "Clear the user config file (other layers still apply)" })
 			.handler(async () => {
 				await configResetHandler();
 			}),
@@ -332,6 +359,84 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 					concurrency: input.concurrency,
 				});
 			}),
+
+		sync: os
+			.input(
+				z.object({
+					from: z
+						.string()
+						.optional()
+						.describe("Only sync if a manifest or lockfile changed since this revision"),
+					to: z.string().optional().describe("End of the revision range (default: HEAD)"),
+					quiet: z
+						.boolean()
+						.default(false)
+						.describe("Print nothing except errors")
+						.meta({ alias: "q" }),
+					concurrency: z
+						.number()
+						.int()
+						.min(1)
+						.default(4)
+						.describe("Max parallel installs for remote refs (min: 1)")
+						.meta({ alias: "c" }),
+				}),
+			)
+			.meta({
+				description: "Non-interactively reconcile project references with the manifests",
+			})
+			.handler(async ({ input }) => {
+				await projectSyncHandler({
+					from: input.from,
+					to: input.to,
+					quiet: input.quiet,
+					concurrency: input.concurrency,
+				});
+			}),
+
+		hooks: os.router({
+			install: os
+				.input(
+					z.object({
+						manager: z
+							.enum(["git", "husky", "lefthook"])
+							.optional()
+							.describe("Hook manager to write to (default: detected)"),
+					}),
+				)
+				.meta({
+					description: "Add post-merge/post-checkout hooks that run 'ow project sync'",
+				})
+				.handler(async ({ input }) => {
+					await projectHooksInstallHandler({ manager: input.manager });
+				}),
+
+			uninstall: os
+				.input(
+					z.object({
+						manager: z
+							.enum(["git", "husky", "lefthook"])
+							.optional()
+							.describe("Hook manager to remove from (default: detected)"),
+					}),
+				)
+				.meta({
+					description: "Remove the offworld project hooks",
+				})
+				.handler(async ({ input }) => {
+					await projectHooksUninstallHandler({ manager: input.manager });
+				}),
+
+			status: os
+				.input(z.object({}))
+				.meta({
+					description: "Show which offworld project hooks are installed",
+					default: true,
+				})
+				.handler(async () => {
+					await projectHooksStatusHandler();
+				}),
+		}),
 	}),
 
 	map: os.router({
@@ -342,6 +447,10 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 					json: z.boolean().default(false).describe("Output as JSON"),
 					path: z.boolean().default(false).describe("Print only local path"),
 					ref: z.boolean().default(false).describe("Print only reference file path"),
+					short: z
+						.boolean()
+						.default(false)
+						.describe("With --ref, print the cheat sheet path instead"),
 				}),
 			)
 			.meta({
@@ -354,6 +463,7 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 					json: input.json,
 					path: input.path,
 					ref: input.ref,
+					short: input.short,
 				});
 			}),
 
@@ -375,6 +485,267 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 					json: input.json,
 				});
 			}),
+
+		related: os
+			.input(
+				z.object({
+					repo: z.string().describe("repo").meta({ positional: true }),
+					json: z.boolean().default(false).describe("Output as JSON"),
+				}),
+			)
+			.meta({
+				description: "List installed references that mention or are mentioned by a repo",
+			})
+			.handler(async ({ input }) => {
+				await mapRelatedHandler({ repo: input.repo, json: input.json });
+			}),
+
+		graph: os
+			.input(
+				z.object({
+					repo: z
+						.string()
+						.optional()
+						.describe("Only this repo's dependencies and dependents")
+						.meta({ positional: true }),
+					format: z.enum(GRAPH_FORMATS).default("dot").describe("Output format (dot or json)"),
+				}),
+			)
+			.meta({
+				description: "Show which installed repos depend on each other",
+			})
+			.handler(async ({ input }) => {
+				await mapGraphHandler({ repo: input.repo, format: input.format });
+			}),
+	}),
+
+	examples: os.router({
+		search: os
+			.input(
+				z.object({
+					repo: z.string().describe("repo").meta({ positional: true }),
+					query: z.string().describe("Symbol or topic").meta({ positional: true }),
+					limit: z.number().default(5).describe("Max results").meta({ alias: "n" }),
+					json: z.boolean().default(false).describe("Output as JSON"),
+				}),
+			)
+			.meta({
+				description: "Find harvested code examples for a symbol or topic",
+				default: true,
+			})
+			.handler(async ({ input }) => {
+				await examplesSearchHandler({
+					repo: input.repo,
+					query: input.query,
+					limit: input.limit,
+					json: input.json,
+				});
+			}),
+
+		build: os
+			.input(
+				z.object({
+					repo: z.string().optional().describe("repo").meta({ positional: true }),
+					all: z.boolean().default(false).describe("Index every repo in the map"),
+				}),
+			)
+			.meta({
+				description: "Extract code examples from a clone's examples, tests and docs",
+			})
+			.handler(async ({ input }) => {
+				await examplesBuildHandler({ repo: input.repo, all: input.all });
+			}),
+	}),
+
+	api: os
+		.input(
+			z.object({
+				repo: z.string().describe("repo").meta({ positional: true }),
+				symbol: z.string().describe("Exported name, or Name.member").meta({ positional: true }),
+				json: z.boolean().default(false).describe("Output as JSON"),
+			}),
+		)
+		.meta({
+			description: "Show the exact signature and JSDoc of an exported symbol",
+		})
+		.handler(async ({ input }) => {
+			await apiHandler({
+				repo: input.repo,
+				symbol: input.symbol,
+				json: input.json,
+			});
+		}),
+
+	stats: os
+		.input(
+			z.object({
+				limit: z.number().default(10).describe("Repos per section").meta({ alias: "n" }),
+				json: z.boolean().default(false).describe("Output as JSON"),
+			}),
+		)
+		.meta({
+			description: "Show which references are read most and least (local only)",
+		})
+		.handler(async ({ input }) => {
+			await statsHandler({
+				limit: input.limit,
+				json: input.json,
+			});
+		}),
+
+	log: os
+		.input(
+			z.object({
+				repo: z.string().optional().describe("Only entries for this repo"),
+				since: z.string().optional().describe("Only entries newer than (7d, 12h, 2026-01-31)"),
+				op: z.enum(JOURNAL_OPERATIONS).optional().describe("Only this operation"),
+				limit: z.number().default(50).describe("Max entries").meta({ alias: "n" }),
+				json: z.boolean().default(false).describe("Output as JSON"),
+			}),
+		)
+		.meta({
+			description: "Show the journal of clones, updates, installs, removals and config changes",
+		})
+		.handler(async ({ input }) => {
+			await logHandler({
+				repo: input.repo,
+				since: input.since,
+				op: input.op,
+				limit: input.limit,
+				json: input.json,
+			});
+		}),
+
+	completion: os
+		.input(
+			z.object({
+				shell: z.enum(COMPLETION_SHELLS).describe("shell").meta({ positional: true }),
+			}),
+		)
+		.meta({
+			description: "Print a shell completion script (bash, zsh or fish)",
+		})
+		.handler(async ({ input }) => {
+			await completionHandler({ shell: input.shell });
+		}),
+
+	serve: os
+		.input(
+			z.object({
+				port: z.number().default(4747).describe("Port on 127.0.0.1").meta({ alias: "p" }),
+				rotateToken: z
+					.boolean()
+					.default(false)
+					.describe("Issue a new API token, invalidating the old one"),
+				corsOrigin: z
+					.string()
+					.optional()
+					.describe("Comma-separated browser origins allowed to call the API"),
+			}),
+		)
+		.meta({
+			description: "Serve a token-protected local HTTP API over maps, references and clones",
+		})
+		.handler(async ({ input }) => {
+			await serveHandler({
+				port: input.port,
+				rotateToken: input.rotateToken,
+				corsOrigins: input.corsOrigin
+					?.split(",")
+					.map((origin) => origin.trim())
+					.filter(Boolean),
+			});
+		}),
+
+	daemon: os.router({
+		start: os
+			.input(
+				z.object({
+					foreground: z
+						.boolean()
+						.default(false)
+						.describe("Run in this process instead of detaching"),
+				}),
+			)
+			.meta({
+				description: "Start the background daemon (fetch, remote checks, reference refresh)",
+			})
+			.handler(async ({ input }) => {
+				await daemonStartHandler({ foreground: input.foreground });
+			}),
+
+		status: os
+			.input(
+				z.object({
+					json: z.boolean().default(false).describe("Output as JSON"),
+				}),
+			)
+			.meta({
+				description: "Show daemon state, schedule and last task results",
+				default: true,
+			})
+			.handler(async ({ input }) => {
+				await daemonStatusHandler({ json: input.json });
+			}),
+
+		logs: os
+			.input(
+				z.object({
+					lines: z.number().default(50).describe("Lines to show").meta({ alias: "n" }),
+					follow: z
+						.boolean()
+						.default(false)
+						.describe("Keep printing new lines")
+						.meta({ alias: "f" }),
+				}),
+			)
+			.meta({
+				description: "Show the daemon log",
+			})
+			.handler(async ({ input }) => {
+				await daemonLogsHandler({ lines: input.lines, follow: input.follow });
+			}),
+
+		stop: os
+			.input(z.object({}))
+			.meta({
+				description: "Stop the background daemon",
+			})
+			.handler(async () => {
+				await daemonStopHandler();
+			}),
+
+		run: os
+			.input(
+				z.object({
+					task: z
+						.enum(["fetch", "remote-check", "refresh"])
+						.describe("task")
+						.meta({ positional: true }),
+				}),
+			)
+			.meta({
+				description: "Queue a daemon task now",
+			})
+			.handler(async ({ input }) => {
+				await daemonRunHandler({ task: input.task });
+			}),
+
+		unit: os
+			.input(
+				z.object({
+					install: z
+						.boolean()
+						.default(false)
+						.describe("Write to ~/.config/systemd/user instead of printing"),
+				}),
+			)
+			.meta({
+				description: "Print or install a systemd user unit for the daemon",
+			})
+			.handler(async ({ input }) => {
+				await daemonUnitHandler({ install: input.install });
+			}),
 	}),
 
 	repo: os.router({
@@ -409,14 +780,23 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 						.default(false)
 						.describe("Show what would be updated")
 						.meta({ alias: "d" }),
+					yes: z.boolean().default(false).describe("Migrate without asking").meta({ alias: "y" }),
+					skipChecks: z
+						.boolean()
+						.default(false)
+						.describe("Skip the check for renamed, archived and deprecated repos"),
 				}),
 			)
-			.meta({ description: "Update repos (git fetch + pull)" })
+			.meta({
+				description: "Update repos (git fetch + pull), then check for renamed or archived repos",
+			})
 			.handler(async ({ input }) => {
 				await repoUpdateHandler({
 					all: input.all,
 					pattern: input.pattern,
 					dryRun: input.dryRun,
+					yes: input.yes,
+					skipChecks: input.skipChecks,
 				});
 			}),
 
@@ -445,11 +825,15 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 			.input(
 				z.object({
 					json: z.boolean().default(false).describe("Output as JSON"),
+					refresh: z
+						.boolean()
+						.default(false)
+						.describe("Re-measure disk usage instead of using cached sizes"),
 				}),
 			)
 			.meta({ description: "Show summary of managed repos" })
 			.handler(async ({ input }) => {
-				await repoStatusHandler({ json: input.json });
+				await repoStatusHandler({ json: input.json, refresh: input.refresh });
 			}),
 
 		gc: os
@@ -498,10 +882,36 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 			}),
 	}),
 
+	plugins: os.router({
+		list: os
+			.input(
+				z.object({
+					json: z.boolean().default(false).describe("Output as JSON"),
+				}),
+			)
+			.meta({
+				description: "List configured plugins and what they register",
+				default: true,
+			})
+			.handler(async ({ input }) => {
+				await pluginsListHandler({
+					json: input.json,
+				});
+			}),
+	}),
+
 	upgrade: os
 		.input(
 			z.object({
 				target: z.string().optional().describe("Version to upgrade to"),
+				channel: z
+					.enum(["stable", "beta"])
+					.default("stable")
+					.describe("Release channel to upgrade from"),
+				rollback: z
+					.boolean()
+					.default(false)
+					.describe("Restore the version installed before the last upgrade"),
 			}),
 		)
 		.meta({
@@ -510,6 +920,8 @@ Valid keys: repoRoot, defaultModel, maxCommitDistance, acceptUnknownDistance, ag
 		.handler(async ({ input }) => {
 			await upgradeHandler({
 				target: input.target,
+				channel: input.channel,
+				rollback: input.rollback,
 			});
 		}),
 
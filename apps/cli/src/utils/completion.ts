@@ -0,0 +1,179 @@
+import { CONFIG_KEYS, LOG_LEVELS, listMapNames } from "@offworld/sdk/internal";
+
+export const COMPLETION_SHELLS = ["bash", "zsh", "fish"] as const;
+
+export type CompletionShell = (typeof COMPLETION_SHELLS)[number];
+
+/**
+ * The parts of a commander Command that completion reads. trpc-cli builds the program from
+ * the router, so commands, flags and enum choices always match the real CLI.
+ */
+export interface CompletionCommand {
+	name(): string;
+	aliases(): string[];
+	readonly commands: readonly CompletionCommand[];
+	readonly options: readonly CompletionOption[];
+	readonly registeredArguments: readonly { name(): string; argChoices?: string[] }[];
+}
+
+export interface CompletionOption {
+	long?: string;
+	short?: string;
+	/** Takes a required value */
+	required: boolean;
+	/** Takes an optional value */
+	optional: boolean;
+	argChoices?: string[];
+}
+
+/** Dynamic values, looked up lazily so most completions don't read the maps */
+export interface CompletionSources {
+	repos(): string[];
+	references(): string[];
+	configKeys(): string[];
+}
+
+/** Flags handled in cli.ts before the router sees argv */
+const GLOBAL_OPTIONS: CompletionOption[] = [
+	{ long: "--help", short: "-h", required: false, optional: false },
+	{ long: "--config", short: "-c", required: true, optional: false },
+	{ long: "--log-level", required: true, optional: false, argChoices: [...LOG_LEVELS] },
+	{ long: "--log-file", required: true, optional: false },
+];
+
+export function createCompletionSources(): CompletionSources {
+	let names: ReturnType<typeof listMapNames> | undefined;
+	const mapNames = () => (names ??= listMapNames());
+	return {
+		repos: () => mapNames().repos,
+		references: () => mapNames().references,
+		configKeys: () => [...CONFIG_KEYS],
+	};
+}
+
+function findSubcommand(command: CompletionCommand, word: string): CompletionCommand | undefined {
+	return command.commands.find((sub) => sub.name() === word || sub.aliases().includes(word));
+}
+
+function findOption(
+	options: readonly CompletionOption[],
+	flag: string,
+): CompletionOption | undefined {
+	return options.find((option) => option.long === flag || option.short === flag);
+}
+
+/**
+ * Values for a named argument or option. Names come from the router's zod keys.
+ */
+function completeValue(
+	name: string,
+	choices: string[] | undefined,
+	sources: CompletionSources,
+): string[] {
+	if (choices && choices.length > 0) return choices;
+	switch (name) {
+		case "repo":
+			return sources.repos();
+		case "reference":
+			return sources.references();
+		case "key":
+			return sources.configKeys();
+		case "config":
+			return sources.configKeys().map((key) => `${key}=`);
+		default:
+			return [];
+	}
+}
+
+function optionName(option: CompletionOption): string {
+	return (option.long ?? option.short ?? "").replace(/^-+/, "");
+}
+
+/**
+ * Completion candidates for the word being typed.
+ *
+ * @param words - Words after `ow`; the last one is the (possibly empty) word being completed
+ */
+export function getCompletions(
+	program: CompletionCommand,
+	words: string[],
+	sources: CompletionSources = createCompletionSources(),
+): string[] {
+	const current = words.at(-1) ?? "";
+	let command = program;
+	let argIndex = 0;
+	let pendingOption: CompletionOption | undefined;
+
+	for (const word of words.slice(0, -1)) {
+		if (pendingOption) {
+			pendingOption = undefined;
+			continue;
+		}
+		if (word.startsWith("-")) {
+			const option = findOption([...command.options, ...GLOBAL_OPTIONS], word);
+			if (option?.required && !word.includes("=")) pendingOption = option;
+			continue;
+		}
+
+		const sub = findSubcommand(command, word);
+		if (sub) {
+			command = sub;
+			argIndex = 0;
+		} else {
+			argIndex++;
+		}
+	}
+
+	let candidates: string[];
+	if (pendingOption) {
+		candidates = completeValue(optionName(pendingOption), pendingOption.argChoices, sources);
+	} else if (current.startsWith("-")) {
+		candidates = [...command.options, ...GLOBAL_OPTIONS]
+			.map((option) => option.long)
+			.filter((flag): flag is string => flag !== undefined);
+	} else {
+		const subcommands = command.commands.map((sub) => sub.name());
+		const argument = command.registeredArguments[argIndex];
+		const values = argument ? completeValue(argument.name(), argument.argChoices, sources) : [];
+		candidates = [...subcommands, ...values];
+	}
+
+	return [...new Set(candidates)].filter((candidate) => candidate.startsWith(current));
+}
+
+/**
+ * Shell script that completes `ow` and `offworld` through the hidden `ow __complete` command.
+ */
+export function renderCompletionScript(shell: CompletionShell): string {
+	switch (shell) {
+		case "bash":
+			return `# ow bash completion. Add to ~/.bashrc: eval "$(ow completion bash)"
+_ow_completion() {
+	local IFS=$'\\n'
+	COMPREPLY=($(ow __complete -- "\${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _ow_completion ow offworld
+`;
+		case "zsh":
+			return `#compdef ow offworld
+# ow zsh completion. Add to ~/.zshrc: eval "$(ow completion zsh)"
+_ow() {
+	local -a completions
+	completions=(\${(f)"$(ow __complete -- "\${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	compadd -a completions
+}
+compdef _ow ow offworld
+`;
+		case "fish":
+			return `# ow fish completion. Save to ~/.config/fish/completions/ow.fish
+function __ow_complete
+	set -l tokens (commandline -opc)
+	set -e tokens[1]
+	set -l current (commandline -ct)
+	ow __complete -- $tokens "$current" 2>/dev/null
+end
+complete -c ow -f -a '(__ow_complete)'
+complete -c offworld -f -a '(__ow_complete)'
+`;
+	}
+}
@@ -0,0 +1,64 @@
+import { CONFIG_KEYS, parseConfigValue, type ConfigKey } from "@offworld/sdk/internal";
+import type { Config } from "@offworld/types";
+
+export interface ConfigFlagsResult {
+	/** argv with the -c/--config flags removed */
+	argv: string[];
+	overrides: Partial<Config>;
+}
+
+function isConfigKey(key: string): key is ConfigKey {
+	return CONFIG_KEYS.includes(key as ConfigKey);
+}
+
+function parseAssignment(assignment: string | undefined): [ConfigKey, Config[ConfigKey]] {
+	const index = assignment?.indexOf("=") ?? -1;
+	if (!assignment || index <= 0) {
+		throw new Error(`Expected key=value after -c/--config, got: ${assignment ?? "(nothing)"}`);
+	}
+
+	const key = assignment.slice(0, index);
+	if (!isConfigKey(key)) {
+		throw new Error(`Invalid config key: ${key}. Valid keys: ${CONFIG_KEYS.join(", ")}`);
+	}
+	try {
+		return [key, parseConfigValue(key, assignment.slice(index + 1))];
+	} catch {
+		throw new Error(`Invalid value for ${key}: ${assignment.slice(index + 1)}`);
+	}
+}
+
+/**
+ * Extract global `-c key=value` / `--config key=value` flags from argv. `--config` may appear
+ * anywhere before a `--` separator; `-c` only before the command (as in `git -c`), since
+ * subcommands use it for their own options (`ow project init -c 8`). Both can be repeated.
+ */
+export function extractConfigFlags(argv: string[]): ConfigFlagsResult {
+	const rest: string[] = [];
+	const overrides: Record<string, unknown> = {};
+	let seenCommand = false;
+
+	for (let i = 0; i < argv.length; i++) {
+		const arg = argv[i]!;
+		if (arg === "--") {
+			rest.push(...argv.slice(i));
+			break;
+		}
+
+		let assignment: string | undefined;
+		if ((arg === "-c" && !seenCommand) || arg === "--config") {
+			assignment = argv[++i];
+		} else if (arg.startsWith("--config=")) {
+			assignment = arg.slice("--config=".length);
+		} else {
+			if (!arg.startsWith("-")) seenCommand = true;
+			rest.push(arg);
+			continue;
+		}
+
+		const [key, value] = parseAssignment(assignment);
+		overrides[key] = value;
+	}
+
+	return { argv: rest, overrides: overrides as Partial<Config> };
+}
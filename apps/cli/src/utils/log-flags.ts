@@ -0,0 +1,46 @@
+import { parseLogLevel, type LogLevel } from "@offworld/sdk/internal";
+
+export interface LogFlagsResult {
+	/** argv with the --log-level/--log-file flags removed */
+	argv: string[];
+	level?: LogLevel;
+	file?: string;
+}
+
+/**
+ * Extract global `--log-level <level>` and `--log-file <path>` flags from argv.
+ * They may appear anywhere before a `--` separator.
+ */
+export function extractLogFlags(argv: string[]): LogFlagsResult {
+	const rest: string[] = [];
+	let level: LogLevel | undefined;
+	let file: string | undefined;
+
+	for (let i = 0; i < argv.length; i++) {
+		const arg = argv[i]!;
+		if (arg === "--") {
+			rest.push(...argv.slice(i));
+			break;
+		}
+
+		const separator = arg.startsWith("--log-") ? arg.indexOf("=") : -1;
+		const flag = separator === -1 ? arg : arg.slice(0, separator);
+		const inline = separator === -1 ? undefined : arg.slice(separator + 1);
+		if (flag !== "--log-level" && flag !== "--log-file") {
+			rest.push(arg);
+			continue;
+		}
+
+		const value = inline ?? argv[++i];
+		if (!value) {
+			throw new Error(`Expected a value after ${flag}`);
+		}
+		if (flag === "--log-level") {
+			level = parseLogLevel(value);
+		} else {
+			file = value;
+		}
+	}
+
+	return { argv: rest, level, file };
+}
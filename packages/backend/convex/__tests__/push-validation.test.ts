@@ -47,6 +47,15 @@ describe("Push Input Validation", () => {
 			expect(result.field).toBe("referenceContent");
 		});
 
+		it("accepts an optional cheat sheet and rejects oversized ones", () => {
+			expect(validatePushArgs({ ...validArgs, shortContent: "# Zod (cheat sheet)" })).toEqual({
+				valid: true,
+			});
+			const result = validatePushArgs({ ...validArgs, shortContent: "a".repeat(6_001) });
+			expect(result.valid).toBe(false);
+			expect(result.field).toBe("shortContent");
+		});
+
 		it("rejects invalid commit SHA length", () => {
 			const result = validatePushArgs({ ...validArgs, commitSha: "invalid" });
 			expect(result.valid).toBe(false);
@@ -167,8 +167,10 @@ export const pull = query({
 			referenceName: ref.referenceName,
 			referenceDescription: ref.referenceDescription,
 			referenceContent: ref.referenceContent,
+			shortContent: ref.shortContent,
 			commitSha: ref.commitSha,
 			generatedAt: ref.generatedAt,
+			isVerified: ref.isVerified,
 		};
 	},
 });
@@ -291,6 +293,7 @@ export const pushInternal = internalMutation({
 		referenceName: v.string(),
 		referenceDescription: v.string(),
 		referenceContent: v.string(),
+		shortContent: v.optional(v.string()),
 		commitSha: v.string(),
 		generatedAt: v.string(),
 		workosId: v.string(),
@@ -386,6 +389,7 @@ export const pushInternal = internalMutation({
 			referenceName: referenceData.referenceName,
 			referenceDescription: referenceData.referenceDescription,
 			referenceContent: referenceData.referenceContent,
+			shortContent: referenceData.shortContent,
 			commitSha: referenceData.commitSha,
 			generatedAt: referenceData.generatedAt,
 			pullCount: 0,
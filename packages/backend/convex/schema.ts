@@ -33,6 +33,7 @@ export default defineSchema({
 		referenceName: v.string(),
 		referenceDescription: v.string(),
 		referenceContent: v.string(), // markdown
+		shortContent: v.optional(v.string()), // cheat sheet markdown
 
 		commitSha: v.string(),
 		generatedAt: v.string(), // ISO timestamp
@@ -7,6 +7,7 @@ export const SKILLNAME_MAX = 80;
 export const DESCRIPTION_MAX = 200;
 export const CONTENT_MIN = 500;
 export const CONTENT_MAX = 200_000;
+export const SHORT_CONTENT_MAX = 6_000;
 export const COMMIT_SHA_LENGTH = 40;
 
 export const FULLNAME_PATTERN = /^[A-Za-z0-9_.-]+\/[A-Za-z0-9_.-]+$/;
@@ -18,6 +19,7 @@ export const pushArgs = {
 	referenceName: v.string(),
 	referenceDescription: v.string(),
 	referenceContent: v.string(),
+	shortContent: v.optional(v.string()),
 	commitSha: v.string(),
 	generatedAt: v.string(),
 };
@@ -37,6 +39,7 @@ export function validatePushArgs(args: {
 	referenceName: string;
 	referenceDescription: string;
 	referenceContent: string;
+	shortContent?: string;
 	commitSha: string;
 	generatedAt: string;
 }): ValidationResult {
@@ -88,6 +91,19 @@ export function validatePushArgs(args: {
 		};
 	}
 
+	if (args.shortContent !== undefined) {
+		if (args.shortContent.trim().length === 0) {
+			return { valid: false, error: "shortContent cannot be empty", field: "shortContent" };
+		}
+		if (args.shortContent.length > SHORT_CONTENT_MAX) {
+			return {
+				valid: false,
+				error: `shortContent too large (max ${SHORT_CONTENT_MAX / 1000}KB)`,
+				field: "shortContent",
+			};
+		}
+	}
+
 	if (args.commitSha.length !== COMMIT_SHA_LENGTH) {
 		return { valid: false, error: "commitSha must be 40 characters", field: "commitSha" };
 	}
@@ -0,0 +1,140 @@
+/**
+ * Unit tests for api-signatures.ts
+ */
+
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { dirname, join } from "node:path";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import {
+	appendApiSignatures,
+	ApiExtractionError,
+	extractApiTableSymbols,
+	findApiSignatures,
+	listApiExports,
+	stripApiAppendix,
+} from "../api-signatures.js";
+
+const FILES: Record<string, string> = {
+	"package.json": JSON.stringify({
+		name: "tiny-query",
+		types: "./dist/index.d.ts",
+		exports: { ".": { types: "./dist/index.d.ts", import: "./dist/index.js" } },
+	}),
+	"src/index.ts": [
+		'export { slugify as toSlug } from "./util";',
+		"",
+		'export const VERSION = "1.0";',
+		"",
+		"/**",
+		" * Create a query key.",
+		" * @param parts - Key segments",
+		" */",
+		"export function createKey(...parts: string[]): string {",
+		'\treturn parts.join(":");',
+		"}",
+		"",
+		"export class QueryClient {",
+		"\tprivate cache = new Map<string, unknown>();",
+		"\tconstructor(readonly name: string) {}",
+		"\t/** Fetch and cache a query. */",
+		"\tasync fetchQuery<T>(key: string, fn: () => Promise<T>): Promise<T> {",
+		"\t\treturn fn();",
+		"\t}",
+		"}",
+	].join("\n"),
+	"src/util.ts": "export function slugify(value: string): string {\n\treturn value;\n}\n",
+};
+
+describe("api signatures", () => {
+	let repo: string;
+
+	beforeEach(() => {
+		repo = mkdtempSync(join(tmpdir(), "ow-api-"));
+		for (const [path, content] of Object.entries(FILES)) {
+			mkdirSync(dirname(join(repo, path)), { recursive: true });
+			writeFileSync(join(repo, path), content);
+		}
+	});
+
+	afterEach(() => {
+		rmSync(repo, { recursive: true, force: true });
+	});
+
+	it("reads signatures and JSDoc from sources when dist declarations are missing", async () => {
+		const [createKey] = await findApiSignatures(repo, "createKey");
+
+		expect(createKey).toMatchObject({
+			kind: "function",
+			signature: "function createKey(...parts: string[]): string;",
+			file: join("src", "index.ts"),
+			line: 9,
+			packageName: "tiny-query",
+		});
+		expect(createKey?.documentation).toBe("Create a query key.\n@param parts - Key segments");
+
+		const [version] = await findApiSignatures(repo, "VERSION");
+		expect(version?.signature).toBe('const VERSION: "1.0";');
+	});
+
+	it("resolves re-exports, class members and case-insensitive names", async () => {
+		const [toSlug] = await findApiSignatures(repo, "toSlug");
+		expect(toSlug).toMatchObject({ name: "toSlug", file: join("src", "util.ts") });
+
+		const [client] = await findApiSignatures(repo, "queryclient");
+		expect(client?.signature).toBe(
+			[
+				"class QueryClient {",
+				"\tconstructor(readonly name: string);",
+				"\tasync fetchQuery<T>(key: string, fn: () => Promise<T>): Promise<T>;",
+				"}",
+			].join("\n"),
+		);
+
+		const [fetchQuery] = await findApiSignatures(repo, "QueryClient.fetchQuery");
+		expect(fetchQuery).toMatchObject({ kind: "method", documentation: "Fetch and cache a query." });
+
+		expect(await findApiSignatures(repo, "useQuery")).toEqual([]);
+		expect((await listApiExports(repo)).map((e) => e.name)).toEqual([
+			"createKey",
+			"QueryClient",
+			"toSlug",
+			"VERSION",
+		]);
+	});
+
+	it("rejects repos without TypeScript entry points", async () => {
+		rmSync(join(repo, "src"), { recursive: true });
+
+		await expect(findApiSignatures(repo, "createKey")).rejects.toBeInstanceOf(ApiExtractionError);
+	});
+
+	it("appends only verified symbols from the API table and replaces old appendices", async () => {
+		const reference = [
+			"# tiny-query",
+			"",
+			"## API",
+			"",
+			"| Export | Purpose |",
+			"| --- | --- |",
+			"| `createKey()` | Build keys |",
+			"| `useQuery` | Hallucinated |",
+			"",
+			"## Patterns",
+			"",
+			"| `NotApi` | ignored |",
+			"",
+		].join("\n");
+
+		expect(extractApiTableSymbols(reference)).toEqual(["createKey", "useQuery"]);
+
+		const withAppendix = await appendApiSignatures(reference, repo, "abcdef1234");
+		expect(withAppendix).toContain("## API Signatures (verified)");
+		expect(withAppendix).toContain("### createKey");
+		expect(withAppendix).toContain("Source: `src/index.ts:9`");
+		expect(withAppendix).not.toContain("### useQuery");
+
+		expect(await appendApiSignatures(withAppendix, repo, "abcdef1234")).toBe(withAppendix);
+		expect(stripApiAppendix(withAppendix)).toBe(reference);
+	});
+});
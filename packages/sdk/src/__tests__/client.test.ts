@@ -0,0 +1,139 @@
+/**
+ * Unit tests for client.ts
+ *
+ * Uses injected filesystem and git runner instead of module mocks.
+ */
+
+import { beforeEach, describe, expect, it, vi } from "vitest";
+import { Offworld } from "../client.js";
+import type { GitRunner, OffworldFileSystem } from "../runtime.js";
+import { clearVirtualFs, createFsMock, getVirtualFs } from "./mocks/fs.js";
+
+const DATA_DIR = "/sandbox/data";
+const REPO_ROOT = "/sandbox/repos";
+const MAP_PATH = `${DATA_DIR}/skill/offworld/assets/map.json`;
+const REFERENCES_DIR = `${DATA_DIR}/skill/offworld/references`;
+const HEAD_SHA = "abc1234def5678";
+
+function createGitRunner(fs: OffworldFileSystem): GitRunner & { calls: string[][] } {
+	const calls: string[][] = [];
+	const run = (args: string[]): string => {
+		calls.push(args);
+		if (args[0] === "clone") {
+			fs.mkdirSync(args[args.length - 1]!, { recursive: true });
+			return "";
+		}
+		if (args[0] === "rev-parse") return HEAD_SHA;
+		return "";
+	};
+	return {
+		calls,
+		exec: (args) => run(args),
+		execAsync: async (args) => run(args),
+	};
+}
+
+describe("Offworld client", () => {
+	let fs: OffworldFileSystem;
+	let git: ReturnType<typeof createGitRunner>;
+	let client: Offworld;
+
+	beforeEach(() => {
+		clearVirtualFs();
+		fs = createFsMock() as unknown as OffworldFileSystem;
+		git = createGitRunner(fs);
+		client = new Offworld({
+			fs,
+			git,
+			paths: { config: "/sandbox/config", data: DATA_DIR, state: "/sandbox/state" },
+			config: { repoRoot: REPO_ROOT },
+			cwd: "/sandbox/project",
+		});
+	});
+
+	it("uses injected config without reading the config file", () => {
+		const config = client.config();
+		expect(config.repoRoot).toBe(REPO_ROOT);
+		expect(config.maxCommitDistance).toBe(20);
+		expect(fs.readFileSync).not.toHaveBeenCalled();
+	});
+
+	it("clones through the injected git runner and emits events", async () => {
+		const cloned = vi.fn();
+		client.on("cloned", cloned);
+
+		const result = await client.pull("tanstack/query", { cloneOnly: true });
+
+		expect(result).toEqual({
+			qualifiedName: "github.com:tanstack/query",
+			repoPath: `${REPO_ROOT}/github/tanstack/query`,
+			commitSha: HEAD_SHA,
+			referenceSource: "none",
+		});
+		expect(git.calls[0]).toEqual([
+			"clone",
+			"https://github.com/tanstack/query.git",
+			`${REPO_ROOT}/github/tanstack/query`,
+		]);
+		expect(cloned).toHaveBeenCalledWith({
+			qualifiedName: "github.com:tanstack/query",
+			repoPath: `${REPO_ROOT}/github/tanstack/query`,
+		});
+		expect(getVirtualFs()[MAP_PATH]).toBeDefined();
+	});
+
+	it("returns cached reference when meta matches HEAD", async () => {
+		await client.pull("tanstack/query", { cloneOnly: true });
+		fs.mkdirSync(REFERENCES_DIR, { recursive: true });
+		fs.writeFileSync(`${REFERENCES_DIR}/tanstack-query.md`, "# Query");
+		fs.writeFileSync(
+			`${DATA_DIR}/meta/tanstack-query/meta.json`,
+			JSON.stringify({ referenceUpdatedAt: "2026-01-01", commitSha: HEAD_SHA, version: "0.3.8" }),
+		);
+
+		const result = await client.pull("tanstack/query", { skipUpdate: true });
+
+		expect(result.referenceSource).toBe("cached");
+		expect(result.referencePath).toBe(`${REFERENCES_DIR}/tanstack-query.md`);
+	});
+
+	it("returns none when remote and generation are disabled", async () => {
+		const result = await client.pull("tanstack/query", { remote: false, generate: false });
+		expect(result.referenceSource).toBe("none");
+	});
+
+	it("unsubscribes listeners", async () => {
+		const progress = vi.fn();
+		const off = client.on("progress", progress);
+		off();
+
+		await client.pull("tanstack/query", { cloneOnly: true });
+
+		expect(progress).not.toHaveBeenCalled();
+	});
+
+	it("reads map entries from the injected paths", async () => {
+		await client.pull("tanstack/query", { cloneOnly: true });
+
+		expect(client.map("tanstack/query")).toMatchObject({
+			scope: "global",
+			qualifiedName: "github.com:tanstack/query",
+			localPath: `${REPO_ROOT}/github/tanstack/query`,
+		});
+		expect(client.search("query").map((r) => r.fullName)).toEqual(["tanstack/query"]);
+	});
+
+	it("isolates clients from each other", async () => {
+		const other = new Offworld({
+			fs,
+			git,
+			paths: { data: "/other/data" },
+			config: { repoRoot: "/other/repos" },
+		});
+
+		await client.pull("tanstack/query", { cloneOnly: true });
+
+		expect(other.map("tanstack/query")).toBeNull();
+		expect(other.config().repoRoot).toBe("/other/repos");
+	});
+});
@@ -61,6 +61,24 @@ vi.mock("node:fs", () => ({
 		const normalized = normalizePath(path);
 		return normalized in virtualFs;
 	}),
+	readFileSync: vi.fn((path: string) => {
+		const file = virtualFs[normalizePath(path)];
+		if (!file) throw new Error(`ENOENT: no such file or directory, open '${path}'`);
+		return file.content;
+	}),
+	mkdirSync: vi.fn((path: string, _options?: { recursive?: boolean }) => {
+		virtualFs[normalizePath(path)] = { content: "", isDirectory: true };
+	}),
+	renameSync: vi.fn((oldPath: string, newPath: string) => {
+		const from = normalizePath(oldPath);
+		const to = normalizePath(newPath);
+		for (const key of Object.keys(virtualFs)) {
+			if (key === from || key.startsWith(from + "/")) {
+				virtualFs[to + key.slice(from.length)] = virtualFs[key]!;
+				delete virtualFs[key];
+			}
+		}
+	}),
 	readdirSync: vi.fn((path: string) => {
 		const normalized = normalizePath(path);
 		const entries: string[] = [];
@@ -315,8 +333,19 @@ vi.mock("../config.js", async (importOriginal) => {
 
 const mapEntries: Record<string, GlobalMapRepoEntry> = {};
 
+vi.mock("../reference.js", () => ({
+	removeReferenceSkill: vi.fn(() => []),
+	installReferenceSkill: vi.fn(() => ""),
+}));
+
 vi.mock("../index-manager.js", () => ({
 	readGlobalMap: vi.fn(() => ({ repos: { ...mapEntries } })),
+	writeGlobalMap: vi.fn((map: { repos: Record<string, GlobalMapRepoEntry> }) => {
+		for (const key of Object.keys(mapEntries)) {
+			delete mapEntries[key];
+		}
+		Object.assign(mapEntries, map.repos);
+	}),
 	upsertGlobalMapEntry: vi.fn((qualifiedName: string, entry: GlobalMapRepoEntry) => {
 		mapEntries[qualifiedName] = entry;
 	}),
@@ -337,6 +366,7 @@ import {
 	isRepoCloned,
 	getClonedRepoPath,
 	getCommitSha,
+	moveRepo,
 	RepoExistsError,
 	RepoNotFoundError,
 	GitError,
@@ -738,6 +768,14 @@ describe("removeRepo", () => {
 		expect(removeGlobalMapEntry).toHaveBeenCalledWith("github.com:tanstack/router");
 	});
 
+	it("removes the per-reference skill", async () => {
+		const { removeReferenceSkill } = await import("../reference.js");
+
+		await removeRepo("github.com:tanstack/router");
+
+		expect(removeReferenceSkill).toHaveBeenCalledWith("tanstack-router");
+	});
+
 	it("returns false if not in index", async () => {
 		const result = await removeRepo("github.com:unknown/repo");
 
@@ -793,6 +831,87 @@ describe("removeRepo", () => {
 	});
 });
 
+describe("moveRepo", () => {
+	const target: RemoteRepoSource = {
+		...mockSource,
+		owner: "tanstack-labs",
+		fullName: "tanstack-labs/router",
+		qualifiedName: "github.com:tanstack-labs/router",
+		cloneUrl: "https://github.com/tanstack-labs/router.git",
+	};
+	const newPath = join(mockRepoRoot, "github", "tanstack-labs/router");
+
+	beforeEach(() => {
+		mapEntries["github.com:tanstack/router"] = { ...mockMapEntry };
+		addVirtualPath(mockMapEntry.localPath, true);
+		addVirtualPath(join(mockMapEntry.localPath, ".git"), true);
+		addVirtualPath(join(mockReferencesRoot, "tanstack-router.md"));
+		addVirtualPath(join(mockReferencesRoot, "tanstack-router.short.md"));
+	});
+
+	it("moves the clone directory to the new name", async () => {
+		const result = await moveRepo(mockSource, target);
+
+		expect(result.localPath).toBe(newPath);
+		expect(virtualFs[normalizePath(join(newPath, ".git"))]).toBeDefined();
+		expect(virtualFs[normalizePath(mockMapEntry.localPath)]).toBeUndefined();
+	});
+
+	it("moves the map entry to the new key", async () => {
+		await moveRepo(mockSource, target);
+
+		expect(mapEntries["github.com:tanstack/router"]).toBeUndefined();
+		expect(mapEntries["github.com:tanstack-labs/router"]).toMatchObject({
+			localPath: newPath,
+			references: ["tanstack-labs-router.md"],
+			primary: "tanstack-labs-router.md",
+		});
+	});
+
+	it("renames the reference and its cheat sheet", async () => {
+		const result = await moveRepo(mockSource, target);
+
+		expect(result.references).toEqual(["tanstack-labs-router.md"]);
+		for (const name of ["tanstack-labs-router.md", "tanstack-labs-router.short.md"]) {
+			expect(virtualFs[normalizePath(join(mockReferencesRoot, name))]).toBeDefined();
+		}
+		for (const name of ["tanstack-router.md", "tanstack-router.short.md"]) {
+			expect(virtualFs[normalizePath(join(mockReferencesRoot, name))]).toBeUndefined();
+		}
+	});
+
+	it("reinstalls a per-reference skill under the new name", async () => {
+		const { installReferenceSkill, removeReferenceSkill } = await import("../reference.js");
+		vi.mocked(removeReferenceSkill).mockReturnValueOnce(["/skills/offworld-tanstack-router"]);
+
+		await moveRepo(mockSource, target);
+
+		expect(removeReferenceSkill).toHaveBeenCalledWith("tanstack-router");
+		expect(installReferenceSkill).toHaveBeenCalledWith(
+			expect.objectContaining({
+				referenceName: "tanstack-labs-router",
+				fullName: "tanstack-labs/router",
+				localPath: newPath,
+			}),
+			expect.anything(),
+		);
+	});
+
+	it("leaves skills alone when none was installed", async () => {
+		const { installReferenceSkill } = await import("../reference.js");
+
+		await moveRepo(mockSource, target);
+
+		expect(installReferenceSkill).not.toHaveBeenCalled();
+	});
+
+	it("throws RepoExistsError when the new name is already installed", async () => {
+		mapEntries["github.com:tanstack-labs/router"] = { ...mockMapEntry, localPath: newPath };
+
+		await expect(moveRepo(mockSource, target)).rejects.toThrow(RepoExistsError);
+	});
+});
+
 describe("listRepos", () => {
 	it("returns repos from index", () => {
 		mapEntries[mockSource.qualifiedName] = mockMapEntry;
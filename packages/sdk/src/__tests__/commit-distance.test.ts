@@ -0,0 +1,112 @@
+/**
+ * Unit tests for commit-distance.ts
+ */
+
+import { mkdtempSync, rmSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import type { RemoteRepoSource } from "@offworld/types";
+import { afterEach, beforeEach, describe, expect, it, vi } from "vitest";
+import {
+	formatCommitDistance,
+	getCachedCommitDistance,
+	resolveCommitDistance,
+} from "../commit-distance.js";
+import type { GitBackend } from "../git-backend.js";
+import { createLogger } from "../logger.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+const SOURCE: RemoteRepoSource = {
+	type: "remote",
+	provider: "github",
+	owner: "owner",
+	repo: "repo",
+	fullName: "owner/repo",
+	qualifiedName: "github.com:owner/repo",
+	cloneUrl: "https://github.com/owner/repo.git",
+};
+const REFERENCE_SHA = "aaaaaaa1111111111111111111111111111111";
+const CLONE_SHA = "bbbbbbb2222222222222222222222222222222";
+
+describe("resolveCommitDistance", () => {
+	let dir: string;
+	let commits: Set<string>;
+	let fetchCommit: ReturnType<typeof vi.fn>;
+	let fetch: ReturnType<typeof vi.fn>;
+
+	function createTestRuntime(backend: Partial<GitBackend> = {}): OffworldRuntime {
+		const gitBackend = {
+			name: "stub",
+			hasCommit: (_dir: string, sha: string) => commits.has(sha),
+			countCommits: (_dir: string, from: string) => (from === REFERENCE_SHA ? 4 : 2),
+			fetchCommit,
+			...backend,
+		} as unknown as GitBackend;
+		return createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			logger: createLogger({ level: "silent", write: () => {} }),
+			gitBackend,
+			fetch: fetch as unknown as typeof globalThis.fetch,
+		});
+	}
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-distance-"));
+		commits = new Set([CLONE_SHA]);
+		fetchCommit = vi.fn(async () => {
+			commits.add(REFERENCE_SHA);
+		});
+		fetch = vi.fn(
+			async () =>
+				new Response(JSON.stringify({ status: "diverged", ahead_by: 7, behind_by: 3 }), {
+					status: 200,
+				}),
+		);
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("fetches a missing reference commit and counts both directions", async () => {
+		const runtime = createTestRuntime();
+		const distance = await runWithRuntime(runtime, () =>
+			resolveCommitDistance(SOURCE, dir, REFERENCE_SHA, CLONE_SHA),
+		);
+
+		expect(fetchCommit).toHaveBeenCalledWith(dir, REFERENCE_SHA);
+		expect(fetch).not.toHaveBeenCalled();
+		expect(distance).toMatchObject({ behind: 4, ahead: 2, method: "fetch" });
+		expect(formatCommitDistance(distance!)).toBe("4 behind, 2 ahead");
+	});
+
+	it("falls back to the GitHub compare API and caches the result", async () => {
+		fetchCommit.mockRejectedValue(new Error("fatal: couldn't find remote ref"));
+		const runtime = createTestRuntime();
+
+		await runWithRuntime(runtime, async () => {
+			const distance = await resolveCommitDistance(SOURCE, dir, REFERENCE_SHA, CLONE_SHA);
+			expect(distance).toMatchObject({ behind: 3, ahead: 7, method: "compare" });
+			expect(fetch).toHaveBeenCalledWith(
+				`https://api.github.com/repos/owner/repo/compare/${CLONE_SHA}...${REFERENCE_SHA}`,
+				expect.anything(),
+			);
+
+			await resolveCommitDistance(SOURCE, dir, REFERENCE_SHA, CLONE_SHA);
+			expect(fetch).toHaveBeenCalledTimes(1);
+			expect(getCachedCommitDistance(SOURCE.qualifiedName, REFERENCE_SHA, CLONE_SHA)).toEqual(
+				distance,
+			);
+		});
+	});
+
+	it("returns null without caching when nothing can resolve the commit", async () => {
+		fetch.mockResolvedValue(new Response("Not Found", { status: 404 }));
+		const runtime = createTestRuntime({ fetchCommit: undefined });
+
+		await runWithRuntime(runtime, async () => {
+			expect(await resolveCommitDistance(SOURCE, dir, REFERENCE_SHA, CLONE_SHA)).toBeNull();
+			expect(getCachedCommitDistance(SOURCE.qualifiedName, REFERENCE_SHA, CLONE_SHA)).toBeNull();
+		});
+	});
+});
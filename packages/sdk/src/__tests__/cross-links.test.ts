@@ -0,0 +1,96 @@
+/**
+ * Unit tests for cross-links.ts
+ */
+
+import { mkdirSync, mkdtempSync, readFileSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import type { GlobalMap } from "@offworld/types";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import { getRelatedReferences, stripRelatedBlock, updateCrossLinks } from "../cross-links.js";
+import { createLogger } from "../logger.js";
+import { Paths } from "../paths.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+function entry(primary: string, keywords: string[]): GlobalMap["repos"][string] {
+	return {
+		localPath: `/ow/${primary}`,
+		references: [primary],
+		primary,
+		keywords,
+		updatedAt: "2026-01-01T00:00:00.000Z",
+	};
+}
+
+const map: GlobalMap = {
+	repos: {
+		"github.com:tanstack/router": entry("tanstack-router.md", ["@tanstack/react-router"]),
+		"github.com:tanstack/query": entry("tanstack-query.md", ["@tanstack/react-query", "query"]),
+		"github.com:colinhacks/zod": entry("colinhacks-zod.md", ["zod", "schema"]),
+	},
+};
+
+describe("cross-links", () => {
+	let dir: string;
+	let runtime: OffworldRuntime;
+
+	function readRef(name: string): string {
+		return readFileSync(join(Paths.offworldReferencesDir, name), "utf-8");
+	}
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-links-"));
+		runtime = createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			logger: createLogger({ level: "silent", write: () => {} }),
+		});
+		runWithRuntime(runtime, () => {
+			mkdirSync(Paths.offworldReferencesDir, { recursive: true });
+			const write = (name: string, content: string) =>
+				writeFileSync(join(Paths.offworldReferencesDir, name), content);
+			write("tanstack-router.md", "# Router\n\nPair with `@tanstack/react-query` loaders.\n");
+			write("tanstack-query.md", "# Query\n\nValidate with zod. Not @acme/zod-tools.\n");
+			write("colinhacks-zod.md", "# Zod\n\nA query builder is out of scope.\n");
+		});
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("links mentions in both directions, ignoring partial and generic matches", () => {
+		runWithRuntime(runtime, () => {
+			const related = getRelatedReferences("github.com:tanstack/query", map);
+
+			expect(related.map((r) => [r.fullName, r.mentions, r.mentionedBy])).toEqual([
+				["colinhacks/zod", true, false],
+				["tanstack/router", false, true],
+			]);
+			expect(getRelatedReferences("github.com:colinhacks/zod", map)).toHaveLength(1);
+		});
+	});
+
+	it("writes related blocks idempotently and drops them when a repo goes away", () => {
+		runWithRuntime(runtime, () => {
+			expect(updateCrossLinks(map)).toBe(3);
+			expect(updateCrossLinks(map)).toBe(0);
+			expect(readRef("colinhacks-zod.md")).toContain(
+				`- tanstack/query: \`${join(Paths.offworldReferencesDir, "tanstack-query.md")}\``,
+			);
+
+			const { "github.com:tanstack/query": _removed, ...rest } = map.repos;
+			updateCrossLinks({ repos: rest });
+
+			expect(readRef("colinhacks-zod.md")).toBe("# Zod\n\nA query builder is out of scope.\n");
+			expect(readRef("tanstack-router.md")).not.toContain("Related References");
+		});
+	});
+
+	it("strips the block without touching surrounding content", () => {
+		const content =
+			"# Zod\n\n<!-- offworld:related -->\n## Related References\n<!-- /offworld:related -->\n";
+
+		expect(stripRelatedBlock(content)).toBe("# Zod\n");
+		expect(stripRelatedBlock("# Zod\n")).toBe("# Zod\n");
+	});
+});
@@ -0,0 +1,181 @@
+/**
+ * Unit tests for daemon.ts and remote-cache.ts
+ */
+
+import { mkdtempSync, rmSync, statSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { afterEach, beforeEach, describe, expect, it, vi } from "vitest";
+import {
+	DaemonNotRunningError,
+	parseInterval,
+	renderSystemdUnit,
+	requestDaemon,
+	resolveDaemonSchedule,
+	startDaemon,
+	type DaemonSchedule,
+	type DaemonStatus,
+} from "../daemon.js";
+import { writeGlobalMap } from "../index-manager.js";
+import { getCachedRemoteCheck, writeRemoteChecks } from "../remote-cache.js";
+import { createLogger } from "../logger.js";
+import { createRuntime, runWithRuntime } from "../runtime.js";
+
+const mocks = vi.hoisted(() => ({ pull: vi.fn() }));
+
+vi.mock("../client.js", () => ({
+	Offworld: class {
+		pull = mocks.pull;
+	},
+}));
+
+const silentLogger = createLogger({ level: "silent", write: () => {} });
+
+const idleSchedule: DaemonSchedule = {
+	intervals: { fetch: null, "remote-check": null, refresh: null },
+	regenerate: false,
+	warmOpenCode: false,
+};
+
+describe("daemon schedule", () => {
+	it("parses intervals and 'off'", () => {
+		expect(parseInterval("30m")).toBe(30 * 60_000);
+		expect(parseInterval(" 6H ")).toBe(6 * 3_600_000);
+		expect(parseInterval("off")).toBeNull();
+		expect(parseInterval("90d")).toBe(2 ** 31 - 1);
+		expect(() => parseInterval("0h")).toThrow("Invalid daemon interval");
+		expect(() => parseInterval("weekly")).toThrow("Invalid daemon interval");
+	});
+
+	it("fills unset keys with defaults", () => {
+		const schedule = resolveDaemonSchedule({ fetchInterval: "1h", refreshInterval: "off" });
+
+		expect(schedule.intervals).toEqual({
+			fetch: 3_600_000,
+			"remote-check": 12 * 3_600_000,
+			refresh: null,
+		});
+		expect(schedule.regenerate).toBe(false);
+		expect(schedule.warmOpenCode).toBe(true);
+	});
+
+	it("renders a systemd unit that runs the daemon in the foreground", () => {
+		const unit = renderSystemdUnit(["/usr/bin/node", "/opt/my tools/ow.mjs"]);
+
+		expect(unit).toContain(
+			'ExecStart=/usr/bin/node "/opt/my tools/ow.mjs" daemon start --foreground',
+		);
+		expect(unit).toContain("WantedBy=default.target");
+	});
+});
+
+describe("daemon socket", () => {
+	let dir: string;
+
+	function inState<T>(fn: () => T): T {
+		return runWithRuntime(createRuntime({ paths: { state: dir }, logger: silentLogger }), fn);
+	}
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-daemon-"));
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("answers status, queue and run requests and stops on request", async () => {
+		const daemon = await inState(() => startDaemon({ schedule: idleSchedule }));
+
+		expect(statSync(daemon.socketPath).mode & 0o777).toBe(0o600);
+		const status = await inState(() =>
+			requestDaemon<{ pid: number; running: string | null }>("status"),
+		);
+		expect(status).toMatchObject({ pid: process.pid, running: null });
+		await expect(inState(() => requestDaemon("queue"))).resolves.toEqual({
+			running: null,
+			pending: [],
+		});
+		await expect(inState(() => requestDaemon("run", { task: "nope" }))).rejects.toThrow(
+			"Unknown task: nope",
+		);
+		await expect(inState(() => requestDaemon("bogus"))).rejects.toThrow("Unknown method");
+
+		await inState(() => requestDaemon("stop"));
+		await daemon.closed;
+		await expect(inState(() => requestDaemon("status"))).rejects.toBeInstanceOf(
+			DaemonNotRunningError,
+		);
+	});
+
+	it("refreshes references but skips clone-only repos", async () => {
+		mocks.pull.mockResolvedValue({ referenceSource: "remote" });
+		const runtime = createRuntime({
+			paths: { state: dir, data: join(dir, "data") },
+			logger: silentLogger,
+		});
+		const entry = { keywords: [], updatedAt: "2026-01-01T00:00:00.000Z" };
+		runWithRuntime(runtime, () =>
+			writeGlobalMap({
+				repos: {
+					"github.com:colinhacks/zod": {
+						...entry,
+						localPath: join(dir, "zod"),
+						references: ["colinhacks-zod.md"],
+						primary: "colinhacks-zod.md",
+					},
+					"github.com:owner/clone-only": {
+						...entry,
+						localPath: join(dir, "clone-only"),
+						references: [],
+						primary: "",
+					},
+				},
+			}),
+		);
+		const daemon = await runWithRuntime(runtime, () => startDaemon({ schedule: idleSchedule }));
+
+		await inState(() => requestDaemon("run", { task: "refresh" }));
+		await vi.waitFor(async () => {
+			const status = await inState(() => requestDaemon<DaemonStatus>("status"));
+			expect(status.tasks.refresh.lastSummary).toBe("1 refreshed, 0 failed");
+		});
+		expect(mocks.pull).toHaveBeenCalledTimes(1);
+		expect(mocks.pull.mock.calls[0]?.[0]).toContain("colinhacks/zod");
+		await daemon.stop();
+	});
+
+	it("refuses to start twice", async () => {
+		const daemon = await inState(() => startDaemon({ schedule: idleSchedule }));
+
+		await expect(inState(() => startDaemon({ schedule: idleSchedule }))).rejects.toThrow(
+			"already running",
+		);
+		await daemon.stop();
+	});
+});
+
+describe("remote check cache", () => {
+	let dir: string;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-remote-cache-"));
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("returns fresh checks case-insensitively and ignores stale ones", () => {
+		runWithRuntime(createRuntime({ paths: { state: dir } }), () => {
+			writeRemoteChecks({ "Colinhacks/Zod": { exists: true, commitSha: "abc1234" } });
+
+			expect(getCachedRemoteCheck("colinhacks/zod")).toMatchObject({
+				exists: true,
+				commitSha: "abc1234",
+			});
+			expect(getCachedRemoteCheck("colinhacks/zod", -1)).toBeNull();
+			expect(getCachedRemoteCheck("tanstack/router")).toBeNull();
+		});
+	});
+});
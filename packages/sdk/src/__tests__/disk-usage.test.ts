@@ -0,0 +1,79 @@
+/**
+ * Unit tests for disk-usage.ts
+ */
+
+import { beforeEach, describe, expect, it } from "vitest";
+import { getDiskUsage, invalidateDiskUsage } from "../disk-usage.js";
+import { createRuntime, runWithRuntime, type OffworldFileSystem } from "../runtime.js";
+import { clearVirtualFs, createFsMock } from "./mocks/fs.js";
+
+const REPO = "/repos/github/tanstack/query";
+const SHA_A = "a".repeat(40);
+const SHA_B = "b".repeat(40);
+
+let fs: OffworldFileSystem;
+
+function withFs<T>(fn: () => T): T {
+	return runWithRuntime(createRuntime({ fs, paths: { state: "/state" } }), fn);
+}
+
+function setHead(sha: string): void {
+	fs.writeFileSync(`${REPO}/.git/HEAD`, "ref: refs/heads/main\n");
+	fs.writeFileSync(`${REPO}/.git/refs/heads/main`, `${sha}\n`);
+}
+
+describe("getDiskUsage", () => {
+	beforeEach(() => {
+		clearVirtualFs();
+		const mock = createFsMock();
+		fs = { ...mock, lstatSync: mock.statSync } as unknown as OffworldFileSystem;
+		fs.mkdirSync(`${REPO}/.git`, { recursive: true });
+		fs.mkdirSync(`${REPO}/src`, { recursive: true });
+		setHead(SHA_A);
+		fs.writeFileSync(`${REPO}/src/index.ts`, "x".repeat(100));
+	});
+
+	it("measures and caches by HEAD", async () => {
+		const first = await withFs(() => getDiskUsage([REPO]));
+		const bytes = first.get(REPO)!.bytes;
+		expect(bytes).toBeGreaterThanOrEqual(100);
+		expect(first.get(REPO)!.cached).toBe(false);
+
+		fs.writeFileSync(`${REPO}/src/extra.ts`, "y".repeat(50));
+		const second = await withFs(() => getDiskUsage([REPO]));
+		expect(second.get(REPO)).toEqual({ repoPath: REPO, bytes, cached: true });
+	});
+
+	it("re-measures when HEAD moves", async () => {
+		await withFs(() => getDiskUsage([REPO]));
+		setHead(SHA_B);
+		fs.writeFileSync(`${REPO}/src/extra.ts`, "y".repeat(50));
+
+		const result = await withFs(() => getDiskUsage([REPO]));
+		expect(result.get(REPO)!.cached).toBe(false);
+	});
+
+	it("re-measures on refresh and after invalidation", async () => {
+		await withFs(() => getDiskUsage([REPO]));
+
+		const refreshed = await withFs(() => getDiskUsage([REPO], { refresh: true }));
+		expect(refreshed.get(REPO)!.cached).toBe(false);
+
+		withFs(() => invalidateDiskUsage(REPO));
+		const invalidated = await withFs(() => getDiskUsage([REPO]));
+		expect(invalidated.get(REPO)!.cached).toBe(false);
+	});
+
+	it("reports cached results before measuring", async () => {
+		const other = "/repos/github/other/repo";
+		fs.mkdirSync(other, { recursive: true });
+		fs.writeFileSync(`${other}/file.txt`, "z");
+		await withFs(() => getDiskUsage([REPO]));
+
+		const order: string[] = [];
+		await withFs(() =>
+			getDiskUsage([other, REPO], { onResult: ({ repoPath }) => order.push(repoPath) }),
+		);
+		expect(order).toEqual([REPO, other]);
+	});
+});
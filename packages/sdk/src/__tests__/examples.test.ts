@@ -0,0 +1,114 @@
+/**
+ * Unit tests for examples.ts
+ */
+
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { dirname, join } from "node:path";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import {
+	buildExamplesIndex,
+	formatExamplesForPrompt,
+	readExamplesIndex,
+	searchExamples,
+	writeExamplesIndex,
+	type ExamplesIndex,
+} from "../examples.js";
+import { createLogger } from "../logger.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+const FILES: Record<string, string> = {
+	"examples/basic/main.tsx": [
+		'import { QueryClient, useQuery as useQ } from "@tanstack/react-query";',
+		"",
+		"const client = new QueryClient();",
+		'const result = useQ({ queryKey: ["todos"] });',
+	].join("\n"),
+	"src/__tests__/cache.test.ts": [
+		'import { describe, expect, it } from "vitest";',
+		'import { QueryCache, QueryClient } from "../index";',
+		"",
+		'describe("cache", () => {',
+		'\tit("stores queries", () => {',
+		"\t\tconst cache = new QueryCache();",
+		"\t\texpect(cache.getAll()).toEqual([]);",
+		"\t});",
+		"",
+		'\tit("one liner", () => expect(1).toBe(1));',
+		"});",
+	].join("\n"),
+	"docs/guides/prefetching.md": [
+		"# Prefetching",
+		"",
+		"```tsx",
+		'import { useQueryClient } from "@tanstack/react-query";',
+		"const queryClient = useQueryClient();",
+		'queryClient.prefetchQuery({ queryKey: ["todos"] });',
+		"```",
+		"",
+		"```bash",
+		"npm install @tanstack/react-query",
+		"```",
+	].join("\n"),
+	"node_modules/dep/examples/skip.ts": 'import { x } from "y";\nx();\nx();\n',
+	"CHANGELOG.md": "# Changelog\n\n```ts\nconst a = 1;\nconst b = 2;\nconst c = 3;\n```\n",
+};
+
+describe("examples index", () => {
+	let dir: string;
+	let runtime: OffworldRuntime;
+	let index: ExamplesIndex;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-examples-"));
+		runtime = createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			logger: createLogger({ level: "silent", write: () => {} }),
+		});
+		for (const [path, content] of Object.entries(FILES)) {
+			mkdirSync(dirname(join(dir, "clone", path)), { recursive: true });
+			writeFileSync(join(dir, "clone", path), content);
+		}
+		index = runWithRuntime(runtime, () =>
+			buildExamplesIndex(join(dir, "clone"), "github.com:tanstack/query"),
+		);
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("harvests example files, test cases and docs code blocks", () => {
+		const summary = index.examples.map((e) => [e.kind, e.path, e.startLine, e.title, e.symbols]);
+
+		expect(summary).toEqual(
+			expect.arrayContaining([
+				["example", "examples/basic/main.tsx", 1, "main.tsx", ["QueryClient", "useQuery"]],
+				["test", "src/__tests__/cache.test.ts", 5, "stores queries", ["QueryCache"]],
+				["docs", "docs/guides/prefetching.md", 4, "Prefetching", ["useQueryClient"]],
+			]),
+		);
+		expect(index.examples).toHaveLength(3);
+	});
+
+	it("ranks symbol matches and requires every query word to match", () => {
+		expect(searchExamples(index, "QueryClient").map((m) => m.path)).toEqual([
+			"examples/basic/main.tsx",
+			"docs/guides/prefetching.md",
+		]);
+		expect(searchExamples(index, "prefetch todos")[0]?.kind).toBe("docs");
+		expect(searchExamples(index, "QueryCache mutation")).toEqual([]);
+	});
+
+	it("round-trips through the meta directory and formats a prompt sample", () => {
+		runWithRuntime(runtime, () => {
+			writeExamplesIndex("tanstack/query", index);
+			expect(readExamplesIndex("tanstack/query")).toEqual(index);
+			expect(readExamplesIndex("tanstack/router")).toBeNull();
+		});
+
+		const prompt = formatExamplesForPrompt(index);
+		expect(prompt).toContain("### examples/basic/main.tsx:1 (example; QueryClient, useQuery)");
+		expect(prompt?.indexOf("examples/basic")).toBeLessThan(prompt!.indexOf("cache.test.ts"));
+	});
+});
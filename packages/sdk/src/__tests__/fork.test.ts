@@ -0,0 +1,140 @@
+/**
+ * Unit tests for fork.ts
+ */
+
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import type { RemoteRepoSource } from "@offworld/types";
+import { afterEach, beforeEach, describe, expect, it, vi } from "vitest";
+import {
+	FORK_SUPPLEMENT_START,
+	findForkUpstream,
+	stripForkSupplement,
+	withForkSupplement,
+	type ForkUpstream,
+} from "../fork.js";
+import type { GitBackend } from "../git-backend.js";
+import { createLogger } from "../logger.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+const mocks = vi.hoisted(() => ({ checkRemote: vi.fn() }));
+
+vi.mock("../sync.js", async (importOriginal) => ({
+	...(await importOriginal<typeof import("../sync.js")>()),
+	checkRemote: mocks.checkRemote,
+}));
+
+const FORK: RemoteRepoSource = {
+	type: "remote",
+	provider: "github",
+	owner: "ourorg",
+	repo: "zod",
+	fullName: "ourorg/zod",
+	qualifiedName: "github.com:ourorg/zod",
+	cloneUrl: "https://github.com/ourorg/zod.git",
+};
+const REFERENCE_SHA = "aaaaaaa1111111111111111111111111111111";
+
+function json(body: unknown, status = 200): Response {
+	return new Response(JSON.stringify(body), { status });
+}
+
+describe("findForkUpstream", () => {
+	let dir: string;
+	let clonePath: string;
+	let commits: Set<string>;
+	let metadata: Record<string, unknown>;
+
+	function createTestRuntime(): OffworldRuntime {
+		const gitBackend = {
+			name: "stub",
+			hasCommit: (_dir: string, sha: string) => commits.has(sha),
+			fetchCommit: async () => {
+				throw new Error("fatal: remote error: upload-pack: not our ref");
+			},
+		} as unknown as GitBackend;
+		const fetch = vi.fn(async (url: string) => {
+			if (url === "https://api.github.com/repos/ourorg/zod") return json(metadata);
+			if (url === "https://registry.npmjs.org/zod") {
+				return json({ repository: { url: "git+https://github.com/colinhacks/zod.git" } });
+			}
+			return json({ message: "Not Found" }, 404);
+		});
+		return createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			logger: createLogger({ level: "silent", write: () => {} }),
+			gitBackend,
+			fetch: fetch as unknown as typeof globalThis.fetch,
+		});
+	}
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-fork-"));
+		clonePath = join(dir, "clone");
+		mkdirSync(clonePath);
+		commits = new Set();
+		metadata = { full_name: "ourorg/zod", fork: false };
+		mocks.checkRemote.mockImplementation(async (fullName: string) =>
+			fullName === "colinhacks/zod"
+				? { exists: true, commitSha: REFERENCE_SHA }
+				: { exists: false },
+		);
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("uses the GitHub fork parent", async () => {
+		metadata = { full_name: "ourorg/zod", fork: true, parent: { full_name: "colinhacks/zod" } };
+
+		const fork = await runWithRuntime(createTestRuntime(), () => findForkUpstream(FORK, clonePath));
+
+		expect(fork).toMatchObject({
+			upstream: { qualifiedName: "github.com:colinhacks/zod" },
+			method: "github",
+			referenceSha: REFERENCE_SHA,
+		});
+	});
+
+	it("requires shared history for repos found through the npm package name", async () => {
+		writeFileSync(join(clonePath, "package.json"), JSON.stringify({ name: "@ourorg/zod" }));
+		const runtime = createTestRuntime();
+
+		expect(await runWithRuntime(runtime, () => findForkUpstream(FORK, clonePath))).toBeNull();
+
+		commits.add(REFERENCE_SHA);
+		const fork = await runWithRuntime(runtime, () => findForkUpstream(FORK, clonePath));
+		expect(fork).toMatchObject({ upstream: { fullName: "colinhacks/zod" }, method: "history" });
+	});
+});
+
+describe("withForkSupplement", () => {
+	const upstream: ForkUpstream = {
+		upstream: {
+			...FORK,
+			owner: "colinhacks",
+			fullName: "colinhacks/zod",
+			qualifiedName: "github.com:colinhacks/zod",
+			cloneUrl: "https://github.com/colinhacks/zod.git",
+		},
+		method: "github",
+		referenceSha: REFERENCE_SHA,
+	};
+
+	it("summarizes the divergence and replaces an earlier supplement", () => {
+		const commits = Array.from({ length: 25 }, (_, i) => `fix: patch ${i + 1}`);
+		const divergence = { ahead: 25, behind: 0, commits, files: ["src/index.ts (modified)"] };
+
+		const once = withForkSupplement("# zod\n", FORK, upstream, divergence);
+		const twice = withForkSupplement(once, FORK, upstream, null);
+
+		expect(once).toContain("Compared with that commit, `ourorg/zod` is 25 commits ahead.");
+		expect(once).toContain("- fix: patch 20\n- ...and 5 more");
+		expect(once).toContain("- src/index.ts (modified)");
+		expect(twice.split(FORK_SUPPLEMENT_START)).toHaveLength(2);
+		expect(twice).toContain("(see `git log aaaaaaa..HEAD`)");
+		expect(stripForkSupplement(twice)).toBe("# zod\n");
+	});
+});
@@ -0,0 +1,104 @@
+/**
+ * Unit tests for freshness.ts
+ */
+
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { ConfigSchema } from "@offworld/types";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import { getReferenceFreshness } from "../freshness.js";
+import type { GitBackend } from "../git-backend.js";
+import { createLogger } from "../logger.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+const REFERENCE_SHA = "aaaaaaa1111111111111111111111111111111";
+const HEAD_SHA = "bbbbbbb2222222222222222222222222222222";
+
+describe("getReferenceFreshness", () => {
+	let dir: string;
+	let clonePath: string;
+	let runtime: OffworldRuntime;
+	let hasReferenceCommit: boolean;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-freshness-"));
+		clonePath = join(dir, "clone");
+		mkdirSync(clonePath);
+		const metaDir = join(dir, "data", "meta", "owner-repo");
+		mkdirSync(metaDir, { recursive: true });
+		writeFileSync(
+			join(metaDir, "meta.json"),
+			JSON.stringify({
+				referenceUpdatedAt: "2026-01-01T00:00:00.000Z",
+				commitSha: REFERENCE_SHA,
+				version: "0.1.0",
+				source: "verified",
+			}),
+		);
+		hasReferenceCommit = true;
+		const gitBackend = {
+			name: "stub",
+			revParse: () => HEAD_SHA,
+			hasCommit: () => hasReferenceCommit,
+			countCommits: () => 25,
+		} as unknown as GitBackend;
+		runtime = createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			logger: createLogger({ level: "silent", write: () => {} }),
+			gitBackend,
+		});
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("flags references further behind the clone than maxCommitDistance", () => {
+		const config = ConfigSchema.parse({});
+		const freshness = runWithRuntime(runtime, () =>
+			getReferenceFreshness("github.com:owner/repo", clonePath, config),
+		);
+
+		expect(freshness).toEqual({
+			referenceCommit: REFERENCE_SHA,
+			cloneCommit: HEAD_SHA,
+			commitDistance: 25,
+			generatedAt: "2026-01-01T00:00:00.000Z",
+			source: "verified",
+			maxCommitDistance: 20,
+			stale: true,
+		});
+	});
+
+	it("respects per-repo overrides and unknown clones", () => {
+		const config = ConfigSchema.parse({ repos: { "owner/repo": { maxCommitDistance: 50 } } });
+		runWithRuntime(runtime, () => {
+			expect(getReferenceFreshness("github.com:owner/repo", clonePath, config).stale).toBe(false);
+
+			const missing = getReferenceFreshness("github.com:owner/repo", join(dir, "gone"), config);
+			expect(missing.cloneCommit).toBeNull();
+			expect(missing.commitDistance).toBeNull();
+			expect(missing.stale).toBe(false);
+		});
+	});
+
+	it("uses distances resolved earlier when the reference commit isn't in the clone", () => {
+		hasReferenceCommit = false;
+		const config = ConfigSchema.parse({});
+		const key = `github.com:owner/repo@${REFERENCE_SHA}..${HEAD_SHA}`;
+		mkdirSync(join(dir, "state"), { recursive: true });
+		writeFileSync(
+			join(dir, "state", "commit-distances.json"),
+			JSON.stringify({
+				[key]: { behind: 3, ahead: 2, method: "compare", resolvedAt: "2026-01-02T00:00:00.000Z" },
+			}),
+		);
+
+		const freshness = runWithRuntime(runtime, () =>
+			getReferenceFreshness("github.com:owner/repo", clonePath, config),
+		);
+		expect(freshness.commitDistance).toBe(3);
+		expect(freshness.stale).toBe(false);
+	});
+});
@@ -8,14 +8,18 @@ vi.mock("../clone.js", () => ({
 	getCommitSha: vi.fn(),
 }));
 
-vi.mock("../agents.js", () => ({
-	agents: {
+vi.mock("../agents.js", () => {
+	const agents = {
 		opencode: {
 			name: "opencode",
 			globalSkillsDir: "/mock/opencode/skills",
 		},
-	},
-}));
+	};
+	return {
+		agents,
+		getAgentConfig: vi.fn((name: string) => agents[name as keyof typeof agents]),
+	};
+});
 
 vi.mock("node:fs", () => ({
 	mkdirSync: vi.fn(),
@@ -30,6 +34,7 @@ vi.mock("node:fs", () => ({
 vi.mock("../paths.js", () => ({
 	Paths: {
 		data: "/mock/data",
+		skillsDir: "/mock/data/skill",
 		metaDir: "/mock/data/meta",
 		offworldReferencesDir: "/mock/data/references",
 		offworldSkillDir: "/mock/data/skill/offworld",
@@ -62,7 +67,12 @@ import { streamPrompt } from "../ai/opencode.js";
 import { getCommitSha } from "../clone.js";
 import { loadConfig } from "../config.js";
 import { generateReferenceWithAI } from "../generate.js";
-import { installReference } from "../reference.js";
+import {
+	buildShortReference,
+	extractReferenceOverview,
+	installReference,
+	MAX_SHORT_REFERENCE_CHARS,
+} from "../reference.js";
 import * as fs from "node:fs";
 
 const mockStreamPrompt = streamPrompt as ReturnType<typeof vi.fn>;
@@ -96,6 +106,37 @@ ${longContent}
 		expect(result.referenceContent).toContain("# Test Library");
 	});
 
+	it("uses the cheat sheet block when present and derives one otherwise", async () => {
+		const longContent = "This is reference content. ".repeat(30);
+		const shortBody = "Short and sweet. ".repeat(20);
+		mockStreamPrompt.mockResolvedValueOnce({
+			text: `<reference_output>
+# Test Library
+
+${longContent}
+</reference_output>
+<short_reference_output>
+# Test Library (cheat sheet)
+
+${shortBody}
+</short_reference_output>`,
+			durationMs: 1000,
+		});
+
+		const withShort = await generateReferenceWithAI("/mock/repo", "test/repo");
+		expect(withShort.shortContent).toContain("# Test Library (cheat sheet)");
+		expect(withShort.referenceContent).not.toContain("Short and sweet");
+
+		mockStreamPrompt.mockResolvedValueOnce({
+			text: `<reference_output>\n# Test Library\n\n${longContent}\n</reference_output>`,
+			durationMs: 1000,
+		});
+
+		const derived = await generateReferenceWithAI("/mock/repo", "test/repo");
+		expect(derived.shortContent).toContain("# Test Library (cheat sheet)");
+		expect(derived.shortContent).toContain("Full reference: `test-repo.md`");
+	});
+
 	it("throws when AI response missing reference_output tags", async () => {
 		mockStreamPrompt.mockResolvedValue({
 			text: "Some random response without tags",
@@ -156,6 +197,33 @@ ${"Content ".repeat(100)}
 			}),
 		);
 	});
+
+	it("applies per-repo model and prompt overrides", async () => {
+		mockLoadConfig.mockReturnValue({
+			defaultModel: "anthropic/claude-sonnet-4",
+			agents: [],
+			repos: {
+				"test/*": { model: "openai/gpt-5", prompt: "Focus on the hooks API." },
+			},
+		});
+		mockStreamPrompt.mockResolvedValue({
+			text: `<reference_output>
+# Test
+${"Content ".repeat(100)}
+</reference_output>`,
+			durationMs: 1000,
+		});
+
+		await generateReferenceWithAI("/mock/repo", "test/repo");
+
+		expect(mockStreamPrompt).toHaveBeenCalledWith(
+			expect.objectContaining({
+				provider: "openai",
+				model: "gpt-5",
+				prompt: expect.stringContaining("Focus on the hooks API."),
+			}),
+		);
+	});
 });
 
 describe("installReference", () => {
@@ -168,7 +236,7 @@ describe("installReference", () => {
 		globalMapState = { repos: {} };
 	});
 
-	it("creates reference file, meta file, and updates global map", () => {
+	it("creates reference file, meta file, and updates global map", async () => {
 		const referenceContent = "# TanStack Router\n\nA router library.";
 		const meta = {
 			referenceUpdatedAt: "2026-01-27T00:00:00Z",
@@ -176,7 +244,7 @@ describe("installReference", () => {
 			version: "0.1.0",
 		};
 
-		installReference(
+		await installReference(
 			"github.com:tanstack/router",
 			"tanstack/router",
 			"/home/user/ow/tanstack/router",
@@ -219,8 +287,32 @@ describe("installReference", () => {
 		);
 	});
 
-	it("derives minimal keywords from repo name", () => {
-		installReference(
+	it("writes a cheat sheet next to the reference", async () => {
+		const meta = {
+			referenceUpdatedAt: "2026-01-27T00:00:00Z",
+			commitSha: "abc123",
+			version: "0.1.0",
+		};
+
+		await installReference(
+			"github.com:tanstack/router",
+			"tanstack/router",
+			"/home/user/ow/tanstack/router",
+			"# TanStack Router\n\nA router library.",
+			meta,
+			undefined,
+			{ shortContent: "# TanStack Router (cheat sheet)\n" },
+		);
+
+		expect(fs.writeFileSync).toHaveBeenCalledWith(
+			"/mock/data/references/tanstack-router.short.md",
+			"# TanStack Router (cheat sheet)\n",
+			"utf-8",
+		);
+	});
+
+	it("derives minimal keywords from repo name", async () => {
+		await installReference(
 			"github.com:colinhacks/zod",
 			"colinhacks/zod",
 			"/home/user/ow/colinhacks/zod",
@@ -240,7 +332,7 @@ describe("installReference", () => {
 		);
 	});
 
-	it("replaces keywords with minimal set when updating", () => {
+	it("replaces keywords with minimal set when updating", async () => {
 		globalMapState.repos["github.com:tanstack/router"] = {
 			localPath: "/old/path",
 			references: ["old-reference.md"],
@@ -249,7 +341,7 @@ describe("installReference", () => {
 			updatedAt: "2026-01-01T00:00:00Z",
 		};
 
-		installReference(
+		await installReference(
 			"github.com:tanstack/router",
 			"tanstack/router",
 			"/new/path",
@@ -270,7 +362,26 @@ describe("installReference", () => {
 		);
 	});
 
-	it("handles legacy qualified name migration", () => {
+	it("clears forkOf when the new reference isn't taken from an upstream", async () => {
+		globalMapState.repos["github.com:me/router"] = {
+			localPath: "/home/user/ow/me/router",
+			references: ["me-router.md"],
+			primary: "me-router.md",
+			keywords: [],
+			updatedAt: "2026-01-01T00:00:00Z",
+			forkOf: "github.com:tanstack/router",
+		};
+
+		await installReference("github.com:me/router", "me/router", "/home/user/ow/me/router", "# R", {
+			referenceUpdatedAt: "2026-01-27T00:00:00Z",
+			commitSha: "abc123",
+			version: "0.1.0",
+		});
+
+		expect(globalMapState.repos["github.com:me/router"]?.forkOf).toBeUndefined();
+	});
+
+	it("handles legacy qualified name migration", async () => {
 		globalMapState.repos["github:tanstack/router"] = {
 			localPath: "/old/path",
 			references: ["old-ref.md"],
@@ -279,11 +390,17 @@ describe("installReference", () => {
 			updatedAt: "2026-01-01T00:00:00Z",
 		};
 
-		installReference("github.com:tanstack/router", "tanstack/router", "/new/path", "# Updated", {
-			referenceUpdatedAt: "2026-01-27T00:00:00Z",
-			commitSha: "abc123",
-			version: "0.1.0",
-		});
+		await installReference(
+			"github.com:tanstack/router",
+			"tanstack/router",
+			"/new/path",
+			"# Updated",
+			{
+				referenceUpdatedAt: "2026-01-27T00:00:00Z",
+				commitSha: "abc123",
+				version: "0.1.0",
+			},
+		);
 
 		const updatedEntry = globalMapState.repos["github.com:tanstack/router"];
 		expect(updatedEntry).toBeDefined();
@@ -297,10 +414,10 @@ describe("installReference", () => {
 		expect(globalMapState.repos["github:tanstack/router"]).toBeUndefined();
 	});
 
-	it("handles custom keywords when provided", () => {
+	it("handles custom keywords when provided", async () => {
 		const customKeywords = ["custom-tag", "special"];
 
-		installReference(
+		await installReference(
 			"github.com:owner/repo",
 			"owner/repo",
 			"/path/to/repo",
@@ -320,4 +437,98 @@ describe("installReference", () => {
 			}),
 		);
 	});
+
+	it("installs a per-reference skill in per-reference mode", async () => {
+		mockLoadConfig.mockReturnValue({ agents: ["opencode"], skillMode: "per-reference" });
+
+		await installReference(
+			"github.com:tanstack/router",
+			"tanstack/router",
+			"/home/user/ow/tanstack/router",
+			"# TanStack Router\n\nType-safe routing for React.\n\n## When to Use\n",
+			{ referenceUpdatedAt: "2026-01-27T00:00:00Z", commitSha: "abc123", version: "0.1.0" },
+		);
+
+		const skillPath = "/mock/data/skill/offworld-tanstack-router/SKILL.md";
+		const skillCall = vi.mocked(fs.writeFileSync).mock.calls.find((call) => call[0] === skillPath);
+		expect(skillCall?.[1]).toContain("name: offworld-tanstack-router");
+		expect(skillCall?.[1]).toContain(
+			'description: "Type-safe routing for React. Use when working with tanstack/router',
+		);
+		expect(fs.symlinkSync).toHaveBeenCalledWith(
+			"/mock/data/skill/offworld-tanstack-router",
+			"/mock/opencode/skills/offworld-tanstack-router",
+			"dir",
+		);
+	});
+
+	it("skips per-reference skills in router mode", async () => {
+		await installReference("github.com:owner/repo", "owner/repo", "/path", "# Repo", {
+			referenceUpdatedAt: "2026-01-27T00:00:00Z",
+			commitSha: "abc123",
+			version: "0.1.0",
+		});
+
+		const paths = vi.mocked(fs.writeFileSync).mock.calls.map((call) => String(call[0]));
+		expect(paths.some((path) => path.includes("offworld-"))).toBe(false);
+	});
+});
+
+describe("buildShortReference", () => {
+	const reference = [
+		"# Zod",
+		"",
+		"Schema validation with static types.",
+		"",
+		"## Installation",
+		"",
+		"```bash",
+		"npm install zod",
+		"```",
+		"",
+		"## Common Patterns",
+		"",
+		...[1, 2, 3, 4, 5, 6].flatMap((n) => [
+			`### Pattern ${n}`,
+			"```ts",
+			`pattern${n}();`,
+			"```",
+			"",
+		]),
+		"## API Quick Reference",
+		"",
+		"| Export | Purpose |",
+		"| ------ | ------- |",
+		"| `z.object` | Object schemas |",
+		"",
+	].join("\n");
+
+	it("keeps install, the top five patterns and the export table", () => {
+		const short = buildShortReference(reference, "colinhacks-zod.md");
+
+		expect(short).toContain("# Zod (cheat sheet)");
+		expect(short).toContain("npm install zod");
+		expect(short).toContain("### Pattern 5");
+		expect(short).not.toContain("### Pattern 6");
+		expect(short).toContain("| `z.object` | Object schemas |");
+		expect(short).toContain("Full reference: `colinhacks-zod.md`");
+	});
+
+	it("stays within the size budget", () => {
+		const huge = reference.replace("pattern1();", "x".repeat(20_000));
+
+		expect(buildShortReference(huge).length).toBeLessThanOrEqual(MAX_SHORT_REFERENCE_CHARS);
+	});
+});
+
+describe("extractReferenceOverview", () => {
+	it("returns the paragraph after the title", () => {
+		expect(
+			extractReferenceOverview("# Zod\n\nSchema validation\nwith static types.\n\n## Usage\n"),
+		).toBe("Schema validation with static types.");
+	});
+
+	it("returns null when the title is followed by a section", () => {
+		expect(extractReferenceOverview("# Zod\n\n## Quick References\n\ntext")).toBeNull();
+	});
 });
@@ -0,0 +1,106 @@
+/**
+ * Unit tests for git-hooks.ts
+ */
+
+import { execFileSync } from "node:child_process";
+import { existsSync, mkdirSync, mkdtempSync, readFileSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import {
+	detectHookManager,
+	getChangedFiles,
+	getProjectHooksStatus,
+	hasManifestChanges,
+	installProjectHooks,
+	uninstallProjectHooks,
+} from "../git-hooks.js";
+
+describe("project git hooks", () => {
+	let dir: string;
+
+	function git(...args: string[]): string {
+		return execFileSync("git", args, { cwd: dir, encoding: "utf-8" });
+	}
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "offworld-git-hooks-"));
+		git("init", "-q");
+		git("config", "user.email", "test@example.com");
+		git("config", "user.name", "test");
+		writeFileSync(join(dir, "package.json"), "{}");
+		git("add", ".");
+		git("commit", "-qm", "initial");
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("appends to existing git hooks and replaces its own section on reinstall", () => {
+		const postMerge = join(dir, ".git", "hooks", "post-merge");
+		writeFileSync(postMerge, "#!/bin/sh\necho existing\n");
+
+		installProjectHooks(dir);
+		installProjectHooks(dir);
+
+		const content = readFileSync(postMerge, "utf-8");
+		expect(content).toContain("echo existing");
+		expect(content.match(/ow project sync/g)).toHaveLength(1);
+		expect(getProjectHooksStatus(dir)).toEqual({
+			manager: "git",
+			installed: ["post-merge", "post-checkout"],
+		});
+	});
+
+	it("removes its section and deletes hooks it created", () => {
+		const hooksDir = join(dir, ".git", "hooks");
+		writeFileSync(join(hooksDir, "post-merge"), "#!/bin/sh\necho existing\n");
+		installProjectHooks(dir);
+
+		uninstallProjectHooks(dir);
+
+		expect(readFileSync(join(hooksDir, "post-merge"), "utf-8")).toBe("#!/bin/sh\necho existing\n");
+		expect(existsSync(join(hooksDir, "post-checkout"))).toBe(false);
+	});
+
+	it("writes husky hooks when .husky exists", () => {
+		mkdirSync(join(dir, ".husky"));
+
+		const result = installProjectHooks(dir);
+
+		expect(result.manager).toBe("husky");
+		expect(readFileSync(join(dir, ".husky", "post-checkout"), "utf-8")).toContain(
+			'[ "$3" = "1" ] && ow project sync',
+		);
+	});
+
+	it("adds lefthook hooks and refuses to overwrite existing ones", () => {
+		const config = join(dir, "lefthook.yml");
+		writeFileSync(config, "pre-commit:\n  commands:\n    lint:\n      run: bun lint\n");
+
+		expect(detectHookManager(dir)).toBe("lefthook");
+		installProjectHooks(dir);
+		expect(readFileSync(config, "utf-8")).toContain("post-merge:\n  commands:\n    offworld:");
+
+		uninstallProjectHooks(dir);
+		expect(readFileSync(config, "utf-8")).not.toContain("offworld");
+
+		writeFileSync(config, "post-merge:\n  commands:\n    deps:\n      run: bun install\n");
+		expect(() => installProjectHooks(dir)).toThrow("already defines post-merge");
+	});
+
+	it("detects manifest changes between revisions", () => {
+		writeFileSync(join(dir, "README.md"), "docs");
+		git("add", ".");
+		git("commit", "-qm", "docs");
+		expect(hasManifestChanges(getChangedFiles(dir, "HEAD~1") ?? [])).toBe(false);
+
+		writeFileSync(join(dir, "package.json"), '{"dependencies":{"zod":"^4.0.0"}}');
+		git("commit", "-qam", "deps");
+		expect(getChangedFiles(dir, "HEAD~1")).toEqual(["package.json"]);
+		expect(hasManifestChanges(["apps/web/bun.lock"])).toBe(true);
+
+		expect(getChangedFiles(dir, "0000000000000000000000000000000000000000")).toBeNull();
+	});
+});
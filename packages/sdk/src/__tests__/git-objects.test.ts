@@ -0,0 +1,234 @@
+/**
+ * Unit tests for git-objects.ts
+ */
+
+import { createHash } from "node:crypto";
+import { deflateSync } from "node:zlib";
+import { beforeEach, describe, expect, it, type Mock } from "vitest";
+import { applyDelta, GitObjectStore } from "../git-objects.js";
+import { createRuntime, runWithRuntime, type OffworldFileSystem } from "../runtime.js";
+import { clearVirtualFs, createFsMock } from "./mocks/fs.js";
+
+const REPO = "/repo";
+const GIT_DIR = `${REPO}/.git`;
+
+let fs: OffworldFileSystem;
+
+function inRepo<T>(fn: () => T): T {
+	return runWithRuntime(createRuntime({ fs }), fn);
+}
+
+function writeLoose(type: string, content: string): string {
+	const raw = Buffer.concat([Buffer.from(`${type} ${content.length}\0`), Buffer.from(content)]);
+	const sha = createHash("sha1").update(raw).digest("hex");
+	fs.writeFileSync(`${GIT_DIR}/objects/${sha.slice(0, 2)}/${sha.slice(2)}`, deflateSync(raw));
+	return sha;
+}
+
+function writeCommit(message: string, parents: string[] = [], time = 0): string {
+	const lines = ["tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904"];
+	for (const parent of parents) lines.push(`parent ${parent}`);
+	lines.push(`author a <a@b> ${time} +0000`, `committer a <a@b> ${time} +0000`, "", message);
+	return writeLoose("commit", lines.join("\n"));
+}
+
+function objectSha(type: string, content: Buffer): string {
+	return createHash("sha1")
+		.update(Buffer.concat([Buffer.from(`${type} ${content.length}\0`), content]))
+		.digest("hex");
+}
+
+function packEntryHeader(type: number, size: number): Buffer {
+	const bytes = [(type << 4) | (size & 0x0f)];
+	for (let rest = size >> 4; rest > 0; rest >>= 7) {
+		bytes[bytes.length - 1]! |= 0x80;
+		bytes.push(rest & 0x7f);
+	}
+	return Buffer.from(bytes);
+}
+
+/**
+ * Write a v2 pack holding `base` as a full blob and `target` as an OFS_DELTA against it.
+ */
+function writeDeltaPack(base: string, target: Buffer, delta: Buffer): [string, string] {
+	const baseEntry = Buffer.concat([
+		packEntryHeader(3, base.length),
+		deflateSync(Buffer.from(base)),
+	]);
+	const deltaEntry = Buffer.concat([
+		packEntryHeader(6, delta.length),
+		Buffer.from([baseEntry.length]),
+		deflateSync(delta),
+	]);
+	const header = Buffer.alloc(12);
+	header.write("PACK", 0);
+	header.writeUInt32BE(2, 4);
+	header.writeUInt32BE(2, 8);
+	const pack = Buffer.concat([header, baseEntry, deltaEntry, Buffer.alloc(20)]);
+
+	const baseSha = objectSha("blob", Buffer.from(base));
+	const targetSha = objectSha("blob", target);
+	const entries = [
+		{ sha: baseSha, offset: 12 },
+		{ sha: targetSha, offset: 12 + baseEntry.length },
+	].sort((a, b) => a.sha.localeCompare(b.sha));
+
+	const fanout = Buffer.alloc(256 * 4);
+	for (let byte = 0; byte < 256; byte++) {
+		const count = entries.filter((entry) => parseInt(entry.sha.slice(0, 2), 16) <= byte).length;
+		fanout.writeUInt32BE(count, byte * 4);
+	}
+	const offsets = Buffer.alloc(entries.length * 4);
+	entries.forEach((entry, index) => offsets.writeUInt32BE(entry.offset, index * 4));
+	const idx = Buffer.concat([
+		Buffer.from([0xff, 0x74, 0x4f, 0x63, 0, 0, 0, 2]),
+		fanout,
+		...entries.map((entry) => Buffer.from(entry.sha, "hex")),
+		Buffer.alloc(entries.length * 4),
+		offsets,
+		Buffer.alloc(40),
+	]);
+
+	fs.mkdirSync(`${GIT_DIR}/objects/pack`, { recursive: true });
+	fs.writeFileSync(`${GIT_DIR}/objects/pack/pack-test.pack`, pack);
+	fs.writeFileSync(`${GIT_DIR}/objects/pack/pack-test.idx`, idx);
+	return [baseSha, targetSha];
+}
+
+describe("git-objects", () => {
+	beforeEach(() => {
+		clearVirtualFs();
+		fs = createFsMock() as unknown as OffworldFileSystem;
+		fs.mkdirSync(GIT_DIR, { recursive: true });
+	});
+
+	describe("applyDelta", () => {
+		it("applies copy and insert instructions", () => {
+			const base = Buffer.from("hello world");
+			// src size 11, dst size 11, copy 6 bytes from offset 0, insert "there"
+			const delta = Buffer.from([11, 11, 0x90, 6, 5, ...Buffer.from("there")]);
+			expect(applyDelta(base, delta).toString()).toBe("hello there");
+		});
+
+		it("rejects deltas for the wrong base", () => {
+			expect(() => applyDelta(Buffer.from("abc"), Buffer.from([5, 1, 1, 0x41]))).toThrow(
+				/base size mismatch/,
+			);
+		});
+	});
+
+	describe("GitObjectStore", () => {
+		let root: string;
+		let main: string;
+		let side: string;
+		let merge: string;
+
+		beforeEach(() => {
+			root = writeCommit("root");
+			main = writeCommit("main", [root]);
+			side = writeCommit("side", [root]);
+			merge = writeCommit("merge", [main, side]);
+			fs.writeFileSync(`${GIT_DIR}/HEAD`, "ref: refs/heads/main\n");
+			fs.writeFileSync(`${GIT_DIR}/refs/heads/main`, `${merge}\n`);
+			fs.writeFileSync(`${GIT_DIR}/packed-refs`, `# pack-refs\n${side} refs/heads/side\n`);
+		});
+
+		it("resolves symbolic, loose and packed refs", () => {
+			inRepo(() => {
+				const store = new GitObjectStore(REPO);
+				expect(store.resolve("HEAD")).toBe(merge);
+				expect(store.resolve("main")).toBe(merge);
+				expect(store.resolve("side")).toBe(side);
+			});
+		});
+
+		it("expands short SHAs", () => {
+			inRepo(() => {
+				expect(new GitObjectStore(REPO).resolve(root.slice(0, 7))).toBe(root);
+			});
+		});
+
+		it("peels annotated tags", () => {
+			const tag = writeLoose("tag", `object ${main}\ntype commit\ntag v1\n\nrelease`);
+			fs.writeFileSync(`${GIT_DIR}/refs/tags/v1`, tag);
+			inRepo(() => {
+				expect(new GitObjectStore(REPO).resolve("v1")).toBe(main);
+			});
+		});
+
+		it("counts commits like rev-list --count", () => {
+			inRepo(() => {
+				const store = new GitObjectStore(REPO);
+				expect(store.countCommits(root, "HEAD")).toBe(3);
+				expect(store.countCommits(side, "HEAD")).toBe(2);
+				expect(store.countCommits("HEAD", root)).toBe(0);
+			});
+		});
+
+		it("stops walking once only excluded history is left", () => {
+			let tip = root;
+			for (let i = 1; i <= 20; i++) tip = writeCommit(`c${i}`, [tip], i * 100);
+			const next = writeCommit("next", [tip], 2100);
+			const readFile = fs.readFileSync as unknown as Mock;
+
+			inRepo(() => {
+				readFile.mockClear();
+				expect(new GitObjectStore(REPO).countCommits(tip, next)).toBe(1);
+			});
+
+			const rootPath = `${GIT_DIR}/objects/${root.slice(0, 2)}/${root.slice(2)}`;
+			expect(readFile.mock.calls.some(([path]) => path === rootPath)).toBe(false);
+		});
+
+		it("reports missing objects", () => {
+			inRepo(() => {
+				const store = new GitObjectStore(REPO);
+				expect(store.has(root)).toBe(true);
+				expect(store.has("0".repeat(40))).toBe(false);
+				expect(() => store.resolve("missing")).toThrow(/unknown revision/);
+			});
+		});
+
+		it("reads packed and deltified objects by offset", () => {
+			const target = Buffer.from("hello there");
+			const delta = Buffer.from([11, 11, 0x90, 6, 5, ...Buffer.from("there")]);
+			const [baseSha, targetSha] = writeDeltaPack("hello world", target, delta);
+			const readFile = fs.readFileSync as unknown as Mock;
+
+			inRepo(() => {
+				readFile.mockClear();
+				const store = new GitObjectStore(REPO);
+				expect(store.read(targetSha)).toEqual({ type: "blob", content: target });
+				expect(store.read(baseSha).content.toString()).toBe("hello world");
+			});
+
+			const packReads = readFile.mock.calls.filter(([path]) => String(path).endsWith(".pack"));
+			expect(packReads).toHaveLength(0);
+		});
+
+		it("evicts cached pack objects past maxCacheBytes", () => {
+			const target = Buffer.from("hello there");
+			const delta = Buffer.from([11, 11, 0x90, 6, 5, ...Buffer.from("there")]);
+			const [baseSha, targetSha] = writeDeltaPack("hello world", target, delta);
+			const openFile = fs.openSync as unknown as Mock;
+
+			inRepo(() => {
+				const store = new GitObjectStore(REPO, { maxCacheBytes: 11 });
+				store.read(targetSha);
+
+				openFile.mockClear();
+				store.read(targetSha);
+				expect(openFile).not.toHaveBeenCalled();
+
+				store.read(baseSha);
+				expect(openFile).toHaveBeenCalled();
+			});
+		});
+
+		it("throws outside a repository", () => {
+			inRepo(() => {
+				expect(() => new GitObjectStore("/elsewhere")).toThrow(/not a git repository/);
+			});
+		});
+	});
+});
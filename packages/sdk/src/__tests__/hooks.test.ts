@@ -0,0 +1,91 @@
+/**
+ * Unit tests for hooks.ts
+ */
+
+import { mkdtempSync, readFileSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { ConfigSchema, type Config } from "@offworld/types";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import { hasHooks, HookError, runHooks } from "../hooks.js";
+
+function withHooks(hooks: Config["hooks"]): Config {
+	return ConfigSchema.parse({ hooks });
+}
+
+describe("runHooks", () => {
+	let dir: string;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "offworld-hooks-"));
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("passes the payload to commands on stdin", async () => {
+		const out = join(dir, "payload.json");
+		const config = withHooks({ postClone: [`cat > "${out}"`] });
+
+		const results = await runHooks(
+			"postClone",
+			{ repo: "github.com:owner/repo", repoPath: dir, commitSha: "abc" },
+			{ config },
+		);
+
+		expect(results).toEqual([expect.objectContaining({ success: true })]);
+		const payload = JSON.parse(readFileSync(out, "utf-8"));
+		expect(payload).toMatchObject({
+			event: "postClone",
+			repo: "github.com:owner/repo",
+			commitSha: "abc",
+		});
+	});
+
+	it("calls the default export of module hooks", async () => {
+		const out = join(dir, "module.txt");
+		const modulePath = join(dir, "hook.mjs");
+		writeFileSync(
+			modulePath,
+			`import { writeFileSync } from "node:fs";
+export default (payload) => writeFileSync(${JSON.stringify(out)}, payload.event);`,
+		);
+
+		const config = withHooks({ postInstall: [{ module: modulePath }] });
+		await runHooks("postInstall", { repo: "x" }, { config });
+
+		expect(readFileSync(out, "utf-8")).toBe("postInstall");
+	});
+
+	it("records failures under the warn policy", async () => {
+		const config = withHooks({ postUpdate: ["echo broken >&2; exit 2", "true"] });
+
+		const results = await runHooks("postUpdate", { repo: "x" }, { config });
+
+		expect(results[0]).toMatchObject({ success: false, error: "broken" });
+		expect(results[1]).toMatchObject({ success: true });
+	});
+
+	it("throws HookError under the fail policy", async () => {
+		const config = withHooks({ preGenerate: [{ command: "exit 1", onFailure: "fail" }] });
+
+		await expect(runHooks("preGenerate", { repo: "x" }, { config })).rejects.toBeInstanceOf(
+			HookError,
+		);
+	});
+
+	it("kills hooks that exceed their timeout", async () => {
+		const config = withHooks({ postRemove: [{ command: "sleep 5", timeout: 100 }] });
+
+		const [result] = await runHooks("postRemove", { repo: "x" }, { config });
+
+		expect(result).toMatchObject({ success: false, error: "timed out after 100ms" });
+	});
+
+	it("does nothing without configured hooks", async () => {
+		const config = withHooks({});
+		expect(hasHooks("postClone", config)).toBe(false);
+		expect(await runHooks("postClone", { repo: "x" }, { config })).toEqual([]);
+	});
+});
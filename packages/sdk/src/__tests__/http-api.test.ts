@@ -0,0 +1,176 @@
+/**
+ * Unit tests for http-api.ts and the reference section helpers
+ */
+
+import { mkdirSync, mkdtempSync, rmSync, statSync, symlinkSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import { getHttpToken, startHttpServer, type HttpServerHandle } from "../http-api.js";
+import { writeGlobalMap } from "../index-manager.js";
+import { createLogger } from "../logger.js";
+import { Paths } from "../paths.js";
+import { extractReferenceSection, listReferenceSections } from "../reference.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+const REFERENCE = `# zod
+
+TypeScript-first schema validation.
+
+## Quick Start
+
+\`\`\`ts
+# not a heading
+\`\`\`
+
+### Parsing
+
+Use safeParse.
+
+## API
+
+z.object()
+`;
+
+describe("reference sections", () => {
+	it("lists headings outside code fences", () => {
+		expect(listReferenceSections(REFERENCE)).toEqual([
+			{ heading: "zod", level: 1 },
+			{ heading: "Quick Start", level: 2 },
+			{ heading: "Parsing", level: 3 },
+			{ heading: "API", level: 2 },
+		]);
+	});
+
+	it("extracts a section with its subsections", () => {
+		const section = extractReferenceSection(REFERENCE, "quick start");
+
+		expect(section).toContain("### Parsing");
+		expect(section).not.toContain("## API");
+		expect(extractReferenceSection(REFERENCE, "Missing")).toBeNull();
+	});
+});
+
+describe("http api", () => {
+	let dir: string;
+	let runtime: OffworldRuntime;
+	let server: HttpServerHandle;
+
+	async function get(path: string, token: string | null = "secret") {
+		const headers: Record<string, string> = token ? { authorization: `Bearer ${token}` } : {};
+		const response = await fetch(`${server.url}${path}`, { headers });
+		return { status: response.status, body: await response.text() };
+	}
+
+	beforeEach(async () => {
+		dir = mkdtempSync(join(tmpdir(), "ow-http-"));
+		runtime = createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			cwd: () => dir,
+			logger: createLogger({ level: "silent", write: () => {} }),
+		});
+
+		const clone = join(dir, "clone");
+		mkdirSync(join(clone, "src"), { recursive: true });
+		mkdirSync(join(clone, ".git"));
+		writeFileSync(join(clone, "src", "index.ts"), "export {};\n");
+		writeFileSync(join(clone, ".git", "config"), "[remote]\n");
+		writeFileSync(join(dir, "outside.txt"), "private");
+		symlinkSync(join(dir, "outside.txt"), join(clone, "link.txt"));
+
+		server = await runWithRuntime(runtime, () => {
+			writeGlobalMap({
+				repos: {
+					"github.com:colinhacks/zod": {
+						localPath: clone,
+						references: ["colinhacks-zod.md"],
+						primary: "colinhacks-zod.md",
+						keywords: ["zod", "validation"],
+						updatedAt: "2026-01-01T00:00:00.000Z",
+					},
+				},
+			});
+			mkdirSync(Paths.offworldReferencesDir, { recursive: true });
+			writeFileSync(join(Paths.offworldReferencesDir, "colinhacks-zod.md"), REFERENCE);
+			return startHttpServer({
+				port: 0,
+				token: "secret",
+				corsOrigins: ["http://localhost:3000"],
+			});
+		});
+	});
+
+	afterEach(async () => {
+		await server.close();
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("requires the token everywhere except the OpenAPI document", async () => {
+		expect((await get("/v1/map", null)).status).toBe(401);
+		expect((await get("/v1/map", "wrong")).status).toBe(401);
+
+		const openapi = await get("/openapi.json", null);
+		expect(openapi.status).toBe(200);
+		expect(JSON.parse(openapi.body).paths).toHaveProperty("/v1/search");
+	});
+
+	it("serves map entries, search results and reference sections", async () => {
+		const entry = await get("/v1/repos/colinhacks/zod");
+		expect(JSON.parse(entry.body)).toMatchObject({ qualifiedName: "github.com:colinhacks/zod" });
+
+		const search = await get("/v1/search?q=validation");
+		expect(JSON.parse(search.body)[0]).toMatchObject({ fullName: "colinhacks/zod" });
+
+		const section = await get("/v1/repos/colinhacks/zod/reference?section=API");
+		expect(section.body).toBe("## API\n\nz.object()");
+		expect((await get("/v1/repos/colinhacks/zod/reference?section=Nope")).status).toBe(404);
+		expect((await get("/v1/repos/other/repo")).status).toBe(404);
+	});
+
+	it("reads clone files but nothing outside the clone or in .git", async () => {
+		expect((await get("/v1/repos/colinhacks/zod/files/src/index.ts")).body).toBe("export {};\n");
+		expect(JSON.parse((await get("/v1/repos/colinhacks/zod/files")).body)).not.toContainEqual(
+			expect.objectContaining({ name: ".git" }),
+		);
+
+		expect((await get("/v1/repos/colinhacks/zod/files/..%2Foutside.txt")).status).toBe(403);
+		expect((await get("/v1/repos/colinhacks/zod/files/link.txt")).status).toBe(403);
+		expect((await get("/v1/repos/colinhacks/zod/files/.git/config")).status).toBe(403);
+	});
+
+	it("rejects malformed percent-encoding without dropping the request", async () => {
+		const malformed = await get("/v1/repos/%E0%A4%A/zod");
+		expect(malformed.status).toBe(400);
+		expect(JSON.parse(malformed.body).error).toContain("Malformed path segment");
+		expect((await get("/v1/repos/colinhacks/zod")).status).toBe(200);
+	});
+
+	it("answers CORS preflights for allowed origins only", async () => {
+		const preflight = (origin: string) =>
+			fetch(`${server.url}/v1/map`, {
+				method: "OPTIONS",
+				headers: { origin, "access-control-request-headers": "authorization" },
+			});
+
+		const allowed = await preflight("http://localhost:3000");
+		expect(allowed.status).toBe(204);
+		expect(allowed.headers.get("access-control-allow-origin")).toBe("http://localhost:3000");
+		expect(allowed.headers.get("access-control-allow-headers")).toBe("Authorization");
+
+		const denied = await preflight("https://evil.example");
+		expect(denied.status).toBe(403);
+		expect(denied.headers.get("access-control-allow-origin")).toBeNull();
+
+		const response = await fetch(`${server.url}/v1/map`, {
+			headers: { origin: "http://localhost:3000", authorization: "Bearer secret" },
+		});
+		expect(response.headers.get("access-control-allow-origin")).toBe("http://localhost:3000");
+	});
+
+	it("creates the token file readable by the owner only", () => {
+		runWithRuntime(runtime, () => {
+			getHttpToken();
+			expect(statSync(join(Paths.state, "serve-token")).mode & 0o777).toBe(0o600);
+		});
+	});
+});
@@ -106,7 +106,12 @@ vi.mock("../paths.js", () => ({
 	},
 }));
 
+vi.mock("../cross-links.js", () => ({
+	refreshCrossLinks: vi.fn(),
+}));
+
 import { mkdirSync, writeFileSync } from "node:fs";
+import { refreshCrossLinks } from "../cross-links.js";
 import { Paths } from "../paths.js";
 import {
 	readGlobalMap,
@@ -192,6 +197,28 @@ describe("index-manager.ts", () => {
 			expect(saved).toBeDefined();
 			expect(JSON.parse(saved!.content)).toEqual(sampleMap);
 		});
+
+		it("refreshes cross-links only when the installed references change", () => {
+			const mockRefreshCrossLinks = refreshCrossLinks as ReturnType<typeof vi.fn>;
+			addVirtualFile(globalMapPath, JSON.stringify(sampleMap));
+
+			upsertGlobalMapEntry("github.com:tanstack/router", {
+				...sampleEntry,
+				updatedAt: "2026-02-01T00:00:00Z",
+			});
+			expect(mockRefreshCrossLinks).not.toHaveBeenCalled();
+
+			upsertGlobalMapEntry("github.com:tanstack/query", {
+				...sampleEntry,
+				localPath: "/home/user/ow/github/tanstack/query",
+				references: ["tanstack-query.md"],
+				primary: "tanstack-query.md",
+			});
+			expect(mockRefreshCrossLinks).toHaveBeenCalledTimes(1);
+
+			writeGlobalMap(readGlobalMap(), { referencesChanged: true });
+			expect(mockRefreshCrossLinks).toHaveBeenCalledTimes(2);
+		});
 	});
 
 	describe("upsertGlobalMapEntry", () => {
@@ -0,0 +1,43 @@
+/**
+ * Unit tests for installation.ts upgrade verification
+ */
+
+import { generateKeyPairSync, sign } from "node:crypto";
+import { describe, expect, it } from "vitest";
+import { parseChecksum, sha256, verifyReleaseSignature } from "../installation.js";
+
+const ARCHIVE = Buffer.from("ow binary archive");
+
+describe("parseChecksum", () => {
+	const checksums = [
+		`${"a".repeat(64)}  ow-darwin-arm64.tar.gz`,
+		`${sha256(ARCHIVE)} *ow-linux-x64.tar.gz`,
+		"not-a-hash  ow-linux-arm64.tar.gz",
+	].join("\n");
+
+	it("finds the hash for an asset in sha256sum output", () => {
+		expect(parseChecksum(checksums, "ow-darwin-arm64.tar.gz")).toBe("a".repeat(64));
+		expect(parseChecksum(checksums, "ow-linux-x64.tar.gz")).toBe(sha256(ARCHIVE));
+	});
+
+	it("returns null for missing assets and malformed hashes", () => {
+		expect(parseChecksum(checksums, "ow-darwin-x64.tar.gz")).toBeNull();
+		expect(parseChecksum(checksums, "ow-linux-arm64.tar.gz")).toBeNull();
+	});
+});
+
+describe("verifyReleaseSignature", () => {
+	const { privateKey, publicKey } = generateKeyPairSync("ed25519");
+	const publicPem = publicKey.export({ type: "spki", format: "pem" }).toString();
+	const data = Buffer.from("checksums");
+	const signature = sign(null, data, privateKey).toString("base64");
+
+	it("accepts a valid signature", () => {
+		expect(verifyReleaseSignature(data, `${signature}\n`, publicPem)).toBe(true);
+	});
+
+	it("rejects tampered data and garbage signatures", () => {
+		expect(verifyReleaseSignature(Buffer.from("checksums!"), signature, publicPem)).toBe(false);
+		expect(verifyReleaseSignature(data, "bm90IGEgc2lnbmF0dXJl", publicPem)).toBe(false);
+	});
+});
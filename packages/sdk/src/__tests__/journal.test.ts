@@ -0,0 +1,135 @@
+/**
+ * Unit tests for journal.ts and logger.ts
+ */
+
+import { beforeEach, describe, expect, it } from "vitest";
+import {
+	parseSince,
+	readJournal,
+	recordConfigChange,
+	recordOperation,
+	setJournalCommand,
+} from "../journal.js";
+import { createLogger } from "../logger.js";
+import { createRuntime, runWithRuntime, type OffworldFileSystem } from "../runtime.js";
+import type { Config } from "@offworld/types";
+import { clearVirtualFs, createFsMock } from "./mocks/fs.js";
+
+let fs: OffworldFileSystem;
+
+function withFs<T>(fn: () => T): T {
+	return runWithRuntime(createRuntime({ fs, paths: { state: "/state" } }), fn);
+}
+
+describe("journal", () => {
+	beforeEach(() => {
+		clearVirtualFs();
+		fs = createFsMock() as unknown as OffworldFileSystem;
+		setJournalCommand(undefined);
+	});
+
+	it("records operations with the triggering command", () => {
+		setJournalCommand("ow repo update --all");
+		withFs(() =>
+			recordOperation({
+				op: "update",
+				repo: "github.com:colinhacks/zod",
+				summary: "abc1234 → def5678",
+				before: { commitSha: "abc1234" },
+				after: { commitSha: "def5678" },
+			}),
+		);
+		expect(fs.appendFileSync).toHaveBeenCalledTimes(1);
+		expect(fs.readFileSync).not.toHaveBeenCalled();
+
+		const [entry] = withFs(() => readJournal());
+		expect(entry).toMatchObject({
+			op: "update",
+			repo: "github.com:colinhacks/zod",
+			before: { commitSha: "abc1234" },
+			command: "ow repo update --all",
+		});
+	});
+
+	it("filters by repo, operation and limit", () => {
+		withFs(() => {
+			recordOperation({ op: "clone", repo: "github.com:colinhacks/zod" });
+			recordOperation({ op: "clone", repo: "github.com:tanstack/router" });
+			recordOperation({ op: "install", repo: "github.com:colinhacks/zod" });
+		});
+
+		expect(withFs(() => readJournal({ repo: "zod" }))).toHaveLength(2);
+		expect(withFs(() => readJournal({ repo: "tanstack/router" }))).toHaveLength(1);
+		expect(withFs(() => readJournal({ op: "clone", limit: 1 }))[0]?.repo).toBe(
+			"github.com:tanstack/router",
+		);
+	});
+
+	it("records only changed config keys", () => {
+		const before = { repoRoot: "~/ow", agents: ["opencode"] } as unknown as Config;
+		withFs(() => {
+			recordConfigChange(before, { ...before });
+			recordConfigChange(before, { ...before, agents: ["claude-code"] });
+		});
+
+		const entries = withFs(() => readJournal());
+		expect(entries).toHaveLength(1);
+		expect(entries[0]).toMatchObject({
+			op: "config",
+			summary: "changed agents",
+			before: { agents: ["opencode"] },
+			after: { agents: ["claude-code"] },
+		});
+	});
+
+	it("rotates large journals and reads across files", () => {
+		const summary = "x".repeat(400 * 1024);
+		withFs(() => {
+			for (let i = 0; i < 4; i++) {
+				recordOperation({ op: "push", repo: `github.com:o/r${i}`, summary });
+			}
+		});
+
+		expect(fs.existsSync("/state/journal.1.jsonl")).toBe(true);
+		const repos = withFs(() => readJournal()).map((entry) => entry.repo);
+		expect(repos).toEqual([
+			"github.com:o/r0",
+			"github.com:o/r1",
+			"github.com:o/r2",
+			"github.com:o/r3",
+		]);
+	});
+});
+
+describe("parseSince", () => {
+	const now = new Date("2026-02-10T12:00:00Z");
+
+	it("parses durations and dates", () => {
+		expect(parseSince("7d", now).toISOString()).toBe("2026-02-03T12:00:00.000Z");
+		expect(parseSince("2h", now).toISOString()).toBe("2026-02-10T10:00:00.000Z");
+		expect(parseSince("2026-01-31", now).toISOString()).toBe("2026-01-31T00:00:00.000Z");
+	});
+
+	it("rejects garbage", () => {
+		expect(() => parseSince("yesterday", now)).toThrow("Invalid --since");
+	});
+});
+
+describe("createLogger", () => {
+	it("prints at or above the configured level with fields", () => {
+		const lines: string[] = [];
+		const logger = createLogger({ level: "info", write: (line) => lines.push(line) });
+
+		logger.debug("hidden");
+		logger.info("cloned", { repo: "o/r" });
+		logger.child({ command: "pull" }).warn("slow", { ms: 1200 });
+
+		expect(lines).toEqual(["[info] cloned repo=o/r\n", "[warn] slow command=pull ms=1200\n"]);
+	});
+
+	it("prints nothing when silent", () => {
+		const lines: string[] = [];
+		createLogger({ level: "silent", write: (line) => lines.push(line) }).error("boom");
+		expect(lines).toEqual([]);
+	});
+});
@@ -60,7 +60,13 @@ vi.mock("../paths.js", () => ({
 	},
 }));
 
-import { resolveRepoKey, getMapEntry, searchMap, getProjectMapPath } from "../map.js";
+import {
+	resolveRepoKey,
+	getMapEntry,
+	searchMap,
+	getProjectMapPath,
+	listMapNames,
+} from "../map.js";
 
 describe("map.ts", () => {
 	const sampleGlobalMap: GlobalMap = {
@@ -256,4 +262,32 @@ describe("map.ts", () => {
 			expect(result).toBe(mapPath);
 		});
 	});
+
+	describe("listMapNames", () => {
+		it("returns empty lists without maps", () => {
+			expect(listMapNames("/some/project")).toEqual({ repos: [], references: [] });
+		});
+
+		it("merges repo and reference names from both maps", () => {
+			addVirtualFile(globalMapPath, JSON.stringify(sampleGlobalMap));
+			addVirtualFile(
+				"/some/project/.offworld/map.json",
+				JSON.stringify({
+					...sampleProjectMap,
+					repos: {
+						"github.com:vercel/ai": {
+							localPath: "/home/user/ow/github/vercel/ai",
+							reference: "vercel-ai.md",
+							keywords: [],
+						},
+					},
+				}),
+			);
+
+			expect(listMapNames("/some/project")).toEqual({
+				repos: ["colinhacks/zod", "microsoft/TypeScript", "tanstack/router", "vercel/ai"],
+				references: ["colinhacks-zod", "microsoft-TypeScript", "tanstack-router", "vercel-ai"],
+			});
+		});
+	});
 });
@@ -109,6 +109,70 @@ export function createFsMock() {
 		};
 	});
 
+	const appendFileSync: Mock = vi.fn((path: string, data: string | Buffer) => {
+		const normalized = normalizePath(path);
+		const existing = virtualFs[normalized];
+		const content = existing && !existing.isDirectory ? existing.content.toString() : "";
+		virtualFs[normalized] = {
+			content: content + data.toString(),
+			isDirectory: false,
+		};
+	});
+
+	const renameSync: Mock = vi.fn((oldPath: string, newPath: string) => {
+		const from = normalizePath(oldPath);
+		const to = normalizePath(newPath);
+
+		if (!(from in virtualFs)) {
+			const error = new Error(
+				`ENOENT: no such file or directory, rename '${oldPath}' -> '${newPath}'`,
+			) as NodeJS.ErrnoException;
+			error.code = "ENOENT";
+			throw error;
+		}
+
+		for (const key of Object.keys(virtualFs)) {
+			if (key === from || key.startsWith(from + "/")) {
+				virtualFs[to + key.slice(from.length)] = virtualFs[key]!;
+				delete virtualFs[key];
+			}
+		}
+	});
+
+	const openFiles = new Map<number, string>();
+	let nextFd = 100;
+
+	const openSync: Mock = vi.fn((path: string) => {
+		const normalized = normalizePath(path);
+		if (!virtualFs[normalized] || virtualFs[normalized].isDirectory) {
+			const error = new Error(
+				`ENOENT: no such file or directory, open '${path}'`,
+			) as NodeJS.ErrnoException;
+			error.code = "ENOENT";
+			throw error;
+		}
+		openFiles.set(nextFd, normalized);
+		return nextFd++;
+	});
+
+	const readSync: Mock = vi.fn(
+		(fd: number, buffer: Buffer, offset: number, length: number, position: number) => {
+			const file = virtualFs[openFiles.get(fd) ?? ""];
+			if (!file) {
+				const error = new Error("EBADF: bad file descriptor, read") as NodeJS.ErrnoException;
+				error.code = "EBADF";
+				throw error;
+			}
+			const content = Buffer.from(file.content);
+			if (position >= content.length) return 0;
+			return content.copy(buffer, offset, position, Math.min(position + length, content.length));
+		},
+	);
+
+	const closeSync: Mock = vi.fn((fd: number) => {
+		openFiles.delete(fd);
+	});
+
 	const mkdirSync: Mock = vi.fn((path: string, options?: { recursive?: boolean }) => {
 		const normalized = normalizePath(path);
 
@@ -188,6 +252,11 @@ export function createFsMock() {
 		existsSync,
 		readFileSync,
 		writeFileSync,
+		appendFileSync,
+		renameSync,
+		openSync,
+		readSync,
+		closeSync,
 		mkdirSync,
 		rmSync,
 		readdirSync,
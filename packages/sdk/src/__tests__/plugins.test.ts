@@ -0,0 +1,177 @@
+/**
+ * Unit tests for plugins.ts
+ */
+
+import { mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { ConfigSchema, type Config } from "@offworld/types";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import { getAgentConfig, getAllAgentConfigs } from "../agents.js";
+import { resolveDependencyRepo } from "../dep-mappings.js";
+import { parseDependencies } from "../manifest.js";
+import {
+	applyReferencePostProcessors,
+	definePlugin,
+	getLoadedPlugins,
+	getUnknownAgents,
+	loadPlugins,
+	PLUGIN_API_VERSION,
+	registerPlugin,
+	resetPlugins,
+} from "../plugins.js";
+
+function withPlugins(plugins: string[]): Config {
+	return ConfigSchema.parse({ plugins });
+}
+
+describe("plugins", () => {
+	let dir: string;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "offworld-plugins-"));
+	});
+
+	afterEach(() => {
+		resetPlugins();
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("registers agents, resolvers and manifest parsers", async () => {
+		const plugin = definePlugin({
+			name: "example",
+			apiVersion: PLUGIN_API_VERSION,
+			setup(api) {
+				api.registerAgent({
+					name: "zed",
+					displayName: "Zed",
+					skillsDir: ".zed/skills",
+					globalSkillsDir: "~/.config/zed/skills",
+					detectInstalled: () => false,
+				});
+				api.registerResolver({
+					name: "internal",
+					resolve: (dep) => (dep === "@acme/ui" ? "acme/ui" : null),
+				});
+				api.registerManifestParser({
+					name: "deps-txt",
+					detect: (root) => root === dir,
+					parse: () => [
+						{ name: "alpha", dev: false },
+						{ name: "beta", dev: false },
+					],
+				});
+			},
+		});
+
+		const record = await registerPlugin(plugin);
+
+		expect(record.error).toBeUndefined();
+		expect(record.contributions.agents).toEqual(["zed"]);
+		expect(getAgentConfig("zed")?.displayName).toBe("Zed");
+		expect(getAllAgentConfigs().map((agent) => agent.name)).toContain("zed");
+		expect(await resolveDependencyRepo("@acme/ui", undefined, { allowNpm: false })).toEqual({
+			dep: "@acme/ui",
+			repo: "acme/ui",
+			source: "plugin",
+		});
+		expect(parseDependencies(dir).map((dep) => dep.name)).toEqual(["alpha", "beta"]);
+	});
+
+	it("flags configured agents that are neither built in nor registered", async () => {
+		await registerPlugin(
+			definePlugin({
+				name: "zed-agent",
+				apiVersion: PLUGIN_API_VERSION,
+				setup(api) {
+					api.registerAgent({
+						name: "zed",
+						displayName: "Zed",
+						skillsDir: ".zed/skills",
+						globalSkillsDir: "~/.config/zed/skills",
+						detectInstalled: () => false,
+					});
+				},
+			}),
+		);
+		const config = { ...withPlugins([]), agents: ["claude-code", "zed", "claud-code"] };
+
+		expect(getUnknownAgents(config)).toEqual(["claud-code"]);
+	});
+
+	it("rejects plugins built for another API version", async () => {
+		const record = await registerPlugin({
+			name: "future",
+			apiVersion: PLUGIN_API_VERSION + 1,
+			setup(api) {
+				api.registerResolver({ name: "never", resolve: () => "never/used" });
+			},
+		});
+
+		expect(record.error).toMatch(/not supported/);
+		expect(await resolveDependencyRepo("x", undefined, { allowNpm: false })).toMatchObject({
+			source: "unknown",
+		});
+	});
+
+	it("discards contributions when setup throws", async () => {
+		const record = await registerPlugin({
+			name: "broken",
+			apiVersion: PLUGIN_API_VERSION,
+			setup(api) {
+				api.registerPostProcessor({ name: "upper", process: (content) => content.toUpperCase() });
+				throw new Error("boom");
+			},
+		});
+
+		expect(record).toMatchObject({ error: "boom", contributions: { postProcessors: [] } });
+		const context = { qualifiedName: "local:x", fullName: "x", repoPath: dir };
+		expect(applyReferencePostProcessors("text", context)).toBe("text");
+	});
+
+	it("runs post-processors in registration order", async () => {
+		await registerPlugin({
+			name: "footer",
+			apiVersion: PLUGIN_API_VERSION,
+			setup(api) {
+				api.registerPostProcessor({
+					name: "footer",
+					process: (content, ctx) => `${content}\n<!-- ${ctx.fullName} -->`,
+				});
+				api.registerPostProcessor({ name: "trim", process: (content) => content.trim() });
+			},
+		});
+
+		const context = { qualifiedName: "github.com:o/r", fullName: "o/r", repoPath: "/tmp/r" };
+		expect(applyReferencePostProcessors("  # Ref", context)).toBe("# Ref\n<!-- o/r -->");
+	});
+
+	it("loads local plugin files from config once", async () => {
+		const pluginPath = join(dir, "plugin.mjs");
+		writeFileSync(
+			pluginPath,
+			`export default () => ({
+	name: "local",
+	version: "1.0.0",
+	apiVersion: ${PLUGIN_API_VERSION},
+	setup(api) {
+		api.registerCommand({ name: "hello", description: "Say hello", run() {} });
+	},
+});`,
+		);
+		const config = withPlugins([pluginPath, join(dir, "missing.mjs")]);
+
+		const loaded = await loadPlugins({ config });
+		await loadPlugins({ config });
+
+		expect(loaded).toHaveLength(2);
+		expect(loaded[0]).toMatchObject({
+			name: "local",
+			version: "1.0.0",
+			source: pluginPath,
+			contributions: { commands: ["hello"] },
+		});
+		expect(loaded[1]?.error).toBeDefined();
+		expect(getLoadedPlugins()).toHaveLength(2);
+	});
+});
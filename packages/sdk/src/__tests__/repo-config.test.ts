@@ -0,0 +1,67 @@
+/**
+ * Unit tests for repo-config.ts
+ */
+
+import { ConfigSchema, type Config } from "@offworld/types";
+import { describe, expect, it } from "vitest";
+import { getRepoSettings, matchesRepoPattern } from "../repo-config.js";
+
+function makeConfig(repos: Config["repos"]): Config {
+	return ConfigSchema.parse({ repos });
+}
+
+describe("matchesRepoPattern", () => {
+	it("matches owner globs within one segment", () => {
+		expect(matchesRepoPattern("vercel/*", "vercel/next.js")).toBe(true);
+		expect(matchesRepoPattern("vercel/*", "vercel-labs/ai")).toBe(false);
+		expect(matchesRepoPattern("*/*-docs", "acme/api-docs")).toBe(true);
+	});
+
+	it("is case insensitive", () => {
+		expect(matchesRepoPattern("TanStack/Query", "tanstack/query")).toBe(true);
+	});
+
+	it("checks the provider prefix", () => {
+		expect(matchesRepoPattern("github:owner/repo", "github.com:owner/repo")).toBe(true);
+		expect(matchesRepoPattern("github:owner/repo", "gitlab.com:owner/repo")).toBe(false);
+		expect(matchesRepoPattern("gitlab:owner/*", "gitlab:owner/repo")).toBe(true);
+		expect(matchesRepoPattern("owner/repo", "gitlab.com:owner/repo")).toBe(true);
+	});
+
+	it("treats bare names as GitHub", () => {
+		expect(matchesRepoPattern("github:owner/repo", "owner/repo")).toBe(true);
+	});
+});
+
+describe("getRepoSettings", () => {
+	it("falls back to global config", () => {
+		const settings = getRepoSettings("vercel/next.js", makeConfig({}));
+		expect(settings).toMatchObject({
+			model: "anthropic/claude-sonnet-4-20250514",
+			sparse: false,
+			maxCommitDistance: 20,
+			trustRemote: true,
+			matched: [],
+		});
+	});
+
+	it("lets more specific patterns win", () => {
+		const config = makeConfig({
+			"vercel/next.js": { maxCommitDistance: 0 },
+			"vercel/*": { maxCommitDistance: 5, trustRemote: false },
+		});
+
+		const settings = getRepoSettings("vercel/next.js", config);
+		expect(settings.maxCommitDistance).toBe(0);
+		expect(settings.trustRemote).toBe(false);
+		expect(settings.matched).toEqual(["vercel/*", "vercel/next.js"]);
+	});
+
+	it("enables sparse checkout when sparse paths are set", () => {
+		const config = makeConfig({ "huge/*": { sparsePaths: ["packages/core"] } });
+		expect(getRepoSettings("huge/monorepo", config)).toMatchObject({
+			sparse: true,
+			sparsePaths: ["packages/core"],
+		});
+	});
+});
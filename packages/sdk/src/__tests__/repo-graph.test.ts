@@ -0,0 +1,140 @@
+/**
+ * Unit tests for repo-graph.ts
+ */
+
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { dirname, join } from "node:path";
+import type { GlobalMap } from "@offworld/types";
+import { afterEach, beforeEach, describe, expect, it } from "vitest";
+import { createLogger } from "../logger.js";
+import {
+	buildDependencyGraph,
+	getRepoDependencies,
+	renderDependencyGraph,
+	selectDependencyGraph,
+} from "../repo-graph.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+const CLONES: Record<string, Record<string, string>> = {
+	query: {
+		"package.json": JSON.stringify({ name: "query-monorepo", workspaces: ["packages/*"] }),
+		"packages/query-core/package.json": JSON.stringify({ name: "@tanstack/query-core" }),
+		"packages/react-query/package.json": JSON.stringify({
+			name: "@tanstack/react-query",
+			dependencies: { "@tanstack/query-core": "workspace:*" },
+		}),
+	},
+	router: {
+		"package.json": JSON.stringify({
+			name: "@tanstack/react-router",
+			dependencies: { zod: "^3.0.0" },
+			peerDependencies: { "@tanstack/react-query": "^5.0.0" },
+		}),
+	},
+	zod: {
+		"package.json": JSON.stringify({
+			name: "zod",
+			devDependencies: { "@tanstack/react-router": "^1.0.0" },
+		}),
+	},
+	cobra: {
+		"go.mod": "module github.com/spf13/cobra\n\nrequire github.com/spf13/pflag v1.0.5\n",
+	},
+	pflag: {
+		"go.mod": "module github.com/spf13/pflag\n",
+	},
+};
+
+function entry(localPath: string): GlobalMap["repos"][string] {
+	return {
+		localPath,
+		references: [],
+		primary: "",
+		keywords: [],
+		updatedAt: "2026-01-01T00:00:00.000Z",
+	};
+}
+
+describe("repo dependency graph", () => {
+	let dir: string;
+	let runtime: OffworldRuntime;
+	let map: GlobalMap;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-graph-"));
+		runtime = createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			logger: createLogger({ level: "silent", write: () => {} }),
+		});
+		for (const [clone, files] of Object.entries(CLONES)) {
+			for (const [path, content] of Object.entries(files)) {
+				mkdirSync(dirname(join(dir, clone, path)), { recursive: true });
+				writeFileSync(join(dir, clone, path), content);
+			}
+		}
+		map = {
+			repos: {
+				"github.com:tanstack/query": entry(join(dir, "query")),
+				"github.com:tanstack/router": entry(join(dir, "router")),
+				"github.com:colinhacks/zod": entry(join(dir, "zod")),
+				"github.com:spf13/cobra": entry(join(dir, "cobra")),
+				"github.com:spf13/pflag": entry(join(dir, "pflag")),
+			},
+		};
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("links repos through published packages, ignoring dev and in-repo dependencies", () => {
+		const graph = runWithRuntime(runtime, () => buildDependencyGraph(map));
+
+		expect(graph).toEqual({
+			"github.com:tanstack/query": [],
+			"github.com:tanstack/router": ["github.com:colinhacks/zod", "github.com:tanstack/query"],
+			"github.com:colinhacks/zod": [],
+			"github.com:spf13/cobra": ["github.com:spf13/pflag"],
+			"github.com:spf13/pflag": [],
+		});
+		expect(getRepoDependencies("github.com:tanstack/query", map, graph)).toEqual({
+			upstream: [],
+			downstream: ["github.com:tanstack/router"],
+		});
+	});
+
+	it("reuses cached manifests until the map entry changes", () => {
+		runWithRuntime(runtime, () => {
+			buildDependencyGraph(map);
+			writeFileSync(join(dir, "pflag", "go.mod"), "module github.com/spf13/pflag/v2\n");
+			expect(buildDependencyGraph(map)["github.com:spf13/cobra"]).toEqual([
+				"github.com:spf13/pflag",
+			]);
+
+			map.repos["github.com:spf13/pflag"]!.updatedAt = "2026-02-01T00:00:00.000Z";
+			expect(buildDependencyGraph(map)["github.com:spf13/cobra"]).toEqual([]);
+		});
+	});
+
+	it("limits the graph to a repo's neighbourhood and renders dot", () => {
+		runWithRuntime(runtime, () => {
+			const selected = selectDependencyGraph(map, "github.com:spf13/pflag");
+			expect(selected.edges).toEqual([
+				{ from: "github.com:spf13/cobra", to: "github.com:spf13/pflag" },
+			]);
+
+			expect(renderDependencyGraph(map, { repo: "github.com:spf13/pflag" })).toBe(
+				[
+					"digraph offworld {",
+					"\trankdir=LR;",
+					"\tnode [shape=box];",
+					'\t"github.com:spf13/cobra" [label="spf13/cobra"];',
+					'\t"github.com:spf13/pflag" [label="spf13/pflag"];',
+					'\t"github.com:spf13/cobra" -> "github.com:spf13/pflag";',
+					"}",
+				].join("\n"),
+			);
+		});
+	});
+});
@@ -0,0 +1,115 @@
+/**
+ * Unit tests for repo-identity.ts
+ */
+
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { afterEach, beforeEach, describe, expect, it, vi } from "vitest";
+import { readGlobalMap, writeGlobalMap } from "../index-manager.js";
+import { createLogger } from "../logger.js";
+import { checkRepoIdentities, toRemoteSource } from "../repo-identity.js";
+import { createRuntime, runWithRuntime, type OffworldRuntime } from "../runtime.js";
+
+function json(body: unknown, status = 200): Response {
+	return new Response(JSON.stringify(body), { status });
+}
+
+describe("checkRepoIdentities", () => {
+	let dir: string;
+	let clonePath: string;
+	let fetch: ReturnType<typeof vi.fn>;
+	let runtime: OffworldRuntime;
+
+	beforeEach(() => {
+		dir = mkdtempSync(join(tmpdir(), "ow-identity-"));
+		clonePath = join(dir, "clone");
+		mkdirSync(clonePath);
+		writeFileSync(join(clonePath, "package.json"), JSON.stringify({ name: "old-lib" }));
+		fetch = vi.fn(async (url: string) => {
+			if (url === "https://api.github.com/repos/old-org/lib") {
+				return json({ full_name: "new-org/lib", archived: true });
+			}
+			if (url.startsWith("https://registry.npmjs.org/old-lib/latest")) {
+				return json({ name: "old-lib", deprecated: "Use new-lib instead" });
+			}
+			return json({ message: "Not Found" }, 404);
+		});
+		runtime = createRuntime({
+			paths: { data: join(dir, "data"), state: join(dir, "state") },
+			logger: createLogger({ level: "silent", write: () => {} }),
+			fetch: fetch as unknown as typeof globalThis.fetch,
+		});
+		runWithRuntime(runtime, () =>
+			writeGlobalMap({
+				repos: {
+					"github.com:old-org/lib": {
+						localPath: clonePath,
+						references: ["old-org-lib.md"],
+						primary: "old-org-lib.md",
+						keywords: [],
+						updatedAt: "2026-01-01T00:00:00.000Z",
+					},
+				},
+			}),
+		);
+	});
+
+	afterEach(() => {
+		rmSync(dir, { recursive: true, force: true });
+	});
+
+	it("reports moves and records archived and deprecated status", async () => {
+		const identities = await runWithRuntime(runtime, () => checkRepoIdentities());
+
+		expect(identities).toHaveLength(1);
+		expect(identities[0]).toMatchObject({
+			qualifiedName: "github.com:old-org/lib",
+			movedTo: { qualifiedName: "github.com:new-org/lib", fullName: "new-org/lib" },
+			archived: true,
+			deprecated: "Use new-lib instead",
+		});
+		const entry = runWithRuntime(runtime, () => readGlobalMap()).repos["github.com:old-org/lib"];
+		expect(entry).toMatchObject({ archived: true, deprecated: "Use new-lib instead" });
+	});
+
+	it("keeps previous status when GitHub and npm can't be reached and skips dry runs", async () => {
+		await runWithRuntime(runtime, () => checkRepoIdentities());
+		fetch.mockResolvedValue(json({ message: "rate limited" }, 403));
+
+		await runWithRuntime(runtime, async () => {
+			const dryRun = await checkRepoIdentities({ dryRun: true, maxAgeMs: 0 });
+			expect(dryRun[0]).toMatchObject({ movedTo: null, archived: null, deprecated: null });
+
+			await checkRepoIdentities({ maxAgeMs: 0 });
+			const entry = readGlobalMap().repos["github.com:old-org/lib"];
+			expect(entry?.archived).toBe(true);
+			expect(entry?.deprecated).toBe("Use new-lib instead");
+		});
+	});
+
+	it("skips repos checked within the TTL and sends the GitHub token", async () => {
+		vi.stubEnv("GITHUB_TOKEN", "gh-secret");
+		try {
+			await runWithRuntime(runtime, () => checkRepoIdentities());
+			const [, init] = fetch.mock.calls.find(([url]) => String(url).includes("api.github.com"))!;
+			expect(init.headers).toMatchObject({ Authorization: "Bearer gh-secret" });
+
+			fetch.mockClear();
+			expect(await runWithRuntime(runtime, () => checkRepoIdentities())).toEqual([]);
+			expect(fetch).not.toHaveBeenCalled();
+		} finally {
+			vi.unstubAllEnvs();
+		}
+	});
+});
+
+describe("toRemoteSource", () => {
+	it("parses map keys and ignores local repos", () => {
+		expect(toRemoteSource("github.com:Owner/Repo")).toMatchObject({
+			provider: "github",
+			fullName: "owner/repo",
+		});
+		expect(toRemoteSource("local:abc123")).toBeNull();
+	});
+});
@@ -0,0 +1,108 @@
+/**
+ * Unit tests for skill-template.ts
+ */
+
+import { ConfigSchema, type Config, type GlobalMap } from "@offworld/types";
+import { describe, expect, it } from "vitest";
+import {
+	buildSkillTemplateContext,
+	DEFAULT_SKILL_TEMPLATE,
+	renderTemplate,
+} from "../skill-template.js";
+
+function makeConfig(templates: Config["templates"] = {}): Config {
+	return ConfigSchema.parse({ templates });
+}
+
+function makeMap(count: number): GlobalMap {
+	const repos: GlobalMap["repos"] = {};
+	for (let i = 0; i < count; i++) {
+		repos[`github.com:owner/repo-${i}`] = {
+			localPath: `/ow/github/owner/repo-${i}`,
+			references: [`owner-repo-${i}.md`],
+			primary: `owner-repo-${i}.md`,
+			keywords: [`repo-${i}`],
+			updatedAt: `2026-01-${String(i + 1).padStart(2, "0")}T00:00:00Z`,
+		};
+	}
+	return { repos };
+}
+
+describe("renderTemplate", () => {
+	it("substitutes variables and nested fields", () => {
+		expect(renderTemplate("v{{version}} {{repo.name}}", { version: 1, repo: { name: "x" } })).toBe(
+			"v1 x",
+		);
+	});
+
+	it("renders if/else blocks", () => {
+		const template = "{{#if items}}some{{else}}none{{/if}}";
+		expect(renderTemplate(template, { items: [1] })).toBe("some");
+		expect(renderTemplate(template, { items: [] })).toBe("none");
+	});
+
+	it("repeats each blocks with the item in scope", () => {
+		const template = "{{#each repos}}{{name}}:{{prefix}}{{/each}}|{{#each tags}}[{{this}}]{{/each}}";
+		expect(
+			renderTemplate(template, { prefix: "!", repos: [{ name: "a" }, { name: "b" }], tags: ["x"] }),
+		).toBe("a:!b:!|[x]");
+	});
+
+	it("drops lines that only hold block tags", () => {
+		const template = "start\n{{#each items}}\n- {{this}}\n{{/each}}\nend\n";
+		expect(renderTemplate(template, { items: ["a", "b"] })).toBe("start\n- a\n- b\nend\n");
+	});
+
+	it("rejects unbalanced blocks", () => {
+		expect(() => renderTemplate("{{#if x}}open", {})).toThrow("Unclosed");
+		expect(() => renderTemplate("{{/each}}", {})).toThrow("Unexpected");
+	});
+});
+
+describe("default skill template", () => {
+	it("lists references inline when there are few", () => {
+		const context = buildSkillTemplateContext(makeMap(2), makeConfig());
+		const skill = renderTemplate(DEFAULT_SKILL_TEMPLATE, context);
+
+		expect(skill).toContain("## Installed References");
+		expect(skill).toContain("- `owner/repo-0`: `references/owner-repo-0.md`");
+		expect(skill).not.toContain("{{");
+	});
+
+	it("leaves out clone-only repos", () => {
+		const map = makeMap(1);
+		map.repos["github.com:owner/clone-only"] = {
+			localPath: "/ow/github/owner/clone-only",
+			references: [],
+			primary: "",
+			keywords: [],
+			updatedAt: "2026-02-01T00:00:00Z",
+		};
+		const context = buildSkillTemplateContext(map, makeConfig());
+		const skill = renderTemplate(DEFAULT_SKILL_TEMPLATE, context);
+
+		expect(context.referenceCount).toBe(1);
+		expect(context.topRepos.map((repo) => repo.name)).toEqual(["owner/repo-0"]);
+		expect(skill).not.toContain("clone-only");
+	});
+
+	it("falls back to search instructions when there are many", () => {
+		const context = buildSkillTemplateContext(
+			makeMap(3),
+			makeConfig({ inlineReferenceLimit: 2 }),
+		);
+		const skill = renderTemplate(DEFAULT_SKILL_TEMPLATE, context);
+
+		expect(context.topRepos[0]?.name).toBe("owner/repo-2");
+		expect(skill).toContain("3 references are installed.");
+		expect(skill).not.toContain("references/owner-repo-0.md");
+	});
+
+	it("omits the section without references", () => {
+		const skill = renderTemplate(
+			DEFAULT_SKILL_TEMPLATE,
+			buildSkillTemplateContext(makeMap(0), makeConfig()),
+		);
+		expect(skill).not.toContain("## Installed References");
+	});
+});
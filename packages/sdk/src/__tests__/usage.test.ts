@@ -0,0 +1,122 @@
+/**
+ * Unit tests for usage.ts
+ */
+
+import { beforeEach, describe, expect, it } from "vitest";
+import { writeGlobalMap } from "../index-manager.js";
+import { createRuntime, runWithRuntime, type OffworldFileSystem } from "../runtime.js";
+import {
+	getUsageStats,
+	MAX_USAGE_BYTES,
+	MAX_USAGE_EVENTS,
+	readUsageEvents,
+	recordUsage,
+	summarizeUsage,
+} from "../usage.js";
+import { clearVirtualFs, createFsMock } from "./mocks/fs.js";
+
+let fs: OffworldFileSystem;
+let cwd = "/work/app";
+
+function withFs<T>(fn: () => T): T {
+	return runWithRuntime(
+		createRuntime({ fs, paths: { state: "/state", data: "/data" }, cwd: () => cwd }),
+		fn,
+	);
+}
+
+describe("usage", () => {
+	beforeEach(() => {
+		clearVirtualFs();
+		fs = createFsMock() as unknown as OffworldFileSystem;
+		cwd = "/work/app";
+	});
+
+	it("records events with the project when run inside one", () => {
+		fs.mkdirSync("/work/app/.offworld", { recursive: true });
+		fs.writeFileSync("/work/app/.offworld/map.json", "{}");
+
+		withFs(() => recordUsage("github.com:colinhacks/zod", "map show"));
+		cwd = "/tmp";
+		withFs(() => recordUsage("github.com:colinhacks/zod", "map search"));
+
+		const events = withFs(() => readUsageEvents());
+		expect(events).toHaveLength(2);
+		expect(events[0]).toMatchObject({ command: "map show", project: "/work/app" });
+		expect(events[1]?.project).toBeUndefined();
+	});
+
+	it("summarizes counts, commands and projects per repo", () => {
+		const summary = summarizeUsage([
+			{ repo: "a", command: "map show", project: "/p1", timestamp: "2026-01-02T00:00:00Z" },
+			{ repo: "a", command: "map show", project: "/p2", timestamp: "2026-01-01T00:00:00Z" },
+			{ repo: "a", command: "map search", timestamp: "2026-01-03T00:00:00Z" },
+			{ repo: "b", command: "map show", timestamp: "2026-01-01T00:00:00Z" },
+		]);
+
+		expect(summary.get("a")).toEqual({
+			repo: "a",
+			count: 3,
+			lastUsedAt: "2026-01-03T00:00:00Z",
+			commands: { "map show": 2, "map search": 1 },
+			projects: 2,
+		});
+		expect(summary.get("b")?.count).toBe(1);
+	});
+
+	it("keeps only the latest events", () => {
+		const line = JSON.stringify({ repo: "old", command: "map show", timestamp: "2026-01-01" });
+		fs.mkdirSync("/state", { recursive: true });
+		fs.writeFileSync("/state/usage.jsonl", `${line}\n`.repeat(MAX_USAGE_EVENTS) + "not json\n");
+
+		withFs(() => recordUsage("new", "map show"));
+
+		const events = withFs(() => readUsageEvents());
+		expect(events).toHaveLength(MAX_USAGE_EVENTS);
+		expect(events.at(-1)?.repo).toBe("new");
+	});
+
+	it("appends events and compacts the log once it grows too large", () => {
+		fs.mkdirSync("/state", { recursive: true });
+		withFs(() => recordUsage("first", "map show"));
+		expect(fs.appendFileSync).toHaveBeenCalledTimes(1);
+		expect(fs.writeFileSync).not.toHaveBeenCalled();
+
+		const command = "x".repeat(Math.ceil(MAX_USAGE_BYTES / MAX_USAGE_EVENTS));
+		const line = JSON.stringify({ repo: "old", command, timestamp: "2026-01-01" });
+		fs.writeFileSync("/state/usage.jsonl", `${line}\n`.repeat(MAX_USAGE_EVENTS + 100));
+		withFs(() => recordUsage("new", "map show"));
+
+		const content = fs.readFileSync("/state/usage.jsonl", "utf-8") as string;
+		expect(content.trimEnd().split("\n")).toHaveLength(MAX_USAGE_EVENTS);
+		expect(withFs(() => readUsageEvents()).at(-1)?.repo).toBe("new");
+	});
+
+	it("reports most used and never used references", () => {
+		const entry = (name: string, references: string[]) => ({
+			localPath: `/ow/${name}`,
+			references,
+			primary: references[0] ?? "",
+			keywords: [],
+			updatedAt: "2026-01-01T00:00:00Z",
+		});
+		withFs(() =>
+			writeGlobalMap({
+				repos: {
+					"github.com:o/used": entry("used", ["o-used.md"]),
+					"github.com:o/idle": entry("idle", ["o-idle.md"]),
+					"github.com:o/bare": entry("bare", []),
+				},
+			}),
+		);
+		withFs(() => {
+			recordUsage("github.com:o/used", "map show");
+			recordUsage("github.com:o/used", "map show");
+		});
+
+		const stats = withFs(() => getUsageStats());
+		expect(stats.totalEvents).toBe(2);
+		expect(stats.mostUsed.map((usage) => usage.repo)).toEqual(["github.com:o/used"]);
+		expect(stats.unused).toEqual(["github.com:o/idle"]);
+	});
+});
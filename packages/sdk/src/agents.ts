@@ -8,10 +8,11 @@
 import { existsSync } from "node:fs";
 import type { Agent } from "@offworld/types";
 import { expandTilde } from "./paths";
+import { getPluginAgents } from "./plugins.js";
 
 export interface AgentConfig {
-	/** Agent identifier (matches AgentSchema enum) */
-	name: Agent;
+	/** Agent identifier (AgentSchema enum for built-ins; plugins may register others) */
+	name: string;
 	/** Human-readable name for display */
 	displayName: string;
 	/** Project-level skill directory (relative path) */
@@ -73,10 +74,10 @@ export const agents: Record<Agent, AgentConfig> = {
  *
  * @returns Array of installed agent identifiers
  */
-export function detectInstalledAgents(): Agent[] {
-	const installed: Agent[] = [];
+export function detectInstalledAgents(): string[] {
+	const installed: string[] = [];
 
-	for (const config of Object.values(agents)) {
+	for (const config of getAllAgentConfigs()) {
 		if (config.detectInstalled()) {
 			installed.push(config.name);
 		}
@@ -88,18 +89,23 @@ export function detectInstalledAgents(): Agent[] {
 /**
  * Get the configuration for a specific agent.
  *
- * @param type - Agent identifier
- * @returns AgentConfig for the specified agent
+ * @param type - Agent identifier (built-in or registered by a plugin)
+ * @returns AgentConfig for the specified agent, or undefined if unknown
  */
-export function getAgentConfig(type: Agent): AgentConfig {
-	return agents[type];
+export function getAgentConfig(type: Agent | string): AgentConfig | undefined {
+	if (Object.hasOwn(agents, type)) {
+		return agents[type as Agent];
+	}
+	return getPluginAgents().find((agent) => agent.name === type);
 }
 
 /**
  * Get all agent configurations as an array.
+ * Built-in agents come first; plugin agents with a built-in name are ignored.
  *
  * @returns Array of all agent configurations
  */
 export function getAllAgentConfigs(): AgentConfig[] {
-	return Object.values(agents);
+	const pluginAgents = getPluginAgents().filter((agent) => !Object.hasOwn(agents, agent.name));
+	return [...Object.values(agents), ...pluginAgents];
 }
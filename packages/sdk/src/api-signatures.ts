@@ -0,0 +1,579 @@
+/**
+ * Exact API signatures from a clone's type declarations
+ *
+ * Resolves a package's type entry points (package.json `types`/`exports`, falling back to
+ * sources when declarations aren't built) and reads exported symbols with the TypeScript
+ * compiler API, so signatures and JSDoc are quoted rather than paraphrased. TypeScript is an
+ * optional peer dependency loaded on first use, falling back to the one installed in the
+ * current project, then to the global npm install (compiled binaries can't resolve either on
+ * their own). Extractors for Python stubs, Go doc and rustdoc JSON can be added to
+ * API_EXTRACTORS later.
+ */
+
+import { execFileSync } from "node:child_process";
+import { createRequire } from "node:module";
+import { join, relative } from "node:path";
+import type * as TS from "typescript";
+import { z } from "zod";
+import { getFs, getRuntime } from "./runtime.js";
+
+export class ApiExtractionError extends Error {
+	constructor(message: string) {
+		super(message);
+		this.name = "ApiExtractionError";
+	}
+}
+
+export interface ApiSignature {
+	/** Symbol name as looked up, e.g. "useQuery" or "QueryClient.fetchQuery" */
+	name: string;
+	kind: string;
+	/** Declaration text without bodies or initializers */
+	signature: string;
+	/** JSDoc summary and tags */
+	documentation: string;
+	/** Declaring file relative to the clone */
+	file: string;
+	line: number;
+	/** Package whose entry point exports the symbol */
+	packageName?: string;
+}
+
+export interface ApiExport {
+	name: string;
+	kind: string;
+	packageName?: string;
+}
+
+/**
+ * Symbol lookups against one parsed repo, so several lookups share the parse.
+ */
+export interface ApiLookup {
+	find(symbol: string): ApiSignature[];
+	list(): ApiExport[];
+}
+
+/**
+ * One language's signature source. Only TypeScript exists today.
+ */
+export interface ApiExtractor {
+	name: string;
+	detect(repoPath: string): boolean;
+	open(repoPath: string): Promise<ApiLookup>;
+}
+
+/** Declarations longer than this are cut with a marker */
+const MAX_SIGNATURE_LINES = 80;
+const PACKAGE_DIRS = ["packages", "libs"];
+/** Tried when package.json doesn't declare types that exist in the clone */
+const ENTRY_FALLBACKS = ["src/index.ts", "src/index.tsx", "index.d.ts", "index.ts"];
+
+const PackageJsonTypesSchema = z.object({
+	name: z.string().optional(),
+	types: z.string().optional(),
+	typings: z.string().optional(),
+	exports: z.unknown().optional(),
+});
+
+export interface ApiEntryPoint {
+	/** Absolute path of a declaration or source file */
+	file: string;
+	packageName?: string;
+}
+
+type TypeScript = typeof TS;
+
+let cachedTypeScript: TypeScript | null = null;
+
+/** Directory of globally installed npm packages, or null without npm */
+function getGlobalNodeModules(): string | null {
+	try {
+		return (
+			execFileSync("npm", ["root", "-g"], {
+				encoding: "utf-8",
+				stdio: ["ignore", "pipe", "ignore"],
+				timeout: 5000,
+				shell: process.platform === "win32",
+			}).trim() || null
+		);
+	} catch {
+		return null;
+	}
+}
+
+async function loadTypeScript(): Promise<TypeScript> {
+	if (cachedTypeScript) return cachedTypeScript;
+	try {
+		const module = await import("typescript");
+		cachedTypeScript = (module.default ?? module) as TypeScript;
+		return cachedTypeScript;
+	} catch {}
+	try {
+		const projectRequire = createRequire(join(getRuntime().cwd(), "package.json"));
+		cachedTypeScript = projectRequire("typescript") as TypeScript;
+		return cachedTypeScript;
+	} catch {}
+	const globalRoot = getGlobalNodeModules();
+	try {
+		if (globalRoot) {
+			const globalRequire = createRequire(join(globalRoot, "package.json"));
+			cachedTypeScript = globalRequire(join(globalRoot, "typescript")) as TypeScript;
+			return cachedTypeScript;
+		}
+	} catch {}
+	throw new ApiExtractionError(
+		"TypeScript is not installed. Add it to this project (npm install -D typescript) " +
+			"or install it globally (npm install -g typescript)",
+	);
+}
+
+function collectExportTypes(value: unknown, out: string[]): void {
+	if (typeof value === "string") {
+		if (/\.d\.[mc]?ts$/.test(value)) out.push(value);
+		return;
+	}
+	if (!value || typeof value !== "object") return;
+	for (const [key, nested] of Object.entries(value)) {
+		if (key.includes("*")) continue;
+		if (key === "types" && typeof nested === "string") out.push(nested);
+		else collectExportTypes(nested, out);
+	}
+}
+
+/**
+ * Clones rarely contain build output, so map dist/*.d.ts back to the source it came from.
+ */
+function resolveTypesFile(packageDir: string, path: string): string | null {
+	const fs = getFs();
+	const direct = join(packageDir, path);
+	if (fs.existsSync(direct)) return direct;
+
+	const source = path
+		.replace(/^\.?\/?(dist|build|lib|out|types)\//, "src/")
+		.replace(/\.d\.([mc]?)ts$/, ".$1ts");
+	for (const candidate of [source, source.replace(/\.([mc]?)ts$/, ".$1tsx")]) {
+		if (fs.existsSync(join(packageDir, candidate))) return join(packageDir, candidate);
+	}
+	return null;
+}
+
+function readPackageEntryPoints(packageDir: string): ApiEntryPoint[] {
+	const fs = getFs();
+	const packageJsonPath = join(packageDir, "package.json");
+	if (!fs.existsSync(packageJsonPath)) return [];
+
+	let packageJson: z.infer<typeof PackageJsonTypesSchema>;
+	try {
+		const parsed = PackageJsonTypesSchema.safeParse(
+			JSON.parse(fs.readFileSync(packageJsonPath, "utf-8")),
+		);
+		if (!parsed.success) return [];
+		packageJson = parsed.data;
+	} catch {
+		return [];
+	}
+
+	const declared: string[] = [];
+	collectExportTypes(packageJson.exports, declared);
+	if (packageJson.types) declared.push(packageJson.types);
+	if (packageJson.typings) declared.push(packageJson.typings);
+
+	const files = new Set<string>();
+	for (const path of declared) {
+		const file = resolveTypesFile(packageDir, path);
+		if (file) files.add(file);
+	}
+	if (files.size === 0) {
+		const fallback = ENTRY_FALLBACKS.find((path) => fs.existsSync(join(packageDir, path)));
+		if (fallback) files.add(join(packageDir, fallback));
+	}
+	return [...files].map((file) => ({ file, packageName: packageJson.name }));
+}
+
+/**
+ * Type entry points of the repo's root package and of workspace packages under packages/ and
+ * libs/.
+ */
+export function findTypeEntryPoints(repoPath: string): ApiEntryPoint[] {
+	const fs = getFs();
+	const packageDirs = [repoPath];
+	for (const dir of PACKAGE_DIRS) {
+		const parent = join(repoPath, dir);
+		if (!fs.existsSync(parent)) continue;
+		try {
+			for (const name of fs.readdirSync(parent).sort()) {
+				packageDirs.push(join(parent, String(name)));
+			}
+		} catch {
+			// Not a directory
+		}
+	}
+	return packageDirs.flatMap(readPackageEntryPoints);
+}
+
+function stripModifiers(text: string): string {
+	return text.replace(/^(export\s+)?(default\s+)?(declare\s+)?/, "");
+}
+
+function capLines(text: string): string {
+	const lines = text.split("\n");
+	if (lines.length <= MAX_SIGNATURE_LINES) return text;
+	const hidden = lines.length - MAX_SIGNATURE_LINES;
+	return [...lines.slice(0, MAX_SIGNATURE_LINES), `  // ... ${hidden} more lines`].join("\n");
+}
+
+/**
+ * Declaration text without implementation: bodies, initializers and private members are
+ * dropped so source files read like declaration files.
+ */
+function declarationText(ts: TypeScript, node: TS.Node, checker: TS.TypeChecker): string {
+	const sourceFile = node.getSourceFile();
+	const text = (from: number, to: number) => sourceFile.text.slice(from, to).trim();
+
+	if (
+		(ts.isFunctionDeclaration(node) ||
+			ts.isMethodDeclaration(node) ||
+			ts.isConstructorDeclaration(node) ||
+			ts.isGetAccessorDeclaration(node) ||
+			ts.isSetAccessorDeclaration(node)) &&
+		node.body
+	) {
+		return `${text(node.getStart(), node.body.getStart())};`;
+	}
+
+	if (ts.isVariableDeclaration(node)) {
+		const list = node.parent;
+		const keyword = list.flags & ts.NodeFlags.Const ? "const" : "let";
+		const type = node.type
+			? node.type.getText()
+			: checker.typeToString(
+					checker.getTypeAtLocation(node),
+					node,
+					ts.TypeFormatFlags.NoTruncation | ts.TypeFormatFlags.UseAliasDefinedOutsideCurrentScope,
+				);
+		return `${keyword} ${node.name.getText()}: ${type};`;
+	}
+
+	if (ts.isPropertyDeclaration(node) && node.initializer) {
+		return `${text(node.getStart(), node.initializer.getStart()).replace(/=$/, "").trim()};`;
+	}
+
+	if (ts.isClassDeclaration(node)) {
+		const header = text(node.getStart(), node.members.pos).replace(/\{$/, "").trim();
+		const members = node.members
+			.filter((member) => {
+				const modifiers = ts.canHaveModifiers(member) ? ts.getModifiers(member) : undefined;
+				const isPrivate = modifiers?.some((m) => m.kind === ts.SyntaxKind.PrivateKeyword);
+				return !isPrivate && !(member.name && ts.isPrivateIdentifier(member.name));
+			})
+			.map((member) => `\t${declarationText(ts, member, checker).split("\n").join("\n\t")}`);
+		return `${header} {\n${members.join("\n")}\n}`;
+	}
+
+	return node.getText(sourceFile);
+}
+
+function kindOf(ts: TypeScript, node: TS.Node): string {
+	if (ts.isFunctionDeclaration(node)) return "function";
+	if (ts.isClassDeclaration(node)) return "class";
+	if (ts.isInterfaceDeclaration(node)) return "interface";
+	if (ts.isTypeAliasDeclaration(node)) return "type";
+	if (ts.isEnumDeclaration(node)) return "enum";
+	if (ts.isModuleDeclaration(node)) return "namespace";
+	if (ts.isVariableDeclaration(node)) return "variable";
+	if (ts.isMethodDeclaration(node) || ts.isMethodSignature(node)) return "method";
+	if (ts.isPropertyDeclaration(node) || ts.isPropertySignature(node)) return "property";
+	return ts.SyntaxKind[node.kind] ?? "unknown";
+}
+
+function documentationOf(ts: TypeScript, symbol: TS.Symbol, checker: TS.TypeChecker): string {
+	const summary = ts.displayPartsToString(symbol.getDocumentationComment(checker)).trim();
+	const tags = symbol.getJsDocTags(checker).map((tag) => {
+		const body = ts.displayPartsToString(tag.text).trim();
+		return body ? `@${tag.name} ${body}` : `@${tag.name}`;
+	});
+	return [summary, ...tags].filter(Boolean).join("\n");
+}
+
+interface LoadedProgram {
+	ts: TypeScript;
+	checker: TS.TypeChecker;
+	/** Exported symbols per entry point */
+	modules: Array<{ entry: ApiEntryPoint; exports: TS.Symbol[] }>;
+}
+
+async function loadProgram(repoPath: string): Promise<LoadedProgram> {
+	const entries = findTypeEntryPoints(repoPath);
+	const ts = await loadTypeScript();
+	const program = ts.createProgram(
+		entries.map((entry) => entry.file),
+		{
+			noEmit: true,
+			skipLibCheck: true,
+			allowJs: false,
+			jsx: ts.JsxEmit.Preserve,
+			target: ts.ScriptTarget.ESNext,
+			module: ts.ModuleKind.ESNext,
+			moduleResolution: ts.ModuleResolutionKind.Bundler,
+			types: [],
+		},
+	);
+	const checker = program.getTypeChecker();
+
+	const modules = entries.flatMap((entry) => {
+		const sourceFile = program.getSourceFile(entry.file);
+		const moduleSymbol = sourceFile && checker.getSymbolAtLocation(sourceFile);
+		return moduleSymbol ? [{ entry, exports: checker.getExportsOfModule(moduleSymbol) }] : [];
+	});
+	return { ts, checker, modules };
+}
+
+function resolveAlias(ts: TypeScript, symbol: TS.Symbol, checker: TS.TypeChecker): TS.Symbol {
+	return symbol.flags & ts.SymbolFlags.Alias ? checker.getAliasedSymbol(symbol) : symbol;
+}
+
+function findMember(
+	ts: TypeScript,
+	symbol: TS.Symbol,
+	name: string,
+	checker: TS.TypeChecker,
+): TS.Symbol | undefined {
+	if (symbol.flags & (ts.SymbolFlags.Module | ts.SymbolFlags.Namespace)) {
+		const found = checker.getExportsOfModule(symbol).find((member) => member.name === name);
+		if (found) return found;
+	}
+	const declared = symbol.members?.get(name as TS.__String);
+	if (declared) return declared;
+	const declaration = symbol.valueDeclaration ?? symbol.declarations?.[0];
+	if (!declaration) return undefined;
+	return checker.getTypeOfSymbolAtLocation(symbol, declaration).getProperty(name);
+}
+
+function toSignatures(
+	loaded: LoadedProgram,
+	repoPath: string,
+	name: string,
+	symbol: TS.Symbol,
+	packageName?: string,
+): ApiSignature[] {
+	const { ts, checker } = loaded;
+	const resolved = resolveAlias(ts, symbol, checker);
+	const documentation = documentationOf(ts, resolved, checker);
+
+	return (resolved.declarations ?? []).map((declaration) => {
+		const sourceFile = declaration.getSourceFile();
+		const { line } = sourceFile.getLineAndCharacterOfPosition(declaration.getStart());
+		return {
+			name,
+			kind: kindOf(ts, declaration),
+			signature: capLines(stripModifiers(declarationText(ts, declaration, checker))),
+			documentation,
+			file: relative(repoPath, sourceFile.fileName),
+			line: line + 1,
+			packageName,
+		};
+	});
+}
+
+function findTypeScriptSignatures(
+	loaded: LoadedProgram,
+	repoPath: string,
+	symbol: string,
+): ApiSignature[] {
+	const { ts, checker } = loaded;
+	const [head, ...path] = symbol.split(".");
+
+	const lookup = (match: (name: string) => boolean) => {
+		const results: ApiSignature[] = [];
+		const seen = new Set<string>();
+		for (const { entry, exports } of loaded.modules) {
+			for (const exported of exports.filter((candidate) => match(candidate.name))) {
+				let target: TS.Symbol | undefined = resolveAlias(ts, exported, checker);
+				for (const part of path) {
+					target = target && findMember(ts, resolveAlias(ts, target, checker), part, checker);
+				}
+				if (!target) continue;
+				const name = [exported.name, ...path].join(".");
+				for (const signature of toSignatures(loaded, repoPath, name, target, entry.packageName)) {
+					// Re-exports from several entry points resolve to the same declaration
+					const key = `${signature.file}:${signature.line}`;
+					if (seen.has(key)) continue;
+					seen.add(key);
+					results.push(signature);
+				}
+			}
+		}
+		return results;
+	};
+
+	const exact = lookup((name) => name === head);
+	return exact.length > 0 ? exact : lookup((name) => name.toLowerCase() === head!.toLowerCase());
+}
+
+function listTypeScriptExports(loaded: LoadedProgram): ApiExport[] {
+	const { ts, checker } = loaded;
+	const seen = new Set<string>();
+	const exports: ApiExport[] = [];
+
+	for (const { entry, exports: symbols } of loaded.modules) {
+		for (const symbol of symbols) {
+			const key = `${entry.packageName ?? ""}:${symbol.name}`;
+			if (seen.has(key)) continue;
+			seen.add(key);
+			const declaration = resolveAlias(ts, symbol, checker).declarations?.[0];
+			exports.push({
+				name: symbol.name,
+				kind: declaration ? kindOf(ts, declaration) : "unknown",
+				packageName: entry.packageName,
+			});
+		}
+	}
+	return exports.sort((a, b) => a.name.localeCompare(b.name));
+}
+
+const typescriptExtractor: ApiExtractor = {
+	name: "typescript",
+	detect: (repoPath) => findTypeEntryPoints(repoPath).length > 0,
+	open: async (repoPath) => {
+		const loaded = await loadProgram(repoPath);
+		return {
+			find: (symbol) => findTypeScriptSignatures(loaded, repoPath, symbol),
+			list: () => listTypeScriptExports(loaded),
+		};
+	},
+};
+
+export const API_EXTRACTORS: ApiExtractor[] = [typescriptExtractor];
+
+/**
+ * Parse a repo with the first extractor that supports it.
+ *
+ * @throws ApiExtractionError if the repo has no supported declarations or TypeScript is missing
+ */
+export async function openApiLookup(repoPath: string): Promise<ApiLookup> {
+	const extractor = API_EXTRACTORS.find((candidate) => candidate.detect(repoPath));
+	if (!extractor) {
+		throw new ApiExtractionError(
+			`No TypeScript declarations or sources found in ${repoPath}. ` +
+				"Python stubs, Go doc and rustdoc JSON are not supported yet.",
+		);
+	}
+	return extractor.open(repoPath);
+}
+
+/**
+ * Exact signatures and JSDoc for an exported symbol. `Name.member` looks up a class member
+ * or namespace export. Matching is case-sensitive, falling back to case-insensitive.
+ *
+ * @throws ApiExtractionError if the repo has no supported declarations or TypeScript is missing
+ */
+export async function findApiSignatures(repoPath: string, symbol: string): Promise<ApiSignature[]> {
+	return (await openApiLookup(repoPath)).find(symbol);
+}
+
+/**
+ * All symbols exported from the repo's entry points, sorted by name.
+ *
+ * @throws ApiExtractionError if the repo has no supported declarations or TypeScript is missing
+ */
+export async function listApiExports(repoPath: string): Promise<ApiExport[]> {
+	return (await openApiLookup(repoPath)).list();
+}
+
+export const API_APPENDIX_START = "<!-- offworld:api-signatures -->";
+export const API_APPENDIX_END = "<!-- /offworld:api-signatures -->";
+
+/** Symbols quoted in an appendix, in table order */
+const MAX_APPENDIX_SYMBOLS = 20;
+/** Per-symbol cap so one large class doesn't swamp the reference */
+const MAX_APPENDIX_SIGNATURE_LINES = 30;
+
+/**
+ * Symbols named in the first column of the reference's API table(s), e.g. `useQuery()` or
+ * `z.object`.
+ */
+export function extractApiTableSymbols(content: string): string[] {
+	const symbols = new Set<string>();
+	let inApiSection = false;
+
+	for (const line of content.split("\n")) {
+		const heading = line.match(/^#{2,3}\s+(.+)/);
+		if (heading) {
+			inApiSection = /\bapi\b|export/i.test(heading[1]!);
+			continue;
+		}
+		if (!inApiSection || !line.trim().startsWith("|")) continue;
+		const firstCell = line.split("|")[1] ?? "";
+		const name = firstCell.match(/`\s*(?:new\s+)?([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*)/)?.[1];
+		if (name) symbols.add(name);
+	}
+	return [...symbols].slice(0, MAX_APPENDIX_SYMBOLS);
+}
+
+/**
+ * Remove a previously appended signature appendix, if any.
+ */
+export function stripApiAppendix(content: string): string {
+	const start = content.indexOf(API_APPENDIX_START);
+	const end = content.indexOf(API_APPENDIX_END, start);
+	if (start === -1 || end === -1) return content;
+	const rest = content.slice(end + API_APPENDIX_END.length).replace(/^\n+/, "");
+	const before = content.slice(0, start).trimEnd();
+	return rest ? `${before}\n\n${rest}` : `${before}\n`;
+}
+
+/**
+ * Append exact signatures for the symbols in the reference's API table. Symbols the
+ * declarations don't export are left out, so everything in the appendix is verified.
+ *
+ * @returns The reference with a fresh appendix, or unchanged content when nothing resolved
+ */
+export async function appendApiSignatures(
+	content: string,
+	repoPath: string,
+	commitSha?: string,
+): Promise<string> {
+	const base = stripApiAppendix(content);
+	const symbols = extractApiTableSymbols(base);
+	if (symbols.length === 0) return base;
+
+	const lookup = await openApiLookup(repoPath);
+	const sections: string[] = [];
+	for (const symbol of symbols) {
+		const [signature] = lookup.find(symbol);
+		if (!signature) continue;
+		const lines = signature.signature.split("\n");
+		const code =
+			lines.length > MAX_APPENDIX_SIGNATURE_LINES
+				? [...lines.slice(0, MAX_APPENDIX_SIGNATURE_LINES), "  // ..."].join("\n")
+				: signature.signature;
+		const summary = signature.documentation.split("\n")[0];
+		sections.push(
+			[
+				`### ${signature.name}`,
+				"",
+				...(summary ? [summary, ""] : []),
+				"```ts",
+				code,
+				"```",
+				"",
+				`Source: \`${signature.file}:${signature.line}\``,
+			].join("\n"),
+		);
+	}
+	if (sections.length === 0) return base;
+
+	const at = commitSha ? ` at commit \`${commitSha.slice(0, 7)}\`` : "";
+	return [
+		base.trimEnd(),
+		"",
+		API_APPENDIX_START,
+		"## API Signatures (verified)",
+		"",
+		`Extracted from the type declarations${at} by \`ow api\`.`,
+		"",
+		sections.join("\n\n"),
+		API_APPENDIX_END,
+		"",
+	].join("\n");
+}
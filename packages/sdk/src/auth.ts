@@ -7,6 +7,7 @@ import { dirname } from "node:path";
 import { z } from "zod";
 import { WorkOSTokenResponseSchema } from "@offworld/types";
 import { Paths } from "./paths";
+import { getRuntime } from "./runtime";
 
 const AuthDataSchema = z.object({
 	token: z.string(),
@@ -284,7 +285,7 @@ export async function refreshAccessToken(): Promise<AuthData> {
 	}
 
 	try {
-		const response = await fetch(`${WORKOS_API}/user_management/authenticate`, {
+		const response = await getRuntime().fetch(`${WORKOS_API}/user_management/authenticate`, {
 			method: "POST",
 			headers: { "Content-Type": "application/x-www-form-urlencoded" },
 			body: new URLSearchParams({
@@ -0,0 +1,508 @@
+/**
+ * Offworld client - the supported programmatic API
+ *
+ * Built from explicit options instead of ambient state: every call runs with the
+ * client's paths, config, filesystem, git runner, fetch and logger bound, so
+ * several clients can coexist in one process and tests need no module mocks.
+ */
+
+import { join } from "node:path";
+import type { Config, ProjectMapRepoEntry, RemoteRepoSource, RepoSource } from "@offworld/types";
+import { ReferenceMetaSchema } from "@offworld/types";
+import {
+	cloneRepo,
+	getClonedRepoPath,
+	getCommitSha,
+	isRepoCloned,
+	updateRepo,
+} from "./clone.js";
+import { resolveCommitDistance } from "./commit-distance.js";
+import { getMetaPath, getReferencePath, loadConfig, toReferenceFileName } from "./config.js";
+import { VERSION } from "./constants.js";
+import type { OpenCodeContext } from "./ai/opencode.js";
+import type { GitBackend } from "./git-backend.js";
+import { resolveDependencyRepo } from "./dep-mappings.js";
+import { readGlobalMap, writeProjectMap } from "./index-manager.js";
+import { parseDependencies, type Dependency } from "./manifest.js";
+import { getMapEntry, searchMap, type SearchResult } from "./map.js";
+import { Paths } from "./paths.js";
+import { installReference, resolveReferenceKeywords } from "./reference.js";
+import { matchDependenciesToReferences, type ReferenceMatch } from "./reference-matcher.js";
+import { getRepoSettings } from "./repo-config.js";
+import { parseRepoInput } from "./repo-source.js";
+import {
+	createRuntime,
+	getFs,
+	runWithRuntime,
+	type GitRunner,
+	type OffworldFileSystem,
+	type OffworldLogger,
+	type OffworldPathOverrides,
+	type OffworldRuntime,
+} from "./runtime.js";
+
+export interface OffworldOptions {
+	/** Root directory overrides (config, data, state) */
+	paths?: OffworldPathOverrides;
+	/** Explicit config. When set, the user config file is not read. */
+	config?: Partial<Config>;
+	/** Filesystem implementation (defaults to node:fs) */
+	fs?: OffworldFileSystem;
+	/** Git runner (defaults to the system git binary) */
+	git?: GitRunner;
+	/** Git backend, e.g. createJsGitBackend() (defaults to git, falling back to pure JS) */
+	gitBackend?: GitBackend;
+	/** HTTP fetch implementation (defaults to globalThis.fetch) */
+	fetch?: typeof fetch;
+	/** Logger for SDK diagnostics (defaults to silent) */
+	logger?: OffworldLogger;
+	/** Working directory for project operations (defaults to process.cwd()) */
+	cwd?: string;
+}
+
+export type ReferenceSource = "remote" | "local" | "cached" | "none";
+
+export interface OffworldEventMap {
+	/** Human-readable progress for long-running operations */
+	progress: { repo: string; message: string };
+	/** A repository was cloned */
+	cloned: { qualifiedName: string; repoPath: string };
+	/** An existing clone was fetched and fast-forwarded */
+	updated: { qualifiedName: string; previousSha: string; currentSha: string };
+	/** A reference file was written and registered in the global map */
+	referenceInstalled: {
+		qualifiedName: string;
+		fullName: string;
+		referencePath: string;
+		commitSha: string;
+		source: "remote" | "local";
+	};
+}
+
+export type OffworldEventName = keyof OffworldEventMap;
+export type OffworldListener<E extends OffworldEventName> = (payload: OffworldEventMap[E]) => void;
+
+export interface OffworldPullOptions {
+	/** Clone a specific branch */
+	branch?: string;
+	/** Use sparse checkout for new clones */
+	sparse?: boolean;
+	/** Ignore cached and remote references and regenerate */
+	force?: boolean;
+	/** Clone or update only; skip reference download/generation */
+	cloneOnly?: boolean;
+	/** Skip git fetch for existing clones */
+	skipUpdate?: boolean;
+	/** Try offworld.sh before generating (default: true) */
+	remote?: boolean;
+	/** Fall back to local AI generation (default: true) */
+	generate?: boolean;
+	/** Model override in provider/model format */
+	model?: string;
+	/** Shared OpenCode server for generation, e.g. from createOpenCodeContext() */
+	openCodeContext?: OpenCodeContext;
+}
+
+export interface OffworldPullResult {
+	qualifiedName: string;
+	repoPath: string;
+	commitSha: string;
+	referenceSource: ReferenceSource;
+	/** Absolute path to the installed reference, when one is installed */
+	referencePath?: string;
+}
+
+export interface OffworldGenerateOptions {
+	/** Model override in provider/model format */
+	model?: string;
+	/** Called with streamed model output */
+	onStream?: (text: string) => void;
+	/** Shared OpenCode server for generation, e.g. from createOpenCodeContext() */
+	openCodeContext?: OpenCodeContext;
+}
+
+export interface OffworldMapEntry {
+	scope: "project" | "global";
+	qualifiedName: string;
+	localPath: string;
+	referencePath: string;
+	keywords: string[];
+}
+
+export interface OffworldSearchOptions {
+	limit?: number;
+}
+
+export interface OffworldProjectScanOptions {
+	/** Project root (defaults to the client cwd) */
+	projectRoot?: string;
+	/** Query the npm registry when resolving dependencies (default: true) */
+	allowNpm?: boolean;
+}
+
+export interface OffworldProjectScan {
+	projectRoot: string;
+	dependencies: Dependency[];
+	matches: ReferenceMatch[];
+}
+
+function splitModel(model?: string): { provider?: string; model?: string } {
+	if (!model) return {};
+	const parts = model.split("/");
+	if (parts.length === 2) {
+		return { provider: parts[0], model: parts[1] };
+	}
+	return { model };
+}
+
+function sourceName(source: RepoSource): string {
+	return source.type === "remote" ? source.fullName : source.name;
+}
+
+export class Offworld {
+	private readonly runtime: OffworldRuntime;
+	private readonly listeners = new Map<OffworldEventName, Set<(payload: never) => void>>();
+
+	constructor(options: OffworldOptions = {}) {
+		const cwd = options.cwd;
+		this.runtime = createRuntime({
+			fs: options.fs,
+			git: options.git,
+			gitBackend: options.gitBackend,
+			fetch: options.fetch,
+			logger: options.logger,
+			paths: options.paths,
+			config: options.config,
+			cwd: cwd ? () => cwd : undefined,
+		});
+	}
+
+	/**
+	 * Subscribe to a client event. Returns an unsubscribe function.
+	 */
+	on<E extends OffworldEventName>(event: E, listener: OffworldListener<E>): () => void {
+		let set = this.listeners.get(event);
+		if (!set) {
+			set = new Set();
+			this.listeners.set(event, set);
+		}
+		set.add(listener as (payload: never) => void);
+		return () => this.off(event, listener);
+	}
+
+	off<E extends OffworldEventName>(event: E, listener: OffworldListener<E>): void {
+		this.listeners.get(event)?.delete(listener as (payload: never) => void);
+	}
+
+	private emit<E extends OffworldEventName>(event: E, payload: OffworldEventMap[E]): void {
+		for (const listener of this.listeners.get(event) ?? []) {
+			(listener as OffworldListener<E>)(payload);
+		}
+	}
+
+	/**
+	 * Run a function with this client's dependencies bound.
+	 * Useful for calling lower-level SDK functions with the same runtime.
+	 */
+	run<T>(fn: () => T): T {
+		return runWithRuntime(this.runtime, fn);
+	}
+
+	/** The effective config for this client */
+	config(): Config {
+		return this.run(() => loadConfig());
+	}
+
+	/**
+	 * Clone (or update) a repository and install its reference.
+	 * Order: cached reference, offworld.sh within maxCommitDistance, local AI generation.
+	 */
+	pull(repo: string, options: OffworldPullOptions = {}): Promise<OffworldPullResult> {
+		return this.run(async (): Promise<OffworldPullResult> => {
+			const source = parseRepoInput(repo);
+			const repoPath = await this.ensureClone(source, options);
+			const commitSha = getCommitSha(repoPath);
+			const base = { qualifiedName: source.qualifiedName, repoPath, commitSha };
+
+			if (options.cloneOnly) {
+				return { ...base, referenceSource: "none" };
+			}
+
+			const fullName = sourceName(source);
+			if (!options.force && this.hasCurrentReference(fullName, commitSha)) {
+				return {
+					...base,
+					referenceSource: "cached",
+					referencePath: getReferencePath(fullName),
+				};
+			}
+
+			const { trustRemote } = getRepoSettings(source);
+			if (
+				source.type === "remote" &&
+				!options.force &&
+				options.remote !== false &&
+				trustRemote
+			) {
+				const referencePath = await this.installRemote(source, repoPath, commitSha);
+				if (referencePath) {
+					return { ...base, referenceSource: "remote", referencePath };
+				}
+			}
+
+			if (options.generate === false) {
+				return { ...base, referenceSource: "none" };
+			}
+
+			const generated = await this.generateInto(source, repoPath, options);
+			return { ...base, ...generated, referenceSource: "local" };
+		});
+	}
+
+	/**
+	 * Generate a reference locally with AI, ignoring offworld.sh.
+	 * Requires the optional @opencode-ai/sdk peer dependency.
+	 */
+	generate(
+		repo: string,
+		options: OffworldGenerateOptions = {},
+	): Promise<{ referencePath: string; commitSha: string }> {
+		return this.run(async () => {
+			const source = parseRepoInput(repo);
+			const repoPath = await this.ensureClone(source, { skipUpdate: true });
+			return this.generateInto(source, repoPath, options);
+		});
+	}
+
+	/**
+	 * Look up a repo in the project map (preferred) or global map.
+	 */
+	map(repo: string): OffworldMapEntry | null {
+		return this.run(() => {
+			const result = getMapEntry(repo);
+			if (!result) return null;
+
+			const { entry } = result;
+			const primary = "primary" in entry ? entry.primary : entry.reference;
+			return {
+				scope: result.scope,
+				qualifiedName: result.qualifiedName,
+				localPath: entry.localPath,
+				referencePath: join(Paths.offworldReferencesDir, primary),
+				keywords: entry.keywords ?? [],
+			};
+		});
+	}
+
+	/**
+	 * Search the global map by name and keywords.
+	 */
+	search(term: string, options: OffworldSearchOptions = {}): SearchResult[] {
+		return this.run(() => searchMap(term, options));
+	}
+
+	/**
+	 * Parse project manifests and match dependencies to installed references.
+	 */
+	scanProject(options: OffworldProjectScanOptions = {}): Promise<OffworldProjectScan> {
+		return this.run(async (): Promise<OffworldProjectScan> => {
+			const projectRoot = options.projectRoot ?? this.runtime.cwd();
+			const dependencies = parseDependencies(projectRoot);
+			const resolved = await Promise.all(
+				dependencies.map((dep) =>
+					resolveDependencyRepo(dep.name, dep.version, { allowNpm: options.allowNpm }),
+				),
+			);
+			return {
+				projectRoot,
+				dependencies,
+				matches: matchDependenciesToReferences(resolved),
+			};
+		});
+	}
+
+	/**
+	 * Write .offworld/map.json for the installed matches of a project scan.
+	 * Returns the qualified names written.
+	 */
+	writeProjectMap(scan: OffworldProjectScan): string[] {
+		return this.run(() => {
+			const map = readGlobalMap();
+			const entries: Record<string, ProjectMapRepoEntry> = {};
+
+			for (const match of scan.matches) {
+				if (!match.repo || match.status !== "installed") continue;
+				const qualifiedName = `github.com:${match.repo}`;
+				if (entries[qualifiedName]) continue;
+				const entry = map.repos[qualifiedName];
+				entries[qualifiedName] = {
+					localPath: entry?.localPath ?? "",
+					reference: toReferenceFileName(match.repo),
+					keywords: entry?.keywords ?? [],
+				};
+			}
+
+			writeProjectMap(scan.projectRoot, entries);
+			return Object.keys(entries);
+		});
+	}
+
+	private async ensureClone(source: RepoSource, options: OffworldPullOptions): Promise<string> {
+		if (source.type === "local") {
+			return source.path;
+		}
+
+		const { qualifiedName } = source;
+		if (isRepoCloned(qualifiedName)) {
+			if (!options.skipUpdate) {
+				this.emit("progress", { repo: qualifiedName, message: "Updating repository" });
+				const result = await updateRepo(qualifiedName);
+				if (result.updated) {
+					this.emit("updated", {
+						qualifiedName,
+						previousSha: result.previousSha,
+						currentSha: result.currentSha,
+					});
+				}
+			}
+			return getClonedRepoPath(qualifiedName)!;
+		}
+
+		this.emit("progress", { repo: qualifiedName, message: `Cloning ${source.fullName}` });
+		const repoPath = await cloneRepo(source, {
+			branch: options.branch,
+			sparse: options.sparse,
+			config: loadConfig(),
+		});
+		this.emit("cloned", { qualifiedName, repoPath });
+		return repoPath;
+	}
+
+	private hasCurrentReference(fullName: string, commitSha: string): boolean {
+		const fs = getFs();
+		const metaPath = join(getMetaPath(fullName), "meta.json");
+		if (!fs.existsSync(metaPath) || !fs.existsSync(getReferencePath(fullName))) {
+			return false;
+		}
+
+		try {
+			const parsed = ReferenceMetaSchema.safeParse(JSON.parse(fs.readFileSync(metaPath, "utf-8")));
+			return parsed.success && parsed.data.commitSha.slice(0, 7) === commitSha.slice(0, 7);
+		} catch {
+			return false;
+		}
+	}
+
+	private async installRemote(
+		source: RemoteRepoSource,
+		repoPath: string,
+		commitSha: string,
+	): Promise<string | null> {
+		const { checkRemote, pullReference } = await import("./sync.js");
+		const { logger } = this.runtime;
+
+		try {
+			this.emit("progress", { repo: source.qualifiedName, message: "Checking offworld.sh" });
+			const remote = await checkRemote(source.fullName);
+			if (!remote.exists || !remote.commitSha) return null;
+
+			const settings = getRepoSettings(source);
+			const resolved = await resolveCommitDistance(source, repoPath, remote.commitSha, commitSha);
+			const distance = resolved ? resolved.behind + resolved.ahead : null;
+			const isExactMatch =
+				remote.commitSha.slice(0, 7) === commitSha.slice(0, 7) || distance === 0;
+			const isWithinDistance = distance !== null && distance <= settings.maxCommitDistance;
+			const acceptUnknown = distance === null && settings.acceptUnknownDistance;
+			if (!isExactMatch && !isWithinDistance && !acceptUnknown) {
+				logger.info(
+					`Remote reference for ${source.fullName} is outdated (${distance ?? "unknown"} commits)`,
+				);
+				return null;
+			}
+
+			const reference = await pullReference(source.fullName);
+			if (!reference) return null;
+
+			return await this.install(
+				source,
+				repoPath,
+				reference.referenceContent,
+				reference.commitSha,
+				reference.generatedAt,
+				"remote",
+				reference.shortContent,
+				reference.isVerified,
+			);
+		} catch (error) {
+			logger.warn(
+				`Remote reference unavailable for ${source.fullName}: ${error instanceof Error ? error.message : error}`,
+			);
+			return null;
+		}
+	}
+
+	private async generateInto(
+		source: RepoSource,
+		repoPath: string,
+		options: OffworldGenerateOptions,
+	): Promise<{ referencePath: string; commitSha: string }> {
+		const { generateReferenceWithAI } = await import("./generate.js");
+		const { logger } = this.runtime;
+		const { provider, model } = splitModel(options.model);
+		const repo = source.qualifiedName;
+
+		this.emit("progress", { repo, message: "Generating reference" });
+		const result = await generateReferenceWithAI(repoPath, sourceName(source), {
+			provider,
+			model,
+			source,
+			onStream: options.onStream,
+			openCodeContext: options.openCodeContext,
+			onDebug: (message) => logger.debug(message),
+		});
+
+		const referencePath = await this.install(
+			source,
+			repoPath,
+			result.referenceContent,
+			result.commitSha,
+			new Date().toISOString(),
+			"local",
+			result.shortContent,
+		);
+		return { referencePath, commitSha: result.commitSha };
+	}
+
+	private async install(
+		source: RepoSource,
+		repoPath: string,
+		content: string,
+		commitSha: string,
+		referenceUpdatedAt: string,
+		origin: "remote" | "local",
+		shortContent?: string,
+		verified?: boolean,
+	): Promise<string> {
+		const fullName = sourceName(source);
+		const keywords = await resolveReferenceKeywords(fullName, repoPath);
+		await installReference(
+			source.qualifiedName,
+			fullName,
+			repoPath,
+			content,
+			{ referenceUpdatedAt, commitSha, version: VERSION },
+			keywords,
+			{ referenceSource: origin, shortContent, verified },
+		);
+
+		const referencePath = getReferencePath(fullName);
+		this.emit("referenceInstalled", {
+			qualifiedName: source.qualifiedName,
+			fullName,
+			referencePath,
+			commitSha,
+			source: origin,
+		});
+		return referencePath;
+	}
+}
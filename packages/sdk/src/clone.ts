@@ -2,13 +2,30 @@
  * Git clone and repository management utilities
  */
 
-import { existsSync, readdirSync, rmSync } from "node:fs";
 import { dirname, join } from "node:path";
 import { execFileSync, spawn } from "node:child_process";
 import type { Config, RemoteRepoSource } from "@offworld/types";
-import { getRepoPath, loadConfig, toReferenceFileName } from "./config.js";
-import { readGlobalMap, upsertGlobalMapEntry, removeGlobalMapEntry } from "./index-manager.js";
+import {
+	getMetaPath,
+	getRepoPath,
+	loadConfig,
+	toReferenceFileName,
+	toShortReferenceFileName,
+} from "./config.js";
+import { invalidateDiskUsage } from "./disk-usage.js";
+import { createJsGitBackend, type GitBackend, type GitCloneOptions } from "./git-backend.js";
+import { hasHooks, runHooks } from "./hooks.js";
+import {
+	readGlobalMap,
+	upsertGlobalMapEntry,
+	removeGlobalMapEntry,
+	writeGlobalMap,
+} from "./index-manager.js";
+import { recordOperation } from "./journal.js";
 import { Paths } from "./paths.js";
+import { installReferenceSkill, removeReferenceSkill } from "./reference.js";
+import { getRepoSettings } from "./repo-config.js";
+import { getFs, getRuntime } from "./runtime.js";
 
 export class CloneError extends Error {
 	constructor(message: string) {
@@ -42,6 +59,13 @@ export class GitError extends CloneError {
 	}
 }
 
+export class GitNotFoundError extends GitError {
+	constructor(command: string) {
+		super("git executable not found", command, null);
+		this.name = "GitNotFoundError";
+	}
+}
+
 export interface CloneOptions {
 	/** Deprecated: shallow clones are no longer supported */
 	shallow?: boolean;
@@ -53,9 +77,16 @@ export interface CloneOptions {
 	force?: boolean;
 	/** Use sparse checkout for large repos (only src/, lib/, packages/, docs/) */
 	sparse?: boolean;
+	/** Tag or commit to check out after cloning */
+	pin?: string;
 }
 
 function execGit(args: string[], cwd?: string): string {
+	const runner = getRuntime().git;
+	if (runner) {
+		return runner.exec(args, { cwd });
+	}
+
 	try {
 		const result = execFileSync("git", args, {
 			cwd,
@@ -64,6 +95,9 @@ function execGit(args: string[], cwd?: string): string {
 		});
 		return result.trim();
 	} catch (error) {
+		if (isMissingBinary(error, cwd)) {
+			throw new GitNotFoundError(`git ${args.join(" ")}`);
+		}
 		const err = error as { status?: number; stderr?: Buffer | string; message?: string };
 		const stderr = err.stderr
 			? typeof err.stderr === "string"
@@ -75,6 +109,11 @@ function execGit(args: string[], cwd?: string): string {
 }
 
 function execGitAsync(args: string[], cwd?: string): Promise<string> {
+	const runner = getRuntime().git;
+	if (runner) {
+		return runner.execAsync(args, { cwd });
+	}
+
 	return new Promise((resolve, reject) => {
 		const proc = spawn("git", args, {
 			cwd,
@@ -101,13 +140,149 @@ function execGitAsync(args: string[], cwd?: string): Promise<string> {
 		});
 
 		proc.on("error", (err) => {
+			if (isMissingBinary(err, cwd)) {
+				reject(new GitNotFoundError(`git ${args.join(" ")}`));
+				return;
+			}
 			reject(new GitError(err.message, `git ${args.join(" ")}`, null));
 		});
 	});
 }
 
+/**
+ * Spawn reports ENOENT both for a missing binary and a missing cwd; only the former counts.
+ */
+function isMissingBinary(error: unknown, cwd?: string): boolean {
+	const code = (error as NodeJS.ErrnoException | undefined)?.code;
+	return code === "ENOENT" && (!cwd || getFs().existsSync(cwd));
+}
+
+const cliGitBackend: GitBackend = {
+	name: "cli",
+
+	async clone(url, dir, options = {}) {
+		const args = options.sparsePaths
+			? ["clone", "--filter=blob:none", "--no-checkout", "--sparse"]
+			: ["clone"];
+		if (options.branch) {
+			args.push("--branch", options.branch);
+		}
+		args.push(url, dir);
+		await execGitAsync(args);
+
+		if (options.sparsePaths) {
+			await execGitAsync(["sparse-checkout", "set", ...options.sparsePaths], dir);
+			await execGitAsync(["checkout"], dir);
+		}
+	},
+
+	async fetch(dir) {
+		await execGitAsync(["fetch"], dir);
+	},
+
+	async fetchCommit(dir, sha) {
+		await execGitAsync(["fetch", "--no-tags", "origin", sha], dir);
+	},
+
+	async fastForward(dir) {
+		await execGitAsync(["pull", "--ff-only"], dir);
+	},
+
+	async checkout(dir, ref) {
+		await execGitAsync(["checkout", "--detach", ref], dir);
+	},
+
+	getRemoteUrl(dir) {
+		return execGit(["remote", "get-url", "origin"], dir);
+	},
+
+	async setRemoteUrl(dir, url) {
+		await execGitAsync(["remote", "set-url", "origin", url], dir);
+	},
+
+	revParse(dir, ref) {
+		return execGit(["rev-parse", ref], dir);
+	},
+
+	hasCommit(dir, sha) {
+		try {
+			execGit(["cat-file", "-e", sha], dir);
+			return true;
+		} catch (error) {
+			if (error instanceof GitNotFoundError) throw error;
+			return false;
+		}
+	},
+
+	countCommits(dir, from, to) {
+		return Number.parseInt(execGit(["rev-list", "--count", `${from}..${to}`], dir), 10);
+	},
+};
+
+let jsGitBackend: GitBackend | null = null;
+let gitBinaryMissing = false;
+
+/**
+ * Pick the git backend: an injected backend, then the system git binary, then the pure-JS
+ * backend once git has been found missing. An injected GitRunner always uses the CLI path.
+ */
+function selectGitBackend(): GitBackend {
+	const runtime = getRuntime();
+	if (runtime.gitBackend) return runtime.gitBackend;
+	if (gitBinaryMissing && !runtime.git) {
+		jsGitBackend ??= createJsGitBackend();
+		return jsGitBackend;
+	}
+	return cliGitBackend;
+}
+
+function toGitError(error: unknown, command: string): GitError {
+	if (error instanceof GitError) return error;
+	const message = error instanceof Error ? error.message : String(error);
+	return new GitError(message, command, null);
+}
+
+/**
+ * Run a git operation, switching to the pure-JS backend if the git binary is missing.
+ */
+function withGit<T>(command: string, operation: (git: GitBackend) => T): T {
+	const backend = selectGitBackend();
+	try {
+		return operation(backend);
+	} catch (error) {
+		if (backend === cliGitBackend && error instanceof GitNotFoundError) {
+			gitBinaryMissing = true;
+			return withGit(command, operation);
+		}
+		throw toGitError(error, command);
+	}
+}
+
+async function withGitAsync<T>(
+	command: string,
+	operation: (git: GitBackend) => Promise<T>,
+): Promise<T> {
+	const backend = selectGitBackend();
+	try {
+		return await operation(backend);
+	} catch (error) {
+		if (backend === cliGitBackend && error instanceof GitNotFoundError) {
+			gitBinaryMissing = true;
+			return withGitAsync(command, operation);
+		}
+		throw toGitError(error, command);
+	}
+}
+
+/**
+ * Name of the git backend that will handle the next operation ("cli", "js", or a custom name).
+ */
+export function getGitBackendName(): string {
+	return selectGitBackend().name;
+}
+
 export function getCommitSha(repoPath: string): string {
-	return execGit(["rev-parse", "HEAD"], repoPath);
+	return withGit("git rev-parse HEAD", (git) => git.revParse(repoPath, "HEAD"));
 }
 
 export function getCommitDistance(
@@ -116,13 +291,36 @@ export function getCommitDistance(
 	newerSha = "HEAD",
 ): number | null {
 	try {
-		try {
-			execGit(["cat-file", "-e", olderSha], repoPath);
-		} catch {
-			return null;
-		}
-		const count = execGit(["rev-list", "--count", `${olderSha}..${newerSha}`], repoPath);
-		return Number.parseInt(count, 10);
+		return withGit(`git rev-list --count ${olderSha}..${newerSha}`, (git) =>
+			git.hasCommit(repoPath, olderSha) ? git.countCommits(repoPath, olderSha, newerSha) : null,
+		);
+	} catch {
+		return null;
+	}
+}
+
+/**
+ * Fetch a commit missing from the clone, e.g. one on another branch or newer than HEAD.
+ *
+ * @returns Whether the commit is available locally afterwards; false when the backend can't
+ * fetch single commits
+ * @throws GitError if the fetch fails
+ */
+export async function fetchCommit(repoPath: string, sha: string): Promise<boolean> {
+	return withGitAsync(`git fetch origin ${sha}`, async (git) => {
+		if (git.hasCommit(repoPath, sha)) return true;
+		if (!git.fetchCommit) return false;
+		await git.fetchCommit(repoPath, sha);
+		return git.hasCommit(repoPath, sha);
+	});
+}
+
+/**
+ * URL of the clone's origin remote, or null if there is none or the backend can't read it.
+ */
+export function getOriginUrl(repoPath: string): string | null {
+	try {
+		return withGit("git remote get-url origin", (git) => git.getRemoteUrl?.(repoPath) ?? null);
 	} catch {
 		return null;
 	}
@@ -132,6 +330,7 @@ const SPARSE_CHECKOUT_DIRS = ["src", "lib", "packages", "docs", "README.md", "pa
 
 /**
  * Clone a remote repository to the local repo root.
+ * Branch, pin and sparse settings default to the matching `repos` overrides in config.
  *
  * @param source - Remote repo source from parseRepoInput()
  * @param options - Clone options (branch, config)
@@ -143,22 +342,34 @@ export async function cloneRepo(
 	source: RemoteRepoSource,
 	options: CloneOptions = {},
 ): Promise<string> {
+	const fs = getFs();
 	const config = options.config ?? loadConfig();
+	const settings = getRepoSettings(source, config);
 	const repoPath = getRepoPath(source.fullName, source.provider, config);
+	const sparse = options.sparse ?? settings.sparse;
+	const pin = options.pin ?? settings.pin;
 
-	if (existsSync(repoPath)) {
+	if (fs.existsSync(repoPath)) {
 		if (options.force) {
-			rmSync(repoPath, { recursive: true, force: true });
+			fs.rmSync(repoPath, { recursive: true, force: true });
 		} else {
 			throw new RepoExistsError(repoPath);
 		}
 	}
 
 	try {
-		if (options.sparse) {
-			await cloneSparse(source.cloneUrl, repoPath, options);
-		} else {
-			await cloneStandard(source.cloneUrl, repoPath, options);
+		if (options.shallow) {
+			throw new CloneError("Shallow clones are no longer supported. Use a full clone.");
+		}
+		const cloneOptions: GitCloneOptions = {
+			branch: options.branch ?? settings.branch,
+			sparsePaths: sparse ? (settings.sparsePaths ?? SPARSE_CHECKOUT_DIRS) : undefined,
+		};
+		await withGitAsync(`git clone ${source.cloneUrl}`, (git) =>
+			git.clone(source.cloneUrl, repoPath, cloneOptions),
+		);
+		if (pin) {
+			await withGitAsync(`git checkout --detach ${pin}`, (git) => git.checkout(repoPath, pin));
 		}
 	} catch (err) {
 		cleanupEmptyParentDirs(repoPath);
@@ -167,7 +378,7 @@ export async function cloneRepo(
 
 	const referenceFileName = toReferenceFileName(source.fullName);
 	const referencePath = join(Paths.offworldReferencesDir, referenceFileName);
-	const hasReference = existsSync(referencePath);
+	const hasReference = fs.existsSync(referencePath);
 
 	upsertGlobalMapEntry(source.qualifiedName, {
 		localPath: repoPath,
@@ -177,55 +388,35 @@ export async function cloneRepo(
 		updatedAt: new Date().toISOString(),
 	});
 
-	return repoPath;
-}
-
-function cleanupEmptyParentDirs(repoPath: string): void {
-	const ownerDir = dirname(repoPath);
-	if (existsSync(ownerDir) && readdirSync(ownerDir).length === 0) {
-		rmSync(ownerDir, { recursive: true, force: true });
-	}
-}
-
-async function cloneStandard(
-	cloneUrl: string,
-	repoPath: string,
-	options: CloneOptions,
-): Promise<void> {
-	const args = ["clone"];
-
-	if (options.shallow) {
-		throw new CloneError("Shallow clones are no longer supported. Use a full clone.");
-	}
+	recordOperation({
+		op: "clone",
+		repo: source.qualifiedName,
+		summary: repoPath,
+		after: { localPath: repoPath, branch: options.branch ?? settings.branch, pin, sparse },
+	});
 
-	if (options.branch) {
-		args.push("--branch", options.branch);
+	if (hasHooks("postClone", config)) {
+		await runHooks(
+			"postClone",
+			{
+				repo: source.qualifiedName,
+				fullName: source.fullName,
+				repoPath,
+				commitSha: getCommitSha(repoPath),
+			},
+			{ config },
+		);
 	}
 
-	args.push(cloneUrl, repoPath);
-	await execGitAsync(args);
+	return repoPath;
 }
 
-async function cloneSparse(
-	cloneUrl: string,
-	repoPath: string,
-	options: CloneOptions,
-): Promise<void> {
-	const args = ["clone", "--filter=blob:none", "--no-checkout", "--sparse"];
-
-	if (options.shallow) {
-		throw new CloneError("Shallow clones are no longer supported. Use a full clone.");
-	}
-
-	if (options.branch) {
-		args.push("--branch", options.branch);
+function cleanupEmptyParentDirs(repoPath: string): void {
+	const fs = getFs();
+	const ownerDir = dirname(repoPath);
+	if (fs.existsSync(ownerDir) && fs.readdirSync(ownerDir).length === 0) {
+		fs.rmSync(ownerDir, { recursive: true, force: true });
 	}
-
-	args.push(cloneUrl, repoPath);
-	await execGitAsync(args);
-
-	await execGitAsync(["sparse-checkout", "set", ...SPARSE_CHECKOUT_DIRS], repoPath);
-	await execGitAsync(["checkout"], repoPath);
 }
 
 export interface UpdateResult {
@@ -244,6 +435,7 @@ export interface UpdateOptions {
 
 /**
  * Update a cloned repository by running git fetch and pull.
+ * Repos pinned in config are moved to the pinned ref instead of fast-forwarded.
  *
  * @param qualifiedName - The qualified name of the repo (e.g., "github.com:owner/repo")
  * @param options - Update options
@@ -255,6 +447,7 @@ export async function updateRepo(
 	qualifiedName: string,
 	options: UpdateOptions = {},
 ): Promise<UpdateResult> {
+	const fs = getFs();
 	const map = readGlobalMap();
 	const entry = map.repos[qualifiedName];
 	if (!entry) {
@@ -263,22 +456,50 @@ export async function updateRepo(
 
 	const repoPath = entry.localPath;
 
-	if (!existsSync(repoPath)) {
+	if (!fs.existsSync(repoPath)) {
 		throw new RepoNotFoundError(qualifiedName);
 	}
 
 	const previousSha = getCommitSha(repoPath);
 	if (!options.skipFetch) {
-		await execGitAsync(["fetch"], repoPath);
-		await execGitAsync(["pull", "--ff-only"], repoPath);
+		const { pin } = getRepoSettings(qualifiedName);
+		await withGitAsync("git fetch", (git) => git.fetch(repoPath));
+		if (pin) {
+			await withGitAsync(`git checkout --detach ${pin}`, (git) => git.checkout(repoPath, pin));
+		} else {
+			await withGitAsync("git pull --ff-only", (git) => git.fastForward(repoPath));
+		}
 	}
 
 	const currentSha = options.skipFetch ? previousSha : getCommitSha(repoPath);
+	if (currentSha !== previousSha) {
+		invalidateDiskUsage(repoPath);
+	}
 	upsertGlobalMapEntry(qualifiedName, {
 		...entry,
 		updatedAt: new Date().toISOString(),
 	});
 
+	if (currentSha !== previousSha) {
+		recordOperation({
+			op: "update",
+			repo: qualifiedName,
+			summary: `${previousSha.slice(0, 7)} → ${currentSha.slice(0, 7)}`,
+			before: { commitSha: previousSha },
+			after: { commitSha: currentSha },
+		});
+	}
+
+	if (!options.skipFetch) {
+		await runHooks("postUpdate", {
+			repo: qualifiedName,
+			repoPath,
+			previousSha,
+			commitSha: currentSha,
+			updated: previousSha !== currentSha,
+		});
+	}
+
 	return {
 		updated: previousSha !== currentSha,
 		previousSha,
@@ -295,6 +516,7 @@ export async function removeRepo(
 	qualifiedName: string,
 	options: RemoveOptions = {},
 ): Promise<boolean> {
+	const fs = getFs();
 	const map = readGlobalMap();
 	const entry = map.repos[qualifiedName];
 	if (!entry) {
@@ -305,25 +527,26 @@ export async function removeRepo(
 	const removeRepoFiles = !referenceOnly;
 	const removeReferenceFiles = !repoOnly;
 
-	if (removeRepoFiles && existsSync(entry.localPath)) {
-		rmSync(entry.localPath, { recursive: true, force: true });
+	if (removeRepoFiles && fs.existsSync(entry.localPath)) {
+		fs.rmSync(entry.localPath, { recursive: true, force: true });
 		cleanupEmptyParentDirs(entry.localPath);
+		invalidateDiskUsage(entry.localPath);
 	}
 
 	if (removeReferenceFiles) {
 		for (const referenceFileName of entry.references) {
-			const referencePath = join(Paths.offworldReferencesDir, referenceFileName);
-			if (existsSync(referencePath)) {
-				rmSync(referencePath, { force: true });
+			for (const file of [referenceFileName, toShortReferenceFileName(referenceFileName)]) {
+				fs.rmSync(join(Paths.offworldReferencesDir, file), { force: true });
 			}
 		}
 
 		if (entry.primary) {
 			const metaDirName = entry.primary.replace(/\.md$/, "");
 			const metaPath = join(Paths.metaDir, metaDirName);
-			if (existsSync(metaPath)) {
-				rmSync(metaPath, { recursive: true, force: true });
+			if (fs.existsSync(metaPath)) {
+				fs.rmSync(metaPath, { recursive: true, force: true });
 			}
+			removeReferenceSkill(metaDirName);
 		}
 	}
 
@@ -337,19 +560,153 @@ export async function removeRepo(
 		});
 	}
 
+	recordOperation({
+		op: "remove",
+		repo: qualifiedName,
+		summary: referenceOnly ? "reference only" : repoOnly ? "clone only" : "clone and reference",
+		before: { localPath: entry.localPath, references: entry.references },
+		after: removeRepoFiles ? undefined : { localPath: entry.localPath, references: [] },
+	});
+
+	await runHooks("postRemove", { repo: qualifiedName, repoPath: entry.localPath });
+
+	return true;
+}
+
+export interface MoveResult {
+	from: string;
+	to: string;
+	/** Clone directory after the move (unchanged for clones outside the repo root layout) */
+	localPath: string;
+	/** Reference files renamed to the new name */
+	references: string[];
+}
+
+function moveIfPresent(from: string, to: string): boolean {
+	const fs = getFs();
+	if (from === to || !fs.existsSync(from) || fs.existsSync(to)) return false;
+	fs.mkdirSync(dirname(to), { recursive: true });
+	fs.renameSync(from, to);
 	return true;
 }
 
+/**
+ * Move a renamed or transferred repo to its new name: clone directory and origin URL, map
+ * entry, meta directory and the reference files named after the repo. Clones outside the
+ * repo root layout stay where they are.
+ *
+ * @param source - The repo under its current (old) name
+ * @param target - The repo's new location
+ * @throws RepoNotFoundError if the repo isn't in the map
+ * @throws RepoExistsError if the new name is already installed or its clone path is taken
+ */
+export async function moveRepo(
+	source: RemoteRepoSource,
+	target: RemoteRepoSource,
+): Promise<MoveResult> {
+	const fs = getFs();
+	const { qualifiedName, fullName: fromFullName } = source;
+	const map = readGlobalMap();
+	const entry = map.repos[qualifiedName];
+	if (!entry) {
+		throw new RepoNotFoundError(qualifiedName);
+	}
+	const existing = map.repos[target.qualifiedName];
+	if (target.qualifiedName !== qualifiedName && existing) {
+		throw new RepoExistsError(existing.localPath);
+	}
+
+	const config = loadConfig();
+	let localPath = entry.localPath;
+	if (localPath === getRepoPath(fromFullName, source.provider, config)) {
+		const newPath = getRepoPath(target.fullName, target.provider, config);
+		if (newPath !== localPath && fs.existsSync(newPath)) {
+			throw new RepoExistsError(newPath);
+		}
+		if (moveIfPresent(localPath, newPath)) {
+			cleanupEmptyParentDirs(localPath);
+			invalidateDiskUsage(localPath);
+			localPath = newPath;
+		}
+	}
+
+	try {
+		await withGitAsync("git remote set-url origin", async (git) => {
+			await git.setRemoteUrl?.(localPath, target.cloneUrl);
+		});
+	} catch (error) {
+		const message = error instanceof Error ? error.message : error;
+		getRuntime().logger.debug(`Could not update origin for ${target.fullName}: ${message}`);
+	}
+
+	moveIfPresent(getMetaPath(fromFullName), getMetaPath(target.fullName));
+
+	const referencesDir = Paths.offworldReferencesDir;
+	const oldReference = toReferenceFileName(fromFullName);
+	const newReference = toReferenceFileName(target.fullName);
+	const renamed: string[] = [];
+	const references = entry.references.map((referenceFileName) => {
+		if (referenceFileName !== oldReference) return referenceFileName;
+		for (const [from, to] of [
+			[oldReference, newReference],
+			[toShortReferenceFileName(oldReference), toShortReferenceFileName(newReference)],
+		] as const) {
+			moveIfPresent(join(referencesDir, from), join(referencesDir, to));
+		}
+		renamed.push(newReference);
+		return newReference;
+	});
+	const primary = entry.primary === oldReference ? newReference : entry.primary;
+
+	delete map.repos[qualifiedName];
+	map.repos[target.qualifiedName] = {
+		...entry,
+		localPath,
+		references,
+		primary,
+		updatedAt: new Date().toISOString(),
+	};
+	writeGlobalMap(map);
+
+	const oldSkillName = oldReference.replace(/\.md$/, "");
+	if (renamed.length > 0 && removeReferenceSkill(oldSkillName).length > 0) {
+		const referencePath = join(referencesDir, newReference);
+		installReferenceSkill(
+			{
+				referenceName: newReference.replace(/\.md$/, ""),
+				fullName: target.fullName,
+				referencePath,
+				shortReferencePath: join(referencesDir, toShortReferenceFileName(newReference)),
+				localPath,
+				content: fs.readFileSync(referencePath, "utf-8"),
+				keywords: entry.keywords,
+			},
+			config,
+		);
+	}
+
+	recordOperation({
+		op: "move",
+		repo: target.qualifiedName,
+		summary: `${fromFullName} → ${target.fullName}`,
+		before: { qualifiedName, localPath: entry.localPath, references: entry.references },
+		after: { qualifiedName: target.qualifiedName, localPath, references },
+	});
+
+	return { from: qualifiedName, to: target.qualifiedName, localPath, references: renamed };
+}
+
 export function listRepos(): string[] {
 	const map = readGlobalMap();
 	return Object.keys(map.repos);
 }
 
 export function isRepoCloned(qualifiedName: string): boolean {
+	const fs = getFs();
 	const map = readGlobalMap();
 	const entry = map.repos[qualifiedName];
 	if (!entry) return false;
-	return existsSync(entry.localPath);
+	return fs.existsSync(entry.localPath);
 }
 
 /**
@@ -359,9 +716,10 @@ export function isRepoCloned(qualifiedName: string): boolean {
  * @returns The local path or undefined if not cloned
  */
 export function getClonedRepoPath(qualifiedName: string): string | undefined {
+	const fs = getFs();
 	const map = readGlobalMap();
 	const entry = map.repos[qualifiedName];
 	if (!entry) return undefined;
-	if (!existsSync(entry.localPath)) return undefined;
+	if (!fs.existsSync(entry.localPath)) return undefined;
 	return entry.localPath;
 }
@@ -0,0 +1,146 @@
+/**
+ * Commit distance between a remote reference and a clone
+ *
+ * A remote reference's commit is often missing from the clone: it was generated on another
+ * branch, or on commits newer than the last update. The distance is then resolved by fetching
+ * the commit from origin, or failing that with the GitHub compare API. Results are cached in
+ * the state directory; SHAs are immutable, so entries never expire.
+ */
+
+import { join } from "node:path";
+import type { RemoteRepoSource } from "@offworld/types";
+import { z } from "zod";
+import { fetchCommit, getCommitDistance } from "./clone.js";
+import { Paths } from "./paths.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+export const COMMIT_DISTANCE_METHODS = ["local", "fetch", "compare"] as const;
+export type CommitDistanceMethod = (typeof COMMIT_DISTANCE_METHODS)[number];
+
+const CommitDistanceSchema = z.object({
+	/** Commits in the clone that the reference commit lacks */
+	behind: z.number().int().nonnegative(),
+	/** Commits in the reference commit that the clone lacks */
+	ahead: z.number().int().nonnegative(),
+	/** How the distance was resolved */
+	method: z.enum(COMMIT_DISTANCE_METHODS),
+	resolvedAt: z.string(),
+});
+
+export type CommitDistance = z.infer<typeof CommitDistanceSchema>;
+
+/** Oldest cache entries beyond this are dropped */
+export const MAX_COMMIT_DISTANCE_ENTRIES = 500;
+
+function getCachePath(): string {
+	return join(Paths.state, "commit-distances.json");
+}
+
+function cacheKey(qualifiedName: string, referenceSha: string, cloneSha: string): string {
+	return `${qualifiedName.toLowerCase()}@${referenceSha.toLowerCase()}..${cloneSha.toLowerCase()}`;
+}
+
+function readCache(): Record<string, CommitDistance> {
+	const fs = getFs();
+	try {
+		const cachePath = getCachePath();
+		if (!fs.existsSync(cachePath)) return {};
+		const parsed = z
+			.record(z.string(), CommitDistanceSchema)
+			.safeParse(JSON.parse(fs.readFileSync(cachePath, "utf-8")));
+		return parsed.success ? parsed.data : {};
+	} catch {
+		return {};
+	}
+}
+
+function writeCache(key: string, distance: CommitDistance): void {
+	const fs = getFs();
+	try {
+		const entries = Object.entries({ ...readCache(), [key]: distance })
+			.sort(([, a], [, b]) => b.resolvedAt.localeCompare(a.resolvedAt))
+			.slice(0, MAX_COMMIT_DISTANCE_ENTRIES);
+		fs.mkdirSync(Paths.state, { recursive: true });
+		fs.writeFileSync(getCachePath(), JSON.stringify(Object.fromEntries(entries), null, 2), "utf-8");
+	} catch {}
+}
+
+/**
+ * A previously resolved distance, or null.
+ */
+export function getCachedCommitDistance(
+	qualifiedName: string,
+	referenceSha: string,
+	cloneSha: string,
+): CommitDistance | null {
+	return readCache()[cacheKey(qualifiedName, referenceSha, cloneSha)] ?? null;
+}
+
+function countLocally(repoPath: string, referenceSha: string, cloneSha: string) {
+	const behind = getCommitDistance(repoPath, referenceSha, cloneSha);
+	const ahead = getCommitDistance(repoPath, cloneSha, referenceSha);
+	return behind === null || ahead === null ? null : { behind, ahead };
+}
+
+/**
+ * Commits between a remote reference's SHA and the clone's SHA, in both directions.
+ * Counts locally when the commit is present, then fetches it from origin, then asks the
+ * GitHub compare API. Never throws.
+ *
+ * @param source - Remote repo the clone belongs to
+ * @param repoPath - Clone directory
+ * @param referenceSha - Commit the remote reference was generated at
+ * @param cloneSha - Clone HEAD
+ * @returns The distance, or null if it can't be determined
+ */
+export async function resolveCommitDistance(
+	source: RemoteRepoSource,
+	repoPath: string,
+	referenceSha: string,
+	cloneSha: string,
+): Promise<CommitDistance | null> {
+	const { logger } = getRuntime();
+	const key = cacheKey(source.qualifiedName, referenceSha, cloneSha);
+	const cached = readCache()[key];
+	if (cached) return cached;
+
+	let counts = countLocally(repoPath, referenceSha, cloneSha);
+	let method: CommitDistanceMethod = "local";
+
+	if (!counts) {
+		try {
+			if (await fetchCommit(repoPath, referenceSha)) {
+				counts = countLocally(repoPath, referenceSha, cloneSha);
+				method = "fetch";
+			}
+		} catch (error) {
+			const message = error instanceof Error ? error.message : error;
+			logger.debug(`Could not fetch ${referenceSha.slice(0, 7)} from origin: ${message}`);
+		}
+	}
+
+	if (!counts && source.provider === "github") {
+		const { fetchGitHubCompare } = await import("./sync.js");
+		const compare = await fetchGitHubCompare(source.owner, source.repo, cloneSha, referenceSha);
+		if (compare) {
+			counts = { behind: compare.behindBy, ahead: compare.aheadBy };
+			method = "compare";
+		}
+	}
+
+	if (!counts) return null;
+
+	const distance: CommitDistance = { ...counts, method, resolvedAt: new Date().toISOString() };
+	writeCache(key, distance);
+	return distance;
+}
+
+/**
+ * Human-readable distance, e.g. "3 commits behind", "2 commits ahead" or "3 behind, 2 ahead".
+ */
+export function formatCommitDistance(distance: Pick<CommitDistance, "behind" | "ahead">): string {
+	const { behind, ahead } = distance;
+	if (behind > 0 && ahead > 0) return `${behind} behind, ${ahead} ahead`;
+	if (ahead > 0) return `${ahead} commits ahead`;
+	return `${behind} commits behind`;
+}
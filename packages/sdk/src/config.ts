@@ -2,11 +2,11 @@
  * Config utilities for path management and configuration loading
  */
 
-import { existsSync, mkdirSync, readFileSync, writeFileSync } from "node:fs";
-import { dirname, join } from "node:path";
+import { dirname, join, resolve } from "node:path";
 import { ConfigSchema } from "@offworld/types";
 import type { Config } from "@offworld/types";
 import { Paths, expandTilde } from "./paths";
+import { getFs, getRuntime } from "./runtime.js";
 
 /**
  * Returns the repository root directory.
@@ -100,6 +100,17 @@ export function getReferencePath(fullName: string): string {
 	return join(Paths.offworldReferencesDir, toReferenceFileName(fullName));
 }
 
+/**
+ * File name of the cheat sheet stored next to a reference: zod.md -> zod.short.md
+ */
+export function toShortReferenceFileName(referenceFileName: string): string {
+	return referenceFileName.replace(/\.md$/, ".short.md");
+}
+
+export function getShortReferencePath(fullName: string): string {
+	return join(Paths.offworldReferencesDir, toShortReferenceFileName(toReferenceFileName(fullName)));
+}
+
 export function getMetaPath(fullName: string): string {
 	return join(Paths.data, "meta", toMetaDirName(fullName));
 }
@@ -113,44 +124,294 @@ export function getConfigPath(): string {
 }
 
 /**
- * Loads configuration from ~/.config/offworld/offworld.json
- * Returns defaults if file doesn't exist
+ * Where an effective config value came from, lowest precedence first.
  */
-export function loadConfig(): Config {
-	const configPath = getConfigPath();
+export type ConfigSource = "default" | "system" | "user" | "project" | "env" | "flag" | "runtime";
+
+export interface ConfigOrigin {
+	source: ConfigSource;
+	/** File path or environment variable that supplied the value */
+	location?: string;
+}
+
+export type ConfigKey = keyof Config;
+
+export const CONFIG_KEYS = Object.keys(ConfigSchema.shape) as ConfigKey[];
+
+export interface ResolvedConfig {
+	config: Config;
+	origins: Record<ConfigKey, ConfigOrigin>;
+}
+
+interface ConfigLayer {
+	source: ConfigSource;
+	location?: string;
+	values: Partial<Config>;
+}
+
+let flagOverrides: Partial<Config> = {};
+
+/**
+ * Keys that run code or shape what every agent loads (the global skill templates). Any cloned
+ * repo can ship a .offworld/config.json, so these are only read from the system and user files,
+ * environment variables and flags. Per-repo `prompt` overrides are dropped from project files
+ * for the same reason.
+ */
+export const PROJECT_IGNORED_CONFIG_KEYS: readonly ConfigKey[] = ["hooks", "plugins", "templates"];
+
+const warnedProjectFiles = new Set<string>();
+
+/**
+ * Returns the system-wide configuration file (/etc/offworld/offworld.json)
+ */
+export function getSystemConfigPath(): string {
+	return Paths.systemConfigFile;
+}
+
+/**
+ * Returns the project configuration file (.offworld/config.json in the working directory)
+ */
+export function getProjectConfigPath(cwd: string = getRuntime().cwd()): string {
+	return resolve(cwd, ".offworld", "config.json");
+}
+
+/**
+ * Environment variable for a config key, e.g. repoRoot -> OFFWORLD_REPO_ROOT
+ */
+export function toConfigEnvVar(key: ConfigKey): string {
+	return `OFFWORLD_${key.replace(/[A-Z]/g, (c) => `_${c}`).toUpperCase()}`;
+}
+
+/**
+ * Set the command-line flag layer (highest file/env precedence).
+ * Values are validated per key; invalid ones throw.
+ */
+export function setConfigOverrides(overrides: Partial<Config>): void {
+	const values: Partial<Config> = {};
+	for (const key of CONFIG_KEYS) {
+		if (overrides[key] === undefined) continue;
+		assignConfigValue(values, key, ConfigSchema.shape[key].parse(overrides[key]));
+	}
+	flagOverrides = values;
+}
+
+function assignConfigValue(target: Partial<Config>, key: ConfigKey, value: unknown): void {
+	(target as Record<ConfigKey, unknown>)[key] = value;
+}
 
-	if (!existsSync(configPath)) {
-		return ConfigSchema.parse({});
+function pickConfigKeys(config: Config, keys: string[]): Partial<Config> {
+	const values: Partial<Config> = {};
+	for (const key of CONFIG_KEYS) {
+		if (keys.includes(key)) assignConfigValue(values, key, config[key]);
 	}
+	return values;
+}
+
+/**
+ * Reads the keys explicitly set in a config file.
+ * Missing, unreadable or invalid files contribute nothing.
+ */
+function readConfigFile(path: string): Partial<Config> | null {
+	const fs = getFs();
+	if (!fs.existsSync(path)) return null;
 
 	try {
-		const content = readFileSync(configPath, "utf-8");
-		const data = JSON.parse(content);
-		return ConfigSchema.parse(data);
+		const data: unknown = JSON.parse(fs.readFileSync(path, "utf-8"));
+		if (!data || typeof data !== "object" || Array.isArray(data)) return null;
+
+		const parsed = ConfigSchema.safeParse(data);
+		if (!parsed.success) return null;
+		return pickConfigKeys(parsed.data, Object.keys(data));
 	} catch {
-		return ConfigSchema.parse({});
+		return null;
 	}
 }
 
+/**
+ * Parse a string config value as the type of its default (objects are JSON).
+ */
+export function parseConfigValue(key: ConfigKey, raw: string): Config[ConfigKey] {
+	const defaults = ConfigSchema.parse({});
+	const fallback = defaults[key];
+	let value: unknown = raw;
+
+	if (typeof fallback === "number") {
+		value = raw.trim() === "" ? Number.NaN : Number(raw);
+	} else if (typeof fallback === "boolean") {
+		const normalized = raw.trim().toLowerCase();
+		value =
+			normalized === "true" || normalized === "1"
+				? true
+				: normalized === "false" || normalized === "0"
+					? false
+					: raw;
+	} else if (Array.isArray(fallback)) {
+		value = raw
+			.split(",")
+			.map((part) => part.trim())
+			.filter(Boolean);
+	} else if (typeof fallback === "object") {
+		value = JSON.parse(raw);
+	}
+
+	return ConfigSchema.shape[key].parse(value) as Config[ConfigKey];
+}
+
+function withoutProjectIgnoredKeys(values: Partial<Config>, location: string): Partial<Config> {
+	const ignored: string[] = PROJECT_IGNORED_CONFIG_KEYS.filter((key) => values[key] !== undefined);
+	const prompted = Object.entries(values.repos ?? {}).filter(([, repo]) => repo.prompt);
+	if (prompted.length > 0) ignored.push("repos.*.prompt");
+	if (ignored.length === 0) return values;
+
+	if (!warnedProjectFiles.has(location)) {
+		warnedProjectFiles.add(location);
+		getRuntime().logger.warn(
+			`Ignoring ${ignored.join(", ")} in ${location}: set them in your user config instead`,
+		);
+	}
+	const kept: Partial<Config> = { ...values };
+	for (const key of PROJECT_IGNORED_CONFIG_KEYS) delete kept[key];
+	if (prompted.length > 0) {
+		kept.repos = { ...values.repos };
+		for (const [pattern, repo] of prompted) {
+			const withoutPrompt = { ...repo };
+			delete withoutPrompt.prompt;
+			kept.repos[pattern] = withoutPrompt;
+		}
+	}
+	return kept;
+}
+
+/**
+ * Resolve relative template paths in a config file against the file's directory, so they
+ * don't change meaning with the directory ow runs in.
+ */
+function withTemplatesFrom(values: Partial<Config>, location: string): Partial<Config> {
+	const { templates } = values;
+	if (!templates) return values;
+
+	const resolved = { ...templates };
+	for (const key of ["skill", "installation"] as const) {
+		const path = templates[key];
+		if (path) resolved[key] = resolve(dirname(location), expandTilde(path));
+	}
+	return { ...values, templates: resolved };
+}
+
+function readEnvLayers(env: NodeJS.ProcessEnv): ConfigLayer[] {
+	const layers: ConfigLayer[] = [];
+	for (const key of CONFIG_KEYS) {
+		const name = toConfigEnvVar(key);
+		const raw = env[name];
+		if (raw === undefined) continue;
+
+		try {
+			const values: Partial<Config> = {};
+			assignConfigValue(values, key, parseConfigValue(key, raw));
+			layers.push({ source: "env", location: name, values });
+		} catch {
+			getRuntime().logger.warn(`Ignoring invalid ${name}=${raw}`);
+		}
+	}
+	return layers;
+}
+
+function getConfigLayers(): ConfigLayer[] {
+	const runtimeConfig = getRuntime().config;
+	if (runtimeConfig) {
+		const parsed = ConfigSchema.parse(runtimeConfig);
+		return [{ source: "runtime", values: pickConfigKeys(parsed, Object.keys(runtimeConfig)) }];
+	}
+
+	const layers: ConfigLayer[] = [];
+	const files: Array<[ConfigSource, string]> = [
+		["system", getSystemConfigPath()],
+		["user", getConfigPath()],
+		["project", getProjectConfigPath()],
+	];
+	for (const [source, location] of files) {
+		const values = readConfigFile(location);
+		if (!values) continue;
+		layers.push({
+			source,
+			location,
+			values:
+				source === "project"
+					? withoutProjectIgnoredKeys(values, location)
+					: withTemplatesFrom(values, location),
+		});
+	}
+
+	layers.push(...readEnvLayers(process.env));
+	layers.push({ source: "flag", values: flagOverrides });
+	return layers;
+}
+
+/**
+ * Resolves the effective configuration and where each value came from.
+ * Precedence (lowest first): defaults, /etc/offworld/offworld.json, user config file,
+ * .offworld/config.json (without PROJECT_IGNORED_CONFIG_KEYS), OFFWORLD_* environment variables,
+ * command-line flags.
+ * An explicit config bound by the Offworld client replaces every layer except defaults.
+ */
+export function resolveConfig(): ResolvedConfig {
+	const merged: Record<string, unknown> = { ...ConfigSchema.parse({}) };
+	const origins = Object.fromEntries(
+		CONFIG_KEYS.map((key) => [key, { source: "default" }]),
+	) as Record<ConfigKey, ConfigOrigin>;
+
+	for (const layer of getConfigLayers()) {
+		for (const [key, value] of Object.entries(layer.values)) {
+			if (value === undefined) continue;
+			merged[key] = value;
+			origins[key as ConfigKey] = { source: layer.source, location: layer.location };
+		}
+	}
+
+	return { config: ConfigSchema.parse(merged), origins };
+}
+
+/**
+ * Loads the effective configuration (see resolveConfig for layering).
+ * Returns defaults when no layer sets a value.
+ */
+export function loadConfig(): Config {
+	return resolveConfig().config;
+}
+
 /**
  * Saves configuration to ~/.config/offworld/offworld.json
  * Creates directory if it doesn't exist
- * Merges with existing config
+ * Merges with keys already in the user file; values from other layers are not copied in.
+ * Returns the effective configuration after saving.
  */
 export function saveConfig(updates: Partial<Config>): Config {
+	const fs = getFs();
 	const configPath = getConfigPath();
 	const configDir = dirname(configPath);
 
-	if (!existsSync(configDir)) {
-		mkdirSync(configDir, { recursive: true });
+	if (!fs.existsSync(configDir)) {
+		fs.mkdirSync(configDir, { recursive: true });
 	}
 
-	const existing = loadConfig();
+	const existing = readConfigFile(configPath) ?? {};
 	const merged = { ...existing, ...updates };
-
 	const validated = ConfigSchema.parse(merged);
+	const values = pickConfigKeys(validated, Object.keys(merged));
 
-	writeFileSync(configPath, JSON.stringify(validated, null, 2), "utf-8");
+	fs.writeFileSync(configPath, JSON.stringify(values, null, 2), "utf-8");
 
-	return validated;
+	return loadConfig();
+}
+
+/**
+ * Clears the user config file so lower layers (system, defaults) apply again.
+ */
+export function resetConfig(): Config {
+	const fs = getFs();
+	const configPath = getConfigPath();
+	if (fs.existsSync(configPath)) {
+		fs.writeFileSync(configPath, "{}\n", "utf-8");
+	}
+	return loadConfig();
 }
@@ -0,0 +1,186 @@
+/**
+ * Cross-links between installed references
+ *
+ * Whenever references are installed, removed or moved, each installed reference gets a
+ * "Related References" block listing the other installed references it mentions or is mentioned
+ * by. A reference mentions
+ * another when it names its repo (owner/repo), reference name or package name. The block is
+ * local-only: it holds absolute paths and is stripped before a reference is pushed.
+ */
+
+import { join } from "node:path";
+import type { GlobalMap } from "@offworld/types";
+import { Paths } from "./paths.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+export const RELATED_BLOCK_START = "<!-- offworld:related -->";
+export const RELATED_BLOCK_END = "<!-- /offworld:related -->";
+
+/** Shorter terms are too likely to match ordinary words */
+const MIN_TERM_LENGTH = 3;
+
+export interface RelatedReference {
+	qualifiedName: string;
+	fullName: string;
+	referencePath: string;
+	/** This reference names the related one */
+	mentions: boolean;
+	/** The related reference names this one */
+	mentionedBy: boolean;
+}
+
+/** Outgoing mentions per qualified name */
+export type ReferenceGraph = Record<string, string[]>;
+
+function toFullName(qualifiedName: string): string {
+	const separator = qualifiedName.indexOf(":");
+	return separator === -1 ? qualifiedName : qualifiedName.slice(separator + 1);
+}
+
+function escapeRegExp(value: string): string {
+	return value.replace(/[.*+?^${}()|[\]\\]/g, "\\$&");
+}
+
+/**
+ * Terms that count as a mention of a repo: owner/repo, the reference name and the package
+ * name (the first keyword for npm packages) plus any scoped package keywords.
+ */
+function getMentionTerms(qualifiedName: string, entry: GlobalMap["repos"][string]): string[] {
+	const keywords = entry.keywords ?? [];
+	const terms = [
+		toFullName(qualifiedName),
+		entry.primary.replace(/\.md$/, ""),
+		...keywords.slice(0, 1),
+		...keywords.filter((keyword) => keyword.startsWith("@")),
+	];
+	return [...new Set(terms.map((term) => term.toLowerCase()))].filter(
+		(term) => term.length >= MIN_TERM_LENGTH,
+	);
+}
+
+function buildMentionPattern(terms: string[]): RegExp {
+	const alternatives = terms.map(escapeRegExp).join("|");
+	return new RegExp(`(?<![\\w@/.-])(?:${alternatives})(?![\\w/-])`, "i");
+}
+
+/**
+ * Remove the generated "Related References" block, if any.
+ */
+export function stripRelatedBlock(content: string): string {
+	const start = content.indexOf(RELATED_BLOCK_START);
+	if (start === -1) return content;
+	const end = content.indexOf(RELATED_BLOCK_END, start);
+	if (end === -1) return content;
+	const rest = content.slice(end + RELATED_BLOCK_END.length).replace(/^\n+/, "");
+	const before = content.slice(0, start).trimEnd();
+	return rest ? `${before}\n\n${rest}` : `${before}\n`;
+}
+
+function readReference(path: string): string | null {
+	try {
+		return getFs().readFileSync(path, "utf-8");
+	} catch {
+		return null;
+	}
+}
+
+/**
+ * Find which installed references mention which. References whose file is missing are left out.
+ */
+export function buildReferenceGraph(map: GlobalMap): ReferenceGraph {
+	const installed = Object.entries(map.repos)
+		.map(([qualifiedName, entry]) => ({
+			qualifiedName,
+			pattern: buildMentionPattern(getMentionTerms(qualifiedName, entry)),
+			content: readReference(join(Paths.offworldReferencesDir, entry.primary)),
+		}))
+		.filter((repo) => repo.content !== null);
+
+	const graph: ReferenceGraph = {};
+	for (const source of installed) {
+		const content = stripRelatedBlock(source.content!);
+		graph[source.qualifiedName] = installed
+			.filter((target) => target.qualifiedName !== source.qualifiedName)
+			.filter((target) => target.pattern.test(content))
+			.map((target) => target.qualifiedName)
+			.sort();
+	}
+	return graph;
+}
+
+/**
+ * References related to a repo in either direction, sorted by name.
+ */
+export function getRelatedReferences(
+	qualifiedName: string,
+	map: GlobalMap,
+	graph: ReferenceGraph = buildReferenceGraph(map),
+): RelatedReference[] {
+	const mentions = new Set(graph[qualifiedName] ?? []);
+	const mentionedBy = new Set(
+		Object.entries(graph)
+			.filter(([, targets]) => targets.includes(qualifiedName))
+			.map(([source]) => source),
+	);
+
+	return [...new Set([...mentions, ...mentionedBy])]
+		.filter((name) => map.repos[name])
+		.map((name) => ({
+			qualifiedName: name,
+			fullName: toFullName(name),
+			referencePath: join(Paths.offworldReferencesDir, map.repos[name]!.primary),
+			mentions: mentions.has(name),
+			mentionedBy: mentionedBy.has(name),
+		}))
+		.sort((a, b) => a.fullName.localeCompare(b.fullName));
+}
+
+function renderRelatedBlock(related: RelatedReference[]): string {
+	const lines = related.map((ref) => `- ${ref.fullName}: \`${ref.referencePath}\``);
+	return [RELATED_BLOCK_START, "## Related References", "", ...lines, RELATED_BLOCK_END].join(
+		"\n",
+	);
+}
+
+/**
+ * Rewrite the "Related References" block of every installed reference to match the map.
+ * Only files whose block changed are written.
+ *
+ * @returns Number of references rewritten
+ */
+export function updateCrossLinks(map: GlobalMap): number {
+	const fs = getFs();
+	const graph = buildReferenceGraph(map);
+	let updated = 0;
+
+	for (const qualifiedName of Object.keys(graph)) {
+		const referencePath = join(Paths.offworldReferencesDir, map.repos[qualifiedName]!.primary);
+		const current = readReference(referencePath);
+		if (current === null) continue;
+
+		const related = getRelatedReferences(qualifiedName, map, graph);
+		const base = stripRelatedBlock(current);
+		const next =
+			related.length > 0 ? `${base.trimEnd()}\n\n${renderRelatedBlock(related)}\n` : base;
+		if (next === current) continue;
+
+		fs.writeFileSync(referencePath, next, "utf-8");
+		updated++;
+	}
+	return updated;
+}
+
+/**
+ * Update cross-links after a map change. Never throws: a bad reference file must not block
+ * map writes.
+ */
+export function refreshCrossLinks(map: GlobalMap): void {
+	try {
+		const updated = updateCrossLinks(map);
+		if (updated > 0) getRuntime().logger.debug(`Updated cross-links in ${updated} references`);
+	} catch (error) {
+		getRuntime().logger.debug(
+			`Skipped cross-link refresh: ${error instanceof Error ? error.message : error}`,
+		);
+	}
+}
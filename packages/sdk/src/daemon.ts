@@ -0,0 +1,515 @@
+/**
+ * Background daemon
+ *
+ * A long-running process that fetches clones, refreshes the remote check cache and pulls (or
+ * regenerates) references that fell behind their clone, on the schedule in config `daemon`.
+ * Tasks run one at a time from a queue. The daemon serves newline-delimited JSON requests on a
+ * Unix socket (a named pipe on Windows) so the CLI, TUI and editor plugins can query it.
+ */
+
+import { createConnection, createServer, type Socket } from "node:net";
+import { homedir } from "node:os";
+import { join } from "node:path";
+import type { DaemonConfig } from "@offworld/types";
+import type { OpenCodeContext } from "./ai/opencode.js";
+import { Offworld } from "./client.js";
+import { loadConfig } from "./config.js";
+import { VERSION } from "./constants.js";
+import { readGlobalMap } from "./index-manager.js";
+import { searchMap } from "./map.js";
+import { Paths } from "./paths.js";
+import { refreshRemoteChecks } from "./remote-cache.js";
+import { updateAllRepos } from "./repo-manager.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+export const DAEMON_TASKS = ["fetch", "remote-check", "refresh"] as const;
+
+export type DaemonTask = (typeof DAEMON_TASKS)[number];
+
+export interface DaemonSchedule {
+	/** Interval in milliseconds per task; null disables the task */
+	intervals: Record<DaemonTask, number | null>;
+	regenerate: boolean;
+	warmOpenCode: boolean;
+}
+
+export interface DaemonTaskState {
+	lastRunAt?: string;
+	lastDurationMs?: number;
+	/** Result of the last successful run, e.g. "3 updated" */
+	lastSummary?: string;
+	lastError?: string;
+	nextRunAt?: string;
+}
+
+export interface DaemonStatus {
+	pid: number;
+	version: string;
+	startedAt: string;
+	socketPath: string;
+	schedule: DaemonSchedule;
+	running: DaemonTask | null;
+	queue: DaemonTask[];
+	tasks: Record<DaemonTask, DaemonTaskState>;
+	/** Whether a warm OpenCode server is up */
+	openCode: boolean;
+}
+
+export interface DaemonPaths {
+	socket: string;
+	pid: string;
+	log: string;
+}
+
+export interface DaemonHandle {
+	readonly socketPath: string;
+	status(): DaemonStatus;
+	/** Queue a task unless it is already queued or running */
+	enqueue(task: DaemonTask): boolean;
+	stop(): Promise<void>;
+	/** Resolves when the daemon has stopped */
+	readonly closed: Promise<void>;
+}
+
+export interface StartDaemonOptions {
+	/** Defaults to the schedule from config */
+	schedule?: DaemonSchedule;
+	/** Queue every enabled task at startup (default: true) */
+	runOnStart?: boolean;
+}
+
+export interface DaemonRequestOptions {
+	/** Milliseconds to wait for a response (default: 5000) */
+	timeoutMs?: number;
+}
+
+export class DaemonError extends Error {
+	constructor(message: string) {
+		super(message);
+		this.name = "DaemonError";
+	}
+}
+
+export class DaemonNotRunningError extends DaemonError {
+	constructor() {
+		super("The offworld daemon is not running. Start it with 'ow daemon start'.");
+		this.name = "DaemonNotRunningError";
+	}
+}
+
+const DEFAULT_INTERVALS: Record<DaemonTask, string> = {
+	fetch: "6h",
+	"remote-check": "12h",
+	refresh: "1d",
+};
+
+/** setInterval overflows past 2^31-1 ms (~24.8 days) */
+const MAX_INTERVAL_MS = 2 ** 31 - 1;
+
+/** daemon.log is rotated to daemon.log.1 past this size on start */
+export const MAX_DAEMON_LOG_BYTES = 5 * 1024 * 1024;
+
+const UNIT_MS = { m: 60_000, h: 3_600_000, d: 86_400_000 } as const;
+
+/**
+ * Parse a schedule interval ("30m", "6h", "1d"). "off" returns null.
+ *
+ * @throws DaemonError for unparseable values
+ */
+export function parseInterval(value: string): number | null {
+	const trimmed = value.trim().toLowerCase();
+	if (trimmed === "off") return null;
+
+	const match = trimmed.match(/^(\d+)\s*([mhd])$/);
+	if (!match || Number(match[1]) === 0) {
+		throw new DaemonError(`Invalid daemon interval: ${value}. Use e.g. 30m, 6h, 1d or off`);
+	}
+	return Math.min(Number(match[1]) * UNIT_MS[match[2] as keyof typeof UNIT_MS], MAX_INTERVAL_MS);
+}
+
+/**
+ * The effective schedule, with defaults for unset keys.
+ *
+ * @throws DaemonError if an interval is invalid
+ */
+export function resolveDaemonSchedule(config: DaemonConfig = loadConfig().daemon): DaemonSchedule {
+	const interval = (value: string | undefined, task: DaemonTask) =>
+		parseInterval(value ?? DEFAULT_INTERVALS[task]);
+
+	return {
+		intervals: {
+			fetch: interval(config.fetchInterval, "fetch"),
+			"remote-check": interval(config.remoteCheckInterval, "remote-check"),
+			refresh: interval(config.refreshInterval, "refresh"),
+		},
+		regenerate: config.regenerate ?? false,
+		warmOpenCode: config.warmOpenCode ?? true,
+	};
+}
+
+export function getDaemonPaths(): DaemonPaths {
+	return {
+		socket:
+			process.platform === "win32"
+				? "\\\\.\\pipe\\offworld-daemon"
+				: join(Paths.state, "daemon.sock"),
+		pid: join(Paths.state, "daemon.pid"),
+		log: join(Paths.state, "daemon.log"),
+	};
+}
+
+/**
+ * Rotate daemon.log when it has grown past MAX_DAEMON_LOG_BYTES. Returns the log path.
+ */
+export function prepareDaemonLog(): string {
+	const fs = getFs();
+	const { log } = getDaemonPaths();
+	fs.mkdirSync(Paths.state, { recursive: true });
+	if (fs.existsSync(log) && fs.statSync(log).size > MAX_DAEMON_LOG_BYTES) {
+		fs.renameSync(log, `${log}.1`);
+	}
+	return log;
+}
+
+/**
+ * Pid recorded by a running (or crashed) daemon.
+ */
+export function readDaemonPid(): number | null {
+	const fs = getFs();
+	const { pid } = getDaemonPaths();
+	if (!fs.existsSync(pid)) return null;
+	const value = Number.parseInt(fs.readFileSync(pid, "utf-8"), 10);
+	return Number.isInteger(value) && value > 0 ? value : null;
+}
+
+/**
+ * Send one request to the daemon and return its result.
+ *
+ * @throws DaemonNotRunningError if nothing is listening on the socket
+ * @throws DaemonError if the daemon returns an error or does not answer in time
+ */
+export function requestDaemon<T = unknown>(
+	method: string,
+	params: Record<string, unknown> = {},
+	options: DaemonRequestOptions = {},
+): Promise<T> {
+	const { socket: socketPath } = getDaemonPaths();
+	const { timeoutMs = 5000 } = options;
+
+	return new Promise<T>((resolvePromise, reject) => {
+		const socket = createConnection(socketPath);
+		let buffer = "";
+		const timer = setTimeout(() => {
+			socket.destroy();
+			reject(new DaemonError(`Daemon did not answer '${method}' within ${timeoutMs}ms`));
+		}, timeoutMs);
+
+		socket.setEncoding("utf-8");
+		socket.on("connect", () => socket.write(`${JSON.stringify({ id: 1, method, params })}\n`));
+		socket.on("data", (chunk: string) => {
+			buffer += chunk;
+			const newline = buffer.indexOf("\n");
+			if (newline === -1) return;
+
+			clearTimeout(timer);
+			socket.end();
+			try {
+				const response = JSON.parse(buffer.slice(0, newline)) as {
+					result?: T;
+					error?: string;
+				};
+				if (response.error) reject(new DaemonError(response.error));
+				else resolvePromise(response.result as T);
+			} catch {
+				reject(new DaemonError("Malformed response from daemon"));
+			}
+		});
+		socket.on("error", (error: NodeJS.ErrnoException) => {
+			clearTimeout(timer);
+			const notRunning = error.code === "ENOENT" || error.code === "ECONNREFUSED";
+			reject(notRunning ? new DaemonNotRunningError() : new DaemonError(error.message));
+		});
+	});
+}
+
+export async function isDaemonRunning(): Promise<boolean> {
+	try {
+		await requestDaemon("status", {}, { timeoutMs: 1000 });
+		return true;
+	} catch {
+		return false;
+	}
+}
+
+function toSourceUrl(qualifiedName: string): string | null {
+	const match = qualifiedName.match(/^([\w.-]+\.[a-z]+):(.+)$/i);
+	return match ? `https://${match[1]}/${match[2]}` : null;
+}
+
+function errorMessage(error: unknown): string {
+	return error instanceof Error ? error.message : String(error);
+}
+
+/**
+ * Start the daemon in this process: schedule tasks and listen on the socket.
+ *
+ * @throws DaemonError if another daemon is already listening
+ */
+export async function startDaemon(options: StartDaemonOptions = {}): Promise<DaemonHandle> {
+	const fs = getFs();
+	const { logger } = getRuntime();
+	const schedule = options.schedule ?? resolveDaemonSchedule();
+	const paths = getDaemonPaths();
+
+	if (await isDaemonRunning()) {
+		throw new DaemonError("The offworld daemon is already running.");
+	}
+	fs.mkdirSync(Paths.state, { recursive: true });
+	if (process.platform !== "win32") fs.rmSync(paths.socket, { force: true });
+
+	const startedAt = new Date().toISOString();
+	const tasks = Object.fromEntries(DAEMON_TASKS.map((task) => [task, {}])) as Record<
+		DaemonTask,
+		DaemonTaskState
+	>;
+	const queue: DaemonTask[] = [];
+	const timers: NodeJS.Timeout[] = [];
+	let running: DaemonTask | null = null;
+	let stopping = false;
+	let openCodeContext: OpenCodeContext | undefined;
+
+	if (schedule.regenerate && schedule.warmOpenCode) {
+		try {
+			const { createOpenCodeContext } = await import("./ai/opencode.js");
+			openCodeContext = await createOpenCodeContext({
+				onDebug: (message) => logger.debug(message, { component: "opencode" }),
+			});
+			logger.info("Started warm OpenCode server", { url: openCodeContext.baseUrl });
+		} catch (error) {
+			logger.warn("Could not start OpenCode server", { error: errorMessage(error) });
+		}
+	}
+
+	async function runTask(task: DaemonTask): Promise<string> {
+		switch (task) {
+			case "fetch": {
+				const result = await updateAllRepos();
+				return `${result.updated.length} updated, ${result.errors.length} failed`;
+			}
+			case "remote-check": {
+				const fullNames = Object.keys(readGlobalMap().repos)
+					.filter((name) => name.startsWith("github.com:"))
+					.map((name) => name.slice("github.com:".length));
+				const result = await refreshRemoteChecks(fullNames);
+				return `${result.checked} checked, ${result.failed.length} failed`;
+			}
+			case "refresh": {
+				const client = new Offworld({ logger });
+				let refreshed = 0;
+				let failed = 0;
+				for (const [qualifiedName, entry] of Object.entries(readGlobalMap().repos)) {
+					// Clone-only repos have no reference to refresh
+					if (!entry.primary) continue;
+					const url = toSourceUrl(qualifiedName);
+					if (!url || stopping) continue;
+					try {
+						const result = await client.pull(url, {
+							skipUpdate: true,
+							generate: schedule.regenerate,
+							openCodeContext,
+						});
+						if (result.referenceSource === "remote" || result.referenceSource === "local") {
+							refreshed++;
+							logger.info("Refreshed reference", {
+								repo: qualifiedName,
+								source: result.referenceSource,
+							});
+						}
+					} catch (error) {
+						failed++;
+						logger.warn("Reference refresh failed", {
+							repo: qualifiedName,
+							error: errorMessage(error),
+						});
+					}
+				}
+				return `${refreshed} refreshed, ${failed} failed`;
+			}
+		}
+	}
+
+	async function drain(): Promise<void> {
+		if (running) return;
+		while (queue.length > 0 && !stopping) {
+			const task = queue.shift()!;
+			const state = tasks[task];
+			const started = Date.now();
+			running = task;
+			logger.info("Task started", { task });
+			try {
+				state.lastSummary = await runTask(task);
+				state.lastError = undefined;
+				logger.info("Task finished", { task, summary: state.lastSummary });
+			} catch (error) {
+				state.lastError = errorMessage(error);
+				logger.error("Task failed", { task, error: state.lastError });
+			} finally {
+				state.lastRunAt = new Date(started).toISOString();
+				state.lastDurationMs = Date.now() - started;
+				running = null;
+			}
+		}
+	}
+
+	function enqueue(task: DaemonTask): boolean {
+		if (stopping || running === task || queue.includes(task)) return false;
+		queue.push(task);
+		void drain();
+		return true;
+	}
+
+	function status(): DaemonStatus {
+		return {
+			pid: process.pid,
+			version: VERSION,
+			startedAt,
+			socketPath: paths.socket,
+			schedule,
+			running,
+			queue: [...queue],
+			tasks,
+			openCode: openCodeContext !== undefined,
+		};
+	}
+
+	function handleRequest(method: string, params: Record<string, unknown>): unknown {
+		switch (method) {
+			case "status":
+				return status();
+			case "queue":
+				return { running, pending: [...queue] };
+			case "search":
+				return searchMap(String(params.term ?? ""), {
+					limit: typeof params.limit === "number" ? params.limit : undefined,
+				});
+			case "run": {
+				const task = params.task as DaemonTask;
+				if (!DAEMON_TASKS.includes(task)) {
+					throw new DaemonError(`Unknown task: ${String(params.task)}`);
+				}
+				return { queued: enqueue(task) };
+			}
+			case "stop":
+				setImmediate(() => void stop());
+				return { stopping: true };
+			default:
+				throw new DaemonError(`Unknown method: ${method}`);
+		}
+	}
+
+	function handleLine(socket: Socket, line: string): void {
+		let id: unknown = null;
+		try {
+			const request = JSON.parse(line) as {
+				id?: unknown;
+				method?: string;
+				params?: Record<string, unknown>;
+			};
+			id = request.id ?? null;
+			const result = handleRequest(String(request.method), request.params ?? {});
+			socket.write(`${JSON.stringify({ id, result })}\n`);
+		} catch (error) {
+			socket.write(`${JSON.stringify({ id, error: errorMessage(error) })}\n`);
+		}
+	}
+
+	const server = createServer((socket) => {
+		let buffer = "";
+		socket.setEncoding("utf-8");
+		socket.on("data", (chunk: string) => {
+			buffer += chunk;
+			let newline = buffer.indexOf("\n");
+			while (newline !== -1) {
+				const line = buffer.slice(0, newline).trim();
+				buffer = buffer.slice(newline + 1);
+				if (line) handleLine(socket, line);
+				newline = buffer.indexOf("\n");
+			}
+		});
+		socket.on("error", () => {});
+	});
+
+	await new Promise<void>((resolvePromise, reject) => {
+		server.once("error", reject);
+		server.listen(paths.socket, () => resolvePromise());
+	});
+	if (process.platform !== "win32") fs.chmodSync(paths.socket, 0o600);
+	fs.writeFileSync(paths.pid, String(process.pid), "utf-8");
+
+	let resolveClosed!: () => void;
+	const closed = new Promise<void>((resolvePromise) => {
+		resolveClosed = resolvePromise;
+	});
+
+	async function stop(): Promise<void> {
+		if (stopping) return closed;
+		stopping = true;
+		queue.length = 0;
+		for (const timer of timers) clearInterval(timer);
+		openCodeContext?.close();
+		await new Promise<void>((resolvePromise) => server.close(() => resolvePromise()));
+		fs.rmSync(paths.pid, { force: true });
+		if (process.platform !== "win32") fs.rmSync(paths.socket, { force: true });
+		logger.info("Daemon stopped");
+		resolveClosed();
+	}
+
+	logger.info("Daemon started", { pid: process.pid, socket: paths.socket });
+	for (const task of DAEMON_TASKS) {
+		const interval = schedule.intervals[task];
+		if (interval === null) continue;
+
+		tasks[task].nextRunAt = new Date(Date.now() + interval).toISOString();
+		timers.push(
+			setInterval(() => {
+				tasks[task].nextRunAt = new Date(Date.now() + interval).toISOString();
+				enqueue(task);
+			}, interval),
+		);
+		if (options.runOnStart !== false) enqueue(task);
+	}
+
+	return { socketPath: paths.socket, status, enqueue, stop, closed };
+}
+
+/**
+ * systemd user unit that runs the daemon in the foreground.
+ *
+ * @param command - How to invoke ow, e.g. [process.execPath, "/path/to/cli.mjs"]
+ */
+export function renderSystemdUnit(command: string[]): string {
+	const quote = (arg: string) => (/[\s"\\]/.test(arg) ? JSON.stringify(arg) : arg);
+	const execStart = [...command, "daemon", "start", "--foreground"].map(quote).join(" ");
+
+	return `[Unit]
+Description=Offworld background daemon
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart=${execStart}
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`;
+}
+
+/**
+ * Where systemd looks for user units: $XDG_CONFIG_HOME/systemd/user/offworld.service
+ */
+export function getSystemdUnitPath(): string {
+	const configHome = process.env.XDG_CONFIG_HOME || join(homedir(), ".config");
+	return join(configHome, "systemd", "user", "offworld.service");
+}
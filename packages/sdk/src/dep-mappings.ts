@@ -1,17 +1,20 @@
 /**
  * Dependency name to GitHub repo resolution:
  * 1. Parse repo from dependency spec (git/https/github shorthand)
- * 2. Query npm registry for repository.url
- * 3. Fall back to FALLBACK_MAPPINGS for packages missing repository field
- * 4. Return unknown (caller handles)
+ * 2. Ask plugin resolvers
+ * 3. Query npm registry for repository.url
+ * 4. Fall back to FALLBACK_MAPPINGS for packages missing repository field
+ * 5. Return unknown (caller handles)
  */
 
 import { NpmPackageResponseSchema } from "@offworld/types";
+import { getPluginResolvers } from "./plugins.js";
+import { getRuntime } from "./runtime.js";
 
 export type ResolvedDep = {
 	dep: string;
 	repo: string | null;
-	source: "spec" | "npm" | "fallback" | "unknown";
+	source: "spec" | "plugin" | "npm" | "fallback" | "unknown";
 };
 
 export interface ResolveDependencyRepoOptions {
@@ -91,15 +94,18 @@ function resolveFromSpec(spec?: string): string | null {
 async function fetchNpmPackage(
 	packageName: string,
 	timeoutMs = DEFAULT_NPM_FETCH_TIMEOUT_MS,
+	version?: string,
 ): Promise<{
 	repository?: string | { url?: string };
 	keywords?: string[];
+	deprecated?: string;
 } | null> {
 	const controller = new AbortController();
 	const timeoutId = setTimeout(() => controller.abort(), timeoutMs);
+	const path = version ? `${packageName}/${version}` : packageName;
 
 	try {
-		const res = await fetch(`https://registry.npmjs.org/${packageName}`, {
+		const res = await getRuntime().fetch(`https://registry.npmjs.org/${path}`, {
 			signal: controller.signal,
 		});
 		if (!res.ok) return null;
@@ -147,12 +153,23 @@ export async function getNpmKeywords(packageName: string): Promise<string[]> {
 	return Array.from(seen);
 }
 
+/**
+ * Deprecation message of a package's latest version, false if it isn't deprecated, or null if
+ * it can't be fetched.
+ */
+export async function getNpmDeprecation(packageName: string): Promise<string | false | null> {
+	const pkg = await fetchNpmPackage(packageName, undefined, "latest");
+	if (!pkg) return null;
+	return pkg.deprecated?.trim() || false;
+}
+
 /**
  * Resolution order:
  * 1. Parse repo from dependency spec (git/https/github shorthand)
- * 2. Query npm registry for repository.url
- * 3. Check FALLBACK_MAPPINGS for packages missing repository field
- * 4. Return unknown
+ * 2. Ask plugin resolvers in registration order (first non-null wins)
+ * 3. Query npm registry for repository.url
+ * 4. Check FALLBACK_MAPPINGS for packages missing repository field
+ * 5. Return unknown
  */
 export async function resolveDependencyRepo(
 	dep: string,
@@ -166,6 +183,19 @@ export async function resolveDependencyRepo(
 		return { dep, repo: specRepo, source: "spec" };
 	}
 
+	for (const resolver of getPluginResolvers()) {
+		try {
+			const pluginRepo = await resolver.resolve(dep, spec);
+			if (pluginRepo) {
+				return { dep, repo: pluginRepo, source: "plugin" };
+			}
+		} catch (error) {
+			getRuntime().logger.warn(
+				`Resolver ${resolver.name} failed for ${dep}: ${error instanceof Error ? error.message : error}`,
+			);
+		}
+	}
+
 	if (allowNpm) {
 		const npmRepo = await resolveFromNpm(dep, npmTimeoutMs);
 		if (npmRepo) {
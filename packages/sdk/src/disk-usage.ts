@@ -0,0 +1,273 @@
+/**
+ * Disk usage accounting for managed clones
+ *
+ * Directory sizes are computed in a worker pool and cached in the state directory, keyed by
+ * HEAD SHA and pack directory mtime, so unchanged repos are answered without walking the tree.
+ */
+
+import { availableParallelism } from "node:os";
+import { join } from "node:path";
+import { Worker } from "node:worker_threads";
+import { z } from "zod";
+import { GitObjectStore } from "./git-objects.js";
+import { Paths } from "./paths.js";
+import { getFs, usesNodeFs } from "./runtime.js";
+
+export interface DiskUsageResult {
+	repoPath: string;
+	bytes: number;
+	/** True when the size came from the cache */
+	cached: boolean;
+}
+
+export interface DiskUsageOptions {
+	/** Ignore cached sizes and re-measure */
+	refresh?: boolean;
+	/** Maximum number of worker threads */
+	concurrency?: number;
+	/** Called as each size becomes available (cached results first) */
+	onResult?: (result: DiskUsageResult) => void;
+}
+
+const DiskUsageEntrySchema = z.object({
+	headSha: z.string(),
+	packMtimeMs: z.number(),
+	bytes: z.number(),
+	measuredAt: z.string(),
+});
+
+const DiskUsageCacheSchema = z.object({
+	version: z.literal(1),
+	repos: z.record(z.string(), DiskUsageEntrySchema),
+});
+
+type DiskUsageEntry = z.infer<typeof DiskUsageEntrySchema>;
+type DiskUsageCache = z.infer<typeof DiskUsageCacheSchema>;
+
+const MAX_WORKERS = 4;
+
+/**
+ * Worker source, evaluated as CommonJS so it survives bundling.
+ */
+const WORKER_SOURCE = `
+const { parentPort } = require("node:worker_threads");
+const { readdirSync, lstatSync } = require("node:fs");
+const { join } = require("node:path");
+
+function dirSize(dir) {
+	let entries;
+	try {
+		entries = readdirSync(dir, { withFileTypes: true });
+	} catch {
+		return 0;
+	}
+	let size = 0;
+	for (const entry of entries) {
+		const fullPath = join(dir, entry.name);
+		if (entry.isDirectory()) {
+			size += dirSize(fullPath);
+		} else if (entry.isFile()) {
+			try {
+				size += lstatSync(fullPath).size;
+			} catch {}
+		}
+	}
+	return size;
+}
+
+parentPort.on("message", (path) => {
+	parentPort.postMessage({ path, bytes: dirSize(path) });
+});
+`;
+
+function getCachePath(): string {
+	return join(Paths.state, "disk-usage.json");
+}
+
+function readCache(): DiskUsageCache {
+	const fs = getFs();
+	try {
+		const cachePath = getCachePath();
+		if (fs.existsSync(cachePath)) {
+			return DiskUsageCacheSchema.parse(JSON.parse(fs.readFileSync(cachePath, "utf-8")));
+		}
+	} catch {}
+	return { version: 1, repos: {} };
+}
+
+function writeCache(cache: DiskUsageCache): void {
+	const fs = getFs();
+	try {
+		fs.mkdirSync(Paths.state, { recursive: true });
+		fs.writeFileSync(getCachePath(), JSON.stringify(cache, null, 2), "utf-8");
+	} catch {}
+}
+
+/**
+ * Cheap fingerprint of a clone: HEAD from ref files and the mtime of the pack directory.
+ * Returns null when the path is not a readable git repository (such sizes are not cached).
+ */
+function readCacheKey(repoPath: string): Pick<DiskUsageEntry, "headSha" | "packMtimeMs"> | null {
+	const fs = getFs();
+	try {
+		const store = new GitObjectStore(repoPath);
+		const headSha = store.resolveRef("HEAD");
+		if (!headSha) return null;
+
+		const packDir = join(store.gitDir, "objects", "pack");
+		const packMtimeMs = fs.existsSync(packDir) ? fs.statSync(packDir).mtimeMs : 0;
+		return { headSha, packMtimeMs };
+	} catch {
+		return null;
+	}
+}
+
+const yieldToEventLoop = () => new Promise<void>((resolve) => setImmediate(resolve));
+
+/**
+ * Walk a directory on the main thread, yielding between directories.
+ * Used for injected filesystems and when worker threads are unavailable.
+ */
+async function measureInline(dirPath: string): Promise<number> {
+	const fs = getFs();
+	let size = 0;
+	const stack = [dirPath];
+
+	while (stack.length > 0) {
+		const current = stack.pop()!;
+		await yieldToEventLoop();
+
+		let entries: string[];
+		try {
+			entries = fs.readdirSync(current);
+		} catch {
+			continue;
+		}
+		for (const name of entries) {
+			const fullPath = join(current, name);
+			try {
+				const stat = fs.lstatSync(fullPath);
+				if (stat.isDirectory()) stack.push(fullPath);
+				else if (stat.isFile()) size += stat.size;
+			} catch {}
+		}
+	}
+
+	return size;
+}
+
+/**
+ * Measure directories with a pool of worker threads.
+ */
+function measureWithWorkers(
+	paths: string[],
+	concurrency: number,
+	onMeasured: (path: string, bytes: number) => void,
+): Promise<void> {
+	const queue = [...paths];
+	const workerCount = Math.min(concurrency, queue.length);
+
+	const runWorker = () =>
+		new Promise<void>((resolve, reject) => {
+			const worker = new Worker(WORKER_SOURCE, { eval: true });
+			const next = () => {
+				const path = queue.shift();
+				if (path === undefined) {
+					void worker.terminate().then(() => resolve());
+					return;
+				}
+				worker.postMessage(path);
+			};
+
+			worker.on("message", ({ path, bytes }: { path: string; bytes: number }) => {
+				onMeasured(path, bytes);
+				next();
+			});
+			worker.on("error", reject);
+			next();
+		});
+
+	return Promise.all(Array.from({ length: workerCount }, runWorker)).then(() => undefined);
+}
+
+/**
+ * Get disk usage for a set of clone directories.
+ * Cached sizes are reported immediately; the rest are measured off the main thread.
+ */
+export async function getDiskUsage(
+	repoPaths: string[],
+	options: DiskUsageOptions = {},
+): Promise<Map<string, DiskUsageResult>> {
+	const { refresh = false, onResult } = options;
+	const concurrency = Math.max(
+		1,
+		options.concurrency ?? Math.min(availableParallelism(), MAX_WORKERS),
+	);
+	const cache = readCache();
+	const results = new Map<string, DiskUsageResult>();
+	const keys = new Map<string, ReturnType<typeof readCacheKey>>();
+	const pending: string[] = [];
+
+	for (const repoPath of repoPaths) {
+		const key = readCacheKey(repoPath);
+		keys.set(repoPath, key);
+
+		const entry = cache.repos[repoPath];
+		if (
+			!refresh &&
+			key &&
+			entry &&
+			entry.headSha === key.headSha &&
+			entry.packMtimeMs === key.packMtimeMs
+		) {
+			const result = { repoPath, bytes: entry.bytes, cached: true };
+			results.set(repoPath, result);
+			onResult?.(result);
+		} else {
+			pending.push(repoPath);
+		}
+	}
+
+	const record = (repoPath: string, bytes: number) => {
+		const result = { repoPath, bytes, cached: false };
+		results.set(repoPath, result);
+
+		const key = keys.get(repoPath);
+		if (key) {
+			cache.repos[repoPath] = { ...key, bytes, measuredAt: new Date().toISOString() };
+		}
+		onResult?.(result);
+	};
+
+	if (pending.length > 0) {
+		let measured = false;
+		if (usesNodeFs()) {
+			try {
+				await measureWithWorkers(pending, concurrency, record);
+				measured = true;
+			} catch {
+				// Worker threads unavailable; fall back to the main thread
+			}
+		}
+		if (!measured) {
+			for (const repoPath of pending) {
+				if (!results.has(repoPath)) {
+					record(repoPath, await measureInline(repoPath));
+				}
+			}
+		}
+		writeCache(cache);
+	}
+
+	return results;
+}
+
+/**
+ * Drop cached sizes for a clone (after update, removal, etc.). Best-effort.
+ */
+export function invalidateDiskUsage(repoPath: string): void {
+	const cache = readCache();
+	if (!(repoPath in cache.repos)) return;
+	delete cache.repos[repoPath];
+	writeCache(cache);
+}
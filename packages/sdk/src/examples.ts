@@ -0,0 +1,512 @@
+/**
+ * Harvested code examples
+ *
+ * Extracts snippets from a clone's examples directories, test cases and docs code blocks,
+ * tags them with the symbols they import and stores them per repo next to meta.json. Agents
+ * query the index with `ow examples`; reference generation uses it as extra input.
+ */
+
+import { join, relative } from "node:path";
+import { z } from "zod";
+import { getCommitSha } from "./clone.js";
+import { getMetaPath } from "./config.js";
+import { getFs } from "./runtime.js";
+
+export const EXAMPLE_KINDS = ["example", "test", "docs"] as const;
+export type ExampleKind = (typeof EXAMPLE_KINDS)[number];
+
+const ExampleSnippetSchema = z.object({
+	/** Path relative to the clone root */
+	path: z.string(),
+	startLine: z.number(),
+	endLine: z.number(),
+	kind: z.enum(EXAMPLE_KINDS),
+	language: z.string(),
+	/** Test name, nearest docs heading or file name */
+	title: z.string(),
+	/** Imported symbols the snippet uses */
+	symbols: z.array(z.string()),
+	/** Modules the snippet imports from */
+	modules: z.array(z.string()),
+	code: z.string(),
+});
+
+const ExamplesIndexSchema = z.object({
+	repo: z.string(),
+	commitSha: z.string(),
+	builtAt: z.string(),
+	examples: z.array(ExampleSnippetSchema),
+});
+
+export type ExampleSnippet = z.infer<typeof ExampleSnippetSchema>;
+export type ExamplesIndex = z.infer<typeof ExamplesIndexSchema>;
+
+export interface ExampleMatch extends ExampleSnippet {
+	score: number;
+}
+
+/** Snippets longer than this are cut (example files) or skipped (tests, docs blocks) */
+const MAX_SNIPPET_LINES = 80;
+const MIN_SNIPPET_LINES = 3;
+const MAX_FILE_BYTES = 256 * 1024;
+const MAX_FILES = 20_000;
+const MAX_SNIPPETS_PER_FILE = 20;
+const MAX_SNIPPETS = 3000;
+
+const SKIP_DIRS = new Set([
+	".git",
+	"node_modules",
+	"dist",
+	"build",
+	"out",
+	"coverage",
+	"vendor",
+	"target",
+	".next",
+	".turbo",
+	"__snapshots__",
+	"__fixtures__",
+	"fixtures",
+]);
+const EXAMPLE_DIRS = new Set(["examples", "example", "demo", "demos", "samples", "playground"]);
+const TEST_DIRS = new Set(["test", "tests", "__tests__", "spec"]);
+const SKIP_DOCS = /^(changelog|license|contributing|code_of_conduct|security)\b/i;
+/** Test framework imports say nothing about the library under test */
+const TEST_MODULES = new Set([
+	"vitest",
+	"bun:test",
+	"node:test",
+	"node:assert",
+	"@jest/globals",
+	"testing",
+	"pytest",
+	"unittest",
+]);
+
+const LANGUAGES: Record<string, string> = {
+	ts: "ts",
+	mts: "ts",
+	cts: "ts",
+	tsx: "tsx",
+	js: "js",
+	mjs: "js",
+	cjs: "js",
+	jsx: "jsx",
+	vue: "vue",
+	svelte: "svelte",
+	py: "python",
+	go: "go",
+	rs: "rust",
+};
+
+/** Docs fence languages worth harvesting (shell snippets are install commands, not usage) */
+const FENCE_LANGUAGES: Record<string, string> = {
+	...LANGUAGES,
+	typescript: "ts",
+	javascript: "js",
+	python: "python",
+	rust: "rust",
+};
+
+function isJsLike(language: string): boolean {
+	return ["ts", "tsx", "js", "jsx", "vue", "svelte"].includes(language);
+}
+
+function classifyFile(path: string): { kind: ExampleKind; language: string } | null {
+	const segments = path.split("/");
+	const fileName = segments.pop()!;
+	const extension = fileName.slice(fileName.lastIndexOf(".") + 1).toLowerCase();
+
+	if (extension === "md" || extension === "mdx") {
+		return SKIP_DOCS.test(fileName) ? null : { kind: "docs", language: "markdown" };
+	}
+
+	const language = LANGUAGES[extension];
+	if (!language || fileName.endsWith(".d.ts")) return null;
+	if (segments.some((segment) => EXAMPLE_DIRS.has(segment))) return { kind: "example", language };
+	if (
+		segments.some((segment) => TEST_DIRS.has(segment)) ||
+		/\.(test|spec)\.\w+$/.test(fileName) ||
+		/_test\.go$/.test(fileName) ||
+		/^test_.*\.py$/.test(fileName)
+	) {
+		return { kind: "test", language };
+	}
+	return null;
+}
+
+interface Imports {
+	/** Local name -> imported name */
+	symbols: Map<string, string>;
+	modules: string[];
+}
+
+function parseImports(code: string, language: string): Imports {
+	const symbols = new Map<string, string>();
+	const modules: string[] = [];
+
+	if (isJsLike(language)) {
+		const pattern = /import\s+(?:type\s+)?([\w$*{}\s,]+?)\s+from\s+["']([^"']+)["']/g;
+		for (const [, clause, module] of code.matchAll(pattern)) {
+			if (TEST_MODULES.has(module!)) continue;
+			modules.push(module!);
+			const named = clause!.match(/\{([^}]*)\}/)?.[1] ?? "";
+			for (const part of named.split(",")) {
+				const [imported, local] = part
+					.replace(/^\s*type\s+/, "")
+					.split(/\s+as\s+/)
+					.map((name) => name.trim());
+				if (imported) symbols.set(local ?? imported, imported);
+			}
+			const rest = clause!.replace(/\{[^}]*\}/, "");
+			const namespace = rest.match(/\*\s*as\s+([\w$]+)/)?.[1];
+			if (namespace) symbols.set(namespace, namespace);
+			const defaultName = rest.match(/^\s*([\w$]+)/)?.[1];
+			if (defaultName) symbols.set(defaultName, defaultName);
+		}
+	} else if (language === "python") {
+		for (const [, module, names] of code.matchAll(/^\s*from\s+([\w.]+)\s+import\s+([^\n#]+)/gm)) {
+			if (TEST_MODULES.has(module!)) continue;
+			modules.push(module!);
+			for (const part of names!.replace(/[()]/g, "").split(",")) {
+				const [imported, local] = part.split(/\s+as\s+/).map((name) => name.trim());
+				if (imported && imported !== "*") symbols.set(local ?? imported, imported);
+			}
+		}
+		for (const [, module] of code.matchAll(/^\s*import\s+([\w.]+)/gm)) {
+			if (TEST_MODULES.has(module!)) continue;
+			modules.push(module!);
+			symbols.set(module!.split(".").pop()!, module!);
+		}
+	} else if (language === "go") {
+		const block = code.match(/import\s*\(([\s\S]*?)\)/)?.[1] ?? code.match(/import\s+(".*")/)?.[1];
+		for (const [, alias, module] of (block ?? "").matchAll(/(?:([\w.]+)\s+)?"([^"]+)"/g)) {
+			if (TEST_MODULES.has(module!)) continue;
+			modules.push(module!);
+			if (alias === "_" || alias === ".") continue;
+			const segments = module!.split("/");
+			const last = /^v\d+$/.test(segments.at(-1)!) ? segments.at(-2)! : segments.at(-1)!;
+			const name = alias ?? last.replace(/^go-/, "").replace(/\W/g, "");
+			// Go code refers to imports as pkg.Name; tag the selectors it uses
+			for (const [selector] of code.matchAll(new RegExp(`\\b${name}\\.[A-Z]\\w*`, "g"))) {
+				symbols.set(selector, selector);
+			}
+		}
+	} else if (language === "rust") {
+		for (const [, path] of code.matchAll(/^\s*use\s+([^;]+);/gm)) {
+			const braces = path!.match(/^(.*?)::\{([^}]*)\}/);
+			modules.push((braces?.[1] ?? path!.replace(/::[^:]+$/, "")).trim());
+			const names = braces ? braces[2]!.split(",") : [path!.split("::").pop()!];
+			for (const name of names.map((n) => n.trim()).filter((n) => n && n !== "self")) {
+				symbols.set(name, name);
+			}
+		}
+	}
+
+	return { symbols, modules: [...new Set(modules)] };
+}
+
+
+/**
+ * Imported symbols that appear in the snippet. File-level imports are shared by every test
+ * case; only the ones a case uses describe it.
+ */
+function tagSnippet(code: string, imports: Imports): Pick<ExampleSnippet, "symbols" | "modules"> {
+	const used = [...imports.symbols].filter(([local]) =>
+		new RegExp(`(?<![\\w$.])${local.replace(/[$.]/g, "\\$&")}(?![\\w$])`).test(code),
+	);
+	return {
+		symbols: [...new Set(used.map(([, imported]) => imported))].sort(),
+		modules: imports.modules,
+	};
+}
+
+function extractDocsSnippets(path: string, content: string): ExampleSnippet[] {
+	const snippets: ExampleSnippet[] = [];
+	const lines = content.split("\n");
+	let heading = path.split("/").pop()!;
+
+	for (let i = 0; i < lines.length; i++) {
+		const headingMatch = lines[i]!.match(/^#{1,6}\s+(.+)/);
+		if (headingMatch) heading = headingMatch[1]!.trim();
+
+		const fence = lines[i]!.match(/^(\s*)(`{3,}|~{3,})\s*([\w-]*)/);
+		if (!fence) continue;
+		const closing = new RegExp(`^\\s*\\${fence[2]![0]}{${fence[2]!.length},}\\s*$`);
+		const close = lines.findIndex((line, j) => j > i && closing.test(line));
+		if (close === -1) break;
+
+		const language = FENCE_LANGUAGES[fence[3]!.toLowerCase()];
+		const body = lines.slice(i + 1, close);
+		if (language && body.length >= MIN_SNIPPET_LINES && body.length <= MAX_SNIPPET_LINES) {
+			const code = body.map((line) => line.slice(fence[1]!.length)).join("\n");
+			snippets.push({
+				path,
+				startLine: i + 2,
+				endLine: close,
+				kind: "docs",
+				language,
+				title: heading,
+				...tagSnippet(code, parseImports(code, language)),
+				code,
+			});
+		}
+		i = close;
+	}
+	return snippets;
+}
+
+const TEST_START: Record<string, RegExp> = {
+	js: /^(\s*)(?:it|test)(?:\.\w+)*\s*\(\s*(["'`])(.+?)\2/,
+	go: /^()func\s+((?:Test|Example)\w*)\s*\(/,
+	python: /^(\s*)def\s+(test_\w+)\s*\(/,
+	rust: /^(\s*)(?:pub\s+)?fn\s+(\w+)\s*\(/,
+};
+
+function findBlockEnd(lines: string[], start: number, indent: string, language: string): number {
+	for (let j = start + 1; j < lines.length; j++) {
+		const line = lines[j]!;
+		if (language === "python") {
+			if (line.trim() && !line.startsWith(`${indent} `) && !line.startsWith(`${indent}\t`)) {
+				return j - 1;
+			}
+		} else if (line.startsWith(`${indent}}`)) {
+			return j;
+		}
+	}
+	return language === "python" ? lines.length - 1 : -1;
+}
+
+function extractTestSnippets(path: string, content: string, language: string): ExampleSnippet[] {
+	const snippets: ExampleSnippet[] = [];
+	const imports = parseImports(content, language);
+	const lines = content.split("\n");
+	const pattern = TEST_START[isJsLike(language) ? "js" : language];
+	if (!pattern) return snippets;
+
+	for (let i = 0; i < lines.length; i++) {
+		const match = lines[i]!.match(pattern);
+		if (!match) continue;
+		if (language === "rust" && !lines[i - 1]?.includes("#[test]")) continue;
+		// One-line cases are too small to be worth an entry, and have no closing line to find
+		if (lines[i]!.trimEnd().endsWith(");")) continue;
+
+		let end = findBlockEnd(lines, i, match[1]!, language);
+		if (end === -1) continue;
+		while (end > i && !lines[end]!.trim()) end--;
+		const length = end - i + 1;
+		if (length >= MIN_SNIPPET_LINES && length <= MAX_SNIPPET_LINES) {
+			const code = lines.slice(i, end + 1).join("\n");
+			snippets.push({
+				path,
+				startLine: i + 1,
+				endLine: end + 1,
+				kind: "test",
+				language,
+				title: match[3] ?? match[2]!,
+				...tagSnippet(code, imports),
+				code,
+			});
+		}
+		i = end;
+	}
+	return snippets;
+}
+
+function extractExampleSnippet(path: string, content: string, language: string): ExampleSnippet {
+	const lines = content.trimEnd().split("\n");
+	const kept = lines.slice(0, MAX_SNIPPET_LINES);
+	return {
+		path,
+		startLine: 1,
+		endLine: kept.length,
+		kind: "example",
+		language,
+		title: path.split("/").pop()!,
+		...tagSnippet(content, parseImports(content, language)),
+		code: kept.join("\n"),
+	};
+}
+
+function* walkFiles(root: string): Generator<string> {
+	const fs = getFs();
+	const pending = [root];
+	let visited = 0;
+
+	while (pending.length > 0) {
+		const dir = pending.pop()!;
+		let entries: Array<{ name: string; isDirectory(): boolean; isFile(): boolean }>;
+		try {
+			entries = fs.readdirSync(dir, { withFileTypes: true });
+		} catch {
+			continue;
+		}
+		for (const entry of entries.sort((a, b) => a.name.localeCompare(b.name))) {
+			const path = join(dir, entry.name);
+			if (entry.isDirectory()) {
+				if (!SKIP_DIRS.has(entry.name) && !entry.name.startsWith(".")) pending.push(path);
+			} else if (entry.isFile()) {
+				if (++visited > MAX_FILES) return;
+				yield path;
+			}
+		}
+	}
+}
+
+/**
+ * Extract snippets from a clone: whole files under examples/ (and demo/, samples/, ...),
+ * individual test cases, and code blocks in markdown docs.
+ *
+ * @param repoPath - Clone root
+ * @param repo - Qualified name recorded in the index
+ */
+export function buildExamplesIndex(repoPath: string, repo: string): ExamplesIndex {
+	const fs = getFs();
+	const examples: ExampleSnippet[] = [];
+
+	for (const file of walkFiles(repoPath)) {
+		const path = relative(repoPath, file).split("\\").join("/");
+		const type = classifyFile(path);
+		if (!type) continue;
+
+		let content: string;
+		try {
+			if (fs.statSync(file).size > MAX_FILE_BYTES) continue;
+			content = fs.readFileSync(file, "utf-8");
+		} catch {
+			continue;
+		}
+
+		let snippets: ExampleSnippet[];
+		if (type.kind === "docs") snippets = extractDocsSnippets(path, content);
+		else if (type.kind === "test") snippets = extractTestSnippets(path, content, type.language);
+		else snippets = [extractExampleSnippet(path, content, type.language)];
+
+		examples.push(...snippets.filter((s) => s.code.trim()).slice(0, MAX_SNIPPETS_PER_FILE));
+		if (examples.length >= MAX_SNIPPETS) break;
+	}
+
+	let commitSha = "unknown";
+	try {
+		commitSha = getCommitSha(repoPath);
+	} catch {
+		// Not a git checkout; the index is still usable, it just can't tell when it's stale
+	}
+
+	return {
+		repo,
+		commitSha,
+		builtAt: new Date().toISOString(),
+		examples: examples.slice(0, MAX_SNIPPETS),
+	};
+}
+
+/**
+ * Examples index for a repo: ~/.local/share/offworld/meta/{name}/examples.json
+ */
+export function getExamplesIndexPath(fullName: string): string {
+	return join(getMetaPath(fullName), "examples.json");
+}
+
+/**
+ * Read a repo's examples index. Missing or unreadable indexes are null.
+ */
+export function readExamplesIndex(fullName: string): ExamplesIndex | null {
+	const fs = getFs();
+	const path = getExamplesIndexPath(fullName);
+	try {
+		if (!fs.existsSync(path)) return null;
+		const parsed = ExamplesIndexSchema.safeParse(JSON.parse(fs.readFileSync(path, "utf-8")));
+		return parsed.success ? parsed.data : null;
+	} catch {
+		return null;
+	}
+}
+
+export function writeExamplesIndex(fullName: string, index: ExamplesIndex): string {
+	const fs = getFs();
+	const path = getExamplesIndexPath(fullName);
+	fs.mkdirSync(getMetaPath(fullName), { recursive: true });
+	fs.writeFileSync(path, JSON.stringify(index), "utf-8");
+	return path;
+}
+
+const KIND_BONUS: Record<ExampleKind, number> = { example: 3, docs: 2, test: 0 };
+
+function scoreToken(example: ExampleSnippet, token: string): number {
+	let score = 0;
+	for (const symbol of example.symbols) {
+		const name = symbol.toLowerCase();
+		if (name === token || name.endsWith(`.${token}`)) score += 10;
+		else if (name.includes(token)) score += 4;
+	}
+	if (example.modules.some((module) => module.toLowerCase().includes(token))) score += 3;
+	if (example.title.toLowerCase().includes(token)) score += 4;
+	if (example.path.toLowerCase().includes(token)) score += 3;
+	if (example.code.toLowerCase().includes(token)) score += 1;
+	return score;
+}
+
+/**
+ * Rank snippets for a symbol or topic. Every word of the query must match the snippet's
+ * symbols, modules, title, path or code; symbol hits weigh most, runnable examples beat docs
+ * blocks beat tests, and shorter snippets win ties.
+ */
+export function searchExamples(
+	index: ExamplesIndex,
+	query: string,
+	options: { limit?: number } = {},
+): ExampleMatch[] {
+	const { limit = 5 } = options;
+	const tokens = query.toLowerCase().split(/\s+/).filter(Boolean);
+	if (tokens.length === 0) return [];
+
+	const matches: ExampleMatch[] = [];
+	for (const example of index.examples) {
+		const scores = tokens.map((token) => scoreToken(example, token));
+		if (scores.some((score) => score === 0)) continue;
+		const score = scores.reduce((sum, value) => sum + value, 0) + KIND_BONUS[example.kind];
+		matches.push({ ...example, score });
+	}
+
+	return matches
+		.sort(
+			(a, b) =>
+				b.score - a.score ||
+				a.endLine - a.startLine - (b.endLine - b.startLine) ||
+				a.path.localeCompare(b.path),
+		)
+		.slice(0, limit);
+}
+
+/**
+ * A sample of harvested snippets for the generation prompt: runnable examples first, then
+ * docs blocks, then tests, at most one per file, preferring snippets that use more symbols.
+ */
+export function formatExamplesForPrompt(index: ExamplesIndex, maxChars = 12_000): string | null {
+	const seenFiles = new Set<string>();
+	const ranked = [...index.examples].sort(
+		(a, b) =>
+			KIND_BONUS[b.kind] - KIND_BONUS[a.kind] ||
+			b.symbols.length - a.symbols.length ||
+			a.path.localeCompare(b.path),
+	);
+
+	const sections: string[] = [];
+	let length = 0;
+	for (const example of ranked) {
+		if (seenFiles.has(example.path) || example.symbols.length === 0) continue;
+		const section = [
+			`### ${example.path}:${example.startLine} (${example.kind}; ${example.symbols.join(", ")})`,
+			`\`\`\`${example.language}`,
+			example.code,
+			"```",
+		].join("\n");
+		if (length + section.length > maxChars) continue;
+		seenFiles.add(example.path);
+		sections.push(section);
+		length += section.length;
+	}
+
+	return sections.length > 0 ? sections.join("\n\n") : null;
+}
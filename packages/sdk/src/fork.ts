@@ -0,0 +1,259 @@
+/**
+ * Forks of repos with a remote reference
+ *
+ * Patched forks rarely have a reference of their own, but their upstream often does. The
+ * upstream comes from GitHub fork metadata, or from a shared history check: the clone must
+ * contain the commit a candidate's reference was generated at. Candidates are the repo behind
+ * the root package on npm and installed repos with the same name. The upstream reference is
+ * installed for the fork with a supplement summarizing how far the fork has diverged.
+ */
+
+import { join } from "node:path";
+import type { RemoteRepoSource } from "@offworld/types";
+import { fetchCommit } from "./clone.js";
+import { resolveCommitDistance, formatCommitDistance } from "./commit-distance.js";
+import { resolveFromNpm } from "./dep-mappings.js";
+import { readGlobalMap } from "./index-manager.js";
+import { toRemoteSource } from "./repo-identity.js";
+import { parseRepoInput } from "./repo-source.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+export const FORK_DETECTION_METHODS = ["github", "history"] as const;
+export type ForkDetectionMethod = (typeof FORK_DETECTION_METHODS)[number];
+
+export interface ForkCandidate {
+	upstream: RemoteRepoSource;
+	/** "github" candidates are known forks; "history" ones need a shared commit */
+	method: ForkDetectionMethod;
+}
+
+export interface ForkUpstream extends ForkCandidate {
+	/** Commit the upstream's remote reference was generated at */
+	referenceSha: string;
+}
+
+export interface ForkDivergence {
+	/** Commits in the fork that the upstream reference commit lacks */
+	ahead: number;
+	/** Commits in the upstream reference commit that the fork lacks */
+	behind: number;
+	/** Subject lines of the fork's commits, oldest first (empty when only counted locally) */
+	commits: string[];
+	/** Files the fork changed, e.g. "src/index.ts (modified)" */
+	files: string[];
+}
+
+export const FORK_SUPPLEMENT_START = "<!-- offworld:fork -->";
+export const FORK_SUPPLEMENT_END = "<!-- /offworld:fork -->";
+
+/** Lists in the supplement are cut off beyond these */
+const MAX_SUPPLEMENT_COMMITS = 20;
+const MAX_SUPPLEMENT_FILES = 40;
+
+function readRootPackageName(repoPath: string): string | null {
+	try {
+		const pkg = JSON.parse(getFs().readFileSync(join(repoPath, "package.json"), "utf-8")) as {
+			name?: unknown;
+		};
+		return typeof pkg.name === "string" && pkg.name.trim() ? pkg.name.trim() : null;
+	} catch {
+		return null;
+	}
+}
+
+function toSource(fullName: string): RemoteRepoSource | null {
+	try {
+		const source = parseRepoInput(fullName);
+		return source.type === "remote" ? source : null;
+	} catch {
+		return null;
+	}
+}
+
+/**
+ * Possible upstreams of a repo, most likely first: the GitHub fork parent and network root,
+ * then the repos behind its npm package name (scoped forks such as "@org/zod" also try "zod")
+ * and installed repos with the same name.
+ */
+export async function findForkCandidates(
+	source: RemoteRepoSource,
+	repoPath: string,
+): Promise<ForkCandidate[]> {
+	const candidates: ForkCandidate[] = [];
+	const add = (upstream: RemoteRepoSource | null, method: ForkDetectionMethod) => {
+		if (!upstream || upstream.qualifiedName === source.qualifiedName) return;
+		if (candidates.some((c) => c.upstream.qualifiedName === upstream.qualifiedName)) return;
+		candidates.push({ upstream, method });
+	};
+
+	if (source.provider === "github") {
+		const { fetchGitHubMetadata } = await import("./sync.js");
+		const metadata = await fetchGitHubMetadata(source.owner, source.repo);
+		for (const fullName of [metadata?.parent, metadata?.forkSource]) {
+			if (fullName) add(toSource(fullName), "github");
+		}
+	}
+
+	const packageName = readRootPackageName(repoPath);
+	if (packageName) {
+		const unscoped = packageName.replace(/^@[^/]+\//, "");
+		for (const name of new Set([packageName, unscoped])) {
+			const repo = await resolveFromNpm(name);
+			if (repo) add(toSource(repo), "history");
+		}
+	}
+
+	for (const qualifiedName of Object.keys(readGlobalMap().repos)) {
+		const installed = toRemoteSource(qualifiedName);
+		if (installed?.repo === source.repo) add(installed, "history");
+	}
+
+	return candidates;
+}
+
+async function hasSharedHistory(repoPath: string, sha: string): Promise<boolean> {
+	try {
+		return await fetchCommit(repoPath, sha);
+	} catch (error) {
+		const message = error instanceof Error ? error.message : error;
+		getRuntime().logger.debug(`No shared history at ${sha.slice(0, 7)}: ${message}`);
+		return false;
+	}
+}
+
+/**
+ * Find the upstream of a fork that has a remote reference on offworld.sh. Candidates found
+ * by name only count when the clone contains (or can fetch) the reference commit. Never throws.
+ *
+ * @returns The first candidate with a usable reference, or null
+ */
+export async function findForkUpstream(
+	source: RemoteRepoSource,
+	repoPath: string,
+): Promise<ForkUpstream | null> {
+	const { logger } = getRuntime();
+	const { checkRemote } = await import("./sync.js");
+
+	let candidates: ForkCandidate[];
+	try {
+		candidates = await findForkCandidates(source, repoPath);
+	} catch (error) {
+		logger.debug(`Skipped fork detection: ${error instanceof Error ? error.message : error}`);
+		return null;
+	}
+
+	for (const candidate of candidates) {
+		try {
+			const remote = await checkRemote(candidate.upstream.fullName);
+			if (!remote.exists || !remote.commitSha) continue;
+			const { commitSha } = remote;
+			if (candidate.method === "history" && !(await hasSharedHistory(repoPath, commitSha))) {
+				continue;
+			}
+			return { ...candidate, referenceSha: commitSha };
+		} catch (error) {
+			const message = error instanceof Error ? error.message : error;
+			logger.debug(`Skipped upstream ${candidate.upstream.fullName}: ${message}`);
+		}
+	}
+	return null;
+}
+
+/**
+ * How far a fork's clone has moved from the upstream reference commit. Asks the GitHub
+ * compare API for commits and files, falling back to counts only. Never throws.
+ *
+ * @returns The divergence, or null if it can't be determined
+ */
+export async function getForkDivergence(
+	source: RemoteRepoSource,
+	repoPath: string,
+	referenceSha: string,
+	cloneSha: string,
+): Promise<ForkDivergence | null> {
+	if (source.provider === "github") {
+		const { fetchGitHubCompare } = await import("./sync.js");
+		const compare = await fetchGitHubCompare(source.owner, source.repo, referenceSha, cloneSha);
+		if (compare) {
+			return {
+				ahead: compare.aheadBy,
+				behind: compare.behindBy,
+				commits: compare.commits,
+				files: compare.files,
+			};
+		}
+	}
+
+	const distance = await resolveCommitDistance(source, repoPath, referenceSha, cloneSha);
+	if (!distance) return null;
+	return { ahead: distance.behind, behind: distance.ahead, commits: [], files: [] };
+}
+
+/**
+ * Human-readable divergence, e.g. "12 commits ahead" or "3 behind, 12 ahead".
+ */
+export function formatForkDivergence(divergence: ForkDivergence): string {
+	return formatCommitDistance({ behind: divergence.behind, ahead: divergence.ahead });
+}
+
+function truncatedList(items: string[], max: number): string[] {
+	const lines = items.slice(0, max).map((item) => `- ${item}`);
+	if (items.length > max) lines.push(`- ...and ${items.length - max} more`);
+	return lines;
+}
+
+/**
+ * Remove a fork supplement added by withForkSupplement.
+ */
+export function stripForkSupplement(content: string): string {
+	const start = content.indexOf(FORK_SUPPLEMENT_START);
+	const end = content.indexOf(FORK_SUPPLEMENT_END, start);
+	if (start === -1 || end === -1) return content;
+	const rest = content.slice(end + FORK_SUPPLEMENT_END.length).replace(/^\n+/, "");
+	const before = content.slice(0, start).trimEnd();
+	return rest ? `${before}\n\n${rest}` : `${before}\n`;
+}
+
+/**
+ * Append a "Fork Supplement" section to an upstream reference, replacing any earlier one.
+ *
+ * @param content - The upstream reference
+ * @param fork - The fork the reference is installed for
+ * @param upstream - Where the reference came from
+ * @param divergence - From getForkDivergence, or null if unknown
+ */
+export function withForkSupplement(
+	content: string,
+	fork: RemoteRepoSource,
+	upstream: ForkUpstream,
+	divergence: ForkDivergence | null,
+): string {
+	const at = upstream.referenceSha.slice(0, 7);
+	const summary = divergence
+		? `Compared with that commit, \`${fork.fullName}\` is ${formatForkDivergence(divergence)}.`
+		: `How far \`${fork.fullName}\` has diverged is unknown (see \`git log ${at}..HEAD\`).`;
+	const sections = [
+		`This reference was generated for the upstream repo \`${upstream.upstream.fullName}\` at ` +
+			`commit \`${at}\`. ${summary} Where the fork's code disagrees with the sections above, ` +
+			"the fork wins.",
+	];
+	if (divergence && divergence.commits.length > 0) {
+		const commits = truncatedList(divergence.commits, MAX_SUPPLEMENT_COMMITS);
+		sections.push(["### Fork commits", "", ...commits].join("\n"));
+	}
+	if (divergence && divergence.files.length > 0) {
+		const files = truncatedList(divergence.files, MAX_SUPPLEMENT_FILES);
+		sections.push(["### Changed files", "", ...files].join("\n"));
+	}
+
+	return [
+		stripForkSupplement(content).trimEnd(),
+		"",
+		FORK_SUPPLEMENT_START,
+		"## Fork Supplement",
+		"",
+		sections.join("\n\n"),
+		FORK_SUPPLEMENT_END,
+		"",
+	].join("\n");
+}
@@ -0,0 +1,91 @@
+/**
+ * How far an installed reference lags behind its clone
+ *
+ * A reference describes the commit it was generated at. After the clone moves on, the
+ * reference may describe APIs that have since changed; past the repo's maxCommitDistance
+ * agents should treat the source as authoritative. Remote reference commits are often missing
+ * from the clone, so distances resolved earlier by resolveCommitDistance are used first.
+ */
+
+import { join } from "node:path";
+import { ReferenceMetaSchema, type Config, type ReferenceOrigin } from "@offworld/types";
+import { getCommitDistance, getCommitSha } from "./clone.js";
+import { getCachedCommitDistance } from "./commit-distance.js";
+import { getMetaPath, loadConfig } from "./config.js";
+import { getRepoSettings } from "./repo-config.js";
+import { getFs } from "./runtime.js";
+
+export interface ReferenceFreshness {
+	/** Commit the reference was generated at */
+	referenceCommit: string | null;
+	/** Clone HEAD */
+	cloneCommit: string | null;
+	/** Commits from the reference commit to HEAD; null when unknown */
+	commitDistance: number | null;
+	/** When the reference was generated */
+	generatedAt: string | null;
+	/** Null for references installed before the origin was recorded */
+	source: ReferenceOrigin | null;
+	maxCommitDistance: number;
+	/** The distance exceeds maxCommitDistance */
+	stale: boolean;
+}
+
+function toFullName(qualifiedName: string): string {
+	const separator = qualifiedName.indexOf(":");
+	return separator === -1 ? qualifiedName : qualifiedName.slice(separator + 1);
+}
+
+function readReferenceMeta(fullName: string) {
+	try {
+		const metaPath = join(getMetaPath(fullName), "meta.json");
+		const parsed = ReferenceMetaSchema.safeParse(
+			JSON.parse(getFs().readFileSync(metaPath, "utf-8")),
+		);
+		return parsed.success ? parsed.data : null;
+	} catch {
+		return null;
+	}
+}
+
+/**
+ * Reference commit, clone HEAD and the distance between them for an installed repo.
+ *
+ * @param qualifiedName - Map key (e.g., "github.com:owner/repo" or "local:name")
+ * @param localPath - Clone directory
+ */
+export function getReferenceFreshness(
+	qualifiedName: string,
+	localPath: string,
+	config: Config = loadConfig(),
+): ReferenceFreshness {
+	const meta = readReferenceMeta(toFullName(qualifiedName));
+	const { maxCommitDistance } = getRepoSettings(qualifiedName, config);
+
+	let cloneCommit: string | null = null;
+	try {
+		cloneCommit = getFs().existsSync(localPath) ? getCommitSha(localPath) : null;
+	} catch {
+		// Not a git repo or git unavailable
+	}
+
+	const referenceCommit = meta?.commitSha ?? null;
+	let commitDistance: number | null = null;
+	if (referenceCommit && cloneCommit) {
+		commitDistance =
+			referenceCommit.slice(0, 7) === cloneCommit.slice(0, 7)
+				? 0
+				: (getCachedCommitDistance(qualifiedName, referenceCommit, cloneCommit)?.behind ??
+					getCommitDistance(localPath, referenceCommit, cloneCommit));
+	}
+
+	return {
+		referenceCommit,
+		cloneCommit,
+		commitDistance,
+		generatedAt: meta?.referenceUpdatedAt ?? null,
+		source: meta?.source ?? null,
+		maxCommitDistance,
+		stale: commitDistance !== null && commitDistance > maxCommitDistance,
+	};
+}
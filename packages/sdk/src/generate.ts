@@ -3,15 +3,26 @@
  * by delegating all codebase exploration to the AI agent via OpenCode.
  */
 
+import type { RepoSource } from "@offworld/types";
 import { streamPrompt, type OpenCodeContext, type StreamPromptOptions } from "./ai/opencode.js";
-import { loadConfig, toReferenceName } from "./config.js";
+import { toReferenceFileName, toReferenceName } from "./config.js";
 import { getCommitSha } from "./clone.js";
+import { formatExamplesForPrompt, readExamplesIndex } from "./examples.js";
+import { runHooks } from "./hooks.js";
+import { buildShortReference, MAX_SHORT_REFERENCE_CHARS } from "./reference.js";
+import { getRepoSettings } from "./repo-config.js";
 
 export interface GenerateReferenceOptions {
 	/** AI provider ID (e.g., "anthropic", "openai"). Defaults to config value. */
 	provider?: string;
 	/** AI model ID. Defaults to config value. */
 	model?: string;
+	/** Extra instructions appended to the prompt. Defaults to the repo's config override. */
+	prompt?: string;
+	/** Source used to match `repos` overrides in config. Defaults to repoName. */
+	source?: RepoSource;
+	/** Include snippets from the repo's examples index (`ow examples build`). Defaults to true. */
+	examples?: boolean;
 	/** Shared OpenCode server context for multi-repo generation */
 	openCodeContext?: OpenCodeContext;
 	/** Debug callback for detailed logging */
@@ -23,11 +34,34 @@ export interface GenerateReferenceOptions {
 export interface GenerateReferenceResult {
 	/** The generated reference markdown content */
 	referenceContent: string;
+	/** Cheat sheet from the same response, or derived from the reference when missing */
+	shortContent: string;
 	/** The commit SHA at the time of generation */
 	commitSha: string;
 }
 
-function createReferenceGenerationPrompt(referenceName: string): string {
+function createReferenceGenerationPrompt(
+	referenceName: string,
+	instructions?: string,
+	examples?: string | null,
+): string {
+	const harvested = examples
+		? `## HARVESTED EXAMPLES
+
+Snippets extracted from this repository's examples, tests and docs, tagged with the symbols they use. Prefer them as the basis for Common Patterns and open the files for context; adapt test code to what a user would write.
+
+${examples}
+
+`
+		: "";
+	const additional = instructions?.trim()
+		? `## ADDITIONAL INSTRUCTIONS FOR THIS REPOSITORY
+
+${instructions.trim()}
+
+`
+		: "";
+
 	return `You are an expert at analyzing open source libraries and producing reference documentation for AI coding agents.
 
 ## PRIMARY GOAL
@@ -157,7 +191,7 @@ Before outputting, verify:
 - [ ] If monorepo: Packages section lists publishable packages with npm names
 - [ ] If monorepo: paths include package directory (e.g., \`packages/core/src/index.ts\`)
 
-Now explore the codebase and generate the reference content.
+${harvested}${additional}Now explore the codebase and generate the reference content.
 
 ## OUTPUT INSTRUCTIONS
 
@@ -169,13 +203,23 @@ After exploring, output your complete reference wrapped in XML tags like this:
 </reference_output>
 \`\`\`
 
+Then output a cheat sheet of the same library, at most ${MAX_SHORT_REFERENCE_CHARS} characters (about 1000 tokens), for agents with a simple question:
+
+\`\`\`
+<short_reference_output>
+# {Library Name} (cheat sheet)
+
+(one-sentence overview, the install command, the 5 most common patterns as short code blocks, and a table of the key exports)
+</short_reference_output>
+\`\`\`
+
 REQUIREMENTS:
 - Start with a level-1 heading with the actual library name (e.g., "# TanStack Query")
 - Include sections: Quick References (table), When to Use (bullets), Installation, Best Practices, Common Patterns (with code), API Quick Reference (table)
 - Minimum 2000 characters of actual content - short or placeholder content will be rejected
 - Fill in real information from your exploration - do not use placeholder text like "{Library Name}"
 - No YAML frontmatter - start directly with the markdown heading
-- Output ONLY the reference inside the tags, no other text
+- Output ONLY the reference and the cheat sheet inside their tags, no other text
 
 Begin exploring now.`;
 }
@@ -293,6 +337,27 @@ function extractReferenceContent(rawResponse: string, onDebug?: (message: string
 	);
 }
 
+/**
+ * Extract the cheat sheet from the last <short_reference_output> block, or null when it is
+ * missing, a template echo, or over MAX_SHORT_REFERENCE_CHARS.
+ */
+function extractShortReferenceContent(rawResponse: string): string | null {
+	const matches = [
+		...rawResponse.matchAll(/<short_reference_output>([\s\S]*?)<\/short_reference_output>/g),
+	];
+	for (const match of matches.reverse()) {
+		const content = match[1]!
+			.trim()
+			.replace(/^```(?:markdown)?\s*\n?/, "")
+			.replace(/\n?```\s*$/, "")
+			.trim();
+		if (content.includes("{Library Name}") || !content.startsWith("#")) continue;
+		if (content.length < 200 || content.length > MAX_SHORT_REFERENCE_CHARS) continue;
+		return `${content}\n`;
+	}
+	return null;
+}
+
 /**
  * Validate extracted reference content has minimum required structure.
  * Throws if content is invalid.
@@ -351,9 +416,10 @@ export async function generateReferenceWithAI(
 	options: GenerateReferenceOptions = {},
 ): Promise<GenerateReferenceResult> {
 	const { provider, model, onDebug, onStream, openCodeContext } = options;
-	const config = loadConfig();
+	const settings = getRepoSettings(options.source ?? repoName);
+	const instructions = options.prompt ?? settings.prompt;
 
-	const [configProvider, configModel] = config.defaultModel?.split("/") ?? [];
+	const [configProvider, configModel] = settings.model?.split("/") ?? [];
 	const aiProvider = provider ?? configProvider;
 	const aiModel = model ?? configModel;
 
@@ -367,8 +433,26 @@ export async function generateReferenceWithAI(
 	const referenceName = toReferenceName(repoName);
 	onDebug?.(`Reference name: ${referenceName}`);
 
+	if (settings.matched.length > 0) {
+		onDebug?.(`Repo overrides: ${settings.matched.join(", ")}`);
+	}
+
+	const examplesIndex = options.examples === false ? null : readExamplesIndex(repoName);
+	const examples = examplesIndex ? formatExamplesForPrompt(examplesIndex) : null;
+	if (examples) {
+		onDebug?.(`Including harvested examples (${examplesIndex!.examples.length} indexed)`);
+	}
+
+	const hookPayload = {
+		repo: options.source?.qualifiedName ?? repoName,
+		fullName: repoName,
+		repoPath,
+		commitSha,
+	};
+	await runHooks("preGenerate", hookPayload);
+
 	const promptOptions: StreamPromptOptions = {
-		prompt: createReferenceGenerationPrompt(referenceName),
+		prompt: createReferenceGenerationPrompt(referenceName, instructions, examples),
 		cwd: repoPath,
 		provider: aiProvider,
 		model: aiModel,
@@ -381,11 +465,25 @@ export async function generateReferenceWithAI(
 
 	onDebug?.(`Generation complete (${result.durationMs}ms, ${result.text.length} chars)`);
 
-	const referenceContent = extractReferenceContent(result.text, onDebug);
+	const referenceContent = extractReferenceContent(
+		result.text.replace(/<short_reference_output>[\s\S]*?(<\/short_reference_output>|$)/g, ""),
+		onDebug,
+	);
 	onDebug?.(`Extracted reference content (${referenceContent.length} chars)`);
 
+	const shortContent = extractShortReferenceContent(result.text);
+	onDebug?.(
+		shortContent
+			? `Extracted cheat sheet (${shortContent.length} chars)`
+			: "No usable cheat sheet in response, deriving one from the reference",
+	);
+
+	await runHooks("postGenerate", hookPayload);
+
 	return {
 		referenceContent,
+		shortContent:
+			shortContent ?? buildShortReference(referenceContent, toReferenceFileName(repoName)),
 		commitSha,
 	};
 }
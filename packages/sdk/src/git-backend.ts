@@ -0,0 +1,192 @@
+/**
+ * Git backends
+ *
+ * clone.ts talks to git through the GitBackend interface. The CLI backend (in clone.ts) shells
+ * out to the system git binary; the JS backend here uses isomorphic-git for network operations
+ * and reads the object database directly for history queries. The JS backend is selected
+ * automatically when the git binary is missing.
+ */
+
+import { join } from "node:path";
+import { GitObjectStore } from "./git-objects.js";
+import { getFs } from "./runtime.js";
+
+export interface GitCloneOptions {
+	/** Clone a specific branch */
+	branch?: string;
+	/** Restrict the checkout to these paths (sparse checkout) */
+	sparsePaths?: string[];
+}
+
+export interface GitBackend {
+	/** Backend identifier, e.g. "cli" or "js" */
+	readonly name: string;
+	clone(url: string, dir: string, options?: GitCloneOptions): Promise<void>;
+	/** Fetch from the default remote */
+	fetch(dir: string): Promise<void>;
+	/**
+	 * Fetch one commit by SHA from the default remote (the server must allow it, as GitHub does).
+	 * Optional: backends without it can't resolve commits missing from the clone.
+	 */
+	fetchCommit?(dir: string, sha: string): Promise<void>;
+	/** Fast-forward the current branch to its upstream; fails if the branch has diverged */
+	fastForward(dir: string): Promise<void>;
+	/** Check out a tag or commit with a detached HEAD */
+	checkout(dir: string, ref: string): Promise<void>;
+	/** URL of the default remote (origin) */
+	getRemoteUrl?(dir: string): string;
+	/** Point the default remote (origin) at a new URL */
+	setRemoteUrl?(dir: string, url: string): Promise<void>;
+	/** Resolve a ref or (short) SHA to a full commit SHA */
+	revParse(dir: string, ref: string): string;
+	/** Whether the object exists locally */
+	hasCommit(dir: string, sha: string): boolean;
+	/** Number of commits in `from..to` */
+	countCommits(dir: string, from: string, to: string): number;
+}
+
+const ISOMORPHIC_GIT_MISSING =
+	"git is not installed and isomorphic-git is unavailable. Install git or run: bun add isomorphic-git";
+
+type IsomorphicGit = typeof import("isomorphic-git");
+type IsomorphicHttp = typeof import("isomorphic-git/http/node").default;
+type NodeFs = typeof import("node:fs");
+
+interface IsomorphicGitModules {
+	git: IsomorphicGit;
+	http: IsomorphicHttp;
+	/** isomorphic-git needs the promise API, so it always uses node:fs */
+	fs: NodeFs;
+}
+
+let cachedModules: IsomorphicGitModules | null = null;
+
+async function loadIsomorphicGit(): Promise<IsomorphicGitModules> {
+	if (cachedModules) return cachedModules;
+
+	try {
+		const [git, http, fs] = await Promise.all([
+			import("isomorphic-git"),
+			import("isomorphic-git/http/node"),
+			import("node:fs"),
+		]);
+		cachedModules = { git, http: http.default, fs };
+		return cachedModules;
+	} catch {
+		throw new Error(ISOMORPHIC_GIT_MISSING);
+	}
+}
+
+function sparseCheckoutPath(dir: string): string {
+	return join(dir, ".git", "info", "sparse-checkout");
+}
+
+function writeSparseCheckout(dir: string, paths: string[]): void {
+	const fs = getFs();
+	fs.mkdirSync(join(dir, ".git", "info"), { recursive: true });
+	fs.writeFileSync(sparseCheckoutPath(dir), `${paths.map((path) => `/${path}`).join("\n")}\n`);
+}
+
+/**
+ * Read sparse paths in the format written by writeSparseCheckout (and git cone mode).
+ */
+function readSparseCheckout(dir: string): string[] | undefined {
+	const fs = getFs();
+	const path = sparseCheckoutPath(dir);
+	if (!fs.existsSync(path)) return undefined;
+
+	const paths = fs
+		.readFileSync(path, "utf-8")
+		.split("\n")
+		.map((line) => line.trim())
+		.filter((line) => line && !line.startsWith("!") && !line.startsWith("#") && line !== "/*")
+		.map((line) => line.replace(/^\/+|\/+$/g, ""))
+		.filter(Boolean);
+	return paths.length > 0 ? paths : undefined;
+}
+
+/**
+ * Pure-JS backend. Network operations require the optional isomorphic-git dependency;
+ * rev-parse, object lookups and commit counts read .git directly.
+ */
+export function createJsGitBackend(): GitBackend {
+	return {
+		name: "js",
+
+		async clone(url, dir, options = {}) {
+			const { git, http, fs } = await loadIsomorphicGit();
+			const { branch, sparsePaths } = options;
+
+			await git.clone({ fs, http, dir, url, ref: branch, singleBranch: true, noCheckout: true });
+			if (sparsePaths) {
+				writeSparseCheckout(dir, sparsePaths);
+			}
+			await git.checkout({ fs, dir, ref: branch, filepaths: sparsePaths });
+		},
+
+		async fetch(dir) {
+			const { git, http, fs } = await loadIsomorphicGit();
+			await git.fetch({ fs, http, dir, singleBranch: true });
+		},
+
+		async fastForward(dir) {
+			const { git, http, fs } = await loadIsomorphicGit();
+			const branch = await git.currentBranch({ fs, dir });
+			if (!branch) {
+				throw new Error("fatal: not on a branch; cannot fast-forward");
+			}
+
+			await git.fetch({ fs, http, dir, ref: branch, singleBranch: true });
+			const local = await git.resolveRef({ fs, dir, ref: `refs/heads/${branch}` });
+			const remote = await git.resolveRef({ fs, dir, ref: `refs/remotes/origin/${branch}` });
+			if (local === remote) return;
+
+			const isFastForward = await git.isDescendent({
+				fs,
+				dir,
+				oid: remote,
+				ancestor: local,
+				depth: -1,
+			});
+			if (!isFastForward) {
+				throw new Error("fatal: Not possible to fast-forward, aborting.");
+			}
+
+			await git.writeRef({ fs, dir, ref: `refs/heads/${branch}`, value: remote, force: true });
+			await git.checkout({ fs, dir, ref: branch, filepaths: readSparseCheckout(dir) });
+		},
+
+		async checkout(dir, ref) {
+			const { git, fs } = await loadIsomorphicGit();
+			const oid = new GitObjectStore(dir).resolve(ref);
+			await git.checkout({ fs, dir, ref: oid, filepaths: readSparseCheckout(dir) });
+		},
+
+		getRemoteUrl(dir) {
+			const config = getFs().readFileSync(join(dir, ".git", "config"), "utf-8");
+			const section = config.match(/\[remote "origin"\]([^[]*)/)?.[1];
+			const url = section?.match(/^\s*url\s*=\s*(.+)$/m)?.[1]?.trim();
+			if (!url) {
+				throw new Error("fatal: No such remote 'origin'");
+			}
+			return url;
+		},
+
+		async setRemoteUrl(dir, url) {
+			const { git, fs } = await loadIsomorphicGit();
+			await git.setConfig({ fs, dir, path: "remote.origin.url", value: url });
+		},
+
+		revParse(dir, ref) {
+			return new GitObjectStore(dir).resolve(ref);
+		},
+
+		hasCommit(dir, sha) {
+			return new GitObjectStore(dir).has(sha);
+		},
+
+		countCommits(dir, from, to) {
+			return new GitObjectStore(dir).countCommits(from, to);
+		},
+	};
+}
@@ -0,0 +1,290 @@
+/**
+ * Project git hooks
+ *
+ * `post-merge` and `post-checkout` hooks that run `ow project sync` in the background when a
+ * manifest or lockfile changed between the old and new HEAD. Hooks are written into husky or
+ * lefthook config when the project uses one, otherwise into the git hooks directory. The
+ * offworld section is delimited by markers so it can coexist with other hook commands.
+ */
+
+import { execFileSync } from "node:child_process";
+import { basename, dirname, isAbsolute, join } from "node:path";
+import { getFs } from "./runtime.js";
+
+export type GitHookManager = "git" | "husky" | "lefthook";
+
+export const PROJECT_GIT_HOOKS = ["post-merge", "post-checkout"] as const;
+
+export type ProjectGitHook = (typeof PROJECT_GIT_HOOKS)[number];
+
+/** Manifests and lockfiles whose changes trigger a project sync */
+export const MANIFEST_FILES = [
+	"package.json",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"pnpm-lock.yaml",
+	"pnpm-workspace.yaml",
+	"yarn.lock",
+	"bun.lock",
+	"bun.lockb",
+	"pyproject.toml",
+	"requirements.txt",
+	"poetry.lock",
+	"uv.lock",
+	"Pipfile.lock",
+	"Cargo.toml",
+	"Cargo.lock",
+	"go.mod",
+	"go.sum",
+];
+
+const LEFTHOOK_CONFIGS = ["lefthook.yml", "lefthook.yaml", ".lefthook.yml", ".lefthook.yaml"];
+
+const BLOCK_START = "# >>> offworld project sync >>>";
+const BLOCK_END = "# <<< offworld project sync <<<";
+
+export interface ProjectHooksResult {
+	manager: GitHookManager;
+	/** Files that were written (install) or edited (uninstall) */
+	files: string[];
+}
+
+export interface ProjectHooksStatus {
+	manager: GitHookManager;
+	installed: ProjectGitHook[];
+}
+
+export class GitHooksError extends Error {
+	constructor(message: string) {
+		super(message);
+		this.name = "GitHooksError";
+	}
+}
+
+/**
+ * Detect which hook manager a project uses: husky (`.husky/`), lefthook (`lefthook.yml`)
+ * or plain git.
+ */
+export function detectHookManager(projectRoot: string): GitHookManager {
+	if (getFs().existsSync(join(projectRoot, ".husky"))) return "husky";
+	if (findLefthookConfig(projectRoot)) return "lefthook";
+	return "git";
+}
+
+function findLefthookConfig(projectRoot: string): string | null {
+	const fs = getFs();
+	for (const name of LEFTHOOK_CONFIGS) {
+		const path = join(projectRoot, name);
+		if (fs.existsSync(path)) return path;
+	}
+	return null;
+}
+
+function getGitHooksDir(projectRoot: string): string {
+	let hooksDir: string;
+	try {
+		hooksDir = execFileSync("git", ["rev-parse", "--git-path", "hooks"], {
+			cwd: projectRoot,
+			encoding: "utf-8",
+			stdio: ["ignore", "pipe", "ignore"],
+		}).trim();
+	} catch {
+		throw new GitHooksError(`Not a git repository: ${projectRoot}`);
+	}
+	return isAbsolute(hooksDir) ? hooksDir : join(projectRoot, hooksDir);
+}
+
+/**
+ * Shell command for a hook. Git passes post-checkout `<old> <new> <branch-flag>`; file
+ * checkouts (flag 0) are ignored. post-merge compares against ORIG_HEAD.
+ */
+function syncCommand(hook: ProjectGitHook, args: [string, string, string]): string {
+	const sync = "ow project sync --quiet";
+	const background = ">/dev/null 2>&1 &";
+	if (hook === "post-merge") {
+		return `${sync} --from "$(git rev-parse -q --verify ORIG_HEAD)" --to HEAD ${background}`;
+	}
+	return `[ "${args[2]}" = "1" ] && ${sync} --from ${args[0]} --to ${args[1]} ${background}`;
+}
+
+function shellBlock(hook: ProjectGitHook): string {
+	return [
+		BLOCK_START,
+		"if command -v ow >/dev/null 2>&1; then",
+		`\t${syncCommand(hook, ['"$1"', '"$2"', "$3"])}`,
+		"fi",
+		BLOCK_END,
+	].join("\n");
+}
+
+function lefthookBlock(hook: ProjectGitHook): string {
+	return [
+		BLOCK_START,
+		`${hook}:`,
+		"  commands:",
+		"    offworld:",
+		`      run: '${syncCommand(hook, ["{1}", "{2}", "{3}"])}'`,
+		BLOCK_END,
+	].join("\n");
+}
+
+function stripBlock(content: string): string {
+	const pattern = new RegExp(
+		`\\n?${escapeRegExp(BLOCK_START)}[\\s\\S]*?${escapeRegExp(BLOCK_END)}\\n?`,
+		"g",
+	);
+	return content.replace(pattern, "\n").replace(/\n{3,}/g, "\n\n");
+}
+
+function escapeRegExp(value: string): string {
+	return value.replace(/[.*+?^${}()|[\]\\]/g, "\\$&");
+}
+
+function isShellScript(content: string): boolean {
+	const shebang = content.startsWith("#!") ? content.slice(0, content.indexOf("\n")) : "";
+	return !shebang || /\b(ba|z|da)?sh\b/.test(shebang);
+}
+
+function writeShellHook(path: string, hook: ProjectGitHook): void {
+	const fs = getFs();
+	const existing = fs.existsSync(path) ? fs.readFileSync(path, "utf-8") : "#!/bin/sh\n";
+	if (!isShellScript(existing)) {
+		throw new GitHooksError(`${path} is not a shell script; add "ow project sync" to it manually`);
+	}
+
+	const base = stripBlock(existing).trimEnd();
+	fs.writeFileSync(path, `${base}\n\n${shellBlock(hook)}\n`, "utf-8");
+	fs.chmodSync(path, 0o755);
+}
+
+function installLefthook(configPath: string): void {
+	const fs = getFs();
+	const content = stripBlock(fs.readFileSync(configPath, "utf-8"));
+	for (const hook of PROJECT_GIT_HOOKS) {
+		if (new RegExp(`^${hook}:`, "m").test(content)) {
+			throw new GitHooksError(
+				`${basename(configPath)} already defines ${hook}. Add an "offworld" command running ` +
+					`"ow project sync --quiet --from <old> --to <new>" to it manually.`,
+			);
+		}
+	}
+
+	const blocks = PROJECT_GIT_HOOKS.map(lefthookBlock).join("\n");
+	fs.writeFileSync(configPath, `${content.trimEnd()}\n\n${blocks}\n`, "utf-8");
+}
+
+function shellHookPaths(projectRoot: string, manager: "git" | "husky"): string[] {
+	const dir = manager === "husky" ? join(projectRoot, ".husky") : getGitHooksDir(projectRoot);
+	return PROJECT_GIT_HOOKS.map((hook) => join(dir, hook));
+}
+
+/**
+ * Install the post-merge/post-checkout hooks. Re-running replaces the offworld section.
+ *
+ * @throws GitHooksError if the project is not a git repository or an existing hook can't be
+ * extended safely
+ */
+export function installProjectHooks(
+	projectRoot: string,
+	manager: GitHookManager = detectHookManager(projectRoot),
+): ProjectHooksResult {
+	const fs = getFs();
+	if (manager === "lefthook") {
+		const configPath = findLefthookConfig(projectRoot) ?? join(projectRoot, "lefthook.yml");
+		if (!fs.existsSync(configPath)) fs.writeFileSync(configPath, "", "utf-8");
+		installLefthook(configPath);
+		return { manager, files: [configPath] };
+	}
+
+	const paths = shellHookPaths(projectRoot, manager);
+	for (const [index, path] of paths.entries()) {
+		fs.mkdirSync(dirname(path), { recursive: true });
+		writeShellHook(path, PROJECT_GIT_HOOKS[index]!);
+	}
+	return { manager, files: paths };
+}
+
+/**
+ * Remove the offworld section from project hooks. Hook files left with only a shebang are
+ * deleted.
+ */
+export function uninstallProjectHooks(
+	projectRoot: string,
+	manager: GitHookManager = detectHookManager(projectRoot),
+): ProjectHooksResult {
+	const fs = getFs();
+	const candidates =
+		manager === "lefthook"
+			? [findLefthookConfig(projectRoot)].filter((path): path is string => path !== null)
+			: shellHookPaths(projectRoot, manager);
+
+	const files: string[] = [];
+	for (const path of candidates) {
+		if (!fs.existsSync(path)) continue;
+		const content = fs.readFileSync(path, "utf-8");
+		if (!content.includes(BLOCK_START)) continue;
+
+		const remaining = stripBlock(content).trim();
+		if (manager !== "lefthook" && (!remaining || /^#![^\n]*$/.test(remaining))) {
+			fs.rmSync(path, { force: true });
+		} else {
+			fs.writeFileSync(path, `${remaining}\n`, "utf-8");
+		}
+		files.push(path);
+	}
+	return { manager, files };
+}
+
+/**
+ * Which offworld hooks are installed for the project's hook manager.
+ */
+export function getProjectHooksStatus(projectRoot: string): ProjectHooksStatus {
+	const fs = getFs();
+	const manager = detectHookManager(projectRoot);
+
+	if (manager === "lefthook") {
+		const configPath = findLefthookConfig(projectRoot);
+		const content = configPath ? fs.readFileSync(configPath, "utf-8") : "";
+		const installed = PROJECT_GIT_HOOKS.filter((hook) =>
+			content.includes(`${BLOCK_START}\n${hook}:`),
+		);
+		return { manager, installed };
+	}
+
+	let paths: string[];
+	try {
+		paths = shellHookPaths(projectRoot, manager);
+	} catch {
+		return { manager, installed: [] };
+	}
+	const installed = PROJECT_GIT_HOOKS.filter((_, index) => {
+		const path = paths[index]!;
+		return fs.existsSync(path) && fs.readFileSync(path, "utf-8").includes(BLOCK_START);
+	});
+	return { manager, installed };
+}
+
+/**
+ * Files changed between two revisions, or null if git can't tell (unknown revision, initial
+ * checkout, not a repository).
+ */
+export function getChangedFiles(projectRoot: string, from: string, to = "HEAD"): string[] | null {
+	if (!from || /^0+$/.test(from)) return null;
+	try {
+		const output = execFileSync("git", ["diff", "--name-only", from, to], {
+			cwd: projectRoot,
+			encoding: "utf-8",
+			stdio: ["ignore", "pipe", "ignore"],
+		});
+		return output.split("\n").filter(Boolean);
+	} catch {
+		return null;
+	}
+}
+
+/**
+ * Whether any of the paths is a dependency manifest or lockfile.
+ */
+export function hasManifestChanges(paths: string[]): boolean {
+	return paths.some((path) => MANIFEST_FILES.includes(basename(path)));
+}
@@ -0,0 +1,537 @@
+/**
+ * Synchronous git object database reader
+ *
+ * Resolves refs and walks commit history straight from .git (loose objects and v2 packfiles),
+ * so history queries work without a git binary and without going async.
+ */
+
+import { join, resolve } from "node:path";
+import { inflateSync } from "node:zlib";
+import { getFs } from "./runtime.js";
+
+export type GitObjectType = "commit" | "tree" | "blob" | "tag";
+
+export interface GitObject {
+	type: GitObjectType;
+	content: Buffer;
+}
+
+const PACK_OBJECT_TYPES: Record<number, GitObjectType> = {
+	1: "commit",
+	2: "tree",
+	3: "blob",
+	4: "tag",
+};
+const OFS_DELTA = 6;
+const REF_DELTA = 7;
+const IDX_MAGIC = 0xff744f63;
+const FULL_SHA = /^[0-9a-f]{40}$/;
+const SHORT_SHA = /^[0-9a-f]{4,39}$/;
+/** Type, size and delta base of a packed object fit in this many bytes */
+const PACK_HEADER_BYTES = 64;
+/** Decompressed objects kept per store by default */
+export const DEFAULT_OBJECT_CACHE_BYTES = 32 * 1024 * 1024;
+
+/** Reachable from the commit being counted */
+const INCLUDED = 1;
+/** Reachable from the commit counted from */
+const EXCLUDED = 2;
+
+interface Pack {
+	idx: Buffer;
+	packPath: string;
+	/** Packfile size in bytes */
+	size: number;
+	count: number;
+}
+
+interface CommitInfo {
+	parents: string[];
+	/** Committer timestamp in seconds */
+	time: number;
+}
+
+export interface GitObjectStoreOptions {
+	/** Upper bound for cached decompressed objects, in bytes */
+	maxCacheBytes?: number;
+}
+
+/**
+ * Locate the git directory for a working tree (handles .git files from worktrees/submodules).
+ */
+export function findGitDir(repoPath: string): string {
+	const fs = getFs();
+	const dotGit = join(repoPath, ".git");
+	if (fs.existsSync(dotGit)) {
+		if (fs.statSync(dotGit).isDirectory()) return dotGit;
+		const pointer = fs.readFileSync(dotGit, "utf-8").trim();
+		if (pointer.startsWith("gitdir:")) {
+			return resolve(repoPath, pointer.slice("gitdir:".length).trim());
+		}
+	}
+	if (fs.existsSync(join(repoPath, "HEAD")) && fs.existsSync(join(repoPath, "objects"))) {
+		return repoPath;
+	}
+	throw new Error(`fatal: not a git repository: ${repoPath}`);
+}
+
+/**
+ * Apply a git delta to its base object.
+ */
+export function applyDelta(base: Buffer, delta: Buffer): Buffer {
+	let pos = 0;
+	const readSize = (): number => {
+		let size = 0;
+		let shift = 0;
+		let byte: number;
+		do {
+			byte = delta[pos++]!;
+			size += (byte & 0x7f) * 2 ** shift;
+			shift += 7;
+		} while (byte & 0x80);
+		return size;
+	};
+
+	const baseSize = readSize();
+	if (baseSize !== base.length) {
+		throw new Error("Corrupt delta: base size mismatch");
+	}
+
+	const out = Buffer.alloc(readSize());
+	let outPos = 0;
+	while (pos < delta.length) {
+		const op = delta[pos++]!;
+		if (op & 0x80) {
+			let offset = 0;
+			let size = 0;
+			for (let i = 0; i < 4; i++) {
+				if (op & (1 << i)) offset += delta[pos++]! * 2 ** (8 * i);
+			}
+			for (let i = 0; i < 3; i++) {
+				if (op & (0x10 << i)) size += delta[pos++]! * 2 ** (8 * i);
+			}
+			if (size === 0) size = 0x10000;
+			base.copy(out, outPos, offset, offset + size);
+			outPos += size;
+		} else if (op > 0) {
+			delta.copy(out, outPos, pos, pos + op);
+			pos += op;
+			outPos += op;
+		} else {
+			throw new Error("Corrupt delta: invalid opcode");
+		}
+	}
+
+	return out;
+}
+
+/**
+ * Read-only view over a repository's refs and objects.
+ * Pack indexes are loaded lazily; packed objects are read at their offset and the most recently
+ * used ones are cached up to maxCacheBytes.
+ */
+export class GitObjectStore {
+	readonly gitDir: string;
+	private packs: Pack[] | null = null;
+	private packedRefs: Map<string, string> | null = null;
+	private readonly packCache = new Map<string, GitObject>();
+	private packCacheBytes = 0;
+	private readonly maxCacheBytes: number;
+
+	constructor(repoPath: string, options: GitObjectStoreOptions = {}) {
+		this.gitDir = findGitDir(repoPath);
+		this.maxCacheBytes = options.maxCacheBytes ?? DEFAULT_OBJECT_CACHE_BYTES;
+	}
+
+	/**
+	 * Resolve a ref, branch, tag or (short) SHA to a full commit SHA.
+	 */
+	resolve(ref: string): string {
+		const sha = this.resolveObject(ref);
+		return this.peel(sha);
+	}
+
+	/**
+	 * Resolve a full ref name (e.g. "HEAD") from ref files alone, without reading objects.
+	 */
+	resolveRef(name: string): string | null {
+		return this.readRef(name);
+	}
+
+	/** Whether the object exists in this repository */
+	has(ref: string): boolean {
+		try {
+			this.resolve(ref);
+			return true;
+		} catch {
+			return false;
+		}
+	}
+
+	/**
+	 * Count commits reachable from `to` but not from `from` (git rev-list --count from..to).
+	 *
+	 * Like git, both tips are walked newest first and the walk stops once every queued commit is
+	 * reachable from `from` and older than every counted commit, so shared history below the
+	 * fork point isn't read. Commits dated before their parents (clock skew) can make the walk
+	 * run further, never stop it early.
+	 */
+	countCommits(from: string, to: string): number {
+		const flags = new Map<string, number>();
+		const commits = new Map<string, CommitInfo>();
+		// Sorted oldest first; the newest commit is popped from the end
+		const queue: string[] = [];
+
+		const push = (sha: string, flag: number) => {
+			const previous = flags.get(sha) ?? 0;
+			if ((previous | flag) === previous) return;
+			flags.set(sha, previous | flag);
+
+			let commit = commits.get(sha);
+			if (!commit) {
+				try {
+					commit = this.readCommit(sha);
+				} catch (error) {
+					// Missing parents (shallow history) bound the excluded side
+					if (flag & INCLUDED) throw error;
+					return;
+				}
+				commits.set(sha, commit);
+			}
+			const time = commit.time;
+			let low = 0;
+			let high = queue.length;
+			while (low < high) {
+				const mid = (low + high) >>> 1;
+				if (commits.get(queue[mid]!)!.time <= time) low = mid + 1;
+				else high = mid;
+			}
+			queue.splice(low, 0, sha);
+		};
+
+		push(this.resolve(from), EXCLUDED);
+		push(this.resolve(to), INCLUDED);
+
+		let oldestCounted = Number.POSITIVE_INFINITY;
+		while (queue.length > 0) {
+			const newest = commits.get(queue[queue.length - 1]!)!.time;
+			const onlyExcluded = queue.every((sha) => flags.get(sha)! & EXCLUDED);
+			if (onlyExcluded && newest < oldestCounted) break;
+
+			const sha = queue.pop()!;
+			const flag = flags.get(sha)!;
+			const commit = commits.get(sha)!;
+			if (flag === INCLUDED) oldestCounted = Math.min(oldestCounted, commit.time);
+			for (const parent of commit.parents) push(parent, flag);
+		}
+
+		let count = 0;
+		for (const flag of flags.values()) {
+			if (flag === INCLUDED) count++;
+		}
+		return count;
+	}
+
+	/**
+	 * Read and decompress an object by full SHA.
+	 */
+	read(sha: string): GitObject {
+		const loose = this.readLoose(sha);
+		if (loose) return loose;
+
+		for (const pack of this.getPacks()) {
+			const index = this.findInIndex(pack, sha);
+			if (index !== null) {
+				return this.readPacked(pack, this.packOffset(pack, index));
+			}
+		}
+
+		throw new Error(`fatal: object not found: ${sha}`);
+	}
+
+	private readCommit(sha: string): CommitInfo {
+		const object = this.read(sha);
+		if (object.type !== "commit") {
+			throw new Error(`fatal: ${sha} is a ${object.type}, not a commit`);
+		}
+
+		const commit: CommitInfo = { parents: [], time: 0 };
+		for (const line of object.content.toString("utf-8").split("\n")) {
+			if (line === "") break;
+			if (line.startsWith("parent ")) commit.parents.push(line.slice(7));
+			if (line.startsWith("committer ")) {
+				commit.time = Number(/ (\d+) [+-]\d{4}$/.exec(line)?.[1] ?? 0);
+			}
+		}
+		return commit;
+	}
+
+	private peel(sha: string): string {
+		let current = sha;
+		for (let depth = 0; depth < 10; depth++) {
+			const object = this.read(current);
+			if (object.type !== "tag") return current;
+			const target = /^object ([0-9a-f]{40})$/m.exec(object.content.toString("utf-8"));
+			if (!target?.[1]) break;
+			current = target[1];
+		}
+		return current;
+	}
+
+	private resolveObject(ref: string): string {
+		if (FULL_SHA.test(ref)) return ref;
+
+		const candidates =
+			ref === "HEAD" || ref.startsWith("refs/")
+				? [ref]
+				: [
+						`refs/heads/${ref}`,
+						`refs/tags/${ref}`,
+						`refs/remotes/${ref}`,
+						`refs/remotes/${ref}/HEAD`,
+					];
+
+		for (const candidate of candidates) {
+			const value = this.readRef(candidate);
+			if (value) return value;
+		}
+
+		if (SHORT_SHA.test(ref)) {
+			const match = this.expandShortSha(ref);
+			if (match) return match;
+		}
+
+		throw new Error(`fatal: ambiguous argument '${ref}': unknown revision`);
+	}
+
+	private readRef(name: string, depth = 0): string | null {
+		if (depth > 5) return null;
+		const fs = getFs();
+		const refPath = join(this.gitDir, name);
+
+		let value: string | null = null;
+		try {
+			if (fs.existsSync(refPath) && fs.statSync(refPath).isFile()) {
+				value = fs.readFileSync(refPath, "utf-8").trim();
+			}
+		} catch {
+			value = null;
+		}
+		value ??= this.getPackedRefs().get(name) ?? null;
+		if (!value) return null;
+
+		if (value.startsWith("ref:")) {
+			return this.readRef(value.slice(4).trim(), depth + 1);
+		}
+		return FULL_SHA.test(value) ? value : null;
+	}
+
+	private getPackedRefs(): Map<string, string> {
+		if (this.packedRefs) return this.packedRefs;
+
+		const fs = getFs();
+		const refs = new Map<string, string>();
+		const packedPath = join(this.gitDir, "packed-refs");
+		if (fs.existsSync(packedPath)) {
+			for (const line of fs.readFileSync(packedPath, "utf-8").split("\n")) {
+				if (!line || line.startsWith("#") || line.startsWith("^")) continue;
+				const [sha, name] = line.split(" ");
+				if (sha && name) refs.set(name, sha);
+			}
+		}
+
+		this.packedRefs = refs;
+		return refs;
+	}
+
+	private expandShortSha(prefix: string): string | null {
+		const fs = getFs();
+		const matches = new Set<string>();
+
+		const looseDir = join(this.gitDir, "objects", prefix.slice(0, 2));
+		if (fs.existsSync(looseDir)) {
+			for (const name of fs.readdirSync(looseDir)) {
+				const sha = `${prefix.slice(0, 2)}${name}`;
+				if (sha.startsWith(prefix)) matches.add(sha);
+			}
+		}
+
+		for (const pack of this.getPacks()) {
+			let index = this.lowerBound(pack, prefix);
+			while (index < pack.count) {
+				const sha = this.indexSha(pack, index);
+				if (!sha.startsWith(prefix)) break;
+				matches.add(sha);
+				index++;
+			}
+		}
+
+		if (matches.size > 1) {
+			throw new Error(`fatal: short SHA ${prefix} is ambiguous`);
+		}
+		return matches.values().next().value ?? null;
+	}
+
+	private readLoose(sha: string): GitObject | null {
+		const fs = getFs();
+		const objectPath = join(this.gitDir, "objects", sha.slice(0, 2), sha.slice(2));
+		if (!fs.existsSync(objectPath)) return null;
+
+		const raw = inflateSync(fs.readFileSync(objectPath));
+		const nul = raw.indexOf(0);
+		const [type] = raw.subarray(0, nul).toString("utf-8").split(" ");
+		return { type: type as GitObjectType, content: raw.subarray(nul + 1) };
+	}
+
+	private getPacks(): Pack[] {
+		if (this.packs) return this.packs;
+
+		const fs = getFs();
+		const packDir = join(this.gitDir, "objects", "pack");
+		const packs: Pack[] = [];
+		if (fs.existsSync(packDir)) {
+			for (const name of fs.readdirSync(packDir)) {
+				if (!name.endsWith(".idx")) continue;
+				const idx = fs.readFileSync(join(packDir, name));
+				if (idx.readUInt32BE(0) !== IDX_MAGIC || idx.readUInt32BE(4) !== 2) continue;
+				const packPath = join(packDir, name.replace(/\.idx$/, ".pack"));
+				if (!fs.existsSync(packPath)) continue;
+				packs.push({
+					idx,
+					packPath,
+					size: fs.statSync(packPath).size,
+					count: idx.readUInt32BE(8 + 255 * 4),
+				});
+			}
+		}
+
+		this.packs = packs;
+		return packs;
+	}
+
+	private indexSha(pack: Pack, index: number): string {
+		const start = 8 + 256 * 4 + index * 20;
+		return pack.idx.toString("hex", start, start + 20);
+	}
+
+	private lowerBound(pack: Pack, sha: string): number {
+		let low = 0;
+		let high = pack.count;
+		while (low < high) {
+			const mid = (low + high) >>> 1;
+			if (this.indexSha(pack, mid) < sha) low = mid + 1;
+			else high = mid;
+		}
+		return low;
+	}
+
+	private findInIndex(pack: Pack, sha: string): number | null {
+		const index = this.lowerBound(pack, sha);
+		return index < pack.count && this.indexSha(pack, index) === sha ? index : null;
+	}
+
+	private packOffset(pack: Pack, index: number): number {
+		const offsetsStart = 8 + 256 * 4 + pack.count * 24;
+		const offset = pack.idx.readUInt32BE(offsetsStart + index * 4);
+		if (!(offset & 0x80000000)) return offset;
+
+		const largeStart = offsetsStart + pack.count * 4;
+		return Number(pack.idx.readBigUInt64BE(largeStart + (offset & 0x7fffffff) * 8));
+	}
+
+	private readPacked(pack: Pack, offset: number): GitObject {
+		const key = `${pack.packPath}:${offset}`;
+		const cached = this.packCache.get(key);
+		if (cached) {
+			// Refresh its place in the eviction order
+			this.packCache.delete(key);
+			this.packCache.set(key, cached);
+			return cached;
+		}
+
+		const header = this.readPackBytes(pack, offset, PACK_HEADER_BYTES);
+		let pos = 0;
+		let byte = header[pos++]!;
+		const type = (byte >> 4) & 0x7;
+		let size = byte & 0x0f;
+		let shift = 4;
+		while (byte & 0x80) {
+			byte = header[pos++]!;
+			size += (byte & 0x7f) * 2 ** shift;
+			shift += 7;
+		}
+
+		let object: GitObject;
+		if (type === OFS_DELTA) {
+			byte = header[pos++]!;
+			let baseDistance = byte & 0x7f;
+			while (byte & 0x80) {
+				byte = header[pos++]!;
+				baseDistance = (baseDistance + 1) * 128 + (byte & 0x7f);
+			}
+			const base = this.readPacked(pack, offset - baseDistance);
+			const delta = this.inflatePacked(pack, offset + pos, size);
+			object = { type: base.type, content: applyDelta(base.content, delta) };
+		} else if (type === REF_DELTA) {
+			const base = this.read(header.toString("hex", pos, pos + 20));
+			const delta = this.inflatePacked(pack, offset + pos + 20, size);
+			object = { type: base.type, content: applyDelta(base.content, delta) };
+		} else {
+			const objectType = PACK_OBJECT_TYPES[type];
+			if (!objectType) {
+				throw new Error(`Corrupt packfile: unknown object type ${type} at ${offset}`);
+			}
+			object = { type: objectType, content: this.inflatePacked(pack, offset + pos, size) };
+		}
+
+		this.cachePacked(key, object);
+		return object;
+	}
+
+	private cachePacked(key: string, object: GitObject): void {
+		const bytes = object.content.length;
+		if (bytes > this.maxCacheBytes) return;
+
+		this.packCache.set(key, object);
+		this.packCacheBytes += bytes;
+		for (const [oldKey, old] of this.packCache) {
+			if (this.packCacheBytes <= this.maxCacheBytes) break;
+			this.packCache.delete(oldKey);
+			this.packCacheBytes -= old.content.length;
+		}
+	}
+
+	/**
+	 * Inflate the zlib stream at `position`. Its compressed length isn't stored, so read what
+	 * zlib needs at most for `size` bytes of output and grow the read if the stream is cut off.
+	 */
+	private inflatePacked(pack: Pack, position: number, size: number): Buffer {
+		let length = size + (size >> 5) + 64;
+		for (;;) {
+			const input = this.readPackBytes(pack, position, length);
+			try {
+				return inflateSync(input);
+			} catch (error) {
+				if (position + input.length >= pack.size) throw error;
+				length *= 2;
+			}
+		}
+	}
+
+	private readPackBytes(pack: Pack, position: number, length: number): Buffer {
+		const fs = getFs();
+		const buffer = Buffer.alloc(Math.max(0, Math.min(length, pack.size - position)));
+		const fd = fs.openSync(pack.packPath, "r");
+		try {
+			let read = 0;
+			while (read < buffer.length) {
+				const bytes = fs.readSync(fd, buffer, read, buffer.length - read, position + read);
+				if (bytes === 0) break;
+				read += bytes;
+			}
+			return buffer.subarray(0, read);
+		} finally {
+			fs.closeSync(fd);
+		}
+	}
+}
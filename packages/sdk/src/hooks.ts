@@ -0,0 +1,198 @@
+/**
+ * Lifecycle hooks
+ *
+ * Hooks configured under `hooks` in config run around clone, update, generate, install and
+ * remove. Shell commands receive the payload as JSON on stdin; JS modules have their default
+ * export called with it. Hooks for an event run sequentially in config order.
+ */
+
+import { spawn } from "node:child_process";
+import { resolve } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { Config, Hook, HookEvent, HookFailurePolicy } from "@offworld/types";
+import { loadConfig } from "./config.js";
+import { expandTilde } from "./paths.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+export type { HookEvent } from "@offworld/types";
+
+export interface HookPayload {
+	event: HookEvent;
+	/** Qualified repo name (e.g. "github.com:owner/repo") */
+	repo: string;
+	fullName?: string;
+	repoPath?: string;
+	referencePath?: string;
+	previousSha?: string;
+	commitSha?: string;
+	/** Whether an update moved HEAD */
+	updated?: boolean;
+	referenceSource?: "remote" | "local";
+	timestamp: string;
+}
+
+export interface HookResult {
+	hook: string;
+	success: boolean;
+	durationMs: number;
+	error?: string;
+}
+
+export interface RunHooksOptions {
+	/** Config to read hooks from (defaults to loadConfig()) */
+	config?: Config;
+}
+
+export class HookError extends Error {
+	constructor(
+		public readonly event: HookEvent,
+		public readonly hook: string,
+		message: string,
+	) {
+		super(`${event} hook failed (${hook}): ${message}`);
+		this.name = "HookError";
+	}
+}
+
+const DEFAULT_TIMEOUT_MS = 30_000;
+
+interface NormalizedHook {
+	command?: string;
+	module?: string;
+	timeout: number;
+	onFailure: HookFailurePolicy;
+	label: string;
+}
+
+function normalizeHook(hook: Hook): NormalizedHook {
+	if (typeof hook === "string") {
+		return { command: hook, timeout: DEFAULT_TIMEOUT_MS, onFailure: "warn", label: hook };
+	}
+	return {
+		command: hook.command,
+		module: hook.module,
+		timeout: hook.timeout ?? DEFAULT_TIMEOUT_MS,
+		onFailure: hook.onFailure ?? "warn",
+		label: hook.command ?? hook.module ?? "",
+	};
+}
+
+function runCommand(command: string, payload: HookPayload, timeout: number): Promise<void> {
+	const runtime = getRuntime();
+	const cwd =
+		payload.repoPath && getFs().existsSync(payload.repoPath) ? payload.repoPath : runtime.cwd();
+
+	return new Promise((resolvePromise, reject) => {
+		const proc = spawn(command, {
+			cwd,
+			shell: true,
+			stdio: ["pipe", "pipe", "pipe"],
+			env: {
+				...process.env,
+				OFFWORLD_HOOK_EVENT: payload.event,
+				OFFWORLD_HOOK_REPO: payload.repo,
+			},
+		});
+
+		let stderr = "";
+		const timer = setTimeout(() => {
+			proc.kill("SIGTERM");
+			reject(new Error(`timed out after ${timeout}ms`));
+		}, timeout);
+
+		proc.stdout.on("data", (data: Buffer) => {
+			runtime.logger.debug(data.toString().trimEnd());
+		});
+		proc.stderr.on("data", (data: Buffer) => {
+			stderr += data.toString();
+		});
+		proc.on("error", (error) => {
+			clearTimeout(timer);
+			reject(error);
+		});
+		proc.on("close", (code) => {
+			clearTimeout(timer);
+			if (code === 0) {
+				resolvePromise();
+			} else {
+				reject(new Error(stderr.trim() || `exited with code ${code}`));
+			}
+		});
+
+		// The hook may exit without reading stdin
+		proc.stdin.on("error", () => {});
+		proc.stdin.end(JSON.stringify(payload));
+	});
+}
+
+async function runModule(modulePath: string, payload: HookPayload, timeout: number): Promise<void> {
+	const url = pathToFileURL(resolve(getRuntime().cwd(), expandTilde(modulePath))).href;
+	const mod = (await import(url)) as { default?: unknown };
+	if (typeof mod.default !== "function") {
+		throw new Error("module has no default export function");
+	}
+
+	let timer: ReturnType<typeof setTimeout> | undefined;
+	const timedOut = new Promise<never>((_, reject) => {
+		timer = setTimeout(() => reject(new Error(`timed out after ${timeout}ms`)), timeout);
+	});
+	try {
+		await Promise.race([Promise.resolve(mod.default(payload)), timedOut]);
+	} finally {
+		clearTimeout(timer);
+	}
+}
+
+/**
+ * Whether any hooks are configured for an event (lets callers skip building payloads).
+ */
+export function hasHooks(event: HookEvent, config: Config = loadConfig()): boolean {
+	return (config.hooks?.[event]?.length ?? 0) > 0;
+}
+
+/**
+ * Run the hooks configured for an event.
+ * Failures are logged (warn), skipped (ignore) or thrown as HookError (fail).
+ */
+export async function runHooks(
+	event: HookEvent,
+	payload: Omit<HookPayload, "event" | "timestamp">,
+	options: RunHooksOptions = {},
+): Promise<HookResult[]> {
+	const config = options.config ?? loadConfig();
+	const hooks = config.hooks?.[event];
+	if (!hooks || hooks.length === 0) return [];
+
+	const { logger } = getRuntime();
+	const fullPayload: HookPayload = { ...payload, event, timestamp: new Date().toISOString() };
+	const results: HookResult[] = [];
+
+	for (const hook of hooks.map(normalizeHook)) {
+		const start = Date.now();
+		try {
+			if (hook.module) {
+				await runModule(hook.module, fullPayload, hook.timeout);
+			} else if (hook.command) {
+				await runCommand(hook.command, fullPayload, hook.timeout);
+			}
+			results.push({ hook: hook.label, success: true, durationMs: Date.now() - start });
+		} catch (error) {
+			const message = error instanceof Error ? error.message : String(error);
+			results.push({
+				hook: hook.label,
+				success: false,
+				durationMs: Date.now() - start,
+				error: message,
+			});
+
+			if (hook.onFailure === "fail") {
+				throw new HookError(event, hook.label, message);
+			}
+			if (hook.onFailure === "warn") {
+				logger.warn(`${event} hook failed (${hook.label}): ${message}`);
+			}
+		}
+	}
+
+	return results;
+}
@@ -0,0 +1,438 @@
+/**
+ * Local HTTP API
+ *
+ * Read-only REST access to the maps, search, reference content and clone files for editor
+ * plugins and dashboards. Binds to localhost and requires a bearer token on every route except
+ * the OpenAPI document. Browsers may call it from explicitly allowed origins (CORS).
+ */
+
+import { randomBytes, timingSafeEqual } from "node:crypto";
+import { createServer, type IncomingMessage, type ServerResponse } from "node:http";
+import type { AddressInfo } from "node:net";
+import { isAbsolute, join, relative, resolve, sep } from "node:path";
+import { ReferenceMetaSchema } from "@offworld/types/schemas";
+import { getCommitSha } from "./clone.js";
+import { getMetaPath } from "./config.js";
+import { VERSION } from "./constants.js";
+import { readGlobalMap } from "./index-manager.js";
+import { getMapEntry, getProjectMapPath, searchMap } from "./map.js";
+import { Paths } from "./paths.js";
+import { extractReferenceSection, listReferenceSections } from "./reference.js";
+import { getRepoStatus } from "./repo-manager.js";
+import { getFs, getRuntime, runWithRuntime } from "./runtime.js";
+import { recordUsage } from "./usage.js";
+
+export const DEFAULT_HTTP_PORT = 4747;
+
+/** Clone files larger than this are refused */
+export const MAX_FILE_BYTES = 2 * 1024 * 1024;
+
+export interface HttpServerOptions {
+	/** Defaults to DEFAULT_HTTP_PORT; 0 picks a free port */
+	port?: number;
+	/** Defaults to 127.0.0.1 */
+	host?: string;
+	/** Defaults to the persisted token from getHttpToken() */
+	token?: string;
+	/** Browser origins allowed to call the API (e.g. `http://localhost:3000`). Defaults to none. */
+	corsOrigins?: string[];
+}
+
+export interface HttpServerHandle {
+	url: string;
+	port: number;
+	token: string;
+	close(): Promise<void>;
+}
+
+export interface RepoStatus {
+	qualifiedName: string;
+	localPath: string;
+	cloned: boolean;
+	commitSha?: string;
+	reference: {
+		path: string;
+		exists: boolean;
+		commitSha?: string;
+		/** Whether the reference was built from the clone's current commit */
+		current: boolean;
+	};
+}
+
+class HttpError extends Error {
+	constructor(
+		readonly status: number,
+		message: string,
+	) {
+		super(message);
+		this.name = "HttpError";
+	}
+}
+
+function getTokenPath(): string {
+	return join(Paths.state, "serve-token");
+}
+
+/**
+ * The token clients send as `Authorization: Bearer <token>`. Created on first use and kept in
+ * the state directory (mode 600) so editor plugins can read it.
+ */
+export function getHttpToken(): string {
+	const fs = getFs();
+	const tokenPath = getTokenPath();
+	if (fs.existsSync(tokenPath)) {
+		const token = fs.readFileSync(tokenPath, "utf-8").trim();
+		if (token) return token;
+	}
+
+	const token = randomBytes(24).toString("base64url");
+	fs.mkdirSync(Paths.state, { recursive: true });
+	fs.writeFileSync(tokenPath, `${token}\n`, { encoding: "utf-8", mode: 0o600 });
+	return token;
+}
+
+/**
+ * Replace the persisted token, invalidating the old one.
+ */
+export function rotateHttpToken(): string {
+	getFs().rmSync(getTokenPath(), { force: true });
+	return getHttpToken();
+}
+
+function isAuthorized(req: IncomingMessage, token: string): boolean {
+	const header = req.headers.authorization ?? "";
+	const match = header.match(/^Bearer\s+(.+)$/i);
+	if (!match) return false;
+	const given = Buffer.from(match[1]!.trim());
+	const expected = Buffer.from(token);
+	return given.length === expected.length && timingSafeEqual(given, expected);
+}
+
+function sendJson(res: ServerResponse, status: number, body: unknown): void {
+	res.writeHead(status, { "content-type": "application/json; charset=utf-8" });
+	res.end(JSON.stringify(body, null, 2));
+}
+
+function decodePathPart(part: string): string {
+	try {
+		return decodeURIComponent(part);
+	} catch (error) {
+		if (error instanceof URIError) throw new HttpError(400, `Malformed path segment: ${part}`);
+		throw error;
+	}
+}
+
+/**
+ * Allow a listed browser origin to read responses and send the Authorization header.
+ *
+ * @returns Whether the request's origin is allowed
+ */
+function applyCors(req: IncomingMessage, res: ServerResponse, origins: string[]): boolean {
+	const origin = req.headers.origin;
+	if (!origin || !origins.includes(origin)) return false;
+	res.setHeader("Access-Control-Allow-Origin", origin);
+	res.setHeader("Access-Control-Allow-Methods", "GET, OPTIONS");
+	res.setHeader("Access-Control-Allow-Headers", "Authorization");
+	res.setHeader("Access-Control-Max-Age", "600");
+	res.setHeader("Vary", "Origin");
+	return true;
+}
+
+function sendText(res: ServerResponse, body: string, contentType = "text/plain"): void {
+	res.writeHead(200, { "content-type": `${contentType}; charset=utf-8` });
+	res.end(body);
+}
+
+function requireEntry(owner: string, repo: string) {
+	const result = getMapEntry(`${owner}/${repo}`);
+	if (!result) throw new HttpError(404, `Repo not found: ${owner}/${repo}`);
+	const { entry } = result;
+	const primary = "primary" in entry ? entry.primary : entry.reference;
+	return {
+		qualifiedName: result.qualifiedName,
+		fullName: result.qualifiedName.slice(result.qualifiedName.indexOf(":") + 1),
+		localPath: entry.localPath,
+		referencePath: join(Paths.offworldReferencesDir, primary),
+	};
+}
+
+function readReference(referencePath: string): string {
+	const fs = getFs();
+	if (!fs.existsSync(referencePath)) throw new HttpError(404, "Reference not installed");
+	return fs.readFileSync(referencePath, "utf-8");
+}
+
+/**
+ * Resolve a path inside a clone, following symlinks, and refuse anything outside it or in .git.
+ */
+function resolveClonePath(root: string, path: string): string {
+	const fs = getFs();
+	const isOutside = (rel: string) => rel.startsWith("..") || isAbsolute(rel);
+	const target = resolve(root, path);
+	if (isOutside(relative(root, target))) throw new HttpError(403, "Path is outside the clone");
+	if (!fs.existsSync(target)) throw new HttpError(404, `Not found: ${path}`);
+
+	// Symlinks inside the clone may point anywhere
+	const rel = relative(fs.realpathSync(root), fs.realpathSync(target));
+	if (isOutside(rel)) throw new HttpError(403, "Path is outside the clone");
+	if (rel === ".git" || rel.startsWith(`.git${sep}`)) {
+		throw new HttpError(403, "The .git directory is not served");
+	}
+	return target;
+}
+
+function getCloneStatus(entry: ReturnType<typeof requireEntry>): RepoStatus {
+	const fs = getFs();
+	const cloned = fs.existsSync(entry.localPath);
+	let commitSha: string | undefined;
+	try {
+		commitSha = cloned ? getCommitSha(entry.localPath) : undefined;
+	} catch {}
+
+	let referenceSha: string | undefined;
+	try {
+		const metaPath = join(getMetaPath(entry.fullName), "meta.json");
+		const parsed = ReferenceMetaSchema.safeParse(JSON.parse(fs.readFileSync(metaPath, "utf-8")));
+		referenceSha = parsed.success ? parsed.data.commitSha : undefined;
+	} catch {}
+
+	return {
+		qualifiedName: entry.qualifiedName,
+		localPath: entry.localPath,
+		cloned,
+		commitSha,
+		reference: {
+			path: entry.referencePath,
+			exists: fs.existsSync(entry.referencePath),
+			commitSha: referenceSha,
+			current:
+				commitSha !== undefined &&
+				referenceSha !== undefined &&
+				commitSha.slice(0, 7) === referenceSha.slice(0, 7),
+		},
+	};
+}
+
+interface Route {
+	pattern: RegExp;
+	handle: (
+		match: string[],
+		query: URLSearchParams,
+		res: ServerResponse,
+	) => void | Promise<void>;
+}
+
+const REPO = "/v1/repos/([^/]+)/([^/]+)";
+
+const ROUTES: Route[] = [
+	{
+		pattern: /^\/v1\/map$/,
+		handle: (_match, _query, res) => sendJson(res, 200, readGlobalMap()),
+	},
+	{
+		pattern: /^\/v1\/map\/project$/,
+		handle: (_match, query, res) => {
+			const mapPath = getProjectMapPath(query.get("cwd") ?? getRuntime().cwd());
+			if (!mapPath) throw new HttpError(404, "No project map in this directory");
+			sendJson(res, 200, JSON.parse(getFs().readFileSync(mapPath, "utf-8")));
+		},
+	},
+	{
+		pattern: /^\/v1\/search$/,
+		handle: (_match, query, res) => {
+			const term = query.get("q");
+			if (!term) throw new HttpError(400, "Missing query parameter: q");
+			const limit = Number(query.get("limit") ?? 10);
+			sendJson(res, 200, searchMap(term, { limit: Number.isFinite(limit) ? limit : 10 }));
+		},
+	},
+	{
+		pattern: /^\/v1\/status$/,
+		handle: async (_match, _query, res) => sendJson(res, 200, await getRepoStatus()),
+	},
+	{
+		pattern: new RegExp(`^${REPO}$`),
+		handle: ([owner, repo], _query, res) => sendJson(res, 200, requireEntry(owner!, repo!)),
+	},
+	{
+		pattern: new RegExp(`^${REPO}/status$`),
+		handle: ([owner, repo], _query, res) =>
+			sendJson(res, 200, getCloneStatus(requireEntry(owner!, repo!))),
+	},
+	{
+		pattern: new RegExp(`^${REPO}/reference/sections$`),
+		handle: ([owner, repo], _query, res) => {
+			const { referencePath } = requireEntry(owner!, repo!);
+			sendJson(res, 200, listReferenceSections(readReference(referencePath)));
+		},
+	},
+	{
+		pattern: new RegExp(`^${REPO}/reference$`),
+		handle: ([owner, repo], query, res) => {
+			const { qualifiedName, referencePath } = requireEntry(owner!, repo!);
+			const content = readReference(referencePath);
+			const heading = query.get("section");
+			const body = heading ? extractReferenceSection(content, heading) : content;
+			if (body === null) throw new HttpError(404, `Section not found: ${heading}`);
+			recordUsage(qualifiedName, "serve");
+			sendText(res, body, "text/markdown");
+		},
+	},
+	{
+		pattern: new RegExp(`^${REPO}/files(?:/(.*))?$`),
+		handle: ([owner, repo, path], _query, res) => {
+			const fs = getFs();
+			const { localPath } = requireEntry(owner!, repo!);
+			const target = resolveClonePath(localPath, path ?? "");
+			const stats = fs.statSync(target);
+
+			if (stats.isDirectory()) {
+				const entries = fs
+					.readdirSync(target, { withFileTypes: true })
+					.filter((entry) => entry.name !== ".git")
+					.map((entry) => ({ name: entry.name, type: entry.isDirectory() ? "dir" : "file" }));
+				sendJson(res, 200, entries);
+				return;
+			}
+			if (stats.size > MAX_FILE_BYTES) {
+				throw new HttpError(413, `File is larger than ${MAX_FILE_BYTES} bytes`);
+			}
+			sendText(res, fs.readFileSync(target, "utf-8"));
+		},
+	},
+];
+
+/**
+ * OpenAPI 3.1 description of the API.
+ */
+export function buildOpenApiDocument(serverUrl: string): Record<string, unknown> {
+	const repoParams = [
+		{ name: "owner", in: "path", required: true, schema: { type: "string" } },
+		{ name: "repo", in: "path", required: true, schema: { type: "string" } },
+	];
+	const json = { "application/json": { schema: { type: "object" } } };
+	const op = (summary: string, content: object = json, parameters: object[] = []) => ({
+		get: {
+			summary,
+			parameters,
+			responses: {
+				"200": { description: "OK", content },
+				"401": { description: "Missing or invalid bearer token" },
+				"404": { description: "Repo, reference, section or file not found" },
+			},
+		},
+	});
+	const query = (name: string, required = false) => ({
+		name,
+		in: "query",
+		required,
+		schema: { type: "string" },
+	});
+
+	return {
+		openapi: "3.1.0",
+		info: { title: "Offworld local API", version: VERSION },
+		servers: [{ url: serverUrl }],
+		components: { securitySchemes: { bearer: { type: "http", scheme: "bearer" } } },
+		security: [{ bearer: [] }],
+		paths: {
+			"/v1/map": op("Global map"),
+			"/v1/map/project": op("Project map for a directory", json, [query("cwd")]),
+			"/v1/search": op("Search the global map", json, [query("q", true), query("limit")]),
+			"/v1/status": op("Summary of all clones (count, references, disk usage)"),
+			"/v1/repos/{owner}/{repo}": op("Map entry", json, repoParams),
+			"/v1/repos/{owner}/{repo}/status": op("Clone and reference status", json, repoParams),
+			"/v1/repos/{owner}/{repo}/reference": op(
+				"Reference markdown, whole or one section",
+				{ "text/markdown": { schema: { type: "string" } } },
+				[...repoParams, query("section")],
+			),
+			"/v1/repos/{owner}/{repo}/reference/sections": op("Reference headings", json, repoParams),
+			"/v1/repos/{owner}/{repo}/files/{path}": op(
+				"File contents, or a directory listing, inside the clone",
+				{ "text/plain": { schema: { type: "string" } }, ...json },
+				[...repoParams, { name: "path", in: "path", required: true, schema: { type: "string" } }],
+			),
+		},
+	};
+}
+
+/**
+ * Start the HTTP API. Resolves once the server is listening.
+ */
+export async function startHttpServer(options: HttpServerOptions = {}): Promise<HttpServerHandle> {
+	const runtime = getRuntime();
+	const { logger } = runtime;
+	const { port = DEFAULT_HTTP_PORT, host = "127.0.0.1", corsOrigins = [] } = options;
+	const token = options.token ?? getHttpToken();
+	let url = "";
+
+	const handle = async (req: IncomingMessage, res: ServerResponse) => {
+		const { pathname, searchParams } = new URL(req.url ?? "/", url);
+		const corsAllowed = applyCors(req, res, corsOrigins);
+
+		// Preflight: browsers ask before sending the Authorization header cross-origin
+		if (req.method === "OPTIONS") {
+			res.writeHead(corsAllowed ? 204 : 403);
+			res.end();
+			return;
+		}
+		if (req.method !== "GET") {
+			sendJson(res, 405, { error: "Only GET is supported" });
+			return;
+		}
+		if (pathname === "/openapi.json") {
+			sendJson(res, 200, buildOpenApiDocument(url));
+			return;
+		}
+		if (!isAuthorized(req, token)) {
+			sendJson(res, 401, { error: "Missing or invalid bearer token" });
+			return;
+		}
+
+		const route = ROUTES.find((candidate) => candidate.pattern.test(pathname));
+		if (!route) {
+			sendJson(res, 404, { error: `No route for ${pathname}` });
+			return;
+		}
+
+		try {
+			const match = pathname
+				.match(route.pattern)!
+				.slice(1)
+				.map((part) => part && decodePathPart(part));
+			await route.handle(match, searchParams, res);
+		} catch (error) {
+			const status = error instanceof HttpError ? error.status : 500;
+			const message = error instanceof Error ? error.message : String(error);
+			if (status === 500) {
+				logger.error("HTTP request failed", { path: pathname, error: message });
+			}
+			sendJson(res, status, { error: message });
+		}
+	};
+
+	const server = createServer((req, res) => {
+		void runWithRuntime(runtime, () => handle(req, res));
+	});
+
+	await new Promise<void>((resolvePromise, reject) => {
+		server.once("error", reject);
+		server.listen(port, host, () => resolvePromise());
+	});
+
+	const address = server.address() as AddressInfo;
+	url = `http://${host}:${address.port}`;
+	logger.info("HTTP API listening", { url });
+
+	return {
+		url,
+		port: address.port,
+		token,
+		close: () =>
+			new Promise<void>((resolvePromise) => {
+				server.closeAllConnections();
+				server.close(() => resolvePromise());
+			}),
+	};
+}
@@ -6,7 +6,6 @@
  * - Project map: ./.offworld/map.json
  */
 
-import { existsSync, mkdirSync, readFileSync, writeFileSync } from "node:fs";
 import { dirname, join } from "node:path";
 import {
 	GlobalMapSchema,
@@ -16,21 +15,26 @@ import {
 	type ProjectMap,
 	type ProjectMapRepoEntry,
 } from "@offworld/types";
+import { refreshCrossLinks } from "./cross-links.js";
 import { Paths } from "./paths.js";
+import { applyDependencyGraph } from "./repo-graph.js";
+import { getFs } from "./runtime.js";
+import { refreshGlobalSkill } from "./skill-template.js";
 
 /**
  * Reads the global map from ~/.local/share/offworld/skill/offworld/assets/map.json
  * Returns empty map if file doesn't exist or is invalid
  */
 export function readGlobalMap(): GlobalMap {
+	const fs = getFs();
 	const mapPath = Paths.offworldGlobalMapPath;
 
-	if (!existsSync(mapPath)) {
+	if (!fs.existsSync(mapPath)) {
 		return { repos: {} };
 	}
 
 	try {
-		const content = readFileSync(mapPath, "utf-8");
+		const content = fs.readFileSync(mapPath, "utf-8");
 		const data = JSON.parse(content);
 		return GlobalMapSchema.parse(data);
 	} catch {
@@ -38,20 +42,46 @@ export function readGlobalMap(): GlobalMap {
 	}
 }
 
+export interface WriteGlobalMapOptions {
+	/** A reference file was rewritten: refresh cross-links even if no entry changed */
+	referencesChanged?: boolean;
+}
+
+/**
+ * Key describing which references are installed and what counts as a mention of each. Two maps
+ * with the same key produce the same cross-links for unchanged reference files.
+ */
+function getReferenceSetKey(map: GlobalMap): string {
+	return JSON.stringify(
+		Object.entries(map.repos)
+			.filter(([, entry]) => entry.primary)
+			.map(([qualifiedName, entry]) => [qualifiedName, entry.primary, entry.keywords ?? []])
+			.sort(([a], [b]) => String(a).localeCompare(String(b))),
+	);
+}
+
 /**
  * Writes the global map to ~/.local/share/offworld/skill/offworld/assets/map.json
- * Creates directory if it doesn't exist
+ * Creates directory if it doesn't exist, recomputes the dependency graph between repos, then
+ * re-renders the global SKILL.md. Cross-links between installed references are only refreshed
+ * when references were installed, removed or moved, since that rescans every reference file.
  */
-export function writeGlobalMap(map: GlobalMap): void {
+export function writeGlobalMap(map: GlobalMap, options: WriteGlobalMapOptions = {}): void {
+	const fs = getFs();
 	const mapPath = Paths.offworldGlobalMapPath;
 	const mapDir = dirname(mapPath);
 
-	if (!existsSync(mapDir)) {
-		mkdirSync(mapDir, { recursive: true });
+	if (!fs.existsSync(mapDir)) {
+		fs.mkdirSync(mapDir, { recursive: true });
 	}
 
-	const validated = GlobalMapSchema.parse(map);
-	writeFileSync(mapPath, JSON.stringify(validated, null, 2), "utf-8");
+	const previousKey = getReferenceSetKey(readGlobalMap());
+	const validated = GlobalMapSchema.parse(applyDependencyGraph(map));
+	fs.writeFileSync(mapPath, JSON.stringify(validated, null, 2), "utf-8");
+	refreshGlobalSkill(validated);
+	if (options.referencesChanged || getReferenceSetKey(validated) !== previousKey) {
+		refreshCrossLinks(validated);
+	}
 }
 
 /**
@@ -94,11 +124,12 @@ export function writeProjectMap(
 	projectRoot: string,
 	entries: Record<string, ProjectMapRepoEntry>,
 ): void {
+	const fs = getFs();
 	const mapPath = join(projectRoot, ".offworld", "map.json");
 	const mapDir = dirname(mapPath);
 
-	if (!existsSync(mapDir)) {
-		mkdirSync(mapDir, { recursive: true });
+	if (!fs.existsSync(mapDir)) {
+		fs.mkdirSync(mapDir, { recursive: true });
 	}
 
 	const projectMap: ProjectMap = {
@@ -109,5 +140,5 @@ export function writeProjectMap(
 	};
 
 	const validated = ProjectMapSchema.parse(projectMap);
-	writeFileSync(mapPath, JSON.stringify(validated, null, 2), "utf-8");
+	fs.writeFileSync(mapPath, JSON.stringify(validated, null, 2), "utf-8");
 }
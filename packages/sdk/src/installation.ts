@@ -2,12 +2,26 @@
  * Installation utilities for upgrade/uninstall commands
  */
 
-import { execSync, spawn } from "node:child_process";
-import { existsSync, readFileSync, writeFileSync } from "node:fs";
-import { homedir } from "node:os";
-import { join } from "node:path";
+import { execFileSync, execSync, spawn } from "node:child_process";
+import { createHash, verify as cryptoVerify } from "node:crypto";
+import {
+	chmodSync,
+	copyFileSync,
+	existsSync,
+	mkdirSync,
+	mkdtempSync,
+	readFileSync,
+	renameSync,
+	rmSync,
+	writeFileSync,
+} from "node:fs";
+import { homedir, tmpdir } from "node:os";
+import { basename, dirname, join } from "node:path";
 import { NpmPackageResponseSchema } from "@offworld/types";
+import { z } from "zod";
 import { VERSION } from "./constants.js";
+import { Paths } from "./paths.js";
+import { getRuntime } from "./runtime.js";
 
 const GITHUB_REPO = "oscabriel/offworld";
 const NPM_PACKAGE = "offworld";
@@ -108,15 +122,103 @@ export function getCurrentVersion(): string {
 	return VERSION;
 }
 
+export type ReleaseChannel = "stable" | "beta";
+
+const PRERELEASE_PATTERN = /-(alpha|beta|rc|dev)/;
+
+/**
+ * Ed25519 public key (PEM) for checksums.txt.sig. While null, or for releases published
+ * before signing (no .sig asset), upgrades skip the signature with a warning; checksums are
+ * always checked. A signature that is present but invalid fails the upgrade.
+ *
+ * To enable signing, a maintainer generates the key pair offline:
+ *
+ *   openssl genpkey -algorithm ed25519 -out release-signing-key.pem
+ *   openssl pkey -in release-signing-key.pem -pubout
+ *
+ * then stores the private key as the RELEASE_SIGNING_KEY repository secret and pastes the
+ * public key here. The release workflow signs checksums.txt only when the secret is set.
+ */
+export const RELEASE_PUBLIC_KEY: string | null = null;
+
+const SMOKE_CHECK_TIMEOUT_MS = 15_000;
+
+export class UpgradeVerificationError extends Error {
+	constructor(message: string) {
+		super(message);
+		this.name = "UpgradeVerificationError";
+	}
+}
+
+/**
+ * Record of the last upgrade, used by rollbackUpgrade()
+ */
+export interface UpgradeRecord {
+	method: InstallMethod;
+	fromVersion: string;
+	toVersion: string;
+	/** Installed binary (curl installs) */
+	binaryPath?: string;
+	/** Copy of the previous binary (curl installs) */
+	backupPath?: string;
+	upgradedAt: string;
+}
+
+export interface UpgradeOptions {
+	channel?: ReleaseChannel;
+	/** Called with progress messages (download, verification, smoke check) */
+	onProgress?: (message: string) => void;
+}
+
+const UpgradeRecordSchema = z.object({
+	method: z.enum(["curl", "npm", "pnpm", "bun", "brew", "unknown"]),
+	fromVersion: z.string(),
+	toVersion: z.string(),
+	binaryPath: z.string().optional(),
+	backupPath: z.string().optional(),
+	upgradedAt: z.string(),
+});
+
+function getUpgradeStatePath(): string {
+	return join(Paths.upgradesDir, "last-upgrade.json");
+}
+
+/**
+ * The last recorded upgrade, or null if there is nothing to roll back to
+ */
+export function getLastUpgrade(): UpgradeRecord | null {
+	const statePath = getUpgradeStatePath();
+	if (!existsSync(statePath)) return null;
+
+	try {
+		const parsed = UpgradeRecordSchema.safeParse(JSON.parse(readFileSync(statePath, "utf-8")));
+		return parsed.success ? parsed.data : null;
+	} catch {
+		return null;
+	}
+}
+
+function saveLastUpgrade(record: UpgradeRecord): void {
+	mkdirSync(Paths.upgradesDir, { recursive: true });
+	writeFileSync(getUpgradeStatePath(), JSON.stringify(record, null, 2), "utf-8");
+}
+
 /**
  * Fetch latest version from appropriate source
  */
-export async function fetchLatestVersion(method?: InstallMethod): Promise<string | null> {
+export async function fetchLatestVersion(
+	method?: InstallMethod,
+	channel: ReleaseChannel = "stable",
+): Promise<string | null> {
 	const installMethod = method ?? detectInstallMethod();
 
 	try {
 		if (installMethod === "npm" || installMethod === "pnpm" || installMethod === "bun") {
-			const response = await fetch(`https://registry.npmjs.org/${NPM_PACKAGE}/latest`);
+			const tag = channel === "beta" ? "beta" : "latest";
+			let response = await getRuntime().fetch(`https://registry.npmjs.org/${NPM_PACKAGE}/${tag}`);
+			if (!response.ok && tag === "beta") {
+				response = await getRuntime().fetch(`https://registry.npmjs.org/${NPM_PACKAGE}/latest`);
+			}
 			if (!response.ok) return null;
 			const json = await response.json();
 			const result = NpmPackageResponseSchema.safeParse(json);
@@ -124,18 +226,25 @@ export async function fetchLatestVersion(method?: InstallMethod): Promise<string
 			return result.data.version ?? null;
 		}
 
-		const response = await fetch(`https://api.github.com/repos/${GITHUB_REPO}/releases/latest`, {
-			headers: {
-				Accept: "application/vnd.github.v3+json",
-				"User-Agent": "offworld-cli",
+		const response = await getRuntime().fetch(
+			`https://api.github.com/repos/${GITHUB_REPO}/releases`,
+			{
+				headers: {
+					Accept: "application/vnd.github.v3+json",
+					"User-Agent": "offworld-cli",
+				},
 			},
-		});
+		);
 		if (!response.ok) return null;
 		const json = await response.json();
+		if (!Array.isArray(json)) return null;
+
+		const tags = json
+			.filter((release) => typeof release === "object" && release !== null && !release.draft)
+			.map((release) => String(release.tag_name ?? ""))
+			.filter(Boolean);
 		const tagName =
-			typeof json === "object" && json !== null && "tag_name" in json
-				? String(json.tag_name)
-				: null;
+			channel === "stable" ? tags.find((tag) => !PRERELEASE_PATTERN.test(tag)) : tags[0];
 		return tagName?.replace(/^v/, "") ?? null;
 	} catch {
 		return null;
@@ -143,18 +252,194 @@ export async function fetchLatestVersion(method?: InstallMethod): Promise<string
 }
 
 /**
- * Execute upgrade for given method
+ * Release asset name for the current platform (e.g. "ow-darwin-arm64.tar.gz")
+ */
+export function getReleaseAssetName(): string {
+	const os =
+		process.platform === "darwin" ? "darwin" : process.platform === "linux" ? "linux" : null;
+	const arch = process.arch === "x64" ? "x64" : process.arch === "arm64" ? "arm64" : null;
+	if (!os || !arch) {
+		throw new UpgradeVerificationError(
+			`No release binary for ${process.platform}-${process.arch}. Install with npm instead.`,
+		);
+	}
+	return `ow-${os}-${arch}.tar.gz`;
+}
+
+/**
+ * Look up an asset's SHA-256 in a sha256sum-style checksums file
+ */
+export function parseChecksum(checksums: string, assetName: string): string | null {
+	for (const line of checksums.split("\n")) {
+		const [hash, file] = line.trim().split(/\s+\*?/);
+		if (hash && file === assetName && /^[a-f0-9]{64}$/i.test(hash)) {
+			return hash.toLowerCase();
+		}
+	}
+	return null;
+}
+
+export function sha256(data: Buffer): string {
+	return createHash("sha256").update(data).digest("hex");
+}
+
+/**
+ * Verify a base64 Ed25519 signature over data
+ */
+export function verifyReleaseSignature(
+	data: Buffer,
+	signature: string,
+	publicKey: string,
+): boolean {
+	try {
+		return cryptoVerify(null, data, publicKey, Buffer.from(signature.trim(), "base64"));
+	} catch {
+		return false;
+	}
+}
+
+async function download(url: string): Promise<Buffer> {
+	const response = await getRuntime().fetch(url, { headers: { "User-Agent": "offworld-cli" } });
+	if (!response.ok) {
+		throw new UpgradeVerificationError(`Download failed (${response.status}): ${url}`);
+	}
+	return Buffer.from(await response.arrayBuffer());
+}
+
+/**
+ * Run `<bin> --version` and check it reports the expected version
  */
-export function executeUpgrade(method: InstallMethod, version: string): Promise<void> {
+export function runSmokeCheck(binary: string, expectedVersion: string): void {
+	let output: string;
+	try {
+		output = execFileSync(binary, ["--version"], {
+			encoding: "utf-8",
+			timeout: SMOKE_CHECK_TIMEOUT_MS,
+			stdio: ["ignore", "pipe", "pipe"],
+		});
+	} catch (error) {
+		throw new UpgradeVerificationError(
+			`Smoke check failed: ${binary} --version: ${error instanceof Error ? error.message : error}`,
+		);
+	}
+	if (!output.includes(expectedVersion)) {
+		throw new UpgradeVerificationError(
+			`Smoke check failed: expected ${expectedVersion}, got "${output.trim()}"`,
+		);
+	}
+}
+
+/**
+ * Path of the installed standalone binary
+ */
+function getInstalledBinaryPath(): string {
+	const execPath = process.execPath;
+	if (basename(execPath) === "ow") return execPath;
+	return join(homedir(), ".local", "bin", "ow");
+}
+
+function replaceBinary(source: string, target: string): void {
+	const staged = `${target}.new`;
+	copyFileSync(source, staged);
+	chmodSync(staged, 0o755);
+	renameSync(staged, target);
+}
+
+/**
+ * Download, verify and install a release binary, keeping the previous one for rollback.
+ */
+async function upgradeBinary(version: string, options: UpgradeOptions): Promise<UpgradeRecord> {
+	const progress = options.onProgress ?? (() => {});
+	const assetName = getReleaseAssetName();
+	const releaseUrl = `https://github.com/${GITHUB_REPO}/releases/download/v${version}`;
+
+	progress(`Downloading ${assetName}...`);
+	const [archive, checksums] = await Promise.all([
+		download(`${releaseUrl}/${assetName}`),
+		download(`${releaseUrl}/checksums.txt`),
+	]);
+
+	const { logger } = getRuntime();
+	if (!RELEASE_PUBLIC_KEY) {
+		logger.warn("Skipping signature check: this build has no release signing key");
+	} else {
+		let signature: string | null = null;
+		try {
+			signature = (await download(`${releaseUrl}/checksums.txt.sig`)).toString("utf-8");
+		} catch (error) {
+			const message = error instanceof Error ? error.message : error;
+			logger.warn(`Skipping signature check: release v${version} is not signed (${message})`);
+		}
+		if (signature !== null) {
+			progress("Verifying signature...");
+			if (!verifyReleaseSignature(checksums, signature, RELEASE_PUBLIC_KEY)) {
+				throw new UpgradeVerificationError("Invalid signature for checksums.txt");
+			}
+		}
+	}
+
+	progress("Verifying checksum...");
+	const expected = parseChecksum(checksums.toString("utf-8"), assetName);
+	if (!expected) {
+		throw new UpgradeVerificationError(`No checksum for ${assetName} in release v${version}`);
+	}
+	const actual = sha256(archive);
+	if (actual !== expected) {
+		throw new UpgradeVerificationError(
+			`Checksum mismatch for ${assetName}\n  Expected: ${expected}\n  Actual:   ${actual}`,
+		);
+	}
+
+	const tmpDir = mkdtempSync(join(tmpdir(), "offworld-upgrade-"));
+	try {
+		const archivePath = join(tmpDir, assetName);
+		writeFileSync(archivePath, archive);
+		execFileSync("tar", ["-xzf", archivePath, "-C", tmpDir]);
+		const newBinary = join(tmpDir, "ow");
+		if (!existsSync(newBinary)) {
+			throw new UpgradeVerificationError(`${assetName} does not contain an ow binary`);
+		}
+
+		const binaryPath = getInstalledBinaryPath();
+		const fromVersion = getCurrentVersion();
+		let backupPath: string | undefined;
+		if (existsSync(binaryPath)) {
+			mkdirSync(Paths.upgradesDir, { recursive: true });
+			backupPath = join(Paths.upgradesDir, `ow-${fromVersion}`);
+			copyFileSync(binaryPath, backupPath);
+		} else {
+			mkdirSync(dirname(binaryPath), { recursive: true });
+		}
+
+		replaceBinary(newBinary, binaryPath);
+
+		progress("Running smoke check...");
+		try {
+			runSmokeCheck(binaryPath, version);
+		} catch (error) {
+			if (backupPath) replaceBinary(backupPath, binaryPath);
+			throw error;
+		}
+
+		return {
+			method: "curl",
+			fromVersion,
+			toVersion: version,
+			binaryPath,
+			backupPath,
+			upgradedAt: new Date().toISOString(),
+		};
+	} finally {
+		rmSync(tmpDir, { recursive: true, force: true });
+	}
+}
+
+function runInstaller(method: InstallMethod, version: string): Promise<void> {
 	return new Promise((resolve, reject) => {
 		let cmd: string;
 		let args: string[];
 
 		switch (method) {
-			case "curl":
-				cmd = "bash";
-				args = ["-c", `curl -fsSL https://offworld.sh/install | VERSION=${version} bash`];
-				break;
 			case "npm":
 				cmd = "npm";
 				args = ["install", "-g", `${NPM_PACKAGE}@${version}`];
@@ -185,6 +470,73 @@ export function executeUpgrade(method: InstallMethod, version: string): Promise<
 	});
 }
 
+/**
+ * Execute upgrade for given method.
+ *
+ * Standalone (curl) installs download the release binary, verify its SHA-256 against the
+ * release checksums (and the checksums' signature), keep the previous binary and smoke check
+ * the new one, restoring the old binary if it fails. Package manager installs rely on the
+ * registry's integrity checks and are smoke checked after install.
+ * The upgrade is recorded so rollbackUpgrade() can undo it.
+ */
+export async function executeUpgrade(
+	method: InstallMethod,
+	version: string,
+	options: UpgradeOptions = {},
+): Promise<UpgradeRecord> {
+	let record: UpgradeRecord;
+
+	if (method === "curl") {
+		record = await upgradeBinary(version, options);
+	} else {
+		await runInstaller(method, version);
+		if (method !== "brew") {
+			options.onProgress?.("Running smoke check...");
+			runSmokeCheck("ow", version);
+		}
+		record = {
+			method,
+			fromVersion: getCurrentVersion(),
+			toVersion: version,
+			upgradedAt: new Date().toISOString(),
+		};
+	}
+
+	saveLastUpgrade(record);
+	return record;
+}
+
+/**
+ * Restore the version that was installed before the last upgrade.
+ *
+ * @returns The restored upgrade record
+ * @throws Error if there is nothing to roll back or the install method can't roll back
+ */
+export async function rollbackUpgrade(options: UpgradeOptions = {}): Promise<UpgradeRecord> {
+	const record = getLastUpgrade();
+	if (!record) {
+		throw new Error("No previous upgrade to roll back");
+	}
+
+	if (record.method === "curl") {
+		if (!record.backupPath || !record.binaryPath || !existsSync(record.backupPath)) {
+			throw new Error(`Backup of ${record.fromVersion} is missing`);
+		}
+		replaceBinary(record.backupPath, record.binaryPath);
+		options.onProgress?.("Running smoke check...");
+		runSmokeCheck(record.binaryPath, record.fromVersion);
+	} else if (record.method === "brew") {
+		throw new Error("Homebrew installs can't be rolled back automatically. Use brew directly.");
+	} else {
+		await runInstaller(record.method, record.fromVersion);
+		options.onProgress?.("Running smoke check...");
+		runSmokeCheck("ow", record.fromVersion);
+	}
+
+	rmSync(getUpgradeStatePath(), { force: true });
+	return record;
+}
+
 /**
  * Execute uninstall for given method
  */
@@ -7,8 +7,19 @@ export {
 	getCurrentVersion,
 	fetchLatestVersion,
 	executeUpgrade,
+	rollbackUpgrade,
+	getLastUpgrade,
+	getReleaseAssetName,
+	parseChecksum,
+	sha256,
+	verifyReleaseSignature,
+	runSmokeCheck,
 	executeUninstall,
 	getShellConfigFiles,
 	cleanShellConfig,
+	RELEASE_PUBLIC_KEY,
+	UpgradeVerificationError,
 	type InstallMethod,
+	type ReleaseChannel,
+	type UpgradeRecord,
 } from "./installation.js";
@@ -0,0 +1,178 @@
+/**
+ * Operation journal
+ *
+ * Every mutating operation (clone, update, install, remove, push, config change) appends a
+ * JSONL entry with its before/after state to the state directory. The file rotates at
+ * MAX_JOURNAL_BYTES, keeping JOURNAL_ROTATIONS older files.
+ */
+
+import { join } from "node:path";
+import type { Config } from "@offworld/types";
+import { z } from "zod";
+import { Paths } from "./paths.js";
+import { getFs } from "./runtime.js";
+
+export const JOURNAL_OPERATIONS = [
+	"clone",
+	"update",
+	"install",
+	"remove",
+	"move",
+	"push",
+	"config",
+] as const;
+
+export type JournalOperation = (typeof JOURNAL_OPERATIONS)[number];
+
+const JournalEntrySchema = z.object({
+	time: z.string(),
+	op: z.enum(JOURNAL_OPERATIONS),
+	/** Qualified repo name; absent for config changes */
+	repo: z.string().optional(),
+	/** One-line description, e.g. "abc1234 → def5678" */
+	summary: z.string().optional(),
+	before: z.unknown().optional(),
+	after: z.unknown().optional(),
+	/** CLI invocation that triggered the operation */
+	command: z.string().optional(),
+});
+
+export type JournalEntry = z.infer<typeof JournalEntrySchema>;
+
+export interface ReadJournalOptions {
+	/** Qualified name, owner/repo or repo name */
+	repo?: string;
+	/** Only entries at or after this time */
+	since?: Date;
+	op?: JournalOperation;
+	/** Newest entries to return (default: all) */
+	limit?: number;
+}
+
+export const MAX_JOURNAL_BYTES = 1024 * 1024;
+export const JOURNAL_ROTATIONS = 3;
+
+let journalCommand: string | undefined;
+
+/**
+ * Attribute subsequent entries to a command (e.g. "ow pull zod").
+ */
+export function setJournalCommand(command: string | undefined): void {
+	journalCommand = command;
+}
+
+function getJournalPath(rotation = 0): string {
+	return join(Paths.state, rotation === 0 ? "journal.jsonl" : `journal.${rotation}.jsonl`);
+}
+
+function rotate(): void {
+	const fs = getFs();
+	fs.rmSync(getJournalPath(JOURNAL_ROTATIONS), { force: true });
+	for (let rotation = JOURNAL_ROTATIONS - 1; rotation >= 0; rotation--) {
+		const from = getJournalPath(rotation);
+		if (!fs.existsSync(from)) continue;
+		fs.writeFileSync(getJournalPath(rotation + 1), fs.readFileSync(from, "utf-8"), "utf-8");
+		fs.rmSync(from, { force: true });
+	}
+}
+
+/**
+ * Append an entry. Never throws; journaling must not break the operation it records.
+ */
+export function recordOperation(entry: Omit<JournalEntry, "time" | "command">): void {
+	const fs = getFs();
+	try {
+		const line = `${JSON.stringify({
+			time: new Date().toISOString(),
+			...entry,
+			command: journalCommand,
+		})}\n`;
+		const journalPath = getJournalPath();
+		fs.mkdirSync(Paths.state, { recursive: true });
+
+		const size = fs.existsSync(journalPath) ? fs.statSync(journalPath).size : 0;
+		if (size > 0 && size + Buffer.byteLength(line) > MAX_JOURNAL_BYTES) rotate();
+		fs.appendFileSync(journalPath, line, "utf-8");
+	} catch {}
+}
+
+/**
+ * Record the keys that differ between two effective configs, if any.
+ */
+export function recordConfigChange(before: Config, after: Config): void {
+	const changed = (Object.keys({ ...before, ...after }) as Array<keyof Config>).filter(
+		(key) => JSON.stringify(before[key]) !== JSON.stringify(after[key]),
+	);
+	if (changed.length === 0) return;
+
+	recordOperation({
+		op: "config",
+		summary: `changed ${changed.join(", ")}`,
+		before: Object.fromEntries(changed.map((key) => [key, before[key]])),
+		after: Object.fromEntries(changed.map((key) => [key, after[key]])),
+	});
+}
+
+function matchesRepo(entryRepo: string | undefined, repo: string): boolean {
+	if (!entryRepo) return false;
+	const target = repo.toLowerCase();
+	const qualified = entryRepo.toLowerCase();
+	const fullName = qualified.slice(qualified.indexOf(":") + 1);
+	return qualified === target || fullName === target || fullName.split("/").pop() === target;
+}
+
+/**
+ * Read journal entries oldest first, across rotated files.
+ */
+export function readJournal(options: ReadJournalOptions = {}): JournalEntry[] {
+	const fs = getFs();
+	const entries: JournalEntry[] = [];
+
+	for (let rotation = JOURNAL_ROTATIONS; rotation >= 0; rotation--) {
+		let content: string;
+		try {
+			const journalPath = getJournalPath(rotation);
+			if (!fs.existsSync(journalPath)) continue;
+			content = fs.readFileSync(journalPath, "utf-8");
+		} catch {
+			continue;
+		}
+
+		for (const line of content.split("\n")) {
+			if (!line.trim()) continue;
+			try {
+				const parsed = JournalEntrySchema.safeParse(JSON.parse(line));
+				if (!parsed.success) continue;
+				const entry = parsed.data;
+				if (options.op && entry.op !== options.op) continue;
+				if (options.repo && !matchesRepo(entry.repo, options.repo)) continue;
+				if (options.since && new Date(entry.time) < options.since) continue;
+				entries.push(entry);
+			} catch {}
+		}
+	}
+
+	return options.limit ? entries.slice(-options.limit) : entries;
+}
+
+/**
+ * Parse a --since value: a duration ("30m", "12h", "7d", "2w") or an ISO date.
+ *
+ * @throws Error for unparseable values
+ */
+export function parseSince(value: string, now: Date = new Date()): Date {
+	const match = value.trim().match(/^(\d+)\s*([mhdw])$/i);
+	if (match) {
+		const amount = Number(match[1]);
+		const unitMs = { m: 60_000, h: 3_600_000, d: 86_400_000, w: 604_800_000 }[
+			match[2]!.toLowerCase() as "m" | "h" | "d" | "w"
+		];
+		return new Date(now.getTime() - amount * unitMs);
+	}
+
+	const date = new Date(value);
+	if (Number.isNaN(date.getTime())) {
+		throw new Error(`Invalid --since value: ${value}. Use e.g. 7d, 12h or 2026-01-31`);
+	}
+	return date;
+}
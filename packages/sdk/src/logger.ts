@@ -0,0 +1,115 @@
+/**
+ * Leveled, structured logger
+ *
+ * Messages at or above the console level go to stderr; every message at or above the file
+ * level is appended to an optional JSONL log file. Install it with setDefaultLogger() so SDK
+ * diagnostics that go through getRuntime().logger are captured.
+ */
+
+import { dirname } from "node:path";
+import { getFs, getRuntime, type LogFields, type OffworldLogger } from "./runtime.js";
+
+export type LogLevel = "debug" | "info" | "warn" | "error" | "silent";
+
+export const LOG_LEVELS: readonly LogLevel[] = ["debug", "info", "warn", "error", "silent"];
+
+export interface LogRecord extends LogFields {
+	time: string;
+	level: Exclude<LogLevel, "silent">;
+	message: string;
+}
+
+export interface LoggerOptions {
+	/** Minimum level printed to stderr (default: "warn") */
+	level?: LogLevel;
+	/** JSONL file that receives records at fileLevel and above */
+	file?: string;
+	/** Minimum level written to the file (default: "debug") */
+	fileLevel?: LogLevel;
+	/** Fields added to every record */
+	fields?: LogFields;
+	/** Console sink, for tests (default: process.stderr) */
+	write?: (line: string) => void;
+}
+
+export interface Logger extends OffworldLogger {
+	readonly level: LogLevel;
+	/** Logger that adds fields to every record and shares this logger's sinks */
+	child(fields: LogFields): Logger;
+}
+
+/**
+ * Parse a --log-level value.
+ *
+ * @throws Error for unknown levels
+ */
+export function parseLogLevel(value: string): LogLevel {
+	const level = value.toLowerCase() as LogLevel;
+	if (!LOG_LEVELS.includes(level)) {
+		throw new Error(`Invalid log level: ${value}. Valid levels: ${LOG_LEVELS.join(", ")}`);
+	}
+	return level;
+}
+
+function enabled(level: LogLevel, threshold: LogLevel): boolean {
+	return threshold !== "silent" && LOG_LEVELS.indexOf(level) >= LOG_LEVELS.indexOf(threshold);
+}
+
+function formatFields(fields: LogFields): string {
+	const parts = Object.entries(fields)
+		.filter(([, value]) => value !== undefined)
+		.map(([key, value]) => `${key}=${typeof value === "string" ? value : JSON.stringify(value)}`);
+	return parts.length > 0 ? ` ${parts.join(" ")}` : "";
+}
+
+export function createLogger(options: LoggerOptions = {}): Logger {
+	const {
+		level = "warn",
+		file,
+		fileLevel = "debug",
+		fields: baseFields = {},
+		write = (line: string) => process.stderr.write(line),
+	} = options;
+
+	let fileReady = false;
+	const log = (recordLevel: LogRecord["level"], message: string, fields: LogFields = {}) => {
+		const merged = { ...baseFields, ...fields };
+
+		if (enabled(recordLevel, level)) {
+			write(`[${recordLevel}] ${message}${formatFields(merged)}\n`);
+		}
+
+		if (file && enabled(recordLevel, fileLevel)) {
+			const record: LogRecord = {
+				...merged,
+				time: new Date().toISOString(),
+				level: recordLevel,
+				message,
+			};
+			try {
+				const fs = getFs();
+				if (!fileReady) {
+					fs.mkdirSync(dirname(file), { recursive: true });
+					fileReady = true;
+				}
+				fs.appendFileSync(file, `${JSON.stringify(record)}\n`, "utf-8");
+			} catch {}
+		}
+	};
+
+	return {
+		level,
+		debug: (message, fields) => log("debug", message, fields),
+		info: (message, fields) => log("info", message, fields),
+		warn: (message, fields) => log("warn", message, fields),
+		error: (message, fields) => log("error", message, fields),
+		child: (fields) => createLogger({ ...options, fields: { ...baseFields, ...fields } }),
+	};
+}
+
+/**
+ * The logger bound to the current runtime.
+ */
+export function getLogger(): OffworldLogger {
+	return getRuntime().logger;
+}
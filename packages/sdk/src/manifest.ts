@@ -2,9 +2,10 @@
  * Dependency manifest parsing for multiple package ecosystems
  */
 
-import { existsSync, readdirSync, readFileSync } from "node:fs";
 import type { Dirent } from "node:fs";
 import { join } from "node:path";
+import { getPluginManifestParsers } from "./plugins.js";
+import { getFs } from "./runtime.js";
 
 export type ManifestType = "npm" | "python" | "rust" | "go" | "unknown";
 
@@ -28,25 +29,70 @@ const DEFAULT_IGNORED_DIRS = new Set([
  * Detects the manifest type in a directory
  */
 export function detectManifestType(dir: string): ManifestType {
-	if (existsSync(join(dir, "package.json"))) return "npm";
-	if (existsSync(join(dir, "pyproject.toml"))) return "python";
-	if (existsSync(join(dir, "Cargo.toml"))) return "rust";
-	if (existsSync(join(dir, "go.mod"))) return "go";
-	if (existsSync(join(dir, "requirements.txt"))) return "python";
+	const fs = getFs();
+	if (fs.existsSync(join(dir, "package.json"))) return "npm";
+	if (fs.existsSync(join(dir, "pyproject.toml"))) return "python";
+	if (fs.existsSync(join(dir, "Cargo.toml"))) return "rust";
+	if (fs.existsSync(join(dir, "go.mod"))) return "go";
+	if (fs.existsSync(join(dir, "requirements.txt"))) return "python";
 	return "unknown";
 }
 
 /**
- * Parses dependencies from manifest files
+ * Parses dependencies from manifest files, plus any plugin manifest parsers that detect
+ * their ecosystem in the directory. Names already found by an earlier parser are skipped.
  */
 export function parseDependencies(dir: string): Dependency[] {
+	const deps = parseBuiltinDependencies(dir);
+	const seen = new Set(deps.map((dep) => dep.name));
+
+	for (const parser of getPluginManifestParsers()) {
+		if (!parser.detect(dir)) continue;
+		for (const dep of parser.parse(dir)) {
+			if (seen.has(dep.name)) continue;
+			seen.add(dep.name);
+			deps.push(dep);
+		}
+	}
+
+	return deps;
+}
+
+/**
+ * Names the manifests in a directory publish: the package.json name and workspace package
+ * names, the pyproject or Cargo package name, or the go module path.
+ */
+export function parsePackageNames(dir: string): string[] {
+	const names = new Set<string>();
+	const add = (name: unknown) => {
+		if (typeof name === "string" && name.trim()) names.add(name.trim());
+	};
+
+	const packageJsonPath = join(dir, "package.json");
+	if (getFs().existsSync(packageJsonPath)) {
+		add(readJson(packageJsonPath)?.name);
+		for (const path of resolveWorkspacePackageJsonPaths(dir, getWorkspacePatterns(dir))) {
+			add(readJson(path)?.name);
+		}
+	}
+
+	const pyproject = readText(join(dir, "pyproject.toml"));
+	add(readTomlName(pyproject, /\[(?:project|tool\.poetry)\]([\s\S]*?)(?=\n\[|$)/));
+	const cargo = readText(join(dir, "Cargo.toml"));
+	add(readTomlName(cargo, /\[package\]([\s\S]*?)(?=\n\[|$)/));
+	add(readText(join(dir, "go.mod"))?.match(/^module\s+(\S+)/m)?.[1]);
+
+	return Array.from(names).sort();
+}
+
+function parseBuiltinDependencies(dir: string): Dependency[] {
 	const type = detectManifestType(dir);
 
 	switch (type) {
 		case "npm":
 			return parseNpmDependencies(dir);
 		case "python":
-			return existsSync(join(dir, "pyproject.toml"))
+			return getFs().existsSync(join(dir, "pyproject.toml"))
 				? parsePyprojectToml(join(dir, "pyproject.toml"))
 				: parseRequirementsTxt(join(dir, "requirements.txt"));
 		case "rust":
@@ -79,10 +125,11 @@ function parseWorkspaceDependencies(dir: string): Dependency[] {
 }
 
 function getWorkspacePatterns(dir: string): string[] {
+	const fs = getFs();
 	const patterns = new Set<string>();
 
 	const packageJsonPath = join(dir, "package.json");
-	if (existsSync(packageJsonPath)) {
+	if (fs.existsSync(packageJsonPath)) {
 		const rootJson = readJson(packageJsonPath);
 		const workspaces = rootJson?.workspaces;
 		if (Array.isArray(workspaces)) {
@@ -99,9 +146,9 @@ function getWorkspacePatterns(dir: string): string[] {
 		}
 	}
 
-	const pnpmWorkspacePath = existsSync(join(dir, "pnpm-workspace.yaml"))
+	const pnpmWorkspacePath = fs.existsSync(join(dir, "pnpm-workspace.yaml"))
 		? join(dir, "pnpm-workspace.yaml")
-		: existsSync(join(dir, "pnpm-workspace.yml"))
+		: fs.existsSync(join(dir, "pnpm-workspace.yml"))
 			? join(dir, "pnpm-workspace.yml")
 			: null;
 
@@ -133,7 +180,7 @@ function resolveWorkspacePackageJsonPaths(dir: string, patterns: string[]): stri
 		if (excludeRegexes.some((regex) => regex.test(relativePath))) continue;
 
 		const packageJsonPath = join(dir, relativePath, "package.json");
-		if (existsSync(packageJsonPath)) {
+		if (getFs().existsSync(packageJsonPath)) {
 			matches.push(packageJsonPath);
 		}
 	}
@@ -151,7 +198,7 @@ function walkDirectories(root: string): string[] {
 
 		let entries: Dirent[];
 		try {
-			entries = readdirSync(currentPath, { withFileTypes: true }) as Dirent[];
+			entries = getFs().readdirSync(currentPath, { withFileTypes: true }) as Dirent[];
 		} catch {
 			continue;
 		}
@@ -182,7 +229,7 @@ function patternToRegex(pattern: string): RegExp {
 
 function parsePnpmWorkspacePackages(path: string): string[] {
 	try {
-		const content = readFileSync(path, "utf-8");
+		const content = getFs().readFileSync(path, "utf-8");
 		const lines = content.split("\n");
 		const patterns: string[] = [];
 		let inPackages = false;
@@ -216,9 +263,23 @@ function parsePnpmWorkspacePackages(path: string): string[] {
 	}
 }
 
+function readTomlName(content: string | null, section: RegExp): string | undefined {
+	const body = content?.match(section)?.[1];
+	return body?.match(/^\s*name\s*=\s*["']([^"']+)["']/m)?.[1];
+}
+
+function readText(path: string): string | null {
+	const fs = getFs();
+	try {
+		return fs.existsSync(path) ? fs.readFileSync(path, "utf-8") : null;
+	} catch {
+		return null;
+	}
+}
+
 function readJson(path: string): Record<string, unknown> | null {
 	try {
-		const content = readFileSync(path, "utf-8");
+		const content = getFs().readFileSync(path, "utf-8");
 		return JSON.parse(content) as Record<string, unknown>;
 	} catch {
 		return null;
@@ -248,7 +309,7 @@ function mergeDependencies(base: Dependency[], incoming: Dependency[]): Dependen
  */
 function parsePackageJson(path: string): Dependency[] {
 	try {
-		const content = readFileSync(path, "utf-8");
+		const content = getFs().readFileSync(path, "utf-8");
 		const pkg = JSON.parse(content);
 		const deps: Dependency[] = [];
 
@@ -287,7 +348,7 @@ function parsePackageJson(path: string): Dependency[] {
  */
 function parsePyprojectToml(path: string): Dependency[] {
 	try {
-		const content = readFileSync(path, "utf-8");
+		const content = getFs().readFileSync(path, "utf-8");
 		const deps: Dependency[] = [];
 
 		const depsSection = content.match(/\[project\.dependencies\]([\s\S]*?)(?=\[|$)/);
@@ -316,7 +377,7 @@ function parsePyprojectToml(path: string): Dependency[] {
  */
 function parseCargoToml(path: string): Dependency[] {
 	try {
-		const content = readFileSync(path, "utf-8");
+		const content = getFs().readFileSync(path, "utf-8");
 		const deps: Dependency[] = [];
 
 		const depsSection = content.match(/\[dependencies\]([\s\S]*?)(?=\[|$)/);
@@ -345,7 +406,7 @@ function parseCargoToml(path: string): Dependency[] {
  */
 function parseGoMod(path: string): Dependency[] {
 	try {
-		const content = readFileSync(path, "utf-8");
+		const content = getFs().readFileSync(path, "utf-8");
 		const deps: Dependency[] = [];
 
 		const requireSection = content.match(/require\s*\(([\s\S]*?)\)/);
@@ -380,7 +441,7 @@ function parseGoMod(path: string): Dependency[] {
  */
 function parseRequirementsTxt(path: string): Dependency[] {
 	try {
-		const content = readFileSync(path, "utf-8");
+		const content = getFs().readFileSync(path, "utf-8");
 		const deps: Dependency[] = [];
 
 		const lines = content.split("\n");
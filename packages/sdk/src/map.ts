@@ -2,7 +2,6 @@
  * Map query helpers for fast routing without reading full map.json
  */
 
-import { existsSync, readFileSync } from "node:fs";
 import { resolve } from "node:path";
 import type {
 	GlobalMap,
@@ -12,6 +11,8 @@ import type {
 } from "@offworld/types";
 import { GlobalMapSchema, ProjectMapSchema } from "@offworld/types/schemas";
 import { Paths } from "./paths.js";
+import { getFs, getRuntime } from "./runtime.js";
+import { summarizeUsage } from "./usage.js";
 
 export interface MapEntry {
 	scope: "project" | "global";
@@ -38,12 +39,23 @@ export interface SearchMapOptions {
 	cwd?: string;
 }
 
+export interface MapNames {
+	/** owner/repo names */
+	repos: string[];
+	/** Reference names without the .md extension */
+	references: string[];
+}
+
+/** Cap on the search score added by local usage, below a keyword hit */
+const MAX_USAGE_BOOST = 20;
+
 function readGlobalMapSafe(): GlobalMap | null {
+	const fs = getFs();
 	const mapPath = Paths.offworldGlobalMapPath;
-	if (!existsSync(mapPath)) return null;
+	if (!fs.existsSync(mapPath)) return null;
 
 	try {
-		const content = readFileSync(mapPath, "utf-8");
+		const content = fs.readFileSync(mapPath, "utf-8");
 		return GlobalMapSchema.parse(JSON.parse(content));
 	} catch {
 		return null;
@@ -51,11 +63,12 @@ function readGlobalMapSafe(): GlobalMap | null {
 }
 
 function readProjectMapSafe(cwd: string): ProjectMap | null {
+	const fs = getFs();
 	const mapPath = resolve(cwd, ".offworld/map.json");
-	if (!existsSync(mapPath)) return null;
+	if (!fs.existsSync(mapPath)) return null;
 
 	try {
-		const content = readFileSync(mapPath, "utf-8");
+		const content = fs.readFileSync(mapPath, "utf-8");
 		return ProjectMapSchema.parse(JSON.parse(content));
 	} catch {
 		return null;
@@ -140,7 +153,7 @@ export function resolveRepoKey(input: string, map: GlobalMap | ProjectMap): stri
  * @returns Entry with scope and qualified name, or null if not found
  */
 export function getMapEntry(input: string, options: GetMapEntryOptions = {}): MapEntry | null {
-	const { preferProject = true, cwd = process.cwd() } = options;
+	const { preferProject = true, cwd = getRuntime().cwd() } = options;
 
 	const projectMap = preferProject ? readProjectMapSafe(cwd) : null;
 	const globalMap = readGlobalMapSafe();
@@ -178,6 +191,7 @@ export function getMapEntry(input: string, options: GetMapEntryOptions = {}): Ma
  * - Keyword hit: 50 per keyword
  * - Partial contains in fullName: 25
  * - Partial contains in keywords: 10
+ * - Local usage: 2 per recorded read, up to 20 (only for repos that already match)
  *
  * @param term - Search term
  * @param options - Search options
@@ -191,6 +205,7 @@ export function searchMap(term: string, options: SearchMapOptions = {}): SearchR
 
 	const termTokens = tokenize(term);
 	const termLower = term.toLowerCase();
+	const usage = summarizeUsage();
 	const results: SearchResult[] = [];
 
 	for (const qualifiedName of Object.keys(globalMap.repos)) {
@@ -234,6 +249,7 @@ export function searchMap(term: string, options: SearchMapOptions = {}): SearchR
 		}
 
 		if (score > 0) {
+			score += Math.min((usage.get(qualifiedName)?.count ?? 0) * 2, MAX_USAGE_BOOST);
 			results.push({
 				qualifiedName,
 				fullName,
@@ -256,7 +272,28 @@ export function searchMap(term: string, options: SearchMapOptions = {}): SearchR
 /**
  * Get the project map path if it exists in cwd.
  */
-export function getProjectMapPath(cwd: string = process.cwd()): string | null {
+export function getProjectMapPath(cwd: string = getRuntime().cwd()): string | null {
 	const mapPath = resolve(cwd, ".offworld/map.json");
-	return existsSync(mapPath) ? mapPath : null;
+	return getFs().existsSync(mapPath) ? mapPath : null;
+}
+
+/**
+ * Repo and reference names from the global and project maps, sorted. Used for shell completion.
+ */
+export function listMapNames(cwd: string = getRuntime().cwd()): MapNames {
+	const repos = new Set<string>();
+	const references = new Set<string>();
+	const fullName = (qualifiedName: string) => qualifiedName.slice(qualifiedName.indexOf(":") + 1);
+	const referenceName = (file: string) => file.replace(/\.md$/, "");
+
+	for (const [qualifiedName, entry] of Object.entries(readGlobalMapSafe()?.repos ?? {})) {
+		repos.add(fullName(qualifiedName));
+		for (const reference of entry.references) references.add(referenceName(reference));
+	}
+	for (const [qualifiedName, entry] of Object.entries(readProjectMapSafe(cwd)?.repos ?? {})) {
+		repos.add(fullName(qualifiedName));
+		references.add(referenceName(entry.reference));
+	}
+
+	return { repos: [...repos].sort(), references: [...references].sort() };
 }
@@ -1,4 +1,5 @@
 import { ModelsDevDataSchema, type ModelsDevProvider } from "@offworld/types";
+import { getRuntime } from "./runtime.js";
 
 const MODELS_DEV_URL = "https://models.dev/api.json";
 
@@ -42,7 +43,7 @@ async function fetchModelsDevData(): Promise<Record<string, ModelsDevProvider>>
 		return cachedData;
 	}
 
-	const res = await fetch(MODELS_DEV_URL, {
+	const res = await getRuntime().fetch(MODELS_DEV_URL, {
 		signal: AbortSignal.timeout(10_000),
 	});
 
@@ -6,8 +6,10 @@
 import { xdgConfig, xdgData, xdgState } from "xdg-basedir";
 import { join } from "node:path";
 import { homedir } from "node:os";
+import { getRuntime } from "./runtime.js";
 
 const APP_NAME = "offworld";
+const SYSTEM_CONFIG_DIR = "/etc/offworld";
 
 /**
  * Main namespace for all XDG-compliant paths
@@ -18,7 +20,9 @@ export const Paths = {
 	 * Fallback: ~/.config/offworld
 	 */
 	get config(): string {
-		return join(xdgConfig ?? join(homedir(), ".config"), APP_NAME);
+		return (
+			getRuntime().paths?.config ?? join(xdgConfig ?? join(homedir(), ".config"), APP_NAME)
+		);
 	},
 
 	/**
@@ -26,7 +30,9 @@ export const Paths = {
 	 * Fallback: ~/.local/share/offworld
 	 */
 	get data(): string {
-		return join(xdgData ?? join(homedir(), ".local", "share"), APP_NAME);
+		return (
+			getRuntime().paths?.data ?? join(xdgData ?? join(homedir(), ".local", "share"), APP_NAME)
+		);
 	},
 
 	/**
@@ -34,7 +40,9 @@ export const Paths = {
 	 * Fallback: ~/.local/state/offworld
 	 */
 	get state(): string {
-		return join(xdgState ?? join(homedir(), ".local", "state"), APP_NAME);
+		return (
+			getRuntime().paths?.state ?? join(xdgState ?? join(homedir(), ".local", "state"), APP_NAME)
+		);
 	},
 
 	/**
@@ -44,6 +52,13 @@ export const Paths = {
 		return join(this.config, "offworld.json");
 	},
 
+	/**
+	 * System-wide configuration file: /etc/offworld/offworld.json
+	 */
+	get systemConfigFile(): string {
+		return join(getRuntime().paths?.system ?? SYSTEM_CONFIG_DIR, "offworld.json");
+	},
+
 	/**
 	 * Auth file path: ~/.local/share/offworld/auth.json
 	 */
@@ -58,6 +73,20 @@ export const Paths = {
 		return join(this.data, "meta");
 	},
 
+	/**
+	 * Plugin install prefix: ~/.local/share/offworld/plugins (npm plugins live in its node_modules)
+	 */
+	get pluginsDir(): string {
+		return join(this.data, "plugins");
+	},
+
+	/**
+	 * Upgrade state and previous binaries: ~/.local/share/offworld/upgrades
+	 */
+	get upgradesDir(): string {
+		return join(this.data, "upgrades");
+	},
+
 	/**
 	 * Default repo root: ~/ow
 	 */
@@ -65,11 +94,18 @@ export const Paths = {
 		return join(homedir(), "ow");
 	},
 
+	/**
+	 * Generated skills root: ~/.local/share/offworld/skill
+	 */
+	get skillsDir(): string {
+		return join(this.data, "skill");
+	},
+
 	/**
 	 * Offworld single-skill directory: ~/.local/share/offworld/skill/offworld
 	 */
 	get offworldSkillDir(): string {
-		return join(this.data, "skill", "offworld");
+		return join(this.skillsDir, "offworld");
 	},
 
 	/**
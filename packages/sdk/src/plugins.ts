@@ -0,0 +1,365 @@
+/**
+ * Plugin system
+ *
+ * Plugins extend the SDK without editing its internals. A plugin is an object (or a module
+ * whose default export is one, or a function returning one) that declares the plugin API
+ * version it targets and registers contributions in `setup`:
+ *
+ * - manifest parsers (new dependency ecosystems for `ow project init`)
+ * - dependency resolvers (dependency name -> "owner/repo")
+ * - agent targets (skill directories to symlink references into)
+ * - reference post-processors (rewrite reference markdown before it is written)
+ * - CLI subcommands (`ow <name> ...`)
+ *
+ * Plugins listed in config are npm package names (resolved from Paths.pluginsDir first) or
+ * local file paths. Loading is explicit: call loadPlugins() once at startup.
+ */
+
+import { createRequire } from "node:module";
+import { isAbsolute, join, resolve } from "node:path";
+import { pathToFileURL } from "node:url";
+import { AgentSchema, type Config } from "@offworld/types";
+import type { AgentConfig } from "./agents.js";
+import { loadConfig } from "./config.js";
+import { VERSION } from "./constants.js";
+import type { Dependency } from "./manifest.js";
+import { expandTilde, Paths } from "./paths.js";
+import { getRuntime, type OffworldLogger } from "./runtime.js";
+
+/**
+ * Version of the plugin contract. Bumped on breaking changes to OffworldPlugin or PluginAPI;
+ * plugins declaring a different version are rejected.
+ */
+export const PLUGIN_API_VERSION = 1;
+
+export interface ManifestParser {
+	/** Ecosystem name, e.g. "maven" */
+	name: string;
+	/** Whether this parser applies to a project directory */
+	detect(dir: string): boolean;
+	parse(dir: string): Dependency[];
+}
+
+export interface DependencyResolver {
+	name: string;
+	/** Return "owner/repo", or null to defer to the next resolver */
+	resolve(dep: string, spec?: string): string | null | Promise<string | null>;
+}
+
+export interface ReferencePostProcessorContext {
+	qualifiedName: string;
+	fullName: string;
+	repoPath: string;
+}
+
+export interface ReferencePostProcessor {
+	name: string;
+	/** Return the (possibly rewritten) reference markdown */
+	process(content: string, context: ReferencePostProcessorContext): string;
+}
+
+export interface PluginCommand {
+	/** Top-level command name (`ow <name>`); cannot shadow built-in commands */
+	name: string;
+	description: string;
+	run(args: string[]): void | Promise<void>;
+}
+
+export interface PluginAPI {
+	apiVersion: number;
+	sdkVersion: string;
+	logger: OffworldLogger;
+	registerManifestParser(parser: ManifestParser): void;
+	registerResolver(resolver: DependencyResolver): void;
+	registerAgent(agent: AgentConfig): void;
+	registerPostProcessor(processor: ReferencePostProcessor): void;
+	registerCommand(command: PluginCommand): void;
+}
+
+export interface OffworldPlugin {
+	name: string;
+	/** Plugin API version this plugin was written against (PLUGIN_API_VERSION) */
+	apiVersion: number;
+	version?: string;
+	description?: string;
+	setup(api: PluginAPI): void | Promise<void>;
+}
+
+export interface LoadedPlugin {
+	name: string;
+	/** Config entry or "inline" for registerPlugin() */
+	source: string;
+	version?: string;
+	description?: string;
+	apiVersion?: number;
+	/** Names of registered contributions, by kind */
+	contributions: {
+		manifestParsers: string[];
+		resolvers: string[];
+		agents: string[];
+		postProcessors: string[];
+		commands: string[];
+	};
+	/** Set when the plugin failed to load; nothing it registered is kept */
+	error?: string;
+}
+
+interface PluginRegistry {
+	manifestParsers: ManifestParser[];
+	resolvers: DependencyResolver[];
+	agents: AgentConfig[];
+	postProcessors: ReferencePostProcessor[];
+	commands: PluginCommand[];
+}
+
+const registry: PluginRegistry = {
+	manifestParsers: [],
+	resolvers: [],
+	agents: [],
+	postProcessors: [],
+	commands: [],
+};
+
+const loaded: LoadedPlugin[] = [];
+let loadedFromConfig = false;
+
+/**
+ * Identity helper that types a plugin definition.
+ */
+export function definePlugin(plugin: OffworldPlugin): OffworldPlugin {
+	return plugin;
+}
+
+function emptyContributions(): LoadedPlugin["contributions"] {
+	return { manifestParsers: [], resolvers: [], agents: [], postProcessors: [], commands: [] };
+}
+
+function isPlugin(value: unknown): value is OffworldPlugin {
+	if (!value || typeof value !== "object") return false;
+	const candidate = value as Partial<OffworldPlugin>;
+	return typeof candidate.name === "string" && typeof candidate.setup === "function";
+}
+
+/**
+ * Register a plugin object. Contributions are staged and only committed if setup succeeds.
+ */
+export async function registerPlugin(
+	plugin: OffworldPlugin,
+	source = "inline",
+): Promise<LoadedPlugin> {
+	const record: LoadedPlugin = {
+		name: plugin.name,
+		source,
+		version: plugin.version,
+		description: plugin.description,
+		apiVersion: plugin.apiVersion,
+		contributions: emptyContributions(),
+	};
+
+	if (plugin.apiVersion !== PLUGIN_API_VERSION) {
+		record.error =
+			`Plugin API version ${plugin.apiVersion} is not supported ` +
+			`(expected ${PLUGIN_API_VERSION})`;
+		loaded.push(record);
+		return record;
+	}
+
+	const staged: PluginRegistry = {
+		manifestParsers: [],
+		resolvers: [],
+		agents: [],
+		postProcessors: [],
+		commands: [],
+	};
+	const api: PluginAPI = {
+		apiVersion: PLUGIN_API_VERSION,
+		sdkVersion: VERSION,
+		logger: getRuntime().logger,
+		registerManifestParser: (parser) => {
+			staged.manifestParsers.push(parser);
+			record.contributions.manifestParsers.push(parser.name);
+		},
+		registerResolver: (resolver) => {
+			staged.resolvers.push(resolver);
+			record.contributions.resolvers.push(resolver.name);
+		},
+		registerAgent: (agent) => {
+			staged.agents.push(agent);
+			record.contributions.agents.push(agent.name);
+		},
+		registerPostProcessor: (processor) => {
+			staged.postProcessors.push(processor);
+			record.contributions.postProcessors.push(processor.name);
+		},
+		registerCommand: (command) => {
+			staged.commands.push(command);
+			record.contributions.commands.push(command.name);
+		},
+	};
+
+	try {
+		await plugin.setup(api);
+		registry.manifestParsers.push(...staged.manifestParsers);
+		registry.resolvers.push(...staged.resolvers);
+		registry.agents.push(...staged.agents);
+		registry.postProcessors.push(...staged.postProcessors);
+		registry.commands.push(...staged.commands);
+	} catch (error) {
+		record.error = error instanceof Error ? error.message : String(error);
+		record.contributions = emptyContributions();
+	}
+
+	loaded.push(record);
+	return record;
+}
+
+function isPathSpecifier(specifier: string): boolean {
+	return (
+		specifier.startsWith(".") ||
+		specifier.startsWith("~/") ||
+		isAbsolute(specifier) ||
+		/\.[cm]?[jt]s$/.test(specifier)
+	);
+}
+
+/**
+ * Resolve a config entry to an importable URL. npm packages are looked up in
+ * Paths.pluginsDir first, then from the SDK's own install.
+ */
+function resolvePluginUrl(specifier: string): string {
+	if (isPathSpecifier(specifier)) {
+		return pathToFileURL(resolve(getRuntime().cwd(), expandTilde(specifier))).href;
+	}
+
+	try {
+		const require = createRequire(join(Paths.pluginsDir, "package.json"));
+		return pathToFileURL(require.resolve(specifier)).href;
+	} catch {
+		return specifier;
+	}
+}
+
+async function importPlugin(specifier: string): Promise<OffworldPlugin> {
+	const mod = (await import(resolvePluginUrl(specifier))) as { default?: unknown };
+	let candidate = mod.default ?? mod;
+	if (typeof candidate === "function") {
+		candidate = await (candidate as () => unknown)();
+	}
+	if (!isPlugin(candidate)) {
+		throw new Error(
+			"module does not export an Offworld plugin (expected { name, apiVersion, setup })",
+		);
+	}
+	return candidate;
+}
+
+/**
+ * Load the plugins listed in config. Runs once per process unless `force` is set.
+ * Failures are recorded on the returned entries and logged as warnings.
+ */
+export async function loadPlugins(
+	options: { config?: Config; force?: boolean } = {},
+): Promise<LoadedPlugin[]> {
+	if (loadedFromConfig && !options.force) return getLoadedPlugins();
+	loadedFromConfig = true;
+
+	const config = options.config ?? loadConfig();
+	const { logger } = getRuntime();
+
+	for (const specifier of config.plugins ?? []) {
+		if (loaded.some((plugin) => plugin.source === specifier)) continue;
+
+		let record: LoadedPlugin;
+		try {
+			record = await registerPlugin(await importPlugin(specifier), specifier);
+		} catch (error) {
+			record = {
+				name: specifier,
+				source: specifier,
+				contributions: emptyContributions(),
+				error: error instanceof Error ? error.message : String(error),
+			};
+			loaded.push(record);
+		}
+
+		if (record.error) {
+			logger.warn(`Plugin ${specifier} failed to load: ${record.error}`);
+		}
+	}
+
+	const unknownAgents = getUnknownAgents(config);
+	if (unknownAgents.length > 0) {
+		logger.warn(
+			`Skipping unknown agents in config: ${unknownAgents.join(", ")} ` +
+				`(known: ${knownAgentNames().join(", ")})`,
+		);
+	}
+
+	return getLoadedPlugins();
+}
+
+function knownAgentNames(): string[] {
+	return [...AgentSchema.options, ...registry.agents.map((agent) => agent.name)];
+}
+
+/**
+ * Configured agents that are neither built in nor registered by a loaded plugin. References
+ * are not linked into these, so loadPlugins warns about them once plugins are in.
+ */
+export function getUnknownAgents(config: Config = loadConfig()): string[] {
+	const known = new Set(knownAgentNames());
+	return config.agents.filter((name) => !known.has(name));
+}
+
+export function getLoadedPlugins(): LoadedPlugin[] {
+	return loaded.map((plugin) => ({ ...plugin }));
+}
+
+export function getPluginManifestParsers(): readonly ManifestParser[] {
+	return registry.manifestParsers;
+}
+
+export function getPluginResolvers(): readonly DependencyResolver[] {
+	return registry.resolvers;
+}
+
+export function getPluginAgents(): readonly AgentConfig[] {
+	return registry.agents;
+}
+
+export function getPluginCommands(): readonly PluginCommand[] {
+	return registry.commands;
+}
+
+/**
+ * Run reference post-processors in registration order. A processor that throws is skipped.
+ */
+export function applyReferencePostProcessors(
+	content: string,
+	context: ReferencePostProcessorContext,
+): string {
+	let result = content;
+	for (const processor of registry.postProcessors) {
+		try {
+			result = processor.process(result, context);
+		} catch (error) {
+			getRuntime().logger.warn(
+				`Post-processor ${processor.name} failed: ${error instanceof Error ? error.message : error}`,
+			);
+		}
+	}
+	return result;
+}
+
+/**
+ * Forget all plugins and contributions (for tests).
+ */
+export function resetPlugins(): void {
+	registry.manifestParsers.length = 0;
+	registry.resolvers.length = 0;
+	registry.agents.length = 0;
+	registry.postProcessors.length = 0;
+	registry.commands.length = 0;
+	loaded.length = 0;
+	loadedFromConfig = false;
+}
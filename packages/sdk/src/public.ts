@@ -1,5 +1,6 @@
 export {
 	type Config,
+	type RepoOverride,
 	type RepoSource,
 	type GlobalMap,
 	type GlobalMapRepoEntry,
@@ -17,15 +18,37 @@ export {
 	getRepoRoot,
 	getRepoPath,
 	getReferencePath,
+	getShortReferencePath,
 	getMetaPath,
 	getConfigPath,
+	getSystemConfigPath,
+	getProjectConfigPath,
 	loadConfig,
 	saveConfig,
+	resetConfig,
+	resolveConfig,
+	setConfigOverrides,
+	parseConfigValue,
+	toConfigEnvVar,
 	toReferenceName,
 	toReferenceFileName,
+	toShortReferenceFileName,
 	toMetaDirName,
+	CONFIG_KEYS,
+	PROJECT_IGNORED_CONFIG_KEYS,
+	type ConfigKey,
+	type ConfigSource,
+	type ConfigOrigin,
+	type ResolvedConfig,
 } from "./config.js";
 
+export {
+	getRepoSettings,
+	getRepoOverrides,
+	matchesRepoPattern,
+	type RepoSettings,
+} from "./repo-config.js";
+
 export { expandTilde, Paths } from "./paths.js";
 
 export {
@@ -42,6 +65,7 @@ export {
 	upsertGlobalMapEntry,
 	removeGlobalMapEntry,
 	writeProjectMap,
+	type WriteGlobalMapOptions,
 } from "./index-manager.js";
 
 export {
@@ -49,7 +73,9 @@ export {
 	getMapEntry,
 	searchMap,
 	getProjectMapPath,
+	listMapNames,
 	type MapEntry,
+	type MapNames,
 	type SearchResult,
 	type GetMapEntryOptions,
 	type SearchMapOptions,
@@ -64,16 +90,33 @@ export {
 	getClonedRepoPath,
 	getCommitSha,
 	getCommitDistance,
+	fetchCommit,
+	getOriginUrl,
+	moveRepo,
 	CloneError,
 	RepoExistsError,
 	RepoNotFoundError,
 	GitError,
+	GitNotFoundError,
+	getGitBackendName,
 	type CloneOptions,
 	type UpdateOptions,
 	type UpdateResult,
 	type RemoveOptions,
+	type MoveResult,
 } from "./clone.js";
 
+export {
+	REPO_IDENTITY_TTL_MS,
+	checkRepoIdentity,
+	checkRepoIdentities,
+	applyRepoIdentities,
+	findRepoRedirect,
+	toRemoteSource,
+	type RepoIdentity,
+	type CheckRepoIdentitiesOptions,
+} from "./repo-identity.js";
+
 export {
 	AuthError,
 	NotLoggedInError,
@@ -94,10 +137,147 @@ export {
 export {
 	installGlobalSkill,
 	installReference,
+	installReferenceSkill,
+	removeReferenceSkill,
+	getReferenceSkillPaths,
+	getReferenceSkillDir,
+	toReferenceSkillName,
+	extractReferenceOverview,
+	listReferenceSections,
+	extractReferenceSection,
+	buildShortReference,
+	MAX_SHORT_REFERENCE_CHARS,
 	resolveReferenceKeywords,
 	type InstallReferenceMeta,
+	type InstallReferenceOptions,
+	type ReferenceSection,
 } from "./reference.js";
 
+export {
+	renderGlobalSkill,
+	renderTemplate,
+	buildSkillTemplateContext,
+	DEFAULT_SKILL_TEMPLATE,
+	DEFAULT_INSTALLATION_TEMPLATE,
+	type SkillTemplateContext,
+	type SkillTemplateReference,
+	type SkillTemplateCommand,
+} from "./skill-template.js";
+
+export {
+	buildReferenceGraph,
+	getRelatedReferences,
+	stripRelatedBlock,
+	updateCrossLinks,
+	type ReferenceGraph,
+	type RelatedReference,
+} from "./cross-links.js";
+
+export {
+	buildExamplesIndex,
+	readExamplesIndex,
+	writeExamplesIndex,
+	searchExamples,
+	getExamplesIndexPath,
+	EXAMPLE_KINDS,
+	type ExampleKind,
+	type ExampleSnippet,
+	type ExampleMatch,
+	type ExamplesIndex,
+} from "./examples.js";
+
+export {
+	resolveCommitDistance,
+	getCachedCommitDistance,
+	formatCommitDistance,
+	COMMIT_DISTANCE_METHODS,
+	MAX_COMMIT_DISTANCE_ENTRIES,
+	type CommitDistance,
+	type CommitDistanceMethod,
+} from "./commit-distance.js";
+
+export {
+	findForkCandidates,
+	findForkUpstream,
+	getForkDivergence,
+	formatForkDivergence,
+	withForkSupplement,
+	stripForkSupplement,
+	FORK_DETECTION_METHODS,
+	FORK_SUPPLEMENT_START,
+	FORK_SUPPLEMENT_END,
+	type ForkCandidate,
+	type ForkUpstream,
+	type ForkDivergence,
+	type ForkDetectionMethod,
+} from "./fork.js";
+
+export { getReferenceFreshness, type ReferenceFreshness } from "./freshness.js";
+
+export {
+	buildDependencyGraph,
+	applyDependencyGraph,
+	getDependencyGraph,
+	getRepoDependencies,
+	getManifestSummary,
+	selectDependencyGraph,
+	renderDependencyGraph,
+	GRAPH_FORMATS,
+	type GraphFormat,
+	type DependencyGraph,
+	type DependencyGraphJson,
+	type RepoDependencies,
+	type RepoManifestSummary,
+} from "./repo-graph.js";
+
+export {
+	findApiSignatures,
+	listApiExports,
+	openApiLookup,
+	findTypeEntryPoints,
+	appendApiSignatures,
+	extractApiTableSymbols,
+	stripApiAppendix,
+	ApiExtractionError,
+	API_EXTRACTORS,
+	API_APPENDIX_START,
+	API_APPENDIX_END,
+	type ApiSignature,
+	type ApiExport,
+	type ApiExtractor,
+	type ApiLookup,
+	type ApiEntryPoint,
+} from "./api-signatures.js";
+
+export {
+	runHooks,
+	hasHooks,
+	HookError,
+	type HookEvent,
+	type HookPayload,
+	type HookResult,
+	type RunHooksOptions,
+} from "./hooks.js";
+
+export {
+	PLUGIN_API_VERSION,
+	definePlugin,
+	loadPlugins,
+	getUnknownAgents,
+	registerPlugin,
+	getLoadedPlugins,
+	getPluginCommands,
+	resetPlugins,
+	type OffworldPlugin,
+	type PluginAPI,
+	type LoadedPlugin,
+	type ManifestParser,
+	type DependencyResolver,
+	type ReferencePostProcessor,
+	type ReferencePostProcessorContext,
+	type PluginCommand,
+} from "./plugins.js";
+
 export {
 	agents,
 	detectInstalledAgents,
@@ -109,14 +289,32 @@ export {
 export {
 	detectManifestType,
 	parseDependencies,
+	parsePackageNames,
 	type ManifestType,
 	type Dependency,
 } from "./manifest.js";
 
+export {
+	detectHookManager,
+	installProjectHooks,
+	uninstallProjectHooks,
+	getProjectHooksStatus,
+	getChangedFiles,
+	hasManifestChanges,
+	GitHooksError,
+	PROJECT_GIT_HOOKS,
+	MANIFEST_FILES,
+	type GitHookManager,
+	type ProjectGitHook,
+	type ProjectHooksResult,
+	type ProjectHooksStatus,
+} from "./git-hooks.js";
+
 export {
 	FALLBACK_MAPPINGS,
 	resolveFromNpm,
 	getNpmKeywords,
+	getNpmDeprecation,
 	resolveDependencyRepo,
 	type ResolveDependencyRepoOptions,
 	type ResolvedDep,
@@ -148,6 +346,24 @@ export {
 	type DiscoverResult,
 } from "./repo-manager.js";
 
+export {
+	getDiskUsage,
+	invalidateDiskUsage,
+	type DiskUsageOptions,
+	type DiskUsageResult,
+} from "./disk-usage.js";
+
+export {
+	recordUsage,
+	readUsageEvents,
+	summarizeUsage,
+	getUsageStats,
+	MAX_USAGE_EVENTS,
+	type UsageEvent,
+	type RepoUsage,
+	type UsageStats,
+} from "./usage.js";
+
 export {
 	listProviders,
 	getProvider,
@@ -157,3 +373,103 @@ export {
 	type ModelInfo,
 	type ProviderWithModels,
 } from "./models.js";
+
+export { createJsGitBackend, type GitBackend, type GitCloneOptions } from "./git-backend.js";
+
+export {
+	Offworld,
+	type OffworldOptions,
+	type OffworldEventMap,
+	type OffworldEventName,
+	type OffworldListener,
+	type OffworldPullOptions,
+	type OffworldPullResult,
+	type OffworldGenerateOptions,
+	type OffworldMapEntry,
+	type OffworldSearchOptions,
+	type OffworldProjectScanOptions,
+	type OffworldProjectScan,
+	type ReferenceSource,
+} from "./client.js";
+
+export {
+	setDefaultLogger,
+	type OffworldFileSystem,
+	type GitRunner,
+	type GitRunOptions,
+	type OffworldLogger,
+	type LogFields,
+	type OffworldPathOverrides,
+} from "./runtime.js";
+
+export {
+	createLogger,
+	getLogger,
+	parseLogLevel,
+	LOG_LEVELS,
+	type Logger,
+	type LoggerOptions,
+	type LogLevel,
+	type LogRecord,
+} from "./logger.js";
+
+export {
+	recordOperation,
+	recordConfigChange,
+	readJournal,
+	parseSince,
+	setJournalCommand,
+	JOURNAL_OPERATIONS,
+	MAX_JOURNAL_BYTES,
+	JOURNAL_ROTATIONS,
+	type JournalEntry,
+	type JournalOperation,
+	type ReadJournalOptions,
+} from "./journal.js";
+
+export {
+	readRemoteCheckCache,
+	getCachedRemoteCheck,
+	writeRemoteChecks,
+	refreshRemoteChecks,
+	REMOTE_CHECK_TTL_MS,
+	type CachedRemoteCheck,
+	type RefreshRemoteChecksResult,
+} from "./remote-cache.js";
+
+export {
+	startDaemon,
+	requestDaemon,
+	isDaemonRunning,
+	readDaemonPid,
+	parseInterval,
+	resolveDaemonSchedule,
+	getDaemonPaths,
+	prepareDaemonLog,
+	renderSystemdUnit,
+	getSystemdUnitPath,
+	DaemonError,
+	DaemonNotRunningError,
+	DAEMON_TASKS,
+	MAX_DAEMON_LOG_BYTES,
+	type DaemonTask,
+	type DaemonSchedule,
+	type DaemonTaskState,
+	type DaemonStatus,
+	type DaemonPaths,
+	type DaemonHandle,
+	type StartDaemonOptions,
+	type DaemonRequestOptions,
+} from "./daemon.js";
+
+export {
+	startHttpServer,
+	buildOpenApiDocument,
+	getHttpToken,
+	rotateHttpToken,
+	DEFAULT_HTTP_PORT,
+	MAX_FILE_BYTES,
+	type HttpServerOptions,
+	type HttpServerHandle,
+	type RepoStatus,
+} from "./http-api.js";
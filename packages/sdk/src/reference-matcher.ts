@@ -4,11 +4,12 @@
  * Maps dependencies to their reference status (installed, remote, generate, unknown)
  */
 
-import { existsSync } from "node:fs";
 import { join } from "node:path";
 import { toReferenceFileName } from "./config.js";
 import { Paths } from "./paths.js";
 import type { ResolvedDep } from "./dep-mappings.js";
+import { getCachedRemoteCheck, writeRemoteChecks } from "./remote-cache.js";
+import { getFs } from "./runtime.js";
 
 export type ReferenceStatus = "installed" | "remote" | "generate" | "unknown";
 
@@ -33,7 +34,7 @@ export interface ReferenceMatch {
 export function isReferenceInstalled(repo: string): boolean {
 	const referenceFileName = toReferenceFileName(repo);
 	const referencePath = join(Paths.offworldReferencesDir, referenceFileName);
-	return existsSync(referencePath);
+	return getFs().existsSync(referencePath);
 }
 
 /**
@@ -83,7 +84,7 @@ export function matchDependenciesToReferences(resolvedDeps: ResolvedDep[]): Refe
  *
  * Status logic:
  * - installed: {owner-repo}.md exists in offworld/references/
- * - remote: Reference exists on offworld.sh (quick pull)
+ * - remote: Reference exists on offworld.sh (quick pull); fresh cached checks skip the query
  * - generate: Has valid GitHub repo but needs AI generation (slow, uses tokens)
  * - unknown: No GitHub repo found
  *
@@ -95,6 +96,7 @@ export async function matchDependenciesToReferencesWithRemoteCheck(
 ): Promise<ReferenceMatch[]> {
 	const { checkRemote } = await import("./sync.js");
 	const repoStatus = new Map<string, ReferenceStatus>();
+	const checked: Parameters<typeof writeRemoteChecks>[0] = {};
 
 	const remoteChecks: Promise<void>[] = [];
 	for (const dep of resolvedDeps) {
@@ -106,10 +108,16 @@ export async function matchDependenciesToReferencesWithRemoteCheck(
 		}
 
 		repoStatus.set(dep.repo, "generate");
+		if (getCachedRemoteCheck(dep.repo)?.exists) {
+			repoStatus.set(dep.repo, "remote");
+			continue;
+		}
+
 		remoteChecks.push(
 			(async () => {
 				try {
 					const remote = await checkRemote(dep.repo!);
+					checked[dep.repo!] = remote;
 					if (remote.exists) {
 						repoStatus.set(dep.repo!, "remote");
 					}
@@ -121,6 +129,7 @@ export async function matchDependenciesToReferencesWithRemoteCheck(
 	}
 
 	await Promise.all(remoteChecks);
+	writeRemoteChecks(checked);
 
 	return resolvedDeps.map((dep) => {
 		if (!dep.repo) {
@@ -1,20 +1,22 @@
-import {
-	mkdirSync,
-	writeFileSync,
-	readFileSync,
-	lstatSync,
-	unlinkSync,
-	rmSync,
-	symlinkSync,
-	existsSync,
-} from "node:fs";
 import { join } from "node:path";
 import { z } from "zod";
-import { loadConfig, toMetaDirName, toReferenceFileName } from "./config.js";
-import { agents } from "./agents.js";
+import type { Config, ReferenceOrigin } from "@offworld/types";
+import {
+	loadConfig,
+	toMetaDirName,
+	toReferenceFileName,
+	toShortReferenceFileName,
+} from "./config.js";
+import { getAgentConfig, getAllAgentConfigs } from "./agents.js";
 import { expandTilde, Paths } from "./paths.js";
 import { readGlobalMap, writeGlobalMap } from "./index-manager.js";
 import { getNpmKeywords } from "./dep-mappings.js";
+import { runHooks } from "./hooks.js";
+import { recordOperation } from "./journal.js";
+import { applyReferencePostProcessors } from "./plugins.js";
+import { renderGlobalSkill } from "./skill-template.js";
+import { getFs, getRuntime } from "./runtime.js";
+import { appendApiSignatures } from "./api-signatures.js";
 
 const PackageJsonNameSchema = z.object({
 	name: z.string().optional(),
@@ -27,6 +29,21 @@ export interface InstallReferenceMeta {
 	commitSha: string;
 	/** SDK version used for generation */
 	version: string;
+	/** Where the reference came from; set by installReference */
+	source?: ReferenceOrigin;
+}
+
+export interface InstallReferenceOptions {
+	/** Whether the reference was downloaded or generated locally */
+	referenceSource?: "remote" | "local";
+	/** The downloaded reference was verified by the Offworld team */
+	verified?: boolean;
+	/** Cheat sheet from generation or offworld.sh. Derived from the reference when omitted. */
+	shortContent?: string;
+	/** Append verified API signatures (defaults to the apiAppendix config key) */
+	apiAppendix?: boolean;
+	/** Qualified name of the upstream the reference was taken from (cleared when omitted) */
+	forkOf?: string;
 }
 
 function normalizeKeywords(values: string[]): string[] {
@@ -48,11 +65,12 @@ function deriveMinimalKeywords(fullName: string): string[] {
 }
 
 function getPackageName(localPath: string): string | null {
+	const fs = getFs();
 	const packageJsonPath = join(localPath, "package.json");
-	if (!existsSync(packageJsonPath)) return null;
+	if (!fs.existsSync(packageJsonPath)) return null;
 
 	try {
-		const content = readFileSync(packageJsonPath, "utf-8");
+		const content = fs.readFileSync(packageJsonPath, "utf-8");
 		const json = JSON.parse(content);
 		const parsed = PackageJsonNameSchema.safeParse(json);
 		if (!parsed.success || !parsed.data.name) return null;
@@ -88,183 +106,375 @@ export async function resolveReferenceKeywords(
  * Ensure a symlink exists, removing any existing file/directory at the path
  */
 function ensureSymlink(target: string, linkPath: string): void {
+	const fs = getFs();
 	try {
-		const stat = lstatSync(linkPath);
+		const stat = fs.lstatSync(linkPath);
 		if (stat.isSymbolicLink()) {
-			unlinkSync(linkPath);
+			fs.unlinkSync(linkPath);
 		} else if (stat.isDirectory()) {
-			rmSync(linkPath, { recursive: true });
+			fs.rmSync(linkPath, { recursive: true });
 		} else {
-			unlinkSync(linkPath);
+			fs.unlinkSync(linkPath);
 		}
 	} catch {}
 
 	const linkDir = join(linkPath, "..");
-	mkdirSync(linkDir, { recursive: true });
-	symlinkSync(target, linkPath, "dir");
+	fs.mkdirSync(linkDir, { recursive: true });
+	fs.symlinkSync(target, linkPath, "dir");
 }
 
 /**
- * Static template for the global SKILL.md file.
- * This is the single routing skill that all agents see.
+ * Renders the global SKILL.md and symlinks the offworld/ directory to all agent skill directories.
+ *
+ * Creates:
+ * - ~/.local/share/offworld/skill/offworld/SKILL.md (routing skill, see skill-template.ts)
+ * - ~/.local/share/offworld/skill/offworld/assets/ (for map.json)
+ * - ~/.local/share/offworld/skill/offworld/references/ (for reference files)
+ * - Symlinks entire offworld/ directory to each agent's skill directory
  */
-const GLOBAL_SKILL_TEMPLATE = `---
-name: offworld
-description: Routes queries to Offworld reference files. Find and read per-repo references for dependency knowledge.
-allowed-tools: Bash(ow:*) Read
----
-
-# Offworld Reference Router
-
-Use \`ow\` to locate and read Offworld reference files for dependencies.
-
-## What This Does
-
-- Finds references for libraries and repos
-- Returns paths for reference files and local clones
-- Helps you read the right context fast
-
-## When to Use
-
-- You need docs or patterns for a dependency
-- You want the verified reference instead of web search
-- You are about to work inside a repo clone
-
-## Installation and Setup
-
-If Offworld CLI or opencode is missing, read \`references/installation.md\` in this skill directory and follow it.
-
-## Usage
+export function installGlobalSkill(): void {
+	const fs = getFs();
+	const config = loadConfig();
 
-**Find a reference:**
-\`\`\`bash
-ow map search <term>     # search by name or keyword
-ow map show <repo>       # get info for specific repo
-\`\`\`
+	fs.mkdirSync(Paths.offworldSkillDir, { recursive: true });
+	fs.mkdirSync(Paths.offworldAssetsDir, { recursive: true });
+	fs.mkdirSync(Paths.offworldReferencesDir, { recursive: true });
 
-**Get paths for tools:**
-\`\`\`bash
-ow map show <repo> --ref   # reference file path (use with Read)
-ow map show <repo> --path  # clone directory path
-\`\`\`
+	renderGlobalSkill(readGlobalMap(), config);
 
-**Example workflow:**
-\`\`\`bash
-# 1. Find the repo
-ow map search zod
+	const configuredAgents = config.agents ?? [];
+	for (const agentName of configuredAgents) {
+		const agentConfig = getAgentConfig(agentName);
+		if (agentConfig) {
+			const agentSkillDir = expandTilde(join(agentConfig.globalSkillsDir, "offworld"));
+			ensureSymlink(Paths.offworldSkillDir, agentSkillDir);
+		}
+	}
+}
 
-# 2. Get reference path
-ow map show colinhacks/zod --ref
-# Output: /Users/.../.local/share/offworld/skill/offworld/references/colinhacks-zod.md
+const MAX_SKILL_DESCRIPTION_LENGTH = 1024;
 
-# 3. Read the reference with the path from step 2
-\`\`\`
+/**
+ * Skill name for a reference in per-reference mode (e.g. "offworld-zod").
+ *
+ * @param referenceName - Reference file name without .md (see toReferenceName)
+ */
+export function toReferenceSkillName(referenceName: string): string {
+	return `offworld-${referenceName}`;
+}
 
-## If Reference Not Found
+/**
+ * Directory of a per-reference skill: ~/.local/share/offworld/skill/offworld-{name}
+ */
+export function getReferenceSkillDir(referenceName: string): string {
+	return join(Paths.skillsDir, toReferenceSkillName(referenceName));
+}
 
-\`\`\`bash
-ow pull <owner/repo>    # clone + generate reference
-ow project init         # scan project deps, install references
-\`\`\`
+/**
+ * Extract the overview paragraph that follows the reference title.
+ *
+ * @returns The paragraph with whitespace collapsed, or null if there is none
+ */
+export function extractReferenceOverview(content: string): string | null {
+	const lines = content.split("\n");
+	const titleIndex = lines.findIndex((line) => line.startsWith("# "));
+	const paragraph: string[] = [];
+
+	for (const line of lines.slice(titleIndex + 1)) {
+		const trimmed = line.trim();
+		if (!trimmed) {
+			if (paragraph.length > 0) break;
+			continue;
+		}
+		if (/^(#|```|\||>|[-*] )/.test(trimmed)) {
+			if (paragraph.length > 0) break;
+			if (trimmed.startsWith("#")) return null;
+			continue;
+		}
+		paragraph.push(trimmed);
+	}
 
-## Notes
+	return paragraph.length > 0 ? paragraph.join(" ") : null;
+}
 
-- Project map (\`.offworld/map.json\`) takes precedence over global map when present
-- Reference files are markdown with API docs, patterns, best practices
-- Clone paths useful for exploring source code after reading reference
+export interface ReferenceSection {
+	/** Heading text without the leading #s */
+	heading: string;
+	level: number;
+}
 
-## Additional Resources
+/**
+ * Headings of a reference, skipping lines inside code fences.
+ */
+function parseHeadings(lines: string[]): Array<ReferenceSection & { line: number }> {
+	const headings: Array<ReferenceSection & { line: number }> = [];
+	let inFence = false;
+
+	lines.forEach((line, index) => {
+		if (line.trimStart().startsWith("```")) {
+			inFence = !inFence;
+			return;
+		}
+		const match = inFence ? null : line.match(/^(#{1,6})\s+(.+?)\s*#*\s*$/);
+		if (match) headings.push({ heading: match[2]!, level: match[1]!.length, line: index });
+	});
 
-- Docs: https://offworld.sh/cli
-`;
+	return headings;
+}
 
-const INSTALLATION_REFERENCE_TEMPLATE = `# Offworld Installation
+/**
+ * Section headings of a reference, in document order.
+ */
+export function listReferenceSections(content: string): ReferenceSection[] {
+	return parseHeadings(content.split("\n")).map(({ heading, level }) => ({ heading, level }));
+}
 
-Use this when Offworld CLI or opencode is not installed.
+/**
+ * A section of a reference: its heading line through the line before the next heading of the
+ * same or higher level. Headings match case-insensitively.
+ *
+ * @returns The section markdown, or null if no heading matches
+ */
+export function extractReferenceSection(content: string, heading: string): string | null {
+	const lines = content.split("\n");
+	const headings = parseHeadings(lines);
+	const wanted = heading.trim().toLowerCase();
+	const index = headings.findIndex((h) => h.heading.toLowerCase() === wanted);
+	if (index === -1) return null;
+
+	const start = headings[index]!;
+	const end = headings.slice(index + 1).find((h) => h.level <= start.level);
+	return lines
+		.slice(start.line, end?.line ?? lines.length)
+		.join("\n")
+		.trimEnd();
+}
 
-## 1) Check opencode
+/** Cheat sheets are capped at roughly 1k tokens */
+export const MAX_SHORT_REFERENCE_CHARS = 4000;
 
-\`\`\`bash
-opencode --version
-\`\`\`
+const MAX_SHORT_REFERENCE_PATTERNS = 5;
 
-If missing:
+function findSection(content: string, matches: (heading: string) => boolean): string[] {
+	const section = listReferenceSections(content).find(
+		(h) => h.level > 1 && matches(h.heading.toLowerCase()),
+	);
+	if (!section) return [];
+	return (extractReferenceSection(content, section.heading) ?? "").split("\n").slice(1);
+}
 
-\`\`\`bash
-curl -fsSL https://opencode.ai/install | bash
-\`\`\`
+function firstCodeBlock(lines: string[]): string[] {
+	const start = lines.findIndex((line) => line.trimStart().startsWith("```"));
+	if (start === -1) return [];
+	const end = lines.findIndex((line, i) => i > start && line.trimStart().startsWith("```"));
+	return end === -1 ? [] : lines.slice(start, end + 1);
+}
 
-## 2) Check Offworld CLI
+/**
+ * Patterns in a "Common Patterns" section: each `###` subsection or `**Name:**` line with the
+ * first code block that follows it.
+ */
+function splitPatterns(lines: string[]): string[][] {
+	const patterns: string[][] = [];
+	let current: string[] | null = null;
+	let inFence = false;
+
+	for (const line of lines) {
+		if (line.trimStart().startsWith("```")) inFence = !inFence;
+		if (!inFence && /^(###+\s|\*\*.+\*\*:?\s*$)/.test(line.trim())) {
+			current = [line.trim()];
+			patterns.push(current);
+		} else {
+			current?.push(line);
+		}
+	}
 
-\`\`\`bash
-ow --version
-\`\`\`
+	return patterns
+		.map(([title, ...body]) => [title!, ...firstCodeBlock(body)])
+		.filter((pattern) => pattern.length > 1);
+}
 
-If missing:
+/**
+ * Derive a cheat sheet from a full reference: overview, install, the first five patterns and
+ * the key exports table, trimmed to MAX_SHORT_REFERENCE_CHARS. Used when generation or
+ * offworld.sh did not provide one.
+ *
+ * @param referenceFileName - Full reference file name, linked at the end
+ */
+export function buildShortReference(content: string, referenceFileName?: string): string {
+	const title = content.split("\n").find((line) => line.startsWith("# ")) ?? "# Reference";
+	const overview = extractReferenceOverview(content);
+	const install = firstCodeBlock(findSection(content, (h) => h.includes("install")));
+	const patterns = splitPatterns(findSection(content, (h) => h.includes("pattern"))).slice(
+		0,
+		MAX_SHORT_REFERENCE_PATTERNS,
+	);
+	const exports = findSection(content, (h) => /\bapi\b|export/.test(h)).filter(
+		(line) => line.trim().startsWith("|"),
+	);
+	const footer = referenceFileName ? `Full reference: \`${referenceFileName}\`` : null;
+
+	const render = () => {
+		const parts = [`${title} (cheat sheet)`];
+		if (overview) parts.push(overview);
+		if (install.length > 0) parts.push(["## Install", "", ...install].join("\n"));
+		if (patterns.length > 0) {
+			parts.push(["## Top Patterns", ...patterns.map((p) => ["", ...p].join("\n"))].join("\n"));
+		}
+		if (exports.length > 2) parts.push(["## Key Exports", "", ...exports].join("\n"));
+		if (footer) parts.push(footer);
+		return `${parts.join("\n\n")}\n`;
+	};
 
-\`\`\`bash
-curl -fsSL https://offworld.sh/install | bash
-\`\`\`
+	let short = render();
+	while (short.length > MAX_SHORT_REFERENCE_CHARS && patterns.length > 1) {
+		patterns.pop();
+		short = render();
+	}
+	while (short.length > MAX_SHORT_REFERENCE_CHARS && exports.length > 3) {
+		exports.pop();
+		short = render();
+	}
+	return short.length > MAX_SHORT_REFERENCE_CHARS
+		? `${short.slice(0, MAX_SHORT_REFERENCE_CHARS - 4).trimEnd()}\n...\n`
+		: short;
+}
 
-## 3) Initialize Offworld (non-interactive)
+function buildReferenceSkillDescription(
+	fullName: string,
+	overview: string | null,
+	keywords: string[],
+): string {
+	const summary = overview ?? `Offworld reference for ${fullName}.`;
+	const trigger = keywords.length > 0 ? ` (${keywords.join(", ")})` : "";
+	const description = `${summary} Use when working with ${fullName}${trigger}.`;
+	return description.length > MAX_SKILL_DESCRIPTION_LENGTH
+		? `${description.slice(0, MAX_SKILL_DESCRIPTION_LENGTH - 3)}...`
+		: description;
+}
 
-\`\`\`bash
-ow init --yes --agents "<agent-list>" --repo-root "<clone-dir>" --model "<provider/model>"
-\`\`\`
+interface ReferenceSkillInput {
+	referenceName: string;
+	fullName: string;
+	referencePath: string;
+	/** Cheat sheet next to the reference, if any */
+	shortReferencePath?: string;
+	localPath: string;
+	content: string;
+	keywords: string[];
+}
 
-Example:
+function buildReferenceSkill(input: ReferenceSkillInput): string {
+	const overview = extractReferenceOverview(input.content);
+	const description = buildReferenceSkillDescription(input.fullName, overview, input.keywords);
+	const lines = [
+		"---",
+		`name: ${toReferenceSkillName(input.referenceName)}`,
+		`description: ${JSON.stringify(description)}`,
+		"allowed-tools: Read",
+		"---",
+		"",
+		`# ${input.fullName}`,
+		"",
+		...(overview ? [overview, ""] : []),
+		...(input.shortReferencePath ? [`- Cheat sheet: \`${input.shortReferencePath}\``] : []),
+		`- Reference: \`${input.referencePath}\``,
+		`- Clone: \`${input.localPath}\``,
+		...(input.keywords.length > 0 ? [`- Keywords: ${input.keywords.join(", ")}`] : []),
+		"",
+		input.shortReferencePath
+			? "Start with the cheat sheet. Read the full reference when it falls short, and browse " +
+				"the clone when you need source details."
+			: "Read the reference first. Browse the clone when you need source details.",
+		"",
+	];
+	return lines.join("\n");
+}
 
-\`\`\`bash
-ow init --yes --agents "opencode,codex" --repo-root "~/ow" --model "anthropic/claude-sonnet-4-20250514"
-\`\`\`
+/**
+ * Write the per-reference SKILL.md and symlink it into each configured agent's skill directory.
+ *
+ * @returns The skill directory
+ */
+export function installReferenceSkill(
+	input: ReferenceSkillInput,
+	config: Config = loadConfig(),
+): string {
+	const fs = getFs();
+	const skillDir = getReferenceSkillDir(input.referenceName);
+	fs.mkdirSync(skillDir, { recursive: true });
+	fs.writeFileSync(join(skillDir, "SKILL.md"), buildReferenceSkill(input), "utf-8");
+
+	const skillName = toReferenceSkillName(input.referenceName);
+	for (const agentName of config.agents ?? []) {
+		const agentConfig = getAgentConfig(agentName);
+		if (agentConfig) {
+			ensureSymlink(skillDir, expandTilde(join(agentConfig.globalSkillsDir, skillName)));
+		}
+	}
 
-## 4) Initialize current project
+	return skillDir;
+}
 
-\`\`\`bash
-ow project init --yes --all
-\`\`\`
+/**
+ * Existing files for a per-reference skill: its directory and any agent symlinks to it.
+ * Every known agent is checked, not just configured ones, so stale links are found too.
+ */
+export function getReferenceSkillPaths(referenceName: string): string[] {
+	const fs = getFs();
+	const paths: string[] = [];
+	const skillDir = getReferenceSkillDir(referenceName);
+	if (fs.existsSync(skillDir)) {
+		paths.push(skillDir);
+	}
 
-## 5) Verify
+	const skillName = toReferenceSkillName(referenceName);
+	for (const agentConfig of getAllAgentConfigs()) {
+		const linkPath = expandTilde(join(agentConfig.globalSkillsDir, skillName));
+		try {
+			if (fs.lstatSync(linkPath).isSymbolicLink()) {
+				paths.push(linkPath);
+			}
+		} catch {}
+	}
 
-\`\`\`bash
-ow config show
-ow list
-\`\`\`
-`;
+	return paths;
+}
 
 /**
- * Ensures the global SKILL.md exists and symlinks the offworld/ directory to all agent skill directories.
+ * Remove a per-reference skill and its agent symlinks.
  *
- * Creates:
- * - ~/.local/share/offworld/skill/offworld/SKILL.md (static routing template)
- * - ~/.local/share/offworld/skill/offworld/assets/ (for map.json)
- * - ~/.local/share/offworld/skill/offworld/references/ (for reference files)
- * - Symlinks entire offworld/ directory to each agent's skill directory
+ * @returns Paths that were removed
  */
-export function installGlobalSkill(): void {
-	const config = loadConfig();
-
-	mkdirSync(Paths.offworldSkillDir, { recursive: true });
-	mkdirSync(Paths.offworldAssetsDir, { recursive: true });
-	mkdirSync(Paths.offworldReferencesDir, { recursive: true });
-
-	const skillPath = join(Paths.offworldSkillDir, "SKILL.md");
-	if (!existsSync(skillPath)) {
-		writeFileSync(skillPath, GLOBAL_SKILL_TEMPLATE, "utf-8");
+export function removeReferenceSkill(referenceName: string): string[] {
+	const fs = getFs();
+	const paths = getReferenceSkillPaths(referenceName);
+	for (const path of paths) {
+		fs.rmSync(path, { recursive: true, force: true });
 	}
+	return paths;
+}
 
-	const installationReferencePath = join(Paths.offworldReferencesDir, "installation.md");
-	if (!existsSync(installationReferencePath)) {
-		writeFileSync(installationReferencePath, INSTALLATION_REFERENCE_TEMPLATE, "utf-8");
+/**
+ * A missing TypeScript install or unsupported language shouldn't block installing the reference.
+ */
+async function withApiAppendix(content: string, repoPath: string, commitSha: string) {
+	try {
+		return await appendApiSignatures(content, repoPath, commitSha);
+	} catch (error) {
+		const message = error instanceof Error ? error.message : String(error);
+		getRuntime().logger.debug(`Skipped API signature appendix: ${message}`);
+		return content;
 	}
+}
 
-	const configuredAgents = config.agents ?? [];
-	for (const agentName of configuredAgents) {
-		const agentConfig = agents[agentName];
-		if (agentConfig) {
-			const agentSkillDir = expandTilde(join(agentConfig.globalSkillsDir, "offworld"));
-			ensureSymlink(Paths.offworldSkillDir, agentSkillDir);
-		}
+function readInstalledMeta(metaPath: string): InstallReferenceMeta | null {
+	const fs = getFs();
+	try {
+		if (!fs.existsSync(metaPath)) return null;
+		return JSON.parse(fs.readFileSync(metaPath, "utf-8")) as InstallReferenceMeta;
+	} catch {
+		return null;
 	}
 }
 
@@ -273,7 +483,10 @@ export function installGlobalSkill(): void {
  *
  * Creates:
  * - ~/.local/share/offworld/skill/offworld/references/{owner-repo}.md
+ * - ~/.local/share/offworld/skill/offworld/references/{owner-repo}.short.md (cheat sheet)
+ * - An "API Signatures (verified)" appendix in the reference (apiAppendix)
  * - ~/.local/share/offworld/meta/{owner-repo}/meta.json
+ * - ~/.local/share/offworld/skill/offworld-{owner-repo}/SKILL.md (skillMode "per-reference")
  * - Updates global map with reference info
  *
  * @param qualifiedName - Qualified key for map storage (e.g., "github.com:owner/repo" or "local:name")
@@ -282,28 +495,56 @@ export function installGlobalSkill(): void {
  * @param referenceContent - The generated reference markdown content
  * @param meta - Metadata about the generation (referenceUpdatedAt, commitSha, version)
  * @param keywords - Optional array of keywords for search/routing
+ * @param options - Reference source (for postInstall hooks), cheat sheet and API appendix
  */
-export function installReference(
+export async function installReference(
 	qualifiedName: string,
 	fullName: string,
 	localPath: string,
 	referenceContent: string,
 	meta: InstallReferenceMeta,
 	keywords?: string[],
-): void {
+	options: InstallReferenceOptions = {},
+): Promise<void> {
+	const fs = getFs();
 	installGlobalSkill();
 
 	const referenceFileName = toReferenceFileName(fullName);
 	const metaDirName = toMetaDirName(fullName);
 
 	const referencePath = join(Paths.offworldReferencesDir, referenceFileName);
-	mkdirSync(Paths.offworldReferencesDir, { recursive: true });
-	writeFileSync(referencePath, referenceContent, "utf-8");
+	fs.mkdirSync(Paths.offworldReferencesDir, { recursive: true });
+	const config = loadConfig();
+	const processed = applyReferencePostProcessors(referenceContent, {
+		qualifiedName,
+		fullName,
+		repoPath: localPath,
+	});
+	const content =
+		(options.apiAppendix ?? config.apiAppendix)
+			? await withApiAppendix(processed, localPath, meta.commitSha)
+			: processed;
+	fs.writeFileSync(referencePath, content, "utf-8");
+	const shortReferencePath = join(
+		Paths.offworldReferencesDir,
+		toShortReferenceFileName(referenceFileName),
+	);
+	const shortContent =
+		options.shortContent?.trim() && options.shortContent.length <= MAX_SHORT_REFERENCE_CHARS
+			? options.shortContent
+			: buildShortReference(processed, referenceFileName);
+	fs.writeFileSync(shortReferencePath, shortContent, "utf-8");
 
 	const metaDir = join(Paths.metaDir, metaDirName);
-	mkdirSync(metaDir, { recursive: true });
-	const metaJson = JSON.stringify(meta, null, 2);
-	writeFileSync(join(metaDir, "meta.json"), metaJson, "utf-8");
+	const metaPath = join(metaDir, "meta.json");
+	const previousMeta = readInstalledMeta(metaPath);
+	fs.mkdirSync(metaDir, { recursive: true });
+	const source: ReferenceOrigin | undefined =
+		options.referenceSource === "remote" && options.verified
+			? "verified"
+			: options.referenceSource;
+	const metaJson = JSON.stringify({ ...meta, source }, null, 2);
+	fs.writeFileSync(metaPath, metaJson, "utf-8");
 
 	const map = readGlobalMap();
 	const existingEntry = map.repos[qualifiedName];
@@ -331,11 +572,48 @@ export function installReference(
 		primary: referenceFileName,
 		keywords: normalizeKeywords(derivedKeywords),
 		updatedAt: new Date().toISOString(),
+		archived: existingEntry?.archived,
+		deprecated: existingEntry?.deprecated,
+		forkOf: options.forkOf,
 	};
 
 	if (legacyQualifiedName && legacyQualifiedName in map.repos) {
 		delete map.repos[legacyQualifiedName];
 	}
 
-	writeGlobalMap(map);
+	writeGlobalMap(map, { referencesChanged: true });
+
+	if (config.skillMode === "per-reference") {
+		installReferenceSkill(
+			{
+				referenceName: referenceFileName.replace(/\.md$/, ""),
+				fullName,
+				referencePath,
+				shortReferencePath,
+				localPath,
+				content,
+				keywords: map.repos[qualifiedName]!.keywords,
+			},
+			config,
+		);
+	}
+
+	recordOperation({
+		op: "install",
+		repo: qualifiedName,
+		summary: previousMeta
+			? `${previousMeta.commitSha.slice(0, 7)} → ${meta.commitSha.slice(0, 7)}`
+			: `${meta.commitSha.slice(0, 7)} (${options.referenceSource ?? "local"})`,
+		before: previousMeta ?? undefined,
+		after: { ...meta, referencePath, referenceSource: options.referenceSource },
+	});
+
+	await runHooks("postInstall", {
+		repo: qualifiedName,
+		fullName,
+		repoPath: localPath,
+		referencePath,
+		commitSha: meta.commitSha,
+		referenceSource: options.referenceSource,
+	});
 }
@@ -0,0 +1,103 @@
+/**
+ * Remote check cache
+ *
+ * Results of offworld.sh existence checks, keyed by owner/repo and stored in the state
+ * directory. The daemon refreshes them on a schedule; dependency matching reuses fresh
+ * positive results instead of querying offworld.sh for every dependency.
+ */
+
+import { join } from "node:path";
+import { z } from "zod";
+import { Paths } from "./paths.js";
+import { getFs } from "./runtime.js";
+
+const CachedRemoteCheckSchema = z.object({
+	exists: z.boolean(),
+	commitSha: z.string().optional(),
+	generatedAt: z.string().optional(),
+	checkedAt: z.string(),
+});
+
+export type CachedRemoteCheck = z.infer<typeof CachedRemoteCheckSchema>;
+
+/** Cached checks older than this are ignored (6 hours) */
+export const REMOTE_CHECK_TTL_MS = 6 * 60 * 60 * 1000;
+
+export interface RefreshRemoteChecksResult {
+	checked: number;
+	failed: Array<{ repo: string; error: string }>;
+}
+
+function getCachePath(): string {
+	return join(Paths.state, "remote-checks.json");
+}
+
+/**
+ * All cached checks. Missing or unreadable caches are empty.
+ */
+export function readRemoteCheckCache(): Record<string, CachedRemoteCheck> {
+	const fs = getFs();
+	try {
+		const cachePath = getCachePath();
+		if (!fs.existsSync(cachePath)) return {};
+		const parsed = z
+			.record(z.string(), CachedRemoteCheckSchema)
+			.safeParse(JSON.parse(fs.readFileSync(cachePath, "utf-8")));
+		return parsed.success ? parsed.data : {};
+	} catch {
+		return {};
+	}
+}
+
+/**
+ * A cached check for owner/repo, or null if there is none younger than maxAgeMs.
+ */
+export function getCachedRemoteCheck(
+	fullName: string,
+	maxAgeMs: number = REMOTE_CHECK_TTL_MS,
+): CachedRemoteCheck | null {
+	const cached = readRemoteCheckCache()[fullName.toLowerCase()];
+	if (!cached) return null;
+	return Date.now() - new Date(cached.checkedAt).getTime() <= maxAgeMs ? cached : null;
+}
+
+/**
+ * Merge check results into the cache. Never throws.
+ */
+export function writeRemoteChecks(
+	results: Record<string, { exists: boolean; commitSha?: string; generatedAt?: string }>,
+): void {
+	const fs = getFs();
+	try {
+		const checkedAt = new Date().toISOString();
+		const cache = readRemoteCheckCache();
+		for (const [fullName, result] of Object.entries(results)) {
+			cache[fullName.toLowerCase()] = { ...result, checkedAt };
+		}
+		fs.mkdirSync(Paths.state, { recursive: true });
+		fs.writeFileSync(getCachePath(), JSON.stringify(cache, null, 2), "utf-8");
+	} catch {}
+}
+
+/**
+ * Check offworld.sh for each owner/repo and cache the results.
+ */
+export async function refreshRemoteChecks(fullNames: string[]): Promise<RefreshRemoteChecksResult> {
+	const { checkRemote } = await import("./sync.js");
+	const results: Record<string, Awaited<ReturnType<typeof checkRemote>>> = {};
+	const failed: RefreshRemoteChecksResult["failed"] = [];
+
+	for (const fullName of fullNames) {
+		try {
+			results[fullName] = await checkRemote(fullName);
+		} catch (error) {
+			failed.push({
+				repo: fullName,
+				error: error instanceof Error ? error.message : String(error),
+			});
+		}
+	}
+
+	writeRemoteChecks(results);
+	return { checked: Object.keys(results).length, failed };
+}
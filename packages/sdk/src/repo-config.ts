@@ -0,0 +1,152 @@
+/**
+ * Per-repository configuration
+ *
+ * The `repos` section of config maps glob patterns to overrides. Patterns match against
+ * "owner/repo", optionally prefixed with a provider ("github:owner/repo"). `*` matches within
+ * a path segment and `**` across segments. When several patterns match, more specific ones
+ * win: literal patterns beat globs, then longer patterns beat shorter ones.
+ */
+
+import type { Config, RepoOverride, RepoSource } from "@offworld/types";
+import { loadConfig } from "./config.js";
+
+export interface RepoSettings {
+	/** Model in provider/model format */
+	model: string;
+	sparse: boolean;
+	/** Sparse checkout paths; undefined uses the built-in defaults */
+	sparsePaths?: string[];
+	branch?: string;
+	pin?: string;
+	maxCommitDistance: number;
+	acceptUnknownDistance: boolean;
+	trustRemote: boolean;
+	prompt?: string;
+	/** Patterns from config.repos that matched, least specific first */
+	matched: string[];
+}
+
+interface RepoMatchTarget {
+	provider: string;
+	fullName: string;
+}
+
+const HOST_PROVIDERS: Record<string, string> = {
+	"github.com": "github",
+	"gitlab.com": "gitlab",
+	"bitbucket.org": "bitbucket",
+};
+
+function normalizeProvider(provider: string): string {
+	const lower = provider.toLowerCase();
+	return HOST_PROVIDERS[lower] ?? lower;
+}
+
+/**
+ * Accepts a RepoSource, a qualified name ("github.com:owner/repo"), a provider-prefixed
+ * name ("gitlab:owner/repo") or a bare "owner/repo" (GitHub, as in parseRepoInput).
+ */
+function toMatchTarget(repo: RepoSource | string): RepoMatchTarget {
+	if (typeof repo !== "string") {
+		return repo.type === "remote"
+			? { provider: repo.provider, fullName: repo.fullName.toLowerCase() }
+			: { provider: "local", fullName: repo.name.toLowerCase() };
+	}
+
+	const separator = repo.indexOf(":");
+	if (separator === -1) {
+		return { provider: "github", fullName: repo.toLowerCase() };
+	}
+	return {
+		provider: normalizeProvider(repo.slice(0, separator)),
+		fullName: repo.slice(separator + 1).toLowerCase(),
+	};
+}
+
+function globToRegExp(glob: string): RegExp {
+	let source = "";
+	for (let i = 0; i < glob.length; i++) {
+		const char = glob[i]!;
+		if (char === "*") {
+			if (glob[i + 1] === "*") {
+				source += ".*";
+				i++;
+			} else {
+				source += "[^/]*";
+			}
+		} else if (char === "?") {
+			source += "[^/]";
+		} else {
+			source += char.replace(/[.+^${}()|[\]\\]/g, "\\$&");
+		}
+	}
+	return new RegExp(`^${source}$`, "i");
+}
+
+/**
+ * Whether a `repos` pattern applies to a repository.
+ */
+export function matchesRepoPattern(pattern: string, repo: RepoSource | string): boolean {
+	const target = toMatchTarget(repo);
+	const separator = pattern.indexOf(":");
+	if (separator === -1) {
+		return globToRegExp(pattern).test(target.fullName);
+	}
+
+	const providerPattern = normalizeProvider(pattern.slice(0, separator));
+	return (
+		globToRegExp(providerPattern).test(target.provider) &&
+		globToRegExp(pattern.slice(separator + 1)).test(target.fullName)
+	);
+}
+
+function specificity(pattern: string): number {
+	const literal = pattern.replace(/[*?]/g, "");
+	return (literal.length === pattern.length ? 10_000 : 0) + literal.length;
+}
+
+/**
+ * Overrides from every matching pattern, merged with the most specific last.
+ */
+export function getRepoOverrides(
+	repo: RepoSource | string,
+	config: Config = loadConfig(),
+): { override: RepoOverride; matched: string[] } {
+	const matched = Object.keys(config.repos ?? {})
+		.filter((pattern) => matchesRepoPattern(pattern, repo))
+		.sort((a, b) => specificity(a) - specificity(b));
+
+	const override: RepoOverride = {};
+	for (const pattern of matched) {
+		for (const [key, value] of Object.entries(config.repos[pattern]!)) {
+			if (value !== undefined) {
+				(override as Record<string, unknown>)[key] = value;
+			}
+		}
+	}
+	return { override, matched };
+}
+
+/**
+ * Effective settings for a repository: global config with matching overrides applied.
+ */
+export function getRepoSettings(
+	repo: RepoSource | string,
+	config: Config = loadConfig(),
+): RepoSettings {
+	const { override, matched } = getRepoOverrides(repo, config);
+
+	return {
+		model: override.model ?? config.defaultModel,
+		sparse: override.sparse ?? (override.sparsePaths !== undefined),
+		sparsePaths: override.sparsePaths,
+		branch: override.branch,
+		pin: override.pin,
+		maxCommitDistance: override.maxCommitDistance ?? config.maxCommitDistance ?? 20,
+		acceptUnknownDistance:
+			override.acceptUnknownDistance ?? config.acceptUnknownDistance ?? false,
+		trustRemote: override.trustRemote ?? true,
+		prompt: override.prompt,
+		matched,
+	};
+}
@@ -0,0 +1,220 @@
+/**
+ * Dependency graph between installed repos
+ *
+ * A repo depends on another installed repo when its manifests (package.json and workspaces,
+ * pyproject.toml, Cargo.toml, go.mod, plugin parsers) list a package the other one publishes.
+ * Dev dependencies are ignored so shared tooling doesn't link everything together. Edges are
+ * stored as `dependsOn` on global map entries and recomputed on every map write; each repo's
+ * manifest summary is cached in its meta directory until its map entry changes.
+ */
+
+import { join } from "node:path";
+import type { GlobalMap } from "@offworld/types";
+import { getMetaPath } from "./config.js";
+import { parseDependencies, parsePackageNames } from "./manifest.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+export const GRAPH_FORMATS = ["dot", "json"] as const;
+export type GraphFormat = (typeof GRAPH_FORMATS)[number];
+
+/** Installed repos each repo depends on, keyed by qualified name */
+export type DependencyGraph = Record<string, string[]>;
+
+export interface RepoManifestSummary {
+	/** updatedAt of the map entry the summary was read for */
+	updatedAt: string;
+	/** Package names the repo publishes */
+	packages: string[];
+	/** Non-dev dependency names */
+	dependencies: string[];
+}
+
+export interface RepoDependencies {
+	/** Installed repos this repo depends on */
+	upstream: string[];
+	/** Installed repos that depend on this repo */
+	downstream: string[];
+}
+
+export interface DependencyGraphJson {
+	nodes: Array<{ repo: string; fullName: string }>;
+	edges: Array<{ from: string; to: string }>;
+}
+
+type MapEntry = GlobalMap["repos"][string];
+
+function toFullName(qualifiedName: string): string {
+	const separator = qualifiedName.indexOf(":");
+	return separator === -1 ? qualifiedName : qualifiedName.slice(separator + 1);
+}
+
+function getSummaryPath(qualifiedName: string): string {
+	return join(getMetaPath(toFullName(qualifiedName)), "manifest.json");
+}
+
+/**
+ * Package and dependency names for a repo, from the cache when the map entry is unchanged.
+ */
+export function getManifestSummary(qualifiedName: string, entry: MapEntry): RepoManifestSummary {
+	const fs = getFs();
+	const path = getSummaryPath(qualifiedName);
+	try {
+		const cached = JSON.parse(fs.readFileSync(path, "utf-8")) as RepoManifestSummary;
+		if (cached.updatedAt === entry.updatedAt) return cached;
+	} catch {
+		// Missing or unreadable; parse the manifests below
+	}
+
+	const summary: RepoManifestSummary = {
+		updatedAt: entry.updatedAt,
+		packages: fs.existsSync(entry.localPath) ? parsePackageNames(entry.localPath) : [],
+		dependencies: fs.existsSync(entry.localPath)
+			? parseDependencies(entry.localPath)
+					.filter((dep) => !dep.dev)
+					.map((dep) => dep.name)
+			: [],
+	};
+	try {
+		fs.mkdirSync(getMetaPath(toFullName(qualifiedName)), { recursive: true });
+		fs.writeFileSync(path, JSON.stringify(summary, null, 2), "utf-8");
+	} catch {
+		// The cache is an optimization only
+	}
+	return summary;
+}
+
+/**
+ * Compute which installed repos each repo depends on.
+ */
+export function buildDependencyGraph(map: GlobalMap): DependencyGraph {
+	const summaries = Object.entries(map.repos).map(
+		([qualifiedName, entry]) => [qualifiedName, getManifestSummary(qualifiedName, entry)] as const,
+	);
+
+	const publishers = new Map<string, string[]>();
+	for (const [qualifiedName, summary] of summaries) {
+		for (const name of summary.packages) {
+			publishers.set(name, [...(publishers.get(name) ?? []), qualifiedName]);
+		}
+	}
+
+	const graph: DependencyGraph = {};
+	for (const [qualifiedName, summary] of summaries) {
+		const upstream = new Set<string>();
+		for (const dependency of summary.dependencies) {
+			for (const publisher of publishers.get(dependency) ?? []) {
+				// Workspace packages depending on each other stay inside one repo
+				if (publisher !== qualifiedName) upstream.add(publisher);
+			}
+		}
+		graph[qualifiedName] = [...upstream].sort();
+	}
+	return graph;
+}
+
+/**
+ * Store the dependency graph on the map's entries. Never throws: on failure the map is
+ * returned without edges.
+ */
+export function applyDependencyGraph(map: GlobalMap): GlobalMap {
+	try {
+		const graph = buildDependencyGraph(map);
+		const repos: GlobalMap["repos"] = {};
+		for (const [qualifiedName, entry] of Object.entries(map.repos)) {
+			repos[qualifiedName] = { ...entry, dependsOn: graph[qualifiedName] ?? [] };
+		}
+		return { ...map, repos };
+	} catch (error) {
+		getRuntime().logger.debug(
+			`Skipped dependency graph: ${error instanceof Error ? error.message : error}`,
+		);
+		return map;
+	}
+}
+
+/**
+ * The stored graph, or a freshly computed one for maps written before edges were stored.
+ */
+export function getDependencyGraph(map: GlobalMap): DependencyGraph {
+	const entries = Object.entries(map.repos);
+	if (entries.some(([, entry]) => entry.dependsOn === undefined)) {
+		return buildDependencyGraph(map);
+	}
+	return Object.fromEntries(entries.map(([name, entry]) => [name, entry.dependsOn ?? []]));
+}
+
+/**
+ * Direct upstream and downstream repos of an installed repo.
+ */
+export function getRepoDependencies(
+	qualifiedName: string,
+	map: GlobalMap,
+	graph: DependencyGraph = getDependencyGraph(map),
+): RepoDependencies {
+	const downstream = Object.entries(graph)
+		.filter(([name, upstream]) => name !== qualifiedName && upstream.includes(qualifiedName))
+		.map(([name]) => name)
+		.sort();
+	return { upstream: graph[qualifiedName] ?? [], downstream };
+}
+
+function collect(start: string, next: (name: string) => string[], into: Set<string>): void {
+	const stack = [start];
+	while (stack.length > 0) {
+		for (const name of next(stack.pop()!)) {
+			if (into.has(name)) continue;
+			into.add(name);
+			stack.push(name);
+		}
+	}
+}
+
+/**
+ * Graph nodes and edges, limited to a repo and everything it transitively depends on or is
+ * depended on by when one is given.
+ */
+export function selectDependencyGraph(map: GlobalMap, qualifiedName?: string): DependencyGraphJson {
+	const graph = getDependencyGraph(map);
+	let names = new Set(Object.keys(graph));
+
+	if (qualifiedName) {
+		names = new Set([qualifiedName]);
+		collect(qualifiedName, (name) => graph[name] ?? [], names);
+		collect(qualifiedName, (name) => getRepoDependencies(name, map, graph).downstream, names);
+	}
+
+	const sorted = [...names].sort();
+	return {
+		nodes: sorted.map((repo) => ({ repo, fullName: toFullName(repo) })),
+		edges: sorted.flatMap((from) =>
+			(graph[from] ?? []).filter((to) => names.has(to)).map((to) => ({ from, to })),
+		),
+	};
+}
+
+function quote(value: string): string {
+	return `"${value.replace(/\\/g, "\\\\").replace(/"/g, '\\"')}"`;
+}
+
+/**
+ * Render the graph as Graphviz DOT (edges point from a repo to its dependency) or JSON.
+ */
+export function renderDependencyGraph(
+	map: GlobalMap,
+	options: { repo?: string; format?: GraphFormat } = {},
+): string {
+	const selected = selectDependencyGraph(map, options.repo);
+	if (options.format === "json") {
+		return JSON.stringify(selected, null, 2);
+	}
+
+	const lines = ["digraph offworld {", "\trankdir=LR;", "\tnode [shape=box];"];
+	for (const node of selected.nodes) {
+		lines.push(`\t${quote(node.repo)} [label=${quote(node.fullName)}];`);
+	}
+	for (const edge of selected.edges) {
+		lines.push(`\t${quote(edge.from)} -> ${quote(edge.to)};`);
+	}
+	lines.push("}");
+	return lines.join("\n");
+}
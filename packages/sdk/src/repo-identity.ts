@@ -0,0 +1,250 @@
+/**
+ * Renamed, transferred, archived and deprecated repositories
+ *
+ * GitHub redirects a renamed or transferred repo's old name, so clones keep fetching while the
+ * map key, clone path, meta directory and reference file stay on the old name. A repo has moved
+ * when GitHub metadata (which follows the redirect) or the clone's origin URL names a different
+ * owner/repo; moveRepo migrates it. Archived repos (GitHub) and deprecated packages (npm) are
+ * recorded on their map entries. Each repo is checked at most once per REPO_IDENTITY_TTL_MS.
+ */
+
+import { join } from "node:path";
+import type { GlobalMapRepoEntry, RemoteRepoSource } from "@offworld/types";
+import { z } from "zod";
+import { getOriginUrl } from "./clone.js";
+import { getNpmDeprecation } from "./dep-mappings.js";
+import { readGlobalMap, writeGlobalMap } from "./index-manager.js";
+import { Paths } from "./paths.js";
+import { parseRepoInput } from "./repo-source.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+export interface RepoIdentity {
+	qualifiedName: string;
+	/** New location when the repo was renamed or transferred */
+	movedTo: RemoteRepoSource | null;
+	/** Read-only on GitHub; null when GitHub couldn't be asked */
+	archived: boolean | null;
+	/** npm deprecation message for the repo's package; null when npm couldn't be asked */
+	deprecated: string | false | null;
+}
+
+/** Repos checked more recently than this are skipped (24 hours) */
+export const REPO_IDENTITY_TTL_MS = 24 * 60 * 60 * 1000;
+
+/** Repos looked up at the same time */
+const DEFAULT_CONCURRENCY = 4;
+
+export interface CheckRepoIdentitiesOptions {
+	/** Only repos whose qualified name passes the filter */
+	filter?: (qualifiedName: string) => boolean;
+	/** Report without recording status in the map */
+	dryRun?: boolean;
+	/** Skip repos checked within this many milliseconds; 0 checks every repo */
+	maxAgeMs?: number;
+	/** Repos looked up at the same time (default 4) */
+	concurrency?: number;
+	onProgress?: (qualifiedName: string, identity: RepoIdentity) => void;
+}
+
+function getCheckTimesPath(): string {
+	return join(Paths.state, "repo-identity-checks.json");
+}
+
+/** When each repo was last checked, by qualified name. Unreadable files are empty. */
+function readCheckTimes(): Record<string, string> {
+	const fs = getFs();
+	try {
+		const path = getCheckTimesPath();
+		if (!fs.existsSync(path)) return {};
+		const parsed = z
+			.record(z.string(), z.string())
+			.safeParse(JSON.parse(fs.readFileSync(path, "utf-8")));
+		return parsed.success ? parsed.data : {};
+	} catch {
+		return {};
+	}
+}
+
+/** Record check times. Never throws. */
+function writeCheckTimes(times: Record<string, string>): void {
+	const fs = getFs();
+	try {
+		fs.mkdirSync(Paths.state, { recursive: true });
+		fs.writeFileSync(getCheckTimesPath(), JSON.stringify(times, null, 2), "utf-8");
+	} catch {}
+}
+
+/**
+ * The remote source for a map key such as "github.com:owner/repo", or null for local repos.
+ */
+export function toRemoteSource(qualifiedName: string): RemoteRepoSource | null {
+	const separator = qualifiedName.indexOf(":");
+	if (separator === -1 || qualifiedName.startsWith("local:")) return null;
+	try {
+		const source = parseRepoInput(
+			`https://${qualifiedName.slice(0, separator)}/${qualifiedName.slice(separator + 1)}`,
+		);
+		return source.type === "remote" ? source : null;
+	} catch {
+		return null;
+	}
+}
+
+/** Sources are lowercased, so case-only renames don't count as moves */
+function isSameRepo(a: RemoteRepoSource, b: RemoteRepoSource): boolean {
+	return a.qualifiedName === b.qualifiedName;
+}
+
+function fromOriginUrl(source: RemoteRepoSource, localPath: string): RemoteRepoSource | null {
+	if (!getFs().existsSync(localPath)) return null;
+	const url = getOriginUrl(localPath);
+	if (!url) return null;
+	try {
+		const origin = parseRepoInput(url);
+		return origin.type === "remote" && !isSameRepo(origin, source) ? origin : null;
+	} catch {
+		return null;
+	}
+}
+
+function readRootPackageName(localPath: string): string | null {
+	try {
+		const pkg = JSON.parse(getFs().readFileSync(join(localPath, "package.json"), "utf-8")) as {
+			name?: unknown;
+			private?: unknown;
+		};
+		return typeof pkg.name === "string" && pkg.private !== true ? pkg.name : null;
+	} catch {
+		return null;
+	}
+}
+
+async function fetchMetadata(source: RemoteRepoSource) {
+	if (source.provider !== "github") return null;
+	const { fetchGitHubMetadata } = await import("./sync.js");
+	return fetchGitHubMetadata(source.owner, source.repo);
+}
+
+function toRedirect(source: RemoteRepoSource, fullName?: string): RemoteRepoSource | null {
+	if (!fullName) return null;
+	try {
+		const current = parseRepoInput(fullName);
+		return current.type === "remote" && !isSameRepo(current, source) ? current : null;
+	} catch {
+		return null;
+	}
+}
+
+/**
+ * Look up where a GitHub repo lives now.
+ *
+ * @returns The new location if the repo was renamed or transferred, otherwise null (also when
+ * GitHub can't be reached)
+ */
+export async function findRepoRedirect(source: RemoteRepoSource): Promise<RemoteRepoSource | null> {
+	const metadata = await fetchMetadata(source);
+	return toRedirect(source, metadata?.fullName);
+}
+
+/**
+ * Check whether an installed repo has moved, is archived or has a deprecated npm package.
+ * The origin URL is only consulted when there is no GitHub metadata, so pointing origin at a
+ * fork doesn't count as a move. Lookups that fail count as "unchanged".
+ */
+export async function checkRepoIdentity(
+	qualifiedName: string,
+	entry: GlobalMapRepoEntry,
+): Promise<RepoIdentity> {
+	const identity: RepoIdentity = {
+		qualifiedName,
+		movedTo: null,
+		archived: null,
+		deprecated: null,
+	};
+	const source = toRemoteSource(qualifiedName);
+	if (!source) return identity;
+
+	const metadata = await fetchMetadata(source);
+	if (metadata) {
+		identity.archived = metadata.archived;
+		identity.movedTo = toRedirect(source, metadata.fullName);
+	} else {
+		identity.movedTo = fromOriginUrl(source, entry.localPath);
+	}
+
+	const packageName = readRootPackageName(entry.localPath);
+	identity.deprecated = packageName ? await getNpmDeprecation(packageName) : false;
+
+	return identity;
+}
+
+/**
+ * Record archived/deprecated status on map entries, keeping the previous values when GitHub or
+ * npm couldn't be asked. Never throws.
+ */
+export function applyRepoIdentities(identities: RepoIdentity[]): void {
+	try {
+		const map = readGlobalMap();
+		for (const { qualifiedName, archived, deprecated } of identities) {
+			const entry = map.repos[qualifiedName];
+			if (!entry) continue;
+			map.repos[qualifiedName] = {
+				...entry,
+				archived: archived === null ? entry.archived : archived || undefined,
+				deprecated: deprecated === null ? entry.deprecated : deprecated || undefined,
+			};
+		}
+		writeGlobalMap(map);
+	} catch (error) {
+		getRuntime().logger.debug(
+			`Skipped repo status update: ${error instanceof Error ? error.message : error}`,
+		);
+	}
+}
+
+/**
+ * Check installed repos (or those passing the filter) that weren't checked within maxAgeMs and
+ * record archived/deprecated status in the map. Moves are only reported; migrate them with
+ * moveRepo.
+ */
+export async function checkRepoIdentities(
+	options: CheckRepoIdentitiesOptions = {},
+): Promise<RepoIdentity[]> {
+	const {
+		filter,
+		dryRun = false,
+		maxAgeMs = REPO_IDENTITY_TTL_MS,
+		concurrency = DEFAULT_CONCURRENCY,
+		onProgress,
+	} = options;
+	const map = readGlobalMap();
+	const checkTimes = readCheckTimes();
+	const now = Date.now();
+	const queue = Object.entries(map.repos).filter(([qualifiedName]) => {
+		if (filter && !filter(qualifiedName)) return false;
+		const checkedAt = checkTimes[qualifiedName];
+		return !checkedAt || now - new Date(checkedAt).getTime() >= maxAgeMs;
+	});
+	const identities: RepoIdentity[] = [];
+
+	const runWorker = async () => {
+		for (let next = queue.shift(); next; next = queue.shift()) {
+			const [qualifiedName, entry] = next;
+			const identity = await checkRepoIdentity(qualifiedName, entry);
+			identities.push(identity);
+			onProgress?.(qualifiedName, identity);
+		}
+	};
+	await Promise.all(
+		Array.from({ length: Math.max(1, Math.min(concurrency, queue.length)) }, runWorker),
+	);
+	identities.sort((a, b) => a.qualifiedName.localeCompare(b.qualifiedName));
+
+	if (!dryRun) {
+		applyRepoIdentities(identities);
+		const checkedAt = new Date(now).toISOString();
+		for (const { qualifiedName } of identities) checkTimes[qualifiedName] = checkedAt;
+		writeCheckTimes(checkTimes);
+	}
+	return identities;
+}
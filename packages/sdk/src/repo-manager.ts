@@ -2,8 +2,12 @@ import { existsSync, statSync, readdirSync, rmSync } from "node:fs";
 import { join } from "node:path";
 import { updateRepo, GitError } from "./clone.js";
 import { readGlobalMap, removeGlobalMapEntry, upsertGlobalMapEntry } from "./index-manager.js";
-import { loadConfig, getRepoRoot } from "./config.js";
+import { loadConfig, getRepoRoot, toShortReferenceFileName } from "./config.js";
 import { Paths } from "./paths.js";
+import { removeReferenceSkill } from "./reference.js";
+import { getDiskUsage, invalidateDiskUsage } from "./disk-usage.js";
+import { recordOperation } from "./journal.js";
+import { summarizeUsage } from "./usage.js";
 
 export interface RepoStatusSummary {
 	total: number;
@@ -13,7 +17,12 @@ export interface RepoStatusSummary {
 }
 
 export interface RepoStatusOptions {
+	/** Re-measure disk usage instead of using cached sizes */
+	refresh?: boolean;
+	/** Called as each repo's size is known; cached repos report first */
 	onProgress?: (current: number, total: number, repo: string) => void;
+	/** Called with each repo's size and the running total */
+	onDiskUsage?: (repo: string, bytes: number, totalBytes: number, cached: boolean) => void;
 }
 
 export interface UpdateAllOptions {
@@ -54,33 +63,15 @@ export interface GcResult {
 	freedBytes: number;
 }
 
-function getDirSize(dirPath: string): number {
-	if (!existsSync(dirPath)) return 0;
-
-	let size = 0;
-	try {
-		const entries = readdirSync(dirPath, { withFileTypes: true });
-		for (const entry of entries) {
-			const fullPath = join(dirPath, entry.name);
-			if (entry.isDirectory()) {
-				size += getDirSize(fullPath);
-			} else if (entry.isFile()) {
-				try {
-					size += statSync(fullPath).size;
-				} catch {}
-			}
-		}
-	} catch {}
-	return size;
-}
-
-function getLastAccessTime(dirPath: string): Date | null {
+function getLastAccessTime(dirPath: string, lastUsedAt?: string): Date | null {
 	if (!existsSync(dirPath)) return null;
 
-	let latestTime: Date | null = null;
+	let latestTime: Date | null = lastUsedAt ? new Date(lastUsedAt) : null;
 	try {
 		const stat = statSync(dirPath);
-		latestTime = stat.mtime;
+		if (!latestTime || stat.mtime > latestTime) {
+			latestTime = stat.mtime;
+		}
 
 		const fetchHead = join(dirPath, ".git", "FETCH_HEAD");
 		if (existsSync(fetchHead)) {
@@ -111,7 +102,7 @@ function matchesPattern(name: string, pattern: string): boolean {
 const yieldToEventLoop = () => new Promise<void>((resolve) => setImmediate(resolve));
 
 export async function getRepoStatus(options: RepoStatusOptions = {}): Promise<RepoStatusSummary> {
-	const { onProgress } = options;
+	const { refresh, onProgress, onDiskUsage } = options;
 	const map = readGlobalMap();
 	const qualifiedNames = Object.keys(map.repos);
 	const total = qualifiedNames.length;
@@ -119,28 +110,34 @@ export async function getRepoStatus(options: RepoStatusOptions = {}): Promise<Re
 	let withReference = 0;
 	let missing = 0;
 	let diskBytes = 0;
+	let current = 0;
 
-	for (let i = 0; i < qualifiedNames.length; i++) {
-		const qualifiedName = qualifiedNames[i]!;
+	const repoByPath = new Map<string, string>();
+	for (const qualifiedName of qualifiedNames) {
 		const entry = map.repos[qualifiedName]!;
-		onProgress?.(i + 1, total, qualifiedName);
-
-		await yieldToEventLoop();
 
-		const exists = existsSync(entry.localPath);
-
-		if (!exists) {
+		if (!existsSync(entry.localPath)) {
 			missing++;
+			onProgress?.(++current, total, qualifiedName);
 			continue;
 		}
 
 		if (entry.references.length > 0) {
 			withReference++;
 		}
-
-		diskBytes += getDirSize(entry.localPath);
+		repoByPath.set(entry.localPath, qualifiedName);
 	}
 
+	await getDiskUsage([...repoByPath.keys()], {
+		refresh,
+		onResult: ({ repoPath, bytes, cached }) => {
+			const qualifiedName = repoByPath.get(repoPath) ?? repoPath;
+			diskBytes += bytes;
+			onProgress?.(++current, total, qualifiedName);
+			onDiskUsage?.(qualifiedName, bytes, diskBytes, cached);
+		},
+	});
+
 	return {
 		total,
 		withReference,
@@ -153,7 +150,11 @@ export async function updateAllRepos(options: UpdateAllOptions = {}): Promise<Up
 	const { pattern, dryRun = false, onProgress } = options;
 
 	const map = readGlobalMap();
-	const qualifiedNames = Object.keys(map.repos);
+	// Most-read references first, so an interrupted run still refreshes what agents use
+	const usage = summarizeUsage();
+	const qualifiedNames = Object.keys(map.repos).sort(
+		(a, b) => (usage.get(b)?.count ?? 0) - (usage.get(a)?.count ?? 0),
+	);
 	const updated: string[] = [];
 	const skipped: string[] = [];
 	const errors: Array<{ repo: string; error: string }> = [];
@@ -276,6 +277,8 @@ export async function gcRepos(options: GcOptions = {}): Promise<GcResult> {
 		? new Date(now.getTime() - olderThanDays * 24 * 60 * 60 * 1000)
 		: null;
 
+	const usage = summarizeUsage();
+	const candidates: Array<{ qualifiedName: string; reason: string }> = [];
 	for (const qualifiedName of qualifiedNames) {
 		const entry = map.repos[qualifiedName]!;
 		await yieldToEventLoop();
@@ -286,7 +289,10 @@ export async function gcRepos(options: GcOptions = {}): Promise<GcResult> {
 		let reason = "";
 
 		if (cutoffDate) {
-			const lastAccess = getLastAccessTime(entry.localPath);
+			const lastAccess = getLastAccessTime(
+				entry.localPath,
+				usage.get(qualifiedName)?.lastUsedAt,
+			);
 			if (lastAccess && lastAccess < cutoffDate) {
 				shouldRemove = true;
 				reason = `not accessed in ${olderThanDays}+ days`;
@@ -298,18 +304,27 @@ export async function gcRepos(options: GcOptions = {}): Promise<GcResult> {
 			reason = reason ? `${reason}, no reference` : "no reference";
 		}
 
-		if (!shouldRemove) continue;
+		if (shouldRemove) {
+			candidates.push({ qualifiedName, reason });
+		}
+	}
 
-		const sizeBytes = getDirSize(entry.localPath);
+	const sizes = await getDiskUsage(
+		candidates.map(({ qualifiedName }) => map.repos[qualifiedName]!.localPath),
+	);
+
+	for (const { qualifiedName, reason } of candidates) {
+		const entry = map.repos[qualifiedName]!;
+		const sizeBytes = sizes.get(entry.localPath)?.bytes ?? 0;
 		onProgress?.(qualifiedName, reason, sizeBytes);
 
 		if (!dryRun) {
 			rmSync(entry.localPath, { recursive: true, force: true });
+			invalidateDiskUsage(entry.localPath);
 
 			for (const refFile of entry.references) {
-				const refPath = join(Paths.offworldReferencesDir, refFile);
-				if (existsSync(refPath)) {
-					rmSync(refPath, { force: true });
+				for (const file of [refFile, toShortReferenceFileName(refFile)]) {
+					rmSync(join(Paths.offworldReferencesDir, file), { force: true });
 				}
 			}
 
@@ -319,9 +334,16 @@ export async function gcRepos(options: GcOptions = {}): Promise<GcResult> {
 				if (existsSync(metaPath)) {
 					rmSync(metaPath, { recursive: true, force: true });
 				}
+				removeReferenceSkill(metaDirName);
 			}
 
 			removeGlobalMapEntry(qualifiedName);
+			recordOperation({
+				op: "remove",
+				repo: qualifiedName,
+				summary: `gc: ${reason}`,
+				before: { localPath: entry.localPath, references: entry.references },
+			});
 		}
 
 		removed.push({ repo: qualifiedName, reason, sizeBytes });
@@ -3,12 +3,12 @@
  */
 
 import { createHash } from "node:crypto";
-import { existsSync, statSync } from "node:fs";
 import { resolve } from "node:path";
 import { basename } from "node:path";
 import type { GitProvider, LocalRepoSource, RemoteRepoSource, RepoSource } from "@offworld/types";
 import { toReferenceFileName } from "./config.js";
 import { expandTilde } from "./paths.js";
+import { getFs, getRuntime } from "./runtime.js";
 
 export class RepoSourceError extends Error {
 	constructor(message: string) {
@@ -145,19 +145,20 @@ function parseShortFormat(input: string): RemoteRepoSource | null {
  * Validates that the path exists and contains a .git directory
  */
 function parseLocalPath(input: string): LocalRepoSource {
-	const absolutePath = resolve(expandTilde(input));
+	const fs = getFs();
+	const absolutePath = resolve(getRuntime().cwd(), expandTilde(input));
 
-	if (!existsSync(absolutePath)) {
+	if (!fs.existsSync(absolutePath)) {
 		throw new PathNotFoundError(absolutePath);
 	}
 
-	const stats = statSync(absolutePath);
+	const stats = fs.statSync(absolutePath);
 	if (!stats.isDirectory()) {
 		throw new RepoSourceError(`Path is not a directory: ${absolutePath}`);
 	}
 
 	const gitPath = resolve(absolutePath, ".git");
-	if (!existsSync(gitPath)) {
+	if (!fs.existsSync(gitPath)) {
 		throw new NotGitRepoError(absolutePath);
 	}
 
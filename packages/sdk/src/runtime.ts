@@ -0,0 +1,211 @@
+/**
+ * Runtime dependencies for SDK operations
+ *
+ * Module-level functions resolve the filesystem, git runner, fetch, paths and config
+ * through the active runtime. The Offworld client binds its injected options for the
+ * duration of each call; outside a client call the Node defaults are used.
+ */
+
+import { AsyncLocalStorage } from "node:async_hooks";
+import * as nodeFs from "node:fs";
+import type { Config } from "@offworld/types";
+import type { GitBackend } from "./git-backend.js";
+
+/**
+ * Synchronous filesystem surface used by the SDK.
+ * Compatible with node:fs and in-memory implementations such as memfs.
+ */
+export type OffworldFileSystem = Pick<
+	typeof nodeFs,
+	| "existsSync"
+	| "readFileSync"
+	| "writeFileSync"
+	| "appendFileSync"
+	| "mkdirSync"
+	| "rmSync"
+	| "readdirSync"
+	| "statSync"
+	| "lstatSync"
+	| "unlinkSync"
+	| "renameSync"
+	| "symlinkSync"
+	| "chmodSync"
+	| "realpathSync"
+	| "openSync"
+	| "readSync"
+	| "closeSync"
+>;
+
+export interface GitRunOptions {
+	/** Working directory for the git command */
+	cwd?: string;
+}
+
+/**
+ * Executes git commands. Implementations should throw GitError on failure.
+ */
+export interface GitRunner {
+	/** Run a git command synchronously and return trimmed stdout */
+	exec(args: string[], options?: GitRunOptions): string;
+	/** Run a git command asynchronously and return trimmed stdout */
+	execAsync(args: string[], options?: GitRunOptions): Promise<string>;
+}
+
+/**
+ * Structured context attached to a log line (repo, durations, ...). Loggers may ignore it.
+ */
+export type LogFields = Record<string, unknown>;
+
+export interface OffworldLogger {
+	debug(message: string, fields?: LogFields): void;
+	info(message: string, fields?: LogFields): void;
+	warn(message: string, fields?: LogFields): void;
+	error(message: string, fields?: LogFields): void;
+}
+
+/**
+ * Root directory overrides. Derived paths (map, references, meta) follow these.
+ */
+export interface OffworldPathOverrides {
+	/** Replaces XDG_CONFIG_HOME/offworld */
+	config?: string;
+	/** Replaces XDG_DATA_HOME/offworld */
+	data?: string;
+	/** Replaces XDG_STATE_HOME/offworld */
+	state?: string;
+	/** Replaces /etc/offworld */
+	system?: string;
+}
+
+export interface OffworldRuntime {
+	fs: OffworldFileSystem;
+	/** Custom git runner. Undefined uses the system git binary. */
+	git?: GitRunner;
+	/** Custom git backend. Takes precedence over the runner and automatic selection. */
+	gitBackend?: GitBackend;
+	fetch: typeof fetch;
+	paths?: OffworldPathOverrides;
+	/** Explicit config. Undefined reads the user config file. */
+	config?: Partial<Config>;
+	logger: OffworldLogger;
+	cwd: () => string;
+}
+
+const nodeFileSystem = {
+	get existsSync() {
+		return nodeFs.existsSync;
+	},
+	get readFileSync() {
+		return nodeFs.readFileSync;
+	},
+	get writeFileSync() {
+		return nodeFs.writeFileSync;
+	},
+	get appendFileSync() {
+		return nodeFs.appendFileSync;
+	},
+	get mkdirSync() {
+		return nodeFs.mkdirSync;
+	},
+	get rmSync() {
+		return nodeFs.rmSync;
+	},
+	get readdirSync() {
+		return nodeFs.readdirSync;
+	},
+	get statSync() {
+		return nodeFs.statSync;
+	},
+	get lstatSync() {
+		return nodeFs.lstatSync;
+	},
+	get unlinkSync() {
+		return nodeFs.unlinkSync;
+	},
+	get renameSync() {
+		return nodeFs.renameSync;
+	},
+	get symlinkSync() {
+		return nodeFs.symlinkSync;
+	},
+	get chmodSync() {
+		return nodeFs.chmodSync;
+	},
+	get realpathSync() {
+		return nodeFs.realpathSync;
+	},
+	get openSync() {
+		return nodeFs.openSync;
+	},
+	get readSync() {
+		return nodeFs.readSync;
+	},
+	get closeSync() {
+		return nodeFs.closeSync;
+	},
+} as OffworldFileSystem;
+
+const silentLogger: OffworldLogger = {
+	debug: () => {},
+	info: () => {},
+	warn: () => {},
+	error: () => {},
+};
+
+const defaultRuntime: OffworldRuntime = {
+	fs: nodeFileSystem,
+	fetch: (input, init) => globalThis.fetch(input, init),
+	logger: silentLogger,
+	cwd: () => process.cwd(),
+};
+
+/**
+ * Replace the logger used outside client calls (the default is silent).
+ */
+export function setDefaultLogger(logger: OffworldLogger): void {
+	defaultRuntime.logger = logger;
+}
+
+const storage = new AsyncLocalStorage<OffworldRuntime>();
+
+/**
+ * Returns the runtime bound to the current async context, or the Node defaults.
+ */
+export function getRuntime(): OffworldRuntime {
+	return storage.getStore() ?? defaultRuntime;
+}
+
+/**
+ * Shorthand for the active filesystem.
+ */
+export function getFs(): OffworldFileSystem {
+	return getRuntime().fs;
+}
+
+/**
+ * Whether the active filesystem is the real node:fs (as opposed to an injected one).
+ */
+export function usesNodeFs(): boolean {
+	return getRuntime().fs === nodeFileSystem;
+}
+
+/**
+ * Build a runtime from partial overrides, filling gaps with Node defaults.
+ */
+export function createRuntime(overrides: Partial<OffworldRuntime> = {}): OffworldRuntime {
+	return {
+		...defaultRuntime,
+		...overrides,
+		fs: overrides.fs ?? defaultRuntime.fs,
+		fetch: overrides.fetch ?? defaultRuntime.fetch,
+		logger: overrides.logger ?? defaultRuntime.logger,
+		cwd: overrides.cwd ?? defaultRuntime.cwd,
+	};
+}
+
+/**
+ * Run a function with the given runtime bound for all nested SDK calls.
+ */
+export function runWithRuntime<T>(runtime: OffworldRuntime, fn: () => T): T {
+	return storage.run(runtime, fn);
+}
@@ -0,0 +1,472 @@
+/**
+ * Global skill templates
+ *
+ * SKILL.md and references/installation.md are rendered from templates whenever the global map
+ * changes, so the skill can list installed references. Users can replace either template via
+ * `templates` in config. The syntax is a small mustache subset:
+ *
+ * - `{{name}}` inserts a variable (`{{this}}` inside `each`; lists are joined with ", ")
+ * - `{{#if name}}...{{else}}...{{/if}}` (empty lists and 0 are falsy)
+ * - `{{#each name}}...{{/each}}` repeats for each item, with its fields in scope
+ *
+ * Block tags on a line of their own don't leave blank lines behind.
+ */
+
+import { join, resolve } from "node:path";
+import type { Config, GlobalMap } from "@offworld/types";
+import { loadConfig } from "./config.js";
+import { VERSION } from "./constants.js";
+import { expandTilde, Paths } from "./paths.js";
+import { getPluginCommands } from "./plugins.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+const DEFAULT_INLINE_REFERENCE_LIMIT = 20;
+const TOP_REPOS_LIMIT = 10;
+
+export interface SkillTemplateReference {
+	/** Display name ("owner/repo" or local name) */
+	name: string;
+	qualifiedName: string;
+	/** Primary reference file name in references/ */
+	reference: string;
+	keywords: string[];
+	localPath: string;
+	updatedAt: string;
+}
+
+export interface SkillTemplateCommand {
+	command: string;
+	description: string;
+}
+
+export interface SkillTemplateContext {
+	version: string;
+	commands: SkillTemplateCommand[];
+	referenceCount: number;
+	/** All installed references (clone-only repos are left out), sorted by name */
+	references: SkillTemplateReference[];
+	/** Most recently updated references */
+	topRepos: SkillTemplateReference[];
+	/** Whether there are few enough references to list inline */
+	inlineReferences: boolean;
+	skillDir: string;
+	referencesDir: string;
+}
+
+const BUILTIN_COMMANDS: SkillTemplateCommand[] = [
+	{ command: "ow map search <term>", description: "Search references by name or keyword" },
+	{ command: "ow map show <repo>", description: "Show reference and clone paths for a repo" },
+	{ command: "ow map related <repo>", description: "List installed references related to a repo" },
+	{
+		command: "ow examples <repo> <symbol>",
+		description: "Find code examples for a symbol or topic",
+	},
+	{
+		command: "ow api <repo> <symbol>",
+		description: "Show the exact signature and JSDoc of an export",
+	},
+	{ command: "ow pull <owner/repo>", description: "Clone a repo and install its reference" },
+	{ command: "ow project init", description: "Install references for project dependencies" },
+	{ command: "ow list", description: "List installed repos" },
+];
+
+type TemplateNode =
+	| { type: "text"; value: string }
+	| { type: "var"; path: string }
+	| { type: "if"; path: string; then: TemplateNode[]; otherwise: TemplateNode[] }
+	| { type: "each"; path: string; body: TemplateNode[] };
+
+const TAG_PATTERN = /\{\{\s*([#/]?)(\w+(?:\.\w+)*)?(?:\s+([\w.]+))?\s*\}\}/g;
+const STANDALONE_BLOCK_PATTERN =
+	/^[ \t]*(\{\{\s*(?:#if|#each|else|\/if|\/each)\b[^}]*\}\})[ \t]*\r?\n/gm;
+
+function parseTemplate(template: string): TemplateNode[] {
+	const source = template.replace(STANDALONE_BLOCK_PATTERN, "$1");
+	const root: TemplateNode[] = [];
+	const stack: { node: TemplateNode; nodes: TemplateNode[] }[] = [];
+	let current = root;
+	let lastIndex = 0;
+
+	for (const match of source.matchAll(TAG_PATTERN)) {
+		if (match.index > lastIndex) {
+			current.push({ type: "text", value: source.slice(lastIndex, match.index) });
+		}
+		lastIndex = match.index + match[0].length;
+
+		const [tag, sigil, name, arg] = match;
+		if (sigil === "#") {
+			if ((name !== "if" && name !== "each") || !arg) {
+				throw new Error(`Unknown block ${tag}`);
+			}
+			const node: TemplateNode =
+				name === "if"
+					? { type: "if", path: arg, then: [], otherwise: [] }
+					: { type: "each", path: arg, body: [] };
+			current.push(node);
+			stack.push({ node, nodes: current });
+			current = node.type === "if" ? node.then : node.body;
+		} else if (sigil === "/") {
+			const open = stack.pop();
+			if (!open || open.node.type !== name) {
+				throw new Error(`Unexpected ${tag}`);
+			}
+			current = open.nodes;
+		} else if (name === "else" && !arg) {
+			const open = stack[stack.length - 1];
+			if (!open || open.node.type !== "if") {
+				throw new Error("{{else}} outside {{#if}}");
+			}
+			current = open.node.otherwise;
+		} else if (name) {
+			current.push({ type: "var", path: name });
+		}
+	}
+
+	if (stack.length > 0) {
+		throw new Error(`Unclosed {{#${stack[stack.length - 1]!.node.type}}}`);
+	}
+	if (lastIndex < source.length) {
+		current.push({ type: "text", value: source.slice(lastIndex) });
+	}
+	return root;
+}
+
+function lookup(path: string, scopes: unknown[]): unknown {
+	if (path === "this") return scopes[0];
+
+	const [head, ...rest] = path.split(".");
+	const scope = scopes.find(
+		(candidate) => candidate !== null && typeof candidate === "object" && head! in candidate,
+	);
+	let value = scope ? (scope as Record<string, unknown>)[head!] : undefined;
+	for (const part of rest) {
+		if (value === null || typeof value !== "object") return undefined;
+		value = (value as Record<string, unknown>)[part];
+	}
+	return value;
+}
+
+function isTruthy(value: unknown): boolean {
+	return Array.isArray(value) ? value.length > 0 : Boolean(value);
+}
+
+function renderNodes(nodes: TemplateNode[], scopes: unknown[]): string {
+	let output = "";
+	for (const node of nodes) {
+		if (node.type === "text") {
+			output += node.value;
+		} else if (node.type === "var") {
+			const value = lookup(node.path, scopes);
+			output += Array.isArray(value) ? value.join(", ") : (value ?? "").toString();
+		} else if (node.type === "if") {
+			const branch = isTruthy(lookup(node.path, scopes)) ? node.then : node.otherwise;
+			output += renderNodes(branch, scopes);
+		} else {
+			const items = lookup(node.path, scopes);
+			if (!Array.isArray(items)) continue;
+			for (const item of items) {
+				output += renderNodes(node.body, [item, ...scopes]);
+			}
+		}
+	}
+	return output;
+}
+
+/**
+ * Render a template with the given variables.
+ * @throws Error if the template has unbalanced or unknown blocks
+ */
+export function renderTemplate(template: string, context: object): string {
+	return renderNodes(parseTemplate(template), [context]);
+}
+
+function toSkillReference(
+	qualifiedName: string,
+	entry: GlobalMap["repos"][string],
+): SkillTemplateReference {
+	const separator = qualifiedName.indexOf(":");
+	return {
+		name: separator === -1 ? qualifiedName : qualifiedName.slice(separator + 1),
+		qualifiedName,
+		reference: entry.primary,
+		keywords: entry.keywords ?? [],
+		localPath: entry.localPath,
+		updatedAt: entry.updatedAt,
+	};
+}
+
+/**
+ * Variables available to skill templates.
+ */
+export function buildSkillTemplateContext(
+	map: GlobalMap,
+	config: Config = loadConfig(),
+): SkillTemplateContext {
+	const references = Object.entries(map.repos)
+		.filter(([, entry]) => entry.primary)
+		.map(([qualifiedName, entry]) => toSkillReference(qualifiedName, entry))
+		.sort((a, b) => a.name.localeCompare(b.name));
+	const topRepos = [...references]
+		.sort((a, b) => b.updatedAt.localeCompare(a.updatedAt))
+		.slice(0, TOP_REPOS_LIMIT);
+	const inlineLimit = config.templates?.inlineReferenceLimit ?? DEFAULT_INLINE_REFERENCE_LIMIT;
+	const pluginCommands = getPluginCommands().map((command) => ({
+		command: `ow ${command.name}`,
+		description: command.description,
+	}));
+
+	return {
+		version: VERSION,
+		commands: [...BUILTIN_COMMANDS, ...pluginCommands],
+		referenceCount: references.length,
+		references,
+		topRepos,
+		inlineReferences: references.length > 0 && references.length <= inlineLimit,
+		skillDir: Paths.offworldSkillDir,
+		referencesDir: Paths.offworldReferencesDir,
+	};
+}
+
+function loadTemplate(path: string | undefined, fallback: string): string {
+	if (!path) return fallback;
+
+	const fs = getFs();
+	// Paths from config files are already absolute; env and flag values are relative to cwd
+	const templatePath = resolve(getRuntime().cwd(), expandTilde(path));
+	try {
+		return fs.readFileSync(templatePath, "utf-8");
+	} catch (error) {
+		getRuntime().logger.warn(
+			`Could not read template ${templatePath}: ${error instanceof Error ? error.message : error}`,
+		);
+		return fallback;
+	}
+}
+
+function renderWithFallback(
+	template: string,
+	fallback: string,
+	context: SkillTemplateContext,
+): string {
+	try {
+		return renderTemplate(template, context);
+	} catch (error) {
+		getRuntime().logger.warn(
+			`Invalid skill template, using the default: ${error instanceof Error ? error.message : error}`,
+		);
+		return renderTemplate(fallback, context);
+	}
+}
+
+/**
+ * Render SKILL.md and references/installation.md into the global skill directory.
+ */
+export function renderGlobalSkill(map: GlobalMap, config: Config = loadConfig()): void {
+	const fs = getFs();
+	const context = buildSkillTemplateContext(map, config);
+	const templates = config.templates ?? {};
+
+	const skill = renderWithFallback(
+		loadTemplate(templates.skill, DEFAULT_SKILL_TEMPLATE),
+		DEFAULT_SKILL_TEMPLATE,
+		context,
+	);
+	const installation = renderWithFallback(
+		loadTemplate(templates.installation, DEFAULT_INSTALLATION_TEMPLATE),
+		DEFAULT_INSTALLATION_TEMPLATE,
+		context,
+	);
+
+	fs.mkdirSync(Paths.offworldReferencesDir, { recursive: true });
+	fs.writeFileSync(join(Paths.offworldSkillDir, "SKILL.md"), skill, "utf-8");
+	fs.writeFileSync(join(Paths.offworldReferencesDir, "installation.md"), installation, "utf-8");
+}
+
+/**
+ * Re-render the global skill after a map change, if the skill has been installed.
+ * Never throws: a broken template must not block map writes.
+ */
+export function refreshGlobalSkill(map: GlobalMap): void {
+	try {
+		if (!getFs().existsSync(join(Paths.offworldSkillDir, "SKILL.md"))) return;
+		renderGlobalSkill(map);
+	} catch (error) {
+		getRuntime().logger.debug(
+			`Skipped skill refresh: ${error instanceof Error ? error.message : error}`,
+		);
+	}
+}
+
+/**
+ * Default template for the global SKILL.md file.
+ * This is the single routing skill that all agents see.
+ */
+export const DEFAULT_SKILL_TEMPLATE = `---
+name: offworld
+description: Routes queries to Offworld reference files. Find and read per-repo references for dependency knowledge.
+allowed-tools: Bash(ow:*) Read
+---
+
+# Offworld Reference Router
+
+Use \`ow\` to locate and read Offworld reference files for dependencies.
+
+## What This Does
+
+- Finds references for libraries and repos
+- Returns paths for reference files and local clones
+- Helps you read the right context fast
+
+## When to Use
+
+- You need docs or patterns for a dependency
+- You want the verified reference instead of web search
+- You are about to work inside a repo clone
+
+## Installation and Setup
+
+If Offworld CLI or opencode is missing, read \`references/installation.md\` in this skill directory and follow it.
+
+## Usage
+
+**Find a reference:**
+\`\`\`bash
+ow map search <term>     # search by name or keyword
+ow map show <repo>       # get info for specific repo, including reference freshness
+ow map related <repo>    # installed references that mention it (or that it mentions)
+ow map graph <repo>      # installed repos it depends on and that depend on it
+\`\`\`
+
+**Get paths for tools:**
+\`\`\`bash
+ow map show <repo> --ref --short  # cheat sheet path (start here)
+ow map show <repo> --ref          # full reference file path (use with Read)
+ow map show <repo> --path         # clone directory path
+\`\`\`
+
+**Find real usage examples:**
+\`\`\`bash
+ow examples <repo> <symbol|topic>   # snippets from examples, tests and docs, with file:line
+\`\`\`
+
+**Check exact signatures:**
+\`\`\`bash
+ow api <repo> <symbol>              # signature and JSDoc from the clone's type declarations
+\`\`\`
+
+**Example workflow:**
+\`\`\`bash
+# 1. Find the repo
+ow map search zod
+
+# 2. Get the cheat sheet path and read it first
+ow map show colinhacks/zod --ref --short
+# Output: /Users/.../.local/share/offworld/skill/offworld/references/colinhacks-zod.short.md
+
+# 3. If the cheat sheet doesn't cover it, read the full reference
+ow map show colinhacks/zod --ref
+\`\`\`
+
+**Stale references:** \`ow map show\` reports the commit a reference was generated at and how far
+the clone has moved since. When it warns that the reference is stale (more commits behind than
+\`maxCommitDistance\`), prefer the source: read the clone at \`ow map show <repo> --path\` and use
+the reference only for orientation. The warning is printed to stderr with \`--ref\`.
+
+**Forks:** A reference ending in a "Fork Supplement" section was generated for the upstream repo.
+The supplement lists the fork's own commits and changed files; read those files in the clone
+instead of trusting the reference for them.
+
+{{#if inlineReferences}}
+## Installed References
+
+Read these directly from \`references/\` in this skill directory:
+
+{{#each references}}
+- \`{{name}}\`: \`references/{{reference}}\`
+{{/each}}
+
+{{else}}
+{{#if referenceCount}}
+## Installed References
+
+{{referenceCount}} references are installed. Use \`ow map search <term>\` to find the one you need.
+
+{{/if}}
+{{/if}}
+## If Reference Not Found
+
+\`\`\`bash
+ow pull <owner/repo>    # clone + generate reference
+ow project init         # scan project deps, install references
+\`\`\`
+
+## Notes
+
+- Project map (\`.offworld/map.json\`) takes precedence over global map when present
+- Reference files are markdown with API docs, patterns, best practices
+- References come with a \`.short.md\` cheat sheet (install, top patterns, key exports); read it first
+- A "Related References" block at the end of a reference links the installed references related to it
+- \`ow map show\` also lists installed upstream repos and the installed repos that use it
+- Clone paths useful for exploring source code after reading reference
+
+## Additional Resources
+
+- Docs: https://offworld.sh/cli
+`;
+
+/**
+ * Default template for references/installation.md.
+ */
+export const DEFAULT_INSTALLATION_TEMPLATE = `# Offworld Installation
+
+Use this when Offworld CLI or opencode is not installed.
+
+## 1) Check opencode
+
+\`\`\`bash
+opencode --version
+\`\`\`
+
+If missing:
+
+\`\`\`bash
+curl -fsSL https://opencode.ai/install | bash
+\`\`\`
+
+## 2) Check Offworld CLI
+
+\`\`\`bash
+ow --version
+\`\`\`
+
+If missing:
+
+\`\`\`bash
+curl -fsSL https://offworld.sh/install | bash
+\`\`\`
+
+## 3) Initialize Offworld (non-interactive)
+
+\`\`\`bash
+ow init --yes --agents "<agent-list>" --repo-root "<clone-dir>" --model "<provider/model>"
+\`\`\`
+
+Example:
+
+\`\`\`bash
+ow init --yes --agents "opencode,codex" --repo-root "~/ow" --model "anthropic/claude-sonnet-4-20250514"
+\`\`\`
+
+## 4) Initialize current project
+
+\`\`\`bash
+ow project init --yes --all
+\`\`\`
+
+## 5) Verify
+
+\`\`\`bash
+ow config show
+ow list
+\`\`\`
+`;
@@ -4,8 +4,14 @@
  */
 
 import { toReferenceName } from "./config.js";
-import { GitHubRepoMetadataSchema, type RepoSource } from "@offworld/types";
+import {
+	GitHubCompareResponseSchema,
+	GitHubRepoMetadataSchema,
+	type RepoSource,
+} from "@offworld/types";
 import { getConvexClient, SyncUnavailableError } from "./sync/client.js";
+import { recordOperation } from "./journal.js";
+import { getRuntime } from "./runtime.js";
 
 type ConvexApi = typeof import("@offworld/sdk/convex/api").api;
 
@@ -150,6 +156,8 @@ export interface ReferenceData {
 	referenceName: string;
 	referenceDescription: string;
 	referenceContent: string;
+	/** Cheat sheet variant, stored alongside the reference */
+	shortContent?: string;
 	commitSha: string;
 	generatedAt: string;
 }
@@ -160,8 +168,12 @@ export interface PullResponse {
 	referenceName: string;
 	referenceDescription: string;
 	referenceContent: string;
+	/** Absent for references pushed before cheat sheets existed */
+	shortContent?: string;
 	commitSha: string;
 	generatedAt: string;
+	/** Checked by the Offworld team; absent from older servers */
+	isVerified?: boolean;
 }
 
 function toPullResponse(result: PullResponse): PullResponse {
@@ -170,8 +182,10 @@ function toPullResponse(result: PullResponse): PullResponse {
 		referenceName: result.referenceName,
 		referenceDescription: result.referenceDescription,
 		referenceContent: result.referenceContent,
+		shortContent: result.shortContent,
 		commitSha: result.commitSha,
 		generatedAt: result.generatedAt,
+		isVerified: result.isVerified,
 	};
 }
 
@@ -281,6 +295,7 @@ export async function pushReference(
 			referenceName: reference.referenceName,
 			referenceDescription: reference.referenceDescription,
 			referenceContent: reference.referenceContent,
+			...(reference.shortContent ? { shortContent: reference.shortContent } : {}),
 			commitSha: reference.commitSha,
 			generatedAt: reference.generatedAt,
 		});
@@ -312,6 +327,12 @@ export async function pushReference(
 			}
 		}
 
+		recordOperation({
+			op: "push",
+			repo: `github.com:${reference.fullName}`,
+			summary: `${reference.referenceName} @ ${reference.commitSha.slice(0, 7)}`,
+			after: { referenceName: reference.referenceName, commitSha: reference.commitSha },
+		});
 		return { success: true };
 	} catch (error) {
 		if (error instanceof SyncError) throw error;
@@ -408,8 +429,28 @@ export async function checkStaleness(
 	};
 }
 
+/**
+ * GitHub API request headers. GITHUB_TOKEN or GH_TOKEN, when set, raises the rate limit from 60
+ * to 5000 requests per hour.
+ */
+function getGitHubHeaders(): Record<string, string> {
+	const token = process.env.GITHUB_TOKEN || process.env.GH_TOKEN;
+	return {
+		Accept: "application/vnd.github.v3+json",
+		"User-Agent": "offworld-cli",
+		...(token ? { Authorization: `Bearer ${token}` } : {}),
+	};
+}
+
 /** GitHub repository metadata */
 export interface GitHubRepoMetadata {
+	/** Current owner/repo, after following renames and transfers */
+	fullName?: string;
+	archived: boolean;
+	/** Repo this one was forked from (forks only) */
+	parent?: string;
+	/** Root of the fork network (forks only) */
+	forkSource?: string;
 	stars: number;
 	description?: string;
 	language?: string;
@@ -427,11 +468,8 @@ export async function fetchGitHubMetadata(
 	repo: string,
 ): Promise<GitHubRepoMetadata | null> {
 	try {
-		const response = await fetch(`${GITHUB_API_BASE}/repos/${owner}/${repo}`, {
-			headers: {
-				Accept: "application/vnd.github.v3+json",
-				"User-Agent": "offworld-cli",
-			},
+		const response = await getRuntime().fetch(`${GITHUB_API_BASE}/repos/${owner}/${repo}`, {
+			headers: getGitHubHeaders(),
 		});
 
 		if (!response.ok) {
@@ -446,6 +484,10 @@ export async function fetchGitHubMetadata(
 		const data = result.data;
 
 		return {
+			fullName: data.full_name,
+			archived: data.archived ?? false,
+			parent: data.fork ? data.parent?.full_name : undefined,
+			forkSource: data.fork ? data.source?.full_name : undefined,
 			stars: data.stargazers_count ?? 0,
 			description: data.description ?? undefined,
 			language: data.language ?? undefined,
@@ -456,6 +498,61 @@ export async function fetchGitHubMetadata(
 	}
 }
 
+/** Commits between two SHAs on GitHub */
+export interface GitHubCompare {
+	/** Commits in head that base lacks */
+	aheadBy: number;
+	/** Commits in base that head lacks */
+	behindBy: number;
+	/** Subject lines of the commits in head that base lacks, oldest first (at most 250) */
+	commits: string[];
+	/** Files changed between base and head, e.g. "src/index.ts (modified)" */
+	files: string[];
+}
+
+/**
+ * Compares two commits with the GitHub compare API (works across branches and forks in the
+ * same network)
+ * @param owner - Repository owner
+ * @param repo - Repository name
+ * @param base - Base commit SHA
+ * @param head - Head commit SHA
+ * @returns Ahead/behind counts, commits and files, or null on error (unknown SHA, rate limit,
+ * network)
+ */
+export async function fetchGitHubCompare(
+	owner: string,
+	repo: string,
+	base: string,
+	head: string,
+): Promise<GitHubCompare | null> {
+	try {
+		const response = await getRuntime().fetch(
+			`${GITHUB_API_BASE}/repos/${owner}/${repo}/compare/${base}...${head}`,
+			{ headers: getGitHubHeaders() },
+		);
+
+		if (!response.ok) {
+			return null;
+		}
+
+		const result = GitHubCompareResponseSchema.safeParse(await response.json());
+		if (!result.success) {
+			return null;
+		}
+
+		const { ahead_by, behind_by, commits = [], files = [] } = result.data;
+		return {
+			aheadBy: ahead_by,
+			behindBy: behind_by,
+			commits: commits.map(({ commit }) => commit.message.split("\n")[0]!.trim()),
+			files: files.map(({ filename, status }) => `${filename} (${status})`),
+		};
+	} catch {
+		return null;
+	}
+}
+
 /**
  * Fetches GitHub repository stars
  * @param owner - Repository owner
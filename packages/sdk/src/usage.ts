@@ -0,0 +1,162 @@
+/**
+ * Local usage analytics for references
+ *
+ * Read commands append one event per reference they surface to a JSONL log in the state
+ * directory. Events never leave the machine; they rank search results, order updates and
+ * keep recently read clones out of gc. Past MAX_USAGE_BYTES the log is compacted to the
+ * latest MAX_USAGE_EVENTS events.
+ */
+
+import { join, resolve } from "node:path";
+import { z } from "zod";
+import { readGlobalMap } from "./index-manager.js";
+import { Paths } from "./paths.js";
+import { getFs, getRuntime } from "./runtime.js";
+
+const UsageEventSchema = z.object({
+	repo: z.string(),
+	command: z.string(),
+	project: z.string().optional(),
+	timestamp: z.string(),
+});
+
+export type UsageEvent = z.infer<typeof UsageEventSchema>;
+
+export interface RepoUsage {
+	repo: string;
+	count: number;
+	lastUsedAt: string;
+	/** Event count per command */
+	commands: Record<string, number>;
+	/** Distinct projects the reference was read from */
+	projects: number;
+}
+
+export interface UsageStats {
+	totalEvents: number;
+	/** Timestamp of the oldest retained event */
+	since: string | null;
+	mostUsed: RepoUsage[];
+	/** Used references with the fewest reads */
+	leastUsed: RepoUsage[];
+	/** Repos in the global map with no recorded reads */
+	unused: string[];
+}
+
+/** Oldest events are dropped beyond this many */
+export const MAX_USAGE_EVENTS = 5000;
+
+/** The log is compacted once it grows past this size */
+export const MAX_USAGE_BYTES = 1024 * 1024;
+
+function getUsagePath(): string {
+	return join(Paths.state, "usage.jsonl");
+}
+
+/**
+ * Read the latest MAX_USAGE_EVENTS usage events. Malformed lines are skipped.
+ */
+export function readUsageEvents(): UsageEvent[] {
+	const fs = getFs();
+	try {
+		const usagePath = getUsagePath();
+		if (!fs.existsSync(usagePath)) return [];
+
+		const events: UsageEvent[] = [];
+		for (const line of fs.readFileSync(usagePath, "utf-8").split("\n")) {
+			if (!line.trim()) continue;
+			try {
+				const parsed = UsageEventSchema.safeParse(JSON.parse(line));
+				if (parsed.success) events.push(parsed.data);
+			} catch {}
+		}
+		return events.slice(-MAX_USAGE_EVENTS);
+	} catch {
+		return [];
+	}
+}
+
+function compactUsage(): void {
+	const fs = getFs();
+	const usagePath = getUsagePath();
+	if (fs.statSync(usagePath).size <= MAX_USAGE_BYTES) return;
+
+	const lines = readUsageEvents().map((entry) => JSON.stringify(entry));
+	fs.writeFileSync(usagePath, `${lines.join("\n")}\n`, "utf-8");
+}
+
+/**
+ * Record that a reference was read. Never throws; usage tracking must not break commands.
+ *
+ * @param repo - Qualified repo name (e.g. github.com:owner/repo)
+ * @param command - Command that surfaced the reference (e.g. "map show")
+ */
+export function recordUsage(repo: string, command: string): void {
+	const fs = getFs();
+	try {
+		const cwd = getRuntime().cwd();
+		const project = fs.existsSync(resolve(cwd, ".offworld/map.json")) ? cwd : undefined;
+		const event: UsageEvent = { repo, command, project, timestamp: new Date().toISOString() };
+
+		fs.mkdirSync(Paths.state, { recursive: true });
+		fs.appendFileSync(getUsagePath(), `${JSON.stringify(event)}\n`, "utf-8");
+		compactUsage();
+	} catch {}
+}
+
+/**
+ * Aggregate usage events per repo.
+ */
+export function summarizeUsage(events: UsageEvent[] = readUsageEvents()): Map<string, RepoUsage> {
+	const summary = new Map<string, RepoUsage>();
+	const projects = new Map<string, Set<string>>();
+
+	for (const event of events) {
+		let usage = summary.get(event.repo);
+		if (!usage) {
+			usage = {
+				repo: event.repo,
+				count: 0,
+				lastUsedAt: event.timestamp,
+				commands: {},
+				projects: 0,
+			};
+			summary.set(event.repo, usage);
+			projects.set(event.repo, new Set());
+		}
+		usage.count++;
+		usage.commands[event.command] = (usage.commands[event.command] ?? 0) + 1;
+		if (event.timestamp > usage.lastUsedAt) usage.lastUsedAt = event.timestamp;
+		if (event.project) projects.get(event.repo)!.add(event.project);
+	}
+
+	for (const [repo, usage] of summary) {
+		usage.projects = projects.get(repo)!.size;
+	}
+	return summary;
+}
+
+/**
+ * Most and least used references, plus references that were never read.
+ */
+export function getUsageStats(options: { limit?: number } = {}): UsageStats {
+	const { limit = 10 } = options;
+	const events = readUsageEvents();
+	const summary = summarizeUsage(events);
+	const map = readGlobalMap();
+
+	const byCount = [...summary.values()].sort(
+		(a, b) => b.count - a.count || b.lastUsedAt.localeCompare(a.lastUsedAt),
+	);
+	const unused = Object.keys(map.repos)
+		.filter((repo) => !summary.has(repo) && map.repos[repo]!.references.length > 0)
+		.sort();
+
+	return {
+		totalEvents: events.length,
+		since: events[0]?.timestamp ?? null,
+		mostUsed: byCount.slice(0, limit),
+		leastUsed: byCount.slice(-limit).reverse(),
+		unused,
+	};
+}
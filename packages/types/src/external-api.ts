@@ -31,12 +31,30 @@ export const GitHubOwnerResponseSchema = z.object({
 });
 
 export const GitHubRepoMetadataSchema = z.object({
+	/** Current owner/name; differs from the requested one after a rename or transfer */
+	full_name: z.string().optional(),
+	archived: z.boolean().optional(),
+	fork: z.boolean().optional(),
+	/** Repo this one was forked from (forks only) */
+	parent: z.object({ full_name: z.string() }).optional(),
+	/** Root of the fork network (forks only) */
+	source: z.object({ full_name: z.string() }).optional(),
 	stargazers_count: z.number().optional(),
 	description: z.string().nullable().optional(),
 	language: z.string().nullable().optional(),
 	default_branch: z.string().optional(),
 });
 
+export const GitHubCompareResponseSchema = z.object({
+	status: z.enum(["ahead", "behind", "identical", "diverged"]),
+	ahead_by: z.number(),
+	behind_by: z.number(),
+	commits: z
+		.array(z.object({ sha: z.string(), commit: z.object({ message: z.string() }) }))
+		.optional(),
+	files: z.array(z.object({ filename: z.string(), status: z.string() })).optional(),
+});
+
 export const WorkOSDeviceAuthResponseSchema = z.object({
 	device_code: z.string(),
 	user_code: z.string(),
@@ -79,6 +97,8 @@ export const NpmPackageResponseSchema = z.object({
 		])
 		.optional(),
 	keywords: z.array(z.string()).optional(),
+	/** Deprecation message (set on version documents) */
+	deprecated: z.string().optional(),
 });
 
 export const ModelsDevModelSchema = z.object({
@@ -123,6 +143,7 @@ export const ModelsDevDataSchema = z.record(z.string(), ModelsDevProviderSchema)
 export type GitHubRepoResponse = z.infer<typeof GitHubRepoResponseSchema>;
 export type GitHubOwnerResponse = z.infer<typeof GitHubOwnerResponseSchema>;
 export type GitHubRepoMetadata = z.infer<typeof GitHubRepoMetadataSchema>;
+export type GitHubCompareResponse = z.infer<typeof GitHubCompareResponseSchema>;
 export type WorkOSDeviceAuthResponse = z.infer<typeof WorkOSDeviceAuthResponseSchema>;
 export type WorkOSTokenResponse = z.infer<typeof WorkOSTokenResponseSchema>;
 export type WorkOSAuthErrorResponse = z.infer<typeof WorkOSAuthErrorResponseSchema>;
@@ -13,6 +13,110 @@ export const AgentSchema = z.enum([
 	"cursor",
 ]);
 
+/**
+ * Per-repository overrides, keyed in config by glob (e.g. "vercel/*", "github:owner/repo").
+ * Unset fields fall back to the global config.
+ */
+export const RepoOverrideSchema = z.object({
+	/** Model in provider/model format for reference generation */
+	model: z.string().optional(),
+	/** Always clone with sparse checkout */
+	sparse: z.boolean().optional(),
+	/** Sparse checkout paths (implies sparse) */
+	sparsePaths: z.array(z.string()).optional(),
+	/** Branch to clone */
+	branch: z.string().optional(),
+	/** Tag or commit to check out instead of tracking the branch */
+	pin: z.string().optional(),
+	/** Max commit distance to accept remote references */
+	maxCommitDistance: z.number().int().nonnegative().optional(),
+	/** Accept remote references even when commit distance is unknown */
+	acceptUnknownDistance: z.boolean().optional(),
+	/** Use references from offworld.sh (false always generates locally) */
+	trustRemote: z.boolean().optional(),
+	/** Extra instructions appended to the reference generation prompt */
+	prompt: z.string().optional(),
+});
+
+export const HookEventSchema = z.enum([
+	"postClone",
+	"postUpdate",
+	"preGenerate",
+	"postGenerate",
+	"postInstall",
+	"postRemove",
+]);
+
+/**
+ * What to do when a hook exits non-zero, throws or times out.
+ * "fail" aborts the operation (or surfaces an error after it, for post hooks).
+ */
+export const HookFailurePolicySchema = z.enum(["warn", "fail", "ignore"]);
+
+/**
+ * A lifecycle hook: a shell command, or a JS module whose default export is called.
+ * Both receive the JSON payload (commands on stdin). A bare string is a shell command.
+ */
+export const HookSchema = z.union([
+	z.string(),
+	z
+		.object({
+			command: z.string().optional(),
+			module: z.string().optional(),
+			/** Milliseconds before the hook is killed (default 30000) */
+			timeout: z.number().int().positive().optional(),
+			onFailure: HookFailurePolicySchema.optional(),
+		})
+		.refine((hook) => Boolean(hook.command) !== Boolean(hook.module), {
+			message: "A hook needs exactly one of command or module",
+		}),
+]);
+
+export const HooksSchema = z.object({
+	postClone: z.array(HookSchema).optional(),
+	postUpdate: z.array(HookSchema).optional(),
+	preGenerate: z.array(HookSchema).optional(),
+	postGenerate: z.array(HookSchema).optional(),
+	postInstall: z.array(HookSchema).optional(),
+	postRemove: z.array(HookSchema).optional(),
+});
+
+/**
+ * How references are exposed to agents: one routing skill, or additionally one skill per reference.
+ */
+export const SkillModeSchema = z.enum(["router", "per-reference"]);
+
+/**
+ * User templates for the generated global skill files.
+ * Paths may use ~. Relative paths in a config file are resolved from that file's directory;
+ * from environment variables and flags, from the current directory.
+ */
+export const SkillTemplatesSchema = z.object({
+	/** Template for SKILL.md */
+	skill: z.string().optional(),
+	/** Template for references/installation.md */
+	installation: z.string().optional(),
+	/** List installed references inline in SKILL.md up to this many (default 20) */
+	inlineReferenceLimit: z.number().int().nonnegative().optional(),
+});
+
+/**
+ * Background daemon schedule. Intervals are durations like "30m", "6h" or "1d"; "off" disables
+ * the task.
+ */
+export const DaemonConfigSchema = z.object({
+	/** Fetch and fast-forward clones (default "6h") */
+	fetchInterval: z.string().optional(),
+	/** Refresh the offworld.sh remote check cache (default "12h") */
+	remoteCheckInterval: z.string().optional(),
+	/** Pull newer references for clones that moved past their reference (default "1d") */
+	refreshInterval: z.string().optional(),
+	/** Regenerate stale references locally when no remote one fits (default false) */
+	regenerate: z.boolean().optional(),
+	/** Keep an OpenCode server running while regeneration is enabled (default true) */
+	warmOpenCode: z.boolean().optional(),
+});
+
 export const ConfigSchema = z.object({
 	repoRoot: z.string().default("~/ow"),
 	/** Default model in provider/model format (e.g., anthropic/claude-sonnet-4-20250514) */
@@ -21,8 +125,25 @@ export const ConfigSchema = z.object({
 	maxCommitDistance: z.number().int().nonnegative().default(20),
 	/** Accept remote references even when commit distance is unknown */
 	acceptUnknownDistance: z.boolean().default(false),
-	/** Agents to create skill symlinks for. Auto-detected if empty. */
-	agents: z.array(AgentSchema).default([]),
+	/**
+	 * Agents to create skill symlinks for. Auto-detected if empty.
+	 * Built-in names are listed in AgentSchema; plugins may register more.
+	 */
+	agents: z.array(z.string()).default([]),
+	/** Per-repository overrides keyed by glob pattern */
+	repos: z.record(z.string(), RepoOverrideSchema).default({}),
+	/** Lifecycle hooks run after (or before) clone, update, generate, install and remove */
+	hooks: HooksSchema.default({}),
+	/** Plugins to load: npm package names or paths to local modules */
+	plugins: z.array(z.string()).default([]),
+	/** Custom SKILL.md and installation reference templates */
+	templates: SkillTemplatesSchema.default({}),
+	/** "per-reference" also installs a small skill for each reference */
+	skillMode: SkillModeSchema.default("router"),
+	/** Append signatures verified against the clone's type declarations to references */
+	apiAppendix: z.boolean().default(false),
+	/** Schedule for `ow daemon` */
+	daemon: DaemonConfigSchema.default({}),
 });
 
 export const GitProviderSchema = z.enum(["github", "gitlab", "bitbucket"]);
@@ -100,11 +221,16 @@ export const FileIndexEntrySchema = z.object({
 
 export const FileIndexSchema = z.array(FileIndexEntrySchema);
 
+/** Where an installed reference came from ("verified": remote and checked by the Offworld team) */
+export const ReferenceOriginSchema = z.enum(["remote", "local", "verified"]);
+
 export const ReferenceMetaSchema = z.object({
 	referenceUpdatedAt: z.string(),
 	commitSha: z.string(),
 	version: z.string(),
 	tokenCost: z.number().optional(),
+	/** Absent for references installed before the origin was recorded */
+	source: ReferenceOriginSchema.optional(),
 });
 
 /**
@@ -129,6 +255,14 @@ export const GlobalMapRepoEntrySchema = z.object({
 	primary: z.string(),
 	keywords: z.array(z.string()).default([]),
 	updatedAt: z.string(),
+	/** Installed repos this one depends on, from their manifests (recomputed on every write) */
+	dependsOn: z.array(z.string()).optional(),
+	/** The upstream repo is archived (read-only) on GitHub */
+	archived: z.boolean().optional(),
+	/** npm deprecation message for the repo's package */
+	deprecated: z.string().optional(),
+	/** Qualified name of the repo this one is a fork of */
+	forkOf: z.string().optional(),
 });
 
 /**
@@ -2,6 +2,14 @@ import type { z } from "zod";
 import type {
 	AgentSchema,
 	ConfigSchema,
+	RepoOverrideSchema,
+	HookEventSchema,
+	HookFailurePolicySchema,
+	HookSchema,
+	HooksSchema,
+	SkillTemplatesSchema,
+	SkillModeSchema,
+	DaemonConfigSchema,
 	GitProviderSchema,
 	RemoteRepoSourceSchema,
 	LocalRepoSourceSchema,
@@ -14,6 +22,7 @@ import type {
 	FileRoleSchema,
 	FileIndexEntrySchema,
 	FileIndexSchema,
+	ReferenceOriginSchema,
 	ReferenceMetaSchema,
 	ReferenceDataSchema,
 	GlobalMapRepoEntrySchema,
@@ -24,6 +33,14 @@ import type {
 
 export type Agent = z.infer<typeof AgentSchema>;
 export type Config = z.infer<typeof ConfigSchema>;
+export type RepoOverride = z.infer<typeof RepoOverrideSchema>;
+export type HookEvent = z.infer<typeof HookEventSchema>;
+export type HookFailurePolicy = z.infer<typeof HookFailurePolicySchema>;
+export type Hook = z.infer<typeof HookSchema>;
+export type Hooks = z.infer<typeof HooksSchema>;
+export type SkillTemplates = z.infer<typeof SkillTemplatesSchema>;
+export type SkillMode = z.infer<typeof SkillModeSchema>;
+export type DaemonConfig = z.infer<typeof DaemonConfigSchema>;
 
 export type GitProvider = z.infer<typeof GitProviderSchema>;
 export type RemoteRepoSource = z.infer<typeof RemoteRepoSourceSchema>;
@@ -39,6 +56,7 @@ export type Architecture = z.infer<typeof ArchitectureSchema>;
 export type FileRole = z.infer<typeof FileRoleSchema>;
 export type FileIndexEntry = z.infer<typeof FileIndexEntrySchema>;
 export type FileIndex = z.infer<typeof FileIndexSchema>;
+export type ReferenceOrigin = z.infer<typeof ReferenceOriginSchema>;
 export type ReferenceMeta = z.infer<typeof ReferenceMetaSchema>;
 
 export type ReferenceData = z.infer<typeof ReferenceDataSchema>;